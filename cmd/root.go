@@ -3,7 +3,11 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/compliance"
 	"github.com/sebrandon1/compliance-operator-dashboard/internal/config"
 	"github.com/spf13/cobra"
 )
@@ -38,6 +42,28 @@ func init() {
 
 	defaultCORef := os.Getenv("COMPLIANCE_OPERATOR_REF")
 
+	defaultGitOpsRepo := os.Getenv("GITOPS_REPO")
+
+	defaultRemediationMode := os.Getenv("REMEDIATION_MODE")
+	if defaultRemediationMode == "" {
+		defaultRemediationMode = "direct"
+	}
+
+	defaultRequireApproval, _ := strconv.ParseBool(os.Getenv("REQUIRE_APPROVAL"))
+
+	defaultDisconnected, _ := strconv.ParseBool(os.Getenv("DISCONNECTED"))
+	defaultMirrorRegistry := os.Getenv("MIRROR_REGISTRY")
+	defaultCatalogImage := os.Getenv("CATALOG_IMAGE")
+
+	defaultStateNamespace := os.Getenv("STATE_NAMESPACE")
+	if defaultStateNamespace == "" {
+		defaultStateNamespace = podNamespace()
+	}
+
+	defaultCSVTimeout := envDuration("CSV_TIMEOUT", compliance.DefaultPhaseTimeout)
+	defaultPodsTimeout := envDuration("PODS_TIMEOUT", compliance.DefaultPhaseTimeout)
+	defaultProfileBundlesTimeout := envDuration("PROFILE_BUNDLES_TIMEOUT", compliance.DefaultPhaseTimeout)
+
 	defaultLogFormat := os.Getenv("LOG_FORMAT")
 	if defaultLogFormat == "" {
 		defaultLogFormat = "text"
@@ -53,4 +79,43 @@ func init() {
 		"Compliance Operator version reference (env: COMPLIANCE_OPERATOR_REF, default: latest from GitHub)")
 	rootCmd.PersistentFlags().StringVar(&cfg.LogFormat, "log-format", defaultLogFormat,
 		"Log output format: text or json (env: LOG_FORMAT)")
+	rootCmd.PersistentFlags().StringVar(&cfg.GitOpsRepo, "gitops-repo", defaultGitOpsRepo,
+		"Local git repository that remediation exports can be committed to (env: GITOPS_REPO)")
+	rootCmd.PersistentFlags().StringVar(&cfg.RemediationMode, "remediation-mode", defaultRemediationMode,
+		"How remediations are applied: direct or operator (env: REMEDIATION_MODE)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.RequireApproval, "require-approval", defaultRequireApproval,
+		"Require an approved change request to apply or remove remediations (env: REQUIRE_APPROVAL)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Disconnected, "disconnected", defaultDisconnected,
+		"Install without internet access, pulling images from mirrors (env: DISCONNECTED)")
+	rootCmd.PersistentFlags().StringVar(&cfg.MirrorRegistry, "mirror-registry", defaultMirrorRegistry,
+		"Registry prefix images are mirrored to in disconnected mode (env: MIRROR_REGISTRY)")
+	rootCmd.PersistentFlags().StringVar(&cfg.CatalogImage, "catalog-image", defaultCatalogImage,
+		"Catalog image for community installs, e.g. a mirrored copy (env: CATALOG_IMAGE)")
+	rootCmd.PersistentFlags().StringVar(&cfg.StateNamespace, "state-namespace", defaultStateNamespace,
+		"Namespace that records install and uninstall progress (env: STATE_NAMESPACE)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.CSVTimeout, "csv-timeout", defaultCSVTimeout,
+		"How long an install waits for the ClusterServiceVersion to succeed (env: CSV_TIMEOUT)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.PodsTimeout, "pods-timeout", defaultPodsTimeout,
+		"How long an install waits for the operator pods to be ready (env: PODS_TIMEOUT)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.ProfileBundlesTimeout, "profile-bundles-timeout", defaultProfileBundlesTimeout,
+		"How long an install waits for ProfileBundles to become VALID (env: PROFILE_BUNDLES_TIMEOUT)")
+}
+
+// envDuration parses a duration such as "10m" from an environment variable,
+// falling back to def when it is unset or invalid.
+func envDuration(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+// podNamespace returns the namespace the dashboard runs in when deployed in
+// a cluster, or "default" when run outside one.
+func podNamespace() string {
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if ns := strings.TrimSpace(string(data)); err == nil && ns != "" {
+		return ns
+	}
+	return "default"
 }
@@ -49,8 +49,14 @@ func runServe(cmd *cobra.Command, args []string) error {
 		slog.Info("dashboard will start but cluster features will be unavailable")
 	}
 
+	mode, err := compliance.ParseRemediationMode(cfg.RemediationMode)
+	if err != nil {
+		return err
+	}
+	slog.Info("remediation mode", "mode", mode)
+
 	// Initialize compliance service
-	complianceSvc := compliance.NewService(k8sClient, cfg.Namespace, cfg.ComplianceOpRef)
+	complianceSvc := compliance.NewService(k8sClient, cfg.Namespace, cfg.ComplianceOpRef, mode)
 
 	// Initialize WebSocket hub
 	hub := ws.NewHub()
@@ -60,6 +66,21 @@ func runServe(cmd *cobra.Command, args []string) error {
 	if k8sClient != nil {
 		watcher := ws.NewWatcher(k8sClient, hub, cfg.Namespace)
 		go watcher.Start(ctx)
+
+		// Apply remediations queued for maintenance windows
+		scheduler := compliance.NewMaintenanceScheduler(k8sClient, mode, cfg.Namespace, func(item compliance.ScheduledRemediation, result *compliance.RemediationResult) {
+			hub.Broadcast(ws.Message{Type: ws.MessageTypeScheduledRemediation, Payload: item})
+			if result != nil {
+				hub.Broadcast(ws.Message{Type: ws.MessageTypeRemediationResult, Payload: result})
+			}
+		})
+		go scheduler.Run(ctx)
+
+		// Flag applied remediations whose objects were deleted or changed
+		reconciler := compliance.NewDriftReconciler(k8sClient, cfg.Namespace, func(report compliance.DriftReport) {
+			hub.Broadcast(ws.Message{Type: ws.MessageTypeRemediationDrift, Payload: report})
+		})
+		go reconciler.Run(ctx)
 	}
 
 	// Create and start HTTP server
@@ -7,6 +7,8 @@ import RemediationPage from './pages/RemediationPage';
 import CheckDetailPage from './pages/CheckDetailPage';
 import RemediationDetailPage from './pages/RemediationDetailPage';
 import SettingsPage from './pages/SettingsPage';
+import ChangeRequestsPage from './pages/ChangeRequestsPage';
+import MaintenancePage from './pages/MaintenancePage';
 import { useWebSocket } from './hooks/useWebSocket';
 import { useCluster } from './hooks/useCluster';
 
@@ -24,6 +26,8 @@ function App() {
         <Route path="results/:name" element={<CheckDetailPage />} />
         <Route path="remediation" element={<RemediationPage />} />
         <Route path="remediation/:name" element={<RemediationDetailPage />} />
+        <Route path="approvals" element={<ChangeRequestsPage />} />
+        <Route path="maintenance" element={<MaintenancePage />} />
         <Route path="settings" element={<SettingsPage />} />
       </Route>
     </Routes>
@@ -0,0 +1,136 @@
+import { useEffect, useState } from 'react';
+import { Link } from 'react-router-dom';
+import { AlertTriangle, FileText, Settings } from 'lucide-react';
+import { remediationApi } from '../lib/api';
+import type { IgnitionConfig, IgnitionFile, RoleIgnitionPreview, SystemdUnit } from '../types/api';
+
+// IgnitionContent shows the decoded files and systemd units a MachineConfig
+// remediation writes, plus any paths other remediations for the same role
+// also write.
+export default function IgnitionContent({ name, role, ignition }: { name: string; role?: string; ignition: IgnitionConfig }) {
+  const [preview, setPreview] = useState<RoleIgnitionPreview | null>(null);
+  const [showRole, setShowRole] = useState(false);
+
+  useEffect(() => {
+    if (!role) return;
+    let cancelled = false;
+    remediationApi.ignitionPreview(role)
+      .then(data => { if (!cancelled) setPreview(data.find(p => p.role === role) || null); })
+      .catch(() => { if (!cancelled) setPreview(null); });
+    return () => { cancelled = true; };
+  }, [role, name]);
+
+  const conflicts = preview?.conflicts.filter(c => c.remediations.includes(name)) || [];
+
+  return (
+    <div className="card">
+      <div className="px-4 py-3 border-b border-gray-200 bg-gray-50 flex items-center justify-between">
+        <h2 className="font-medium text-sm text-gray-900">
+          Ignition Content
+          {ignition.version && <span className="ml-2 text-xs font-normal text-gray-500">v{ignition.version}</span>}
+        </h2>
+        {preview && (
+          <button className="text-xs text-primary-600 hover:text-primary-800" onClick={() => setShowRole(!showRole)}>
+            {showRole ? 'Show this remediation' : `Show all files for ${preview.role}`}
+          </button>
+        )}
+      </div>
+      <div className="p-4 space-y-4">
+        {conflicts.length > 0 && (
+          <div className="rounded-lg border border-amber-200 bg-amber-50 p-3 space-y-1">
+            {conflicts.map(c => (
+              <p key={c.type + c.path} className="text-xs text-amber-800 flex items-start gap-1.5">
+                <AlertTriangle className="h-3.5 w-3.5 mt-0.5 shrink-0" />
+                <span>
+                  <span className="font-mono">{c.path}</span> is also written by{' '}
+                  {c.remediations.filter(r => r !== name).map((r, i) => (
+                    <span key={r}>
+                      {i > 0 && ', '}
+                      <Link to={`/remediation/${encodeURIComponent(r)}`} className="font-mono underline">{r}</Link>
+                    </span>
+                  ))}
+                  {c.identical ? ' with identical content' : ' with different content'}
+                </span>
+              </p>
+            ))}
+          </div>
+        )}
+
+        {showRole && preview ? (
+          <>
+            {preview.files.map((f, i) => (
+              <FileView key={i} file={f} source={f.remediation} highlight={f.remediation === name} />
+            ))}
+            {preview.units.map((u, i) => (
+              <UnitView key={i} unit={u} source={u.remediation} highlight={u.remediation === name} />
+            ))}
+          </>
+        ) : (
+          <>
+            {ignition.files.length === 0 && ignition.units.length === 0 && (
+              <p className="text-sm text-gray-500">This MachineConfig writes no files or units.</p>
+            )}
+            {ignition.files.map(f => <FileView key={f.path} file={f} />)}
+            {ignition.units.map(u => <UnitView key={u.name} unit={u} />)}
+          </>
+        )}
+      </div>
+    </div>
+  );
+}
+
+function FileView({ file, source, highlight }: { file: IgnitionFile; source?: string; highlight?: boolean }) {
+  return (
+    <div className={`rounded-lg border ${highlight ? 'border-primary-300' : 'border-gray-200'}`}>
+      <div className="px-3 py-2 flex items-center gap-3 text-xs border-b border-gray-100">
+        <FileText className="h-3.5 w-3.5 text-gray-400" />
+        <span className="font-mono text-gray-900">{file.path}</span>
+        {file.mode && <span className="font-mono text-gray-500">{file.mode}</span>}
+        {file.owner && <span className="text-gray-500">{file.owner}</span>}
+        {file.encoding && <span className="badge bg-gray-100 text-gray-600">{file.encoding}</span>}
+        {source && <span className="ml-auto font-mono text-gray-500">{source}</span>}
+      </div>
+      {file.error ? (
+        <p className="px-3 py-2 text-xs text-red-700">
+          {file.error}{file.source && <span className="font-mono text-gray-500"> ({file.source})</span>}
+        </p>
+      ) : file.binary ? (
+        <p className="px-3 py-2 text-xs text-gray-500">Binary content, {file.size} bytes</p>
+      ) : (
+        <pre className="bg-gray-900 text-gray-100 rounded-b-lg p-3 overflow-x-auto text-xs font-mono whitespace-pre">
+          {file.content || '(empty)'}
+          {file.truncated && '\n... (truncated)'}
+        </pre>
+      )}
+    </div>
+  );
+}
+
+function UnitView({ unit, source, highlight }: { unit: SystemdUnit; source?: string; highlight?: boolean }) {
+  return (
+    <div className={`rounded-lg border ${highlight ? 'border-primary-300' : 'border-gray-200'}`}>
+      <div className="px-3 py-2 flex items-center gap-3 text-xs border-b border-gray-100">
+        <Settings className="h-3.5 w-3.5 text-gray-400" />
+        <span className="font-mono text-gray-900">{unit.name}</span>
+        {unit.enabled !== undefined && (
+          <span className={`badge ${unit.enabled ? 'bg-emerald-100 text-emerald-700' : 'bg-gray-100 text-gray-600'}`}>
+            {unit.enabled ? 'enabled' : 'disabled'}
+          </span>
+        )}
+        {unit.mask && <span className="badge bg-red-100 text-red-700">masked</span>}
+        {source && <span className="ml-auto font-mono text-gray-500">{source}</span>}
+      </div>
+      {unit.contents && (
+        <pre className="bg-gray-900 text-gray-100 p-3 overflow-x-auto text-xs font-mono whitespace-pre">{unit.contents}</pre>
+      )}
+      {unit.dropins?.map(d => (
+        <div key={d.name}>
+          <p className="px-3 py-1 text-xs font-mono text-gray-600">{d.name}</p>
+          {d.contents && (
+            <pre className="bg-gray-900 text-gray-100 p-3 overflow-x-auto text-xs font-mono whitespace-pre">{d.contents}</pre>
+          )}
+        </div>
+      ))}
+    </div>
+  );
+}
@@ -0,0 +1,329 @@
+import { useState } from 'react';
+import { ChevronDown, ChevronRight, ShieldCheck } from 'lucide-react';
+import { operatorApi } from '../lib/api';
+import type { InstallOptions, InstallPlanApproval, InstallSource, InstallTimeouts, MirrorImageStatus, MirrorReport, Toleration } from '../types/api';
+
+interface Props {
+  value: InstallOptions;
+  onChange: (options: InstallOptions) => void;
+  onError: (error: string | null) => void;
+}
+
+const proxyVars = ['HTTP_PROXY', 'HTTPS_PROXY', 'NO_PROXY'];
+
+const timeoutFields: { key: keyof InstallTimeouts; label: string }[] = [
+  { key: 'csv_seconds', label: 'CSV timeout (min)' },
+  { key: 'pods_seconds', label: 'Pods timeout (min)' },
+  { key: 'profile_bundles_seconds', label: 'ProfileBundles timeout (min)' },
+];
+
+const imageStatusColors: Record<MirrorImageStatus, string> = {
+  resolved: 'bg-emerald-100 text-emerald-700',
+  unauthorized: 'bg-amber-100 text-amber-700',
+  missing: 'bg-red-100 text-red-700',
+  unreachable: 'bg-red-100 text-red-700',
+  rejected: 'bg-red-100 text-red-700',
+};
+
+// parseKeyValues reads "key=value" lines into a map, skipping blank lines.
+function parseKeyValues(text: string): Record<string, string> | undefined {
+  const out: Record<string, string> = {};
+  for (const line of text.split('\n')) {
+    const trimmed = line.trim();
+    if (!trimmed) continue;
+    const idx = trimmed.indexOf('=');
+    if (idx < 0) out[trimmed] = '';
+    else out[trimmed.slice(0, idx).trim()] = trimmed.slice(idx + 1).trim();
+  }
+  return Object.keys(out).length > 0 ? out : undefined;
+}
+
+// InstallOptionsForm edits the optional install request: catalog source,
+// channel, approval, disconnected mirrors and the Subscription config for
+// the operator pods.
+export default function InstallOptionsForm({ value, onChange, onError }: Props) {
+  const [open, setOpen] = useState(false);
+  const [nodeSelector, setNodeSelector] = useState('');
+  const [tolerations, setTolerations] = useState('');
+  const [proxy, setProxy] = useState<Record<string, string>>({});
+  const [resources, setResources] = useState<Record<string, string>>({});
+  const [mirrorReport, setMirrorReport] = useState<MirrorReport | null>(null);
+  const [checking, setChecking] = useState(false);
+
+  const checkMirrors = async () => {
+    setChecking(true);
+    try {
+      setMirrorReport(await operatorApi.mirrorPreflight(value));
+      onError(null);
+    } catch (err) {
+      onError(err instanceof Error ? err.message : 'Mirror check failed');
+    } finally {
+      setChecking(false);
+    }
+  };
+
+  const set = (patch: Partial<InstallOptions>) => onChange({ ...value, ...patch });
+
+  // setPhaseTimeout stores a phase timeout entered in minutes as seconds.
+  const setPhaseTimeout = (key: keyof InstallTimeouts, minutes: string) => {
+    const next: InstallTimeouts = { ...value.timeouts, [key]: minutes ? Math.round(Number(minutes) * 60) : undefined };
+    const empty = Object.values(next).every(v => v === undefined);
+    set({ timeouts: empty ? undefined : next });
+  };
+
+  // rebuildConfig recomputes config from the raw form fields.
+  const rebuildConfig = (next: {
+    nodeSelector?: string;
+    tolerations?: string;
+    proxy?: Record<string, string>;
+    resources?: Record<string, string>;
+  }) => {
+    const ns = next.nodeSelector ?? nodeSelector;
+    const tol = next.tolerations ?? tolerations;
+    const px = next.proxy ?? proxy;
+    const res = next.resources ?? resources;
+
+    let parsedTolerations: Toleration[] | undefined;
+    if (tol.trim()) {
+      try {
+        parsedTolerations = JSON.parse(tol);
+        if (!Array.isArray(parsedTolerations)) throw new Error();
+        onError(null);
+      } catch {
+        onError('Tolerations must be a JSON array');
+        return;
+      }
+    } else {
+      onError(null);
+    }
+
+    const env = proxyVars.filter(name => px[name]).map(name => ({ name, value: px[name] }));
+    const pick = (prefix: string) => {
+      const out: Record<string, string> = {};
+      for (const r of ['cpu', 'memory']) if (res[`${prefix}.${r}`]) out[r] = res[`${prefix}.${r}`];
+      return Object.keys(out).length > 0 ? out : undefined;
+    };
+    const requests = pick('requests');
+    const limits = pick('limits');
+
+    const config = {
+      nodeSelector: parseKeyValues(ns),
+      tolerations: parsedTolerations,
+      resources: requests || limits ? { requests, limits } : undefined,
+      env: env.length > 0 ? env : undefined,
+    };
+    const empty = Object.values(config).every(v => v === undefined);
+    onChange({ ...value, config: empty ? undefined : config });
+  };
+
+  return (
+    <div className="text-left border border-gray-200 rounded-lg mb-4">
+      <button
+        type="button"
+        className="w-full flex items-center gap-2 px-4 py-2 text-sm font-medium text-gray-700"
+        onClick={() => setOpen(!open)}
+      >
+        {open ? <ChevronDown className="h-4 w-4" /> : <ChevronRight className="h-4 w-4" />}
+        Advanced options
+      </button>
+      {open && (
+        <div className="px-4 pb-4 grid grid-cols-1 md:grid-cols-2 gap-3">
+          <label className="text-xs text-gray-500">
+            Source
+            <select
+              className="input mt-1 block w-full"
+              value={value.source || ''}
+              onChange={e => set({
+                source: (e.target.value || undefined) as InstallSource | undefined,
+                catalog_source: e.target.value === 'custom' ? value.catalog_source : undefined,
+                catalog_source_namespace: e.target.value === 'custom' ? value.catalog_source_namespace : undefined,
+                catalog_image: e.target.value === 'community' || e.target.value === '' ? value.catalog_image : undefined,
+              })}
+            >
+              <option value="">Auto-detect</option>
+              <option value="redhat">Red Hat certified</option>
+              <option value="community">Community (upstream catalog)</option>
+              <option value="custom">Custom CatalogSource</option>
+              <option value="manifests">Bundled manifests (no OLM)</option>
+            </select>
+          </label>
+          {value.source === 'custom' ? (
+            <div className="grid grid-cols-2 gap-2">
+              <label className="text-xs text-gray-500">
+                CatalogSource
+                <input
+                  className="input mt-1 block w-full"
+                  value={value.catalog_source || ''}
+                  onChange={e => set({ catalog_source: e.target.value || undefined })}
+                />
+              </label>
+              <label className="text-xs text-gray-500">
+                Namespace
+                <input
+                  className="input mt-1 block w-full"
+                  placeholder="openshift-marketplace"
+                  value={value.catalog_source_namespace || ''}
+                  onChange={e => set({ catalog_source_namespace: e.target.value || undefined })}
+                />
+              </label>
+            </div>
+          ) : <div />}
+          <label className="text-xs text-gray-500">
+            Channel
+            <input
+              className="input mt-1 block w-full"
+              placeholder="Source default"
+              value={value.channel || ''}
+              onChange={e => set({ channel: e.target.value || undefined })}
+            />
+          </label>
+          <label className="text-xs text-gray-500">
+            Starting CSV
+            <input
+              className="input mt-1 block w-full"
+              placeholder="compliance-operator.v1.6.0"
+              value={value.starting_csv || ''}
+              onChange={e => set({ starting_csv: e.target.value || undefined })}
+            />
+          </label>
+          <label className="text-xs text-gray-500">
+            Approval
+            <select
+              className="input mt-1 block w-full"
+              value={value.approval || 'Automatic'}
+              onChange={e => set({ approval: e.target.value as InstallPlanApproval })}
+            >
+              <option value="Automatic">Automatic</option>
+              <option value="Manual">Manual</option>
+            </select>
+          </label>
+          <label className="flex items-center gap-2 text-xs text-gray-700 self-end">
+            <input
+              type="checkbox"
+              checked={!!value.disconnected}
+              onChange={e => set({
+                disconnected: e.target.checked || undefined,
+                mirror_registry: e.target.checked ? value.mirror_registry : undefined,
+              })}
+            />
+            Disconnected (air-gapped) install
+          </label>
+          {value.disconnected && (
+            <div className="md:col-span-2 space-y-2">
+              <div className="grid grid-cols-1 md:grid-cols-2 gap-3">
+                <label className="text-xs text-gray-500">
+                  Mirror registry
+                  <input
+                    className="input mt-1 block w-full"
+                    placeholder="Cluster mirror rules"
+                    value={value.mirror_registry || ''}
+                    onChange={e => set({ mirror_registry: e.target.value || undefined })}
+                  />
+                </label>
+                <label className="text-xs text-gray-500">
+                  Catalog image
+                  <input
+                    className="input mt-1 block w-full"
+                    placeholder="mirror.example.com:5000/compliance-operator-catalog:v1.7.0"
+                    value={value.catalog_image || ''}
+                    disabled={value.source === 'redhat' || value.source === 'custom' || value.source === 'manifests'}
+                    onChange={e => set({ catalog_image: e.target.value || undefined })}
+                  />
+                </label>
+              </div>
+              <button type="button" className="btn btn-secondary text-xs" onClick={checkMirrors} disabled={checking}>
+                <ShieldCheck className="h-4 w-4" />
+                {checking ? 'Checking...' : 'Check mirrors'}
+              </button>
+              {mirrorReport && (
+                <div className="space-y-1">
+                  {mirrorReport.rules.length > 0 && (
+                    <p className="text-xs text-gray-500">
+                      {mirrorReport.rules.length} cluster mirror rule{mirrorReport.rules.length === 1 ? '' : 's'}
+                    </p>
+                  )}
+                  {mirrorReport.images.map(img => (
+                    <div key={img.mirror} className="flex items-center gap-2 text-xs">
+                      <span className={`badge ${imageStatusColors[img.status]}`}>{img.status}</span>
+                      <span className="font-mono text-gray-700 truncate" title={img.image}>{img.mirror}</span>
+                    </div>
+                  ))}
+                  {!mirrorReport.ready && (
+                    <p className="text-xs text-red-700">Some mirrored images do not resolve; the install will fail.</p>
+                  )}
+                </div>
+              )}
+            </div>
+          )}
+          <label className="text-xs text-gray-500">
+            Node selector (key=value per line)
+            <textarea
+              className="input mt-1 block w-full font-mono text-xs"
+              rows={3}
+              value={nodeSelector}
+              onChange={e => { setNodeSelector(e.target.value); rebuildConfig({ nodeSelector: e.target.value }); }}
+            />
+          </label>
+          <label className="text-xs text-gray-500">
+            Tolerations (JSON array)
+            <textarea
+              className="input mt-1 block w-full font-mono text-xs"
+              rows={3}
+              placeholder='[{"key":"node-role.kubernetes.io/infra","operator":"Exists","effect":"NoSchedule"}]'
+              value={tolerations}
+              onChange={e => { setTolerations(e.target.value); rebuildConfig({ tolerations: e.target.value }); }}
+            />
+          </label>
+          {proxyVars.map(name => (
+            <label key={name} className="text-xs text-gray-500">
+              {name}
+              <input
+                className="input mt-1 block w-full"
+                value={proxy[name] || ''}
+                onChange={e => {
+                  const next = { ...proxy, [name]: e.target.value };
+                  setProxy(next);
+                  rebuildConfig({ proxy: next });
+                }}
+              />
+            </label>
+          ))}
+          <div className="grid grid-cols-3 gap-2 md:col-span-2">
+            {timeoutFields.map(({ key, label }) => {
+              const seconds = value.timeouts?.[key];
+              return (
+                <label key={key} className="text-xs text-gray-500">
+                  {label}
+                  <input
+                    type="number"
+                    min={1}
+                    className="input mt-1 block w-full"
+                    placeholder="Server default"
+                    value={seconds ? seconds / 60 : ''}
+                    onChange={e => setPhaseTimeout(key, e.target.value)}
+                  />
+                </label>
+              );
+            })}
+          </div>
+          <div className="grid grid-cols-4 gap-2 md:col-span-2">
+            {['requests.cpu', 'requests.memory', 'limits.cpu', 'limits.memory'].map(key => (
+              <label key={key} className="text-xs text-gray-500">
+                {key}
+                <input
+                  className="input mt-1 block w-full"
+                  value={resources[key] || ''}
+                  onChange={e => {
+                    const next = { ...resources, [key]: e.target.value };
+                    setResources(next);
+                    rebuildConfig({ resources: next });
+                  }}
+                />
+              </label>
+            ))}
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
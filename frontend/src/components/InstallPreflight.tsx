@@ -0,0 +1,70 @@
+import { useState } from 'react';
+import { AlertTriangle, CheckCircle, ClipboardCheck, XCircle } from 'lucide-react';
+import { operatorApi } from '../lib/api';
+import type { InstallOptions, PreflightCheck, PreflightReport } from '../types/api';
+
+interface Props {
+  options: InstallOptions;
+}
+
+function CheckIcon({ check }: { check: PreflightCheck }) {
+  switch (check.status) {
+    case 'pass':
+      return <CheckCircle className="h-4 w-4 text-emerald-500 flex-shrink-0" />;
+    case 'warn':
+      return <AlertTriangle className="h-4 w-4 text-amber-500 flex-shrink-0" />;
+    default:
+      return <XCircle className="h-4 w-4 text-red-500 flex-shrink-0" />;
+  }
+}
+
+// InstallPreflight runs the install preflight checks for the chosen options
+// and lists each result with its hint.
+export default function InstallPreflight({ options }: Props) {
+  const [report, setReport] = useState<PreflightReport | null>(null);
+  const [running, setRunning] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const run = async () => {
+    setRunning(true);
+    setError(null);
+    try {
+      setReport(await operatorApi.preflight(options));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Preflight failed');
+    } finally {
+      setRunning(false);
+    }
+  };
+
+  return (
+    <div className="text-left mb-4">
+      <button type="button" className="btn btn-secondary" onClick={run} disabled={running}>
+        <ClipboardCheck className="h-4 w-4" />
+        {running ? 'Running checks...' : 'Run Preflight Checks'}
+      </button>
+      {error && <p className="text-sm text-red-700 mt-2">{error}</p>}
+      {report && (
+        <div className="mt-3 space-y-2">
+          {report.checks.map(check => (
+            <div key={check.name} className="flex items-start gap-2">
+              <CheckIcon check={check} />
+              <div className="min-w-0">
+                <p className="text-sm text-gray-900">
+                  <span className="font-medium">{check.title}</span>
+                  <span className="text-gray-500"> - {check.message}</span>
+                </p>
+                {check.hint && <p className="text-xs text-gray-500 mt-0.5">{check.hint}</p>}
+              </div>
+            </div>
+          ))}
+          <p className={`text-sm font-medium ${report.ready ? 'text-emerald-700' : 'text-red-700'}`}>
+            {report.ready
+              ? report.status === 'warn' ? 'Ready to install, with warnings' : 'Ready to install'
+              : 'Fix the failed checks before installing'}
+          </p>
+        </div>
+      )}
+    </div>
+  );
+}
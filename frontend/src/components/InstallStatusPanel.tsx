@@ -0,0 +1,104 @@
+import { useEffect, useState } from 'react';
+import { CheckCircle, ChevronDown, ChevronRight, Circle, Loader2, MinusCircle, RotateCcw, XCircle } from 'lucide-react';
+import { operatorApi } from '../lib/api';
+import { useDashboardStore } from '../lib/store';
+import type { InstallState, InstallStepStatus } from '../types/api';
+
+function StepIcon({ step }: { step: InstallStepStatus }) {
+  switch (step.state) {
+    case 'Succeeded':
+      return <CheckCircle className="h-4 w-4 text-emerald-500 flex-shrink-0" />;
+    case 'Failed':
+      return <XCircle className="h-4 w-4 text-red-500 flex-shrink-0" />;
+    case 'Running':
+      return <Loader2 className="h-4 w-4 text-primary-500 animate-spin flex-shrink-0" />;
+    case 'Skipped':
+      return <MinusCircle className="h-4 w-4 text-gray-400 flex-shrink-0" />;
+    default:
+      return <Circle className="h-4 w-4 text-gray-300 flex-shrink-0" />;
+  }
+}
+
+// InstallStatusPanel shows the persisted step log of the last install or
+// uninstall that did not succeed, so a reloaded page or restarted dashboard
+// still shows where it got to, and offers to resume it.
+export default function InstallStatusPanel() {
+  const { installProgress, uninstallProgress, updateCounter } = useDashboardStore();
+  const [state, setState] = useState<InstallState | null>(null);
+  const [expanded, setExpanded] = useState<string | null>(null);
+  const [resuming, setResuming] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  // Refetch as live progress arrives so step states stay current.
+  useEffect(() => {
+    operatorApi.getInstallState().then(setState).catch(() => setState(null));
+  }, [installProgress.length, uninstallProgress.length, updateCounter]);
+
+  if (!state || state.phase === 'Succeeded') return null;
+
+  const resume = async () => {
+    setResuming(true);
+    setError(null);
+    try {
+      await operatorApi.resumeInstall();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to resume');
+    } finally {
+      setResuming(false);
+    }
+  };
+
+  return (
+    <div className="card">
+      <div className="px-6 py-4 border-b border-gray-200 flex items-center justify-between">
+        <div>
+          <h3 className="font-semibold text-gray-900 capitalize">Last {state.operation}</h3>
+          <p className="text-sm text-gray-500 mt-1">
+            {state.phase} &middot; attempt {state.attempt} &middot; namespace {state.namespace}
+            {state.co_ref && <> &middot; {state.co_ref}</>}
+          </p>
+        </div>
+        {state.resumable && (
+          <button className="btn btn-primary" onClick={resume} disabled={resuming}>
+            <RotateCcw className="h-4 w-4" />
+            {resuming ? 'Resuming...' : `Resume from ${state.failed_step}`}
+          </button>
+        )}
+      </div>
+      <div className="p-6 space-y-2">
+        {state.steps.map(step => (
+          <div key={step.name}>
+            <button
+              type="button"
+              className="w-full flex items-center gap-2 text-left"
+              onClick={() => setExpanded(expanded === step.name ? null : step.name)}
+              disabled={step.log.length === 0}
+            >
+              <StepIcon step={step} />
+              <span className="text-sm text-gray-900 flex-1">{step.title}</span>
+              {step.attempts && step.attempts > 1 && (
+                <span className="text-xs text-gray-500">{step.attempts} attempts</span>
+              )}
+              {step.log.length > 0 && (
+                expanded === step.name
+                  ? <ChevronDown className="h-4 w-4 text-gray-400" />
+                  : <ChevronRight className="h-4 w-4 text-gray-400" />
+              )}
+            </button>
+            {step.error && <p className="text-xs text-red-600 ml-6 mt-0.5">{step.error}</p>}
+            {expanded === step.name && (
+              <div className="ml-6 mt-1 space-y-0.5">
+                {step.log.map((entry, idx) => (
+                  <p key={idx} className={`text-xs font-mono ${entry.error ? 'text-red-600' : 'text-gray-500'}`}>
+                    {new Date(entry.time).toLocaleTimeString()} {entry.message}
+                  </p>
+                ))}
+              </div>
+            )}
+          </div>
+        ))}
+        {error && <p className="text-sm text-red-700 mt-2">{error}</p>}
+      </div>
+    </div>
+  );
+}
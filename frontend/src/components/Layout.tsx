@@ -1,5 +1,5 @@
 import { Outlet, Link, useLocation } from 'react-router-dom';
-import { LayoutDashboard, FileSearch, Radar, Shield, Settings } from 'lucide-react';
+import { LayoutDashboard, FileSearch, Radar, Shield, ClipboardCheck, CalendarClock, Settings } from 'lucide-react';
 import StatusIndicator from './StatusIndicator';
 import { useDashboardStore } from '../lib/store';
 
@@ -8,6 +8,8 @@ const navigation = [
   { name: 'Scans', href: '/scans', icon: Radar },
   { name: 'Results', href: '/results', icon: FileSearch },
   { name: 'Remediation', href: '/remediation', icon: Shield },
+  { name: 'Approvals', href: '/approvals', icon: ClipboardCheck },
+  { name: 'Maintenance', href: '/maintenance', icon: CalendarClock },
   { name: 'Settings', href: '/settings', icon: Settings },
 ];
 
@@ -2,11 +2,15 @@ import { useState } from 'react';
 import { CheckCircle, XCircle, Loader2, Download } from 'lucide-react';
 import { operatorApi } from '../lib/api';
 import { useDashboardStore } from '../lib/store';
-import type { InstallProgress } from '../types/api';
+import type { InstallOptions, InstallProgress } from '../types/api';
+import InstallOptionsForm from './InstallOptionsForm';
+import InstallPreflight from './InstallPreflight';
 
 export default function OperatorInstallWizard() {
   const [installStarted, setInstallStarted] = useState(false);
   const [error, setError] = useState<string | null>(null);
+  const [options, setOptions] = useState<InstallOptions>({});
+  const [optionsError, setOptionsError] = useState<string | null>(null);
   const { installProgress, clearInstallProgress } = useDashboardStore();
 
   const handleInstall = async () => {
@@ -15,7 +19,7 @@ export default function OperatorInstallWizard() {
     clearInstallProgress();
 
     try {
-      await operatorApi.install();
+      await operatorApi.install(options);
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to start installation');
       setInstallStarted(false);
@@ -26,6 +30,7 @@ export default function OperatorInstallWizard() {
   const isComplete = installProgress.some(p => p.done && !p.error);
   const hasFailed = installProgress.some(p => p.done && !!p.error);
   const installing = installStarted && !isComplete && !hasFailed;
+  const resolved = installProgress.find(p => p.options)?.options;
 
   const getStepIcon = (step: InstallProgress) => {
     if (step.error) return <XCircle className="h-5 w-5 text-red-500" />;
@@ -50,10 +55,13 @@ export default function OperatorInstallWizard() {
               This will install the Compliance Operator, create the required namespace,
               set up RBAC, and wait for ProfileBundles to become valid.
             </p>
+            <InstallOptionsForm value={options} onChange={setOptions} onError={setOptionsError} />
+            {optionsError && <p className="text-sm text-red-700 mb-3">{optionsError}</p>}
+            <InstallPreflight options={options} />
             <button
               className="btn btn-primary"
               onClick={handleInstall}
-              disabled={installing}
+              disabled={installing || !!optionsError}
             >
               Start Installation
             </button>
@@ -62,6 +70,26 @@ export default function OperatorInstallWizard() {
 
         {(installProgress.length > 0 || installing) && (
           <div className="space-y-3">
+            {resolved && (
+              <div className="grid grid-cols-2 md:grid-cols-4 gap-3 p-3 bg-gray-50 rounded-lg text-xs">
+                <div>
+                  <p className="text-gray-500">Source</p>
+                  <p className="text-gray-900 capitalize">{resolved.source}</p>
+                </div>
+                <div>
+                  <p className="text-gray-500">Catalog</p>
+                  <p className="font-mono text-gray-900">{resolved.catalog_source}</p>
+                </div>
+                <div>
+                  <p className="text-gray-500">Channel</p>
+                  <p className="font-mono text-gray-900">{resolved.channel}</p>
+                </div>
+                <div>
+                  <p className="text-gray-500">Approval</p>
+                  <p className="text-gray-900">{resolved.approval}</p>
+                </div>
+              </div>
+            )}
             {installProgress.map((step, idx) => (
               <div key={idx} className="flex items-start gap-3">
                 {getStepIcon(step)}
@@ -72,6 +100,11 @@ export default function OperatorInstallWizard() {
                   <p className={`text-xs mt-0.5 ${step.error ? 'text-red-600' : 'text-gray-500'}`}>
                     {step.message}
                   </p>
+                  {step.wait && idx === installProgress.length - 1 && !step.done && (
+                    <div className="mt-1 h-1.5 w-full bg-gray-200 rounded-full overflow-hidden">
+                      <div className="h-full bg-primary-500" style={{ width: `${step.wait.percent}%` }} />
+                    </div>
+                  )}
                 </div>
               </div>
             ))}
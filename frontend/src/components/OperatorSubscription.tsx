@@ -0,0 +1,170 @@
+import { useCallback, useEffect, useState } from 'react';
+import { ArrowUpCircle, Check } from 'lucide-react';
+import { operatorApi } from '../lib/api';
+import { useDashboardStore } from '../lib/store';
+import type { InstallPlanApproval, SubscriptionInfo } from '../types/api';
+
+// OperatorSubscription shows the operator's OLM Subscription and lets the
+// user switch channel, change approval mode and approve pending upgrades.
+export default function OperatorSubscription() {
+  const { updateCounter } = useDashboardStore();
+  const [sub, setSub] = useState<SubscriptionInfo | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [busy, setBusy] = useState(false);
+  const [channel, setChannel] = useState('');
+
+  const fetchSubscription = useCallback(async () => {
+    try {
+      const data = await operatorApi.getSubscription();
+      setSub(data);
+      setChannel(data.channel);
+      setError(null);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to fetch subscription');
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchSubscription();
+  }, [updateCounter, fetchSubscription]);
+
+  const run = async (fn: () => Promise<unknown>) => {
+    setBusy(true);
+    try {
+      await fn();
+      setError(null);
+      await fetchSubscription();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Request failed');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const pending = sub?.install_plans.filter(p => p.requires_approval) || [];
+
+  return (
+    <div className="card">
+      <div className="px-6 py-4 border-b border-gray-200">
+        <h3 className="font-semibold text-gray-900">Subscription and Upgrades</h3>
+      </div>
+      <div className="p-6 space-y-4">
+        {error && <p className="text-sm text-red-700">{error}</p>}
+        {!sub ? (
+          !error && <p className="text-sm text-gray-500">Loading subscription...</p>
+        ) : (
+          <>
+            <div className="grid grid-cols-2 md:grid-cols-4 gap-4 text-sm">
+              <div>
+                <p className="text-xs text-gray-500">Catalog</p>
+                <p className="font-mono text-xs text-gray-900">{sub.source}</p>
+              </div>
+              <div>
+                <p className="text-xs text-gray-500">Installed CSV</p>
+                <p className="font-mono text-xs text-gray-900">{sub.installed_csv || '-'}</p>
+              </div>
+              <div>
+                <p className="text-xs text-gray-500">Current CSV</p>
+                <p className="font-mono text-xs text-gray-900">{sub.current_csv || '-'}</p>
+              </div>
+              <div>
+                <p className="text-xs text-gray-500">State</p>
+                <p className="text-xs text-gray-900">{sub.state || '-'}</p>
+              </div>
+            </div>
+
+            {sub.upgrade_available && (
+              <div className="flex items-center gap-2 text-sm text-sky-700">
+                <ArrowUpCircle className="h-4 w-4" />
+                Upgrade to {sub.current_csv} {sub.pending_approval ? 'is waiting for approval' : 'is in progress'}
+              </div>
+            )}
+
+            <div className="flex flex-wrap items-end gap-3">
+              <label className="text-xs text-gray-500">
+                Channel
+                <select
+                  className="input mt-1 block w-56"
+                  value={channel}
+                  onChange={e => setChannel(e.target.value)}
+                  disabled={sub.channels.length === 0}
+                >
+                  {sub.channels.length === 0 && <option value={sub.channel}>{sub.channel}</option>}
+                  {sub.channels.map(ch => (
+                    <option key={ch.name} value={ch.name}>
+                      {ch.name}{ch.version ? ` (${ch.version})` : ''}{ch.default ? ' - default' : ''}
+                    </option>
+                  ))}
+                </select>
+              </label>
+              <button
+                className="btn btn-secondary"
+                disabled={busy || channel === sub.channel}
+                onClick={() => run(() => operatorApi.updateSubscription({ channel }))}
+              >
+                Switch Channel
+              </button>
+              <label className="text-xs text-gray-500">
+                Approval
+                <select
+                  className="input mt-1 block w-40"
+                  value={sub.approval}
+                  disabled={busy}
+                  onChange={e => run(() => operatorApi.updateSubscription({ approval: e.target.value as InstallPlanApproval }))}
+                >
+                  <option value="Automatic">Automatic</option>
+                  <option value="Manual">Manual</option>
+                </select>
+              </label>
+            </div>
+
+            {sub.channels.find(ch => ch.name === channel)?.entries && (
+              <div>
+                <h4 className="text-xs font-medium text-gray-500 uppercase tracking-wider mb-2">Versions in {channel}</h4>
+                <div className="flex flex-wrap gap-1.5">
+                  {sub.channels.find(ch => ch.name === channel)?.entries?.map(entry => (
+                    <span
+                      key={entry.name}
+                      className={`badge ${entry.name === sub.installed_csv ? 'bg-emerald-100 text-emerald-700' : 'bg-gray-100 text-gray-600'}`}
+                    >
+                      {entry.version || entry.name}
+                    </span>
+                  ))}
+                </div>
+              </div>
+            )}
+
+            {pending.length > 0 && (
+              <div>
+                <h4 className="text-xs font-medium text-gray-500 uppercase tracking-wider mb-2">Pending InstallPlans</h4>
+                <div className="space-y-2">
+                  {pending.map(plan => (
+                    <div key={plan.name} className="flex items-center gap-3 text-sm">
+                      <span className="font-mono text-xs text-gray-700">{plan.name}</span>
+                      <span className="text-xs text-gray-500">{plan.csvs.join(', ')}</span>
+                      {plan.other_csvs && plan.other_csvs.length > 0 && (
+                        <span className="text-xs text-amber-600">
+                          Also installs {plan.other_csvs.join(', ')}
+                        </span>
+                      )}
+                      <button
+                        className="btn btn-primary text-xs px-3 py-1.5 ml-auto"
+                        disabled={busy}
+                        onClick={() => run(() => operatorApi.approveInstallPlan(plan.name, plan.other_csvs))}
+                      >
+                        <Check className="h-4 w-4" />
+                        {plan.other_csvs && plan.other_csvs.length > 0
+                          ? `Approve all ${plan.csvs.length} CSVs`
+                          : 'Approve'}
+                      </button>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            )}
+          </>
+        )}
+      </div>
+    </div>
+  );
+}
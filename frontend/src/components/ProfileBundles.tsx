@@ -0,0 +1,184 @@
+import { useEffect, useState, useCallback } from 'react';
+import { Package, ChevronDown, ChevronRight, Pencil, Trash2 } from 'lucide-react';
+import { profileBundleApi } from '../lib/api';
+import { useDashboardStore } from '../lib/store';
+import type { ProfileBundleInfo, ProfileBundleSpec } from '../types/api';
+
+const statusBadge: Record<string, string> = {
+  VALID: 'bg-emerald-100 text-emerald-700',
+  INVALID: 'bg-red-100 text-red-700',
+  PENDING: 'bg-gray-100 text-gray-600',
+};
+
+const emptyForm: ProfileBundleSpec = { name: '', content_image: '', content_file: 'ssg-ocp4-ds.xml' };
+
+export default function ProfileBundles() {
+  const [bundles, setBundles] = useState<ProfileBundleInfo[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+  const [expanded, setExpanded] = useState<Set<string>>(new Set());
+  const [form, setForm] = useState<ProfileBundleSpec>(emptyForm);
+  const [editing, setEditing] = useState(false);
+  const { updateCounter, clusterStatus } = useDashboardStore();
+
+  const fetchBundles = useCallback(async () => {
+    try {
+      setBundles((await profileBundleApi.list()) || []);
+      setError(null);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to fetch ProfileBundles');
+    } finally {
+      setLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    if (clusterStatus?.connected) {
+      fetchBundles();
+    }
+  }, [clusterStatus?.connected, updateCounter, fetchBundles]);
+
+  const run = async (fn: () => Promise<unknown>) => {
+    try {
+      await fn();
+      setError(null);
+      await fetchBundles();
+      return true;
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Request failed');
+      return false;
+    }
+  };
+
+  const toggle = (name: string) => {
+    setExpanded(prev => {
+      const next = new Set(prev);
+      if (next.has(name)) {
+        next.delete(name);
+      } else {
+        next.add(name);
+      }
+      return next;
+    });
+  };
+
+  const startEdit = (bundle: ProfileBundleInfo) => {
+    setForm({ name: bundle.name, content_image: bundle.content_image, content_file: bundle.content_file });
+    setEditing(true);
+  };
+
+  const resetForm = () => {
+    setForm(emptyForm);
+    setEditing(false);
+  };
+
+  const save = async () => {
+    const ok = await run(() => (editing ? profileBundleApi.update(form) : profileBundleApi.create(form)));
+    if (ok) resetForm();
+  };
+
+  return (
+    <div className="card">
+      <div className="px-5 py-4 border-b border-gray-200 bg-gray-50">
+        <h2 className="font-semibold text-gray-900">Profile Bundles</h2>
+        <p className="text-xs text-gray-500 mt-0.5">
+          ComplianceAsCode content the operator parses into profiles
+        </p>
+      </div>
+      {error && <p className="px-5 pt-3 text-sm text-red-600">{error}</p>}
+      {loading ? (
+        <p className="px-5 py-3 text-sm text-gray-500">Loading...</p>
+      ) : bundles.length === 0 ? (
+        <p className="px-5 py-3 text-sm text-gray-500">No ProfileBundles found.</p>
+      ) : (
+        <div className="divide-y divide-gray-100">
+          {bundles.map(bundle => (
+            <div key={bundle.name} className="px-5 py-3">
+              <div className="flex items-center gap-3 text-sm">
+                <button className="text-gray-400 hover:text-gray-700" onClick={() => toggle(bundle.name)}>
+                  {expanded.has(bundle.name) ? <ChevronDown className="h-4 w-4" /> : <ChevronRight className="h-4 w-4" />}
+                </button>
+                <Package className="h-4 w-4 text-gray-400" />
+                <span className="font-medium text-gray-900">{bundle.name}</span>
+                {bundle.data_stream_status && (
+                  <span className={`badge text-[10px] ${statusBadge[bundle.data_stream_status] ?? 'bg-gray-100 text-gray-600'}`}>
+                    {bundle.data_stream_status}
+                  </span>
+                )}
+                {!bundle.custom && (
+                  <span className="badge bg-primary-100 text-primary-700 text-[10px]">Operator</span>
+                )}
+                <span className="text-xs text-gray-500">{bundle.profile_count} profiles</span>
+                {bundle.custom && (
+                  <div className="ml-auto flex gap-2">
+                    <button className="text-gray-400 hover:text-gray-700" title="Edit bundle" onClick={() => startEdit(bundle)}>
+                      <Pencil className="h-4 w-4" />
+                    </button>
+                    <button
+                      className="text-gray-400 hover:text-red-600"
+                      title="Delete bundle"
+                      onClick={() => run(() => profileBundleApi.delete(bundle.name))}
+                    >
+                      <Trash2 className="h-4 w-4" />
+                    </button>
+                  </div>
+                )}
+              </div>
+              <p className="ml-11 mt-1 font-mono text-xs text-gray-600 break-all">
+                {bundle.content_image} : {bundle.content_file}
+              </p>
+              {bundle.error_message && <p className="ml-11 mt-1 text-xs text-red-600">{bundle.error_message}</p>}
+              {expanded.has(bundle.name) && (
+                <div className="ml-11 mt-2 space-y-1">
+                  {bundle.profiles.length === 0 ? (
+                    <p className="text-xs text-gray-500">No profiles parsed from this bundle yet.</p>
+                  ) : (
+                    bundle.profiles.map(profile => (
+                      <div key={profile.name} className="text-xs">
+                        <span className="font-mono text-gray-900">{profile.name}</span>
+                        <span className="text-gray-500 ml-2">{profile.title}</span>
+                      </div>
+                    ))
+                  )}
+                </div>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+      <div className="px-5 py-3 flex flex-wrap gap-3 border-t border-gray-100">
+        <input
+          className="input w-32"
+          placeholder="Name"
+          value={form.name}
+          disabled={editing}
+          onChange={e => setForm({ ...form, name: e.target.value })}
+        />
+        <input
+          className="input flex-1 min-w-[240px] font-mono"
+          placeholder="Content image"
+          value={form.content_image}
+          onChange={e => setForm({ ...form, content_image: e.target.value })}
+        />
+        <input
+          className="input w-44 font-mono"
+          placeholder="Content file"
+          value={form.content_file}
+          onChange={e => setForm({ ...form, content_file: e.target.value })}
+        />
+        <button
+          className="btn btn-secondary"
+          disabled={!form.name || !form.content_image || !form.content_file}
+          onClick={save}
+        >
+          {editing ? 'Update Bundle' : 'Add Bundle'}
+        </button>
+        {editing && (
+          <button className="btn btn-secondary" onClick={resetForm}>
+            Cancel
+          </button>
+        )}
+      </div>
+    </div>
+  );
+}
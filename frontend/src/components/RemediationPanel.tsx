@@ -1,9 +1,9 @@
 import { useState, useCallback } from 'react';
 import { Link } from 'react-router-dom';
-import { Play, AlertTriangle, RotateCw, Shield, CheckSquare, Square, Loader2, Trash2 } from 'lucide-react';
-import { remediationApi } from '../lib/api';
+import { Play, AlertTriangle, RotateCw, Shield, CheckSquare, Square, Loader2, Trash2, Download } from 'lucide-react';
+import { remediationApi, jobsApi } from '../lib/api';
 import { severityBadgeClass } from '../lib/badges';
-import type { RemediationInfo, RemediationResult, Severity } from '../types/api';
+import type { RemediationImpact, RemediationInfo, RemediationResult, Severity } from '../types/api';
 
 interface RemediationPanelProps {
   remediations: RemediationInfo[];
@@ -24,6 +24,7 @@ export default function RemediationPanel({ remediations, onApplied }: Remediatio
   const [batchProgress, setBatchProgress] = useState<{ current: number; total: number } | null>(null);
   const [, setBatchResults] = useState<{ succeeded: number; failed: number } | null>(null);
   const [showBatchConfirm, setShowBatchConfirm] = useState(false);
+  const [batchImpact, setBatchImpact] = useState<RemediationImpact | null>(null);
 
   const handleApply = async (rem: RemediationInfo) => {
     if (rem.reboot_needed) {
@@ -109,7 +110,9 @@ export default function RemediationPanel({ remediations, onApplied }: Remediatio
   // Batch apply
   const handleBatchApply = () => {
     if (selectedHasReboot) {
+      setBatchImpact(null);
       setShowBatchConfirm(true);
+      remediationApi.impact(Array.from(selected)).then(setBatchImpact).catch(() => setBatchImpact(null));
       return;
     }
     doBatchApply();
@@ -126,7 +129,9 @@ export default function RemediationPanel({ remediations, onApplied }: Remediatio
     setBatchProgress({ current: 0, total: names.length });
 
     try {
-      const results: RemediationResult[] = await remediationApi.applyBatch(names);
+      const job = await remediationApi.applyBatch(names);
+      const finished = await jobsApi.wait<RemediationResult[]>(job.id);
+      const results: RemediationResult[] = finished.result ?? [];
 
       let succeeded = 0;
       let failed = 0;
@@ -160,6 +165,26 @@ export default function RemediationPanel({ remediations, onApplied }: Remediatio
     }
   };
 
+  const handleExport = async () => {
+    setError(null);
+    setSuccessMsg(null);
+    const names = Array.from(selected);
+    try {
+      const blob = await remediationApi.exportGitOps(names);
+      const url = URL.createObjectURL(blob);
+      const a = document.createElement('a');
+      a.href = url;
+      a.download = 'compliance-remediations.tar.gz';
+      a.click();
+      URL.revokeObjectURL(url);
+      setSelected(new Set());
+      setSuccessMsg(`Exported ${names.length} remediation${names.length !== 1 ? 's' : ''} for GitOps`);
+      onApplied();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Export failed');
+    }
+  };
+
   // Group by severity
   const grouped: Record<Severity, RemediationInfo[]> = { high: [], medium: [], low: [] };
   for (const rem of remediations) {
@@ -249,6 +274,31 @@ export default function RemediationPanel({ remediations, onApplied }: Remediatio
                         {rem.applied && (
                           <span className="badge bg-emerald-100 text-emerald-700">Applied</span>
                         )}
+                        {rem.application_state && rem.application_state !== 'Applied' && rem.application_state !== 'NotApplied' && (
+                          <span
+                            className="badge bg-red-100 text-red-700"
+                            title={rem.error_message}
+                          >
+                            {rem.application_state}
+                          </span>
+                        )}
+                        {rem.customized && (
+                          <span className="badge bg-violet-100 text-violet-700">Customized</span>
+                        )}
+                        {rem.drift && (
+                          <span
+                            className="badge bg-orange-100 text-orange-700"
+                            title={rem.drifted_fields?.join(', ')}
+                          >
+                            {rem.drift === 'Missing' ? 'Object missing' : 'Drifted'}
+                          </span>
+                        )}
+                        {rem.outdated && (
+                          <span className="badge bg-sky-100 text-sky-700">Update available</span>
+                        )}
+                        {!rem.applied && rem.gitops_pending && (
+                          <span className="badge bg-indigo-100 text-indigo-700">Pending via GitOps</span>
+                        )}
                       </div>
                       {rem.role && (
                         <span className="text-xs text-gray-500">Role: {rem.role}</span>
@@ -328,6 +378,14 @@ export default function RemediationPanel({ remediations, onApplied }: Remediatio
               >
                 Clear
               </button>
+              <button
+                className="btn btn-secondary text-xs"
+                onClick={handleExport}
+                disabled={batchApplying}
+              >
+                <Download className="h-3.5 w-3.5 mr-1" />
+                Export for GitOps
+              </button>
               <button
                 className="btn btn-primary text-xs px-4"
                 onClick={handleBatchApply}
@@ -396,6 +454,18 @@ export default function RemediationPanel({ remediations, onApplied }: Remediatio
               You are about to apply <span className="font-medium">{selected.size} remediations</span>.
               Some of these include MachineConfig changes that will trigger node reboots.
             </p>
+            {batchImpact && batchImpact.pools && batchImpact.pools.length > 0 && (
+              <ul className="text-sm text-gray-600 mb-2 list-disc pl-5">
+                {batchImpact.pools.map(p => (
+                  <li key={p.pool}>
+                    Pool <span className="font-medium">{p.pool}</span>: {p.node_count} nodes, {p.max_unavailable} at a time, about {p.estimated_duration}
+                  </li>
+                ))}
+                {batchImpact.control_plane_restart && (
+                  <li className="text-amber-700">Restarts control-plane components</li>
+                )}
+              </ul>
+            )}
             <p className="text-sm text-gray-600 mb-6">
               Applying them together consolidates changes before any reboot cycle.
               This operation cannot be easily undone. Proceed?
@@ -4,14 +4,43 @@ import type {
   ClusterStatus,
   ComplianceData,
   OperatorStatus,
+  SubscriptionInfo,
+  InstallPlanInfo,
+  InstallPlanApproval,
+  InstallOptions,
+  InstallState,
+  MirrorReport,
+  PreflightReport,
+  ProfileBundleRewrite,
+  UninstallOptions,
+  UninstallPlan,
   CheckResult,
   CheckResultDetail,
   Summary,
   SuiteStatus,
   ProfileInfo,
+  ProfileBundleInfo,
+  ProfileBundleSpec,
   RemediationInfo,
   RemediationDetail,
   RemediationResult,
+  RemediationSnapshot,
+  GitOpsCommitResult,
+  VerificationResult,
+  RemediationImpact,
+  RemediationSettings,
+  DriftReport,
+  RoleIgnitionPreview,
+  PatchType,
+  ChangeRequest,
+  ChangeRequestAction,
+  MaintenanceWindow,
+  ScheduledRemediation,
+  MachineConfigPoolStatus,
+  Job,
+  RemediationFilter,
+  SeverityApplyPreview,
+  Severity,
   ScanOptions,
 } from '../types/api';
 
@@ -34,15 +63,73 @@ export const clusterApi = {
     unwrap(await api.get('/cluster/status')),
 };
 
+// preflightParams passes the install options a preflight should assume as
+// query parameters.
+function preflightParams(options?: InstallOptions) {
+  return {
+    source: options?.source,
+    catalog_source: options?.catalog_source,
+    catalog_source_namespace: options?.catalog_source_namespace,
+    registry: options?.mirror_registry,
+    catalog_image: options?.catalog_image,
+    disconnected: options?.disconnected ? 'true' : undefined,
+  };
+}
+
+// uninstallParams passes uninstall options as query parameters.
+function uninstallParams(options?: UninstallOptions) {
+  return {
+    keepNamespace: options?.keep_namespace ? 'true' : undefined,
+    export: options?.export ? 'true' : undefined,
+    force: options?.force ? 'true' : undefined,
+  };
+}
+
 export const operatorApi = {
   getStatus: async (): Promise<OperatorStatus> =>
     unwrap(await api.get('/operator/status')),
 
-  install: async (): Promise<{ message: string }> =>
-    unwrap(await api.post('/operator/install')),
+  install: async (options?: InstallOptions): Promise<{ message: string }> =>
+    unwrap(await api.post('/operator/install', options)),
+
+  uninstall: async (options?: UninstallOptions): Promise<{ message: string }> =>
+    unwrap(await api.delete('/operator', { params: uninstallParams(options) })),
+
+  planUninstall: async (options?: UninstallOptions): Promise<UninstallPlan> =>
+    unwrap(await api.delete('/operator', { params: { ...uninstallParams(options), dryRun: 'true' } })),
+
+  downloadExport: async (): Promise<Blob> =>
+    (await api.get('/operator/export', { responseType: 'blob' })).data,
+
+  downloadUninstallExport: async (): Promise<Blob> =>
+    (await api.get('/operator/uninstall/export', { responseType: 'blob' })).data,
+
+  getInstallState: async (): Promise<InstallState> =>
+    unwrap(await api.get('/operator/install/status')),
+
+  resumeInstall: async (): Promise<{ message: string; job_id: string }> =>
+    unwrap(await api.post('/operator/install/resume')),
+
+  getSubscription: async (): Promise<SubscriptionInfo> =>
+    unwrap(await api.get('/operator/subscription')),
+
+  updateSubscription: async (update: { channel?: string; approval?: InstallPlanApproval }): Promise<SubscriptionInfo> =>
+    unwrap(await api.patch('/operator/subscription', update)),
+
+  approveInstallPlan: async (name: string, confirmCsvs?: string[]): Promise<InstallPlanInfo> =>
+    unwrap(await api.post(`/operator/installplans/${encodeURIComponent(name)}/approve`, { confirm_csvs: confirmCsvs })),
+
+  preflight: async (options?: InstallOptions): Promise<PreflightReport> =>
+    unwrap(await api.get('/operator/preflight', { params: preflightParams(options) })),
+
+  mirrorPreflight: async (options?: InstallOptions): Promise<MirrorReport> =>
+    unwrap(await api.get('/operator/mirror', { params: preflightParams(options) })),
+
+  rewriteBundleImages: async (registry?: string): Promise<ProfileBundleRewrite[]> =>
+    unwrap(await api.post('/operator/mirror/bundles', registry ? { registry } : undefined)),
 
-  uninstall: async (): Promise<{ message: string }> =>
-    unwrap(await api.delete('/operator')),
+  downloadDiagnostics: async (): Promise<Blob> =>
+    (await api.get('/operator/diagnostics/bundle', { responseType: 'blob' })).data,
 };
 
 export const scanApi = {
@@ -65,6 +152,20 @@ export const scanApi = {
     unwrap(await api.delete(`/scans/${encodeURIComponent(name)}`)),
 };
 
+export const profileBundleApi = {
+  list: async (): Promise<ProfileBundleInfo[]> =>
+    unwrap(await api.get('/profilebundles')),
+
+  create: async (spec: ProfileBundleSpec): Promise<ProfileBundleInfo> =>
+    unwrap(await api.post('/profilebundles', spec)),
+
+  update: async (spec: ProfileBundleSpec): Promise<ProfileBundleInfo> =>
+    unwrap(await api.put(`/profilebundles/${encodeURIComponent(spec.name)}`, spec)),
+
+  delete: async (name: string): Promise<{ message: string }> =>
+    unwrap(await api.delete(`/profilebundles/${encodeURIComponent(name)}`)),
+};
+
 export const resultsApi = {
   getAll: async (): Promise<ComplianceData> =>
     unwrap(await api.get('/results')),
@@ -90,14 +191,155 @@ export const remediationApi = {
   getDetail: async (name: string): Promise<RemediationDetail> =>
     unwrap(await api.get(`/remediations/${encodeURIComponent(name)}`)),
 
-  apply: async (name: string): Promise<RemediationResult> =>
-    unwrap(await api.post(`/remediate/${encodeURIComponent(name)}`)),
+  getMode: async (): Promise<RemediationSettings> =>
+    unwrap(await api.get('/remediations/mode')),
+
+  listOutdated: async (): Promise<RemediationInfo[]> =>
+    unwrap(await api.get('/remediations/outdated')),
+
+  listDrifted: async (): Promise<RemediationInfo[]> =>
+    unwrap(await api.get('/remediations/drift')),
+
+  checkDrift: async (): Promise<DriftReport[]> =>
+    unwrap(await api.post('/remediations/drift/check')),
+
+  reapply: async (name: string, changeRequest?: string): Promise<RemediationResult> =>
+    unwrap(await api.post(`/remediations/${encodeURIComponent(name)}/reapply`, undefined, {
+      params: changeRequest ? { change_request: changeRequest } : undefined,
+    })),
+
+  promote: async (name: string): Promise<RemediationResult> =>
+    unwrap(await api.post(`/remediations/${encodeURIComponent(name)}/promote`)),
+
+  verify: async (name: string): Promise<Job<VerificationResult>> =>
+    unwrap(await api.post(`/remediations/${encodeURIComponent(name)}/verify`)),
+
+  ignitionPreview: async (role?: string): Promise<RoleIgnitionPreview[]> =>
+    unwrap(await api.get('/remediations/ignition', { params: role ? { role } : undefined })),
+
+  getSnapshot: async (name: string): Promise<RemediationSnapshot> =>
+    unwrap(await api.get(`/remediations/${encodeURIComponent(name)}/snapshot`)),
+
+  apply: async (
+    name: string,
+    changeRequest?: string,
+    patch?: { patch: unknown; patch_type?: PatchType },
+  ): Promise<RemediationResult> =>
+    unwrap(await api.post(`/remediate/${encodeURIComponent(name)}`, patch, {
+      params: changeRequest ? { change_request: changeRequest } : undefined,
+    })),
 
-  applyBatch: async (names: string[]): Promise<RemediationResult[]> =>
-    unwrap(await api.post('/remediate', { names })),
+  impact: async (names: string[]): Promise<RemediationImpact> =>
+    unwrap(await api.post('/remediations/impact', { names })),
 
-  remove: async (name: string): Promise<RemediationResult> =>
-    unwrap(await api.delete(`/remediate/${encodeURIComponent(name)}`)),
+  applyBatch: async (names: string[], changeRequest?: string): Promise<Job<RemediationResult[]>> =>
+    unwrap(await api.post('/remediate', { names, change_request: changeRequest })),
+
+  applyBatchWithPausedPools: async (names: string[]): Promise<Job<RemediationResult[]>> =>
+    unwrap(await api.post('/remediate', { names, pause_pools: true })),
+
+  previewBySeverity: async (severity: Severity, filter: RemediationFilter = {}): Promise<SeverityApplyPreview> =>
+    unwrap(await api.post(`/remediate/severity/${severity}`, filter)),
+
+  applyBySeverity: async (
+    severity: Severity,
+    filter: RemediationFilter,
+    confirmationToken: string,
+  ): Promise<Job<RemediationResult[]>> =>
+    unwrap(await api.post(`/remediate/severity/${severity}`, { ...filter, confirmation_token: confirmationToken })),
+
+  exportGitOps: async (names: string[]): Promise<Blob> =>
+    (await api.post('/remediations/export', { names }, { responseType: 'blob' })).data,
+
+  commitGitOps: async (names: string[], path?: string, message?: string): Promise<GitOpsCommitResult> =>
+    unwrap(await api.post('/remediations/export/git', { names, path, message })),
+
+  remove: async (name: string, changeRequest?: string): Promise<RemediationResult> =>
+    unwrap(await api.delete(`/remediate/${encodeURIComponent(name)}`, {
+      params: changeRequest ? { change_request: changeRequest } : undefined,
+    })),
+};
+
+export const changeRequestApi = {
+  list: async (): Promise<ChangeRequest[]> =>
+    unwrap(await api.get('/change-requests')),
+
+  get: async (id: string): Promise<ChangeRequest> =>
+    unwrap(await api.get(`/change-requests/${encodeURIComponent(id)}`)),
+
+  create: async (req: {
+    action: ChangeRequestAction;
+    names: string[];
+    requester: string;
+    comment?: string;
+    expires_in?: string;
+    patch?: unknown;
+    patch_type?: PatchType;
+  }): Promise<ChangeRequest> =>
+    unwrap(await api.post('/change-requests', req)),
+
+  approve: async (id: string, user: string, comment?: string): Promise<ChangeRequest> =>
+    unwrap(await api.post(`/change-requests/${encodeURIComponent(id)}/approve`, { user, comment })),
+
+  reject: async (id: string, user: string, comment?: string): Promise<ChangeRequest> =>
+    unwrap(await api.post(`/change-requests/${encodeURIComponent(id)}/reject`, { user, comment })),
+
+  comment: async (id: string, user: string, comment: string): Promise<ChangeRequest> =>
+    unwrap(await api.post(`/change-requests/${encodeURIComponent(id)}/comments`, { user, comment })),
+};
+
+export const maintenanceApi = {
+  listWindows: async (): Promise<MaintenanceWindow[]> =>
+    unwrap(await api.get('/maintenance/windows')),
+
+  saveWindow: async (window: MaintenanceWindow): Promise<MaintenanceWindow> =>
+    unwrap(await api.put(`/maintenance/windows/${encodeURIComponent(window.name)}`, window)),
+
+  deleteWindow: async (name: string): Promise<{ message: string }> =>
+    unwrap(await api.delete(`/maintenance/windows/${encodeURIComponent(name)}`)),
+
+  listQueue: async (): Promise<ScheduledRemediation[]> =>
+    unwrap(await api.get('/maintenance/queue')),
+
+  queue: async (window: string, names: string[], changeRequest?: string): Promise<ScheduledRemediation[]> =>
+    unwrap(await api.post('/maintenance/queue', { window, names, change_request: changeRequest })),
+
+  cancel: async (id: string): Promise<ScheduledRemediation> =>
+    unwrap(await api.delete(`/maintenance/queue/${encodeURIComponent(id)}`)),
+};
+
+export const machineConfigPoolApi = {
+  list: async (): Promise<MachineConfigPoolStatus[]> =>
+    unwrap(await api.get('/machineconfigpools')),
+
+  get: async (name: string): Promise<MachineConfigPoolStatus> =>
+    unwrap(await api.get(`/machineconfigpools/${encodeURIComponent(name)}`)),
+
+  pause: async (name: string): Promise<{ message: string }> =>
+    unwrap(await api.post(`/machineconfigpools/${encodeURIComponent(name)}/pause`)),
+
+  resume: async (name: string): Promise<{ message: string }> =>
+    unwrap(await api.post(`/machineconfigpools/${encodeURIComponent(name)}/resume`)),
+};
+
+export const jobsApi = {
+  list: async (): Promise<Job[]> =>
+    unwrap(await api.get('/jobs')),
+
+  get: async <T = unknown>(id: string): Promise<Job<T>> =>
+    unwrap(await api.get(`/jobs/${encodeURIComponent(id)}`)),
+
+  cancel: async (id: string): Promise<{ message: string }> =>
+    unwrap(await api.delete(`/jobs/${encodeURIComponent(id)}`)),
+
+  // Polls a job until it reaches a terminal state.
+  wait: async <T = unknown>(id: string, intervalMs = 1000): Promise<Job<T>> => {
+    for (;;) {
+      const job = await jobsApi.get<T>(id);
+      if (job.state !== 'running') return job;
+      await new Promise((resolve) => setTimeout(resolve, intervalMs));
+    }
+  },
 };
 
 export default api;
@@ -4,6 +4,10 @@ import type {
   OperatorStatus,
   ComplianceData,
   InstallProgress,
+  MachineConfigBatchProgress,
+  MachineConfigPoolStatus,
+  Job,
+  WatchEvent,
   WSMessage,
 } from '../types/api';
 
@@ -34,6 +38,18 @@ interface DashboardState {
   addUninstallProgress: (progress: InstallProgress) => void;
   clearUninstallProgress: () => void;
 
+  // Batched MachineConfig apply progress
+  machineConfigBatchProgress: MachineConfigBatchProgress[];
+  clearMachineConfigBatchProgress: () => void;
+
+  // MachineConfigPool rollout state, keyed by pool name
+  machineConfigPools: Record<string, MachineConfigPoolStatus>;
+  setMachineConfigPools: (pools: MachineConfigPoolStatus[]) => void;
+
+  // Long-running jobs, keyed by ID (seed from GET /api/jobs after a reload)
+  jobs: Record<string, Job>;
+  setJobs: (jobs: Job[]) => void;
+
   // Live updates counter (triggers refetches)
   updateCounter: number;
   incrementUpdateCounter: () => void;
@@ -69,6 +85,16 @@ export const useDashboardStore = create<DashboardState>((set) => ({
     })),
   clearUninstallProgress: () => set({ uninstallProgress: [] }),
 
+  machineConfigBatchProgress: [],
+  clearMachineConfigBatchProgress: () => set({ machineConfigBatchProgress: [] }),
+
+  machineConfigPools: {},
+  setMachineConfigPools: (pools) =>
+    set({ machineConfigPools: Object.fromEntries(pools.map((p) => [p.name, p])) }),
+
+  jobs: {},
+  setJobs: (jobs) => set({ jobs: Object.fromEntries(jobs.map((j) => [j.id, j])) }),
+
   updateCounter: 0,
   incrementUpdateCounter: () =>
     set((state) => ({ updateCounter: state.updateCounter + 1 })),
@@ -104,6 +130,39 @@ export const useDashboardStore = create<DashboardState>((set) => ({
         break;
       }
 
+      case 'machineconfig_batch_progress': {
+        const progress = msg.payload as MachineConfigBatchProgress;
+        set((state) => ({
+          machineConfigBatchProgress: [...state.machineConfigBatchProgress, progress],
+          updateCounter: progress.done ? state.updateCounter + 1 : state.updateCounter,
+        }));
+        break;
+      }
+
+      case 'machineconfigpool_status': {
+        const event = msg.payload as WatchEvent;
+        set((state) => {
+          const pools = { ...state.machineConfigPools };
+          if (event.event_type === 'DELETED') {
+            delete pools[event.name];
+          } else {
+            pools[event.name] = event.data as unknown as MachineConfigPoolStatus;
+          }
+          return { machineConfigPools: pools };
+        });
+        break;
+      }
+
+      case 'job_update': {
+        const job = msg.payload as Job;
+        set((state) => ({ jobs: { ...state.jobs, [job.id]: job } }));
+        break;
+      }
+
+      case 'change_request':
+      case 'scheduled_remediation':
+      case 'remediation_drift':
+      case 'operator_subscription':
       case 'check_result':
       case 'remediation':
       case 'scan_status':
@@ -0,0 +1,283 @@
+import { useEffect, useState, useCallback } from 'react';
+import { Link } from 'react-router-dom';
+import { ClipboardCheck, Check, X, Play, MessageSquare } from 'lucide-react';
+import { changeRequestApi, remediationApi } from '../lib/api';
+import { useDashboardStore } from '../lib/store';
+import type { ChangeRequest, ChangeRequestAction, ChangeRequestState } from '../types/api';
+
+const stateBadge: Record<ChangeRequestState, string> = {
+  Pending: 'bg-amber-100 text-amber-700',
+  Approved: 'bg-emerald-100 text-emerald-700',
+  Rejected: 'bg-red-100 text-red-700',
+  Executed: 'bg-blue-100 text-blue-700',
+  Expired: 'bg-gray-100 text-gray-600',
+};
+
+// The user name is remembered locally; behind an authenticating proxy the
+// server uses X-Forwarded-User instead.
+const userKey = 'dashboard-user';
+
+export default function ChangeRequestsPage() {
+  const [requests, setRequests] = useState<ChangeRequest[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+  const [busy, setBusy] = useState<string | null>(null);
+  const [user, setUser] = useState(() => localStorage.getItem(userKey) || '');
+  const [comments, setComments] = useState<Record<string, string>>({});
+  const { updateCounter, clusterStatus } = useDashboardStore();
+
+  // New request form
+  const [action, setAction] = useState<ChangeRequestAction>('apply');
+  const [names, setNames] = useState('');
+  const [comment, setComment] = useState('');
+  const [expiresIn, setExpiresIn] = useState('24h');
+
+  const fetchRequests = useCallback(async () => {
+    try {
+      const data = await changeRequestApi.list();
+      setRequests(data || []);
+      setError(null);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to fetch change requests');
+    } finally {
+      setLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    if (clusterStatus?.connected) {
+      fetchRequests();
+    }
+  }, [clusterStatus?.connected, updateCounter, fetchRequests]);
+
+  const updateUser = (value: string) => {
+    setUser(value);
+    localStorage.setItem(userKey, value);
+  };
+
+  const run = async (id: string, fn: () => Promise<unknown>) => {
+    setBusy(id);
+    try {
+      await fn();
+      setError(null);
+      await fetchRequests();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Request failed');
+    } finally {
+      setBusy(null);
+    }
+  };
+
+  const handleCreate = () =>
+    run('new', async () => {
+      await changeRequestApi.create({
+        action,
+        names: names.split(/[\s,]+/).filter(Boolean),
+        requester: user,
+        comment: comment || undefined,
+        expires_in: expiresIn || undefined,
+      });
+      setNames('');
+      setComment('');
+    });
+
+  const handleExecute = (cr: ChangeRequest) =>
+    run(cr.id, async () => {
+      if (cr.action === 'remove') {
+        await remediationApi.remove(cr.remediations[0], cr.id);
+      } else if (cr.remediations.length === 1) {
+        await remediationApi.apply(
+          cr.remediations[0],
+          cr.id,
+          cr.patch ? { patch: cr.patch.patch, patch_type: cr.patch.type } : undefined,
+        );
+      } else {
+        await remediationApi.applyBatch(cr.remediations, cr.id);
+      }
+    });
+
+  return (
+    <div className="space-y-6">
+      <div className="flex items-center justify-between">
+        <div>
+          <h1 className="text-2xl font-bold text-gray-900">Approvals</h1>
+          <p className="text-sm text-gray-500 mt-1">
+            Remediation change requests must be approved by a second engineer before they run
+          </p>
+        </div>
+        <input
+          type="text"
+          className="input w-48"
+          placeholder="Your name"
+          value={user}
+          onChange={e => updateUser(e.target.value)}
+        />
+      </div>
+
+      <div className="card p-4 space-y-3">
+        <h2 className="font-semibold text-gray-900">New Change Request</h2>
+        <div className="flex flex-wrap gap-3">
+          <select
+            className="input w-auto"
+            value={action}
+            onChange={e => setAction(e.target.value as ChangeRequestAction)}
+          >
+            <option value="apply">Apply</option>
+            <option value="remove">Remove</option>
+          </select>
+          <input
+            type="text"
+            className="input flex-1 min-w-[240px]"
+            placeholder="Remediation names (comma separated)"
+            value={names}
+            onChange={e => setNames(e.target.value)}
+          />
+          <input
+            type="text"
+            className="input w-28"
+            placeholder="Expires in"
+            value={expiresIn}
+            onChange={e => setExpiresIn(e.target.value)}
+          />
+        </div>
+        <textarea
+          className="input w-full"
+          rows={2}
+          placeholder="Why is this change needed?"
+          value={comment}
+          onChange={e => setComment(e.target.value)}
+        />
+        <button
+          className="btn btn-primary"
+          disabled={!user || !names.trim() || busy === 'new'}
+          onClick={handleCreate}
+        >
+          Request Approval
+        </button>
+      </div>
+
+      {error && (
+        <div className="card border-red-200 bg-red-50 p-4">
+          <p className="text-sm text-red-700">{error}</p>
+        </div>
+      )}
+
+      {loading ? (
+        <div className="card p-8 text-center">
+          <div className="h-8 w-8 animate-spin rounded-full border-2 border-gray-300 border-t-primary-600 mx-auto mb-3" />
+          <p className="text-sm text-gray-500">Loading change requests...</p>
+        </div>
+      ) : requests.length === 0 ? (
+        <div className="card p-12 text-center">
+          <ClipboardCheck className="h-12 w-12 text-gray-300 mx-auto mb-3" />
+          <p className="text-gray-500">No change requests yet.</p>
+        </div>
+      ) : (
+        <div className="space-y-3">
+          {requests.map(cr => (
+            <div key={cr.id} className="card p-4 space-y-3">
+              <div className="flex items-center gap-2 flex-wrap">
+                <span className="font-mono text-xs text-gray-500">#{cr.id}</span>
+                <span className={`badge ${stateBadge[cr.state]}`}>{cr.state}</span>
+                <span className="badge bg-gray-100 text-gray-600 capitalize">{cr.action}</span>
+                <span className="text-xs text-gray-500">
+                  requested by <span className="font-medium text-gray-700">{cr.requester}</span>
+                  {cr.approver && (
+                    <> · {cr.state === 'Rejected' ? 'rejected' : 'approved'} by{' '}
+                      <span className="font-medium text-gray-700">{cr.approver}</span></>
+                  )}
+                </span>
+                <span className="text-xs text-gray-400 ml-auto">
+                  expires {new Date(cr.expires_at).toLocaleString()}
+                </span>
+              </div>
+
+              <div className="flex flex-wrap gap-1.5">
+                {cr.remediations.map(name => (
+                  <Link
+                    key={name}
+                    to={`/remediation/${encodeURIComponent(name)}`}
+                    className="font-mono text-xs text-primary-600 hover:text-primary-800 hover:underline"
+                  >
+                    {name}
+                  </Link>
+                ))}
+              </div>
+
+              {cr.patch && (
+                <pre className="text-xs bg-gray-50 rounded p-2 overflow-x-auto">
+                  {cr.patch.type} patch: {JSON.stringify(cr.patch.patch, null, 2)}
+                </pre>
+              )}
+
+              {cr.outcome && <p className="text-xs text-amber-700">{cr.outcome}</p>}
+
+              {cr.comments && cr.comments.length > 0 && (
+                <div className="space-y-1 border-l-2 border-gray-200 pl-3">
+                  {cr.comments.map((c, i) => (
+                    <p key={i} className="text-xs text-gray-600">
+                      <span className="font-medium text-gray-800">{c.author}</span>: {c.text}
+                      <span className="text-gray-400"> · {new Date(c.created_at).toLocaleString()}</span>
+                    </p>
+                  ))}
+                </div>
+              )}
+
+              <div className="flex items-center gap-2 flex-wrap">
+                <input
+                  type="text"
+                  className="input flex-1 min-w-[200px]"
+                  placeholder="Comment"
+                  value={comments[cr.id] || ''}
+                  onChange={e => setComments({ ...comments, [cr.id]: e.target.value })}
+                />
+                <button
+                  className="btn btn-secondary"
+                  disabled={!user || !comments[cr.id] || busy === cr.id}
+                  onClick={() => run(cr.id, async () => {
+                    await changeRequestApi.comment(cr.id, user, comments[cr.id]);
+                    setComments({ ...comments, [cr.id]: '' });
+                  })}
+                >
+                  <MessageSquare className="h-4 w-4" />
+                  Comment
+                </button>
+                {cr.state === 'Pending' && (
+                  <>
+                    <button
+                      className="btn btn-primary"
+                      disabled={!user || user === cr.requester || busy === cr.id}
+                      title={user === cr.requester ? 'A change request must be approved by someone else' : undefined}
+                      onClick={() => run(cr.id, () => changeRequestApi.approve(cr.id, user, comments[cr.id]))}
+                    >
+                      <Check className="h-4 w-4" />
+                      Approve
+                    </button>
+                    <button
+                      className="btn btn-danger"
+                      disabled={!user || user === cr.requester || busy === cr.id}
+                      onClick={() => run(cr.id, () => changeRequestApi.reject(cr.id, user, comments[cr.id]))}
+                    >
+                      <X className="h-4 w-4" />
+                      Reject
+                    </button>
+                  </>
+                )}
+                {cr.state === 'Approved' && (
+                  <button
+                    className="btn btn-primary"
+                    disabled={busy === cr.id}
+                    onClick={() => handleExecute(cr)}
+                  >
+                    <Play className="h-4 w-4" />
+                    {cr.action === 'remove' ? 'Remove' : 'Apply'}
+                  </button>
+                )}
+              </div>
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
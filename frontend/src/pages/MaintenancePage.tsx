@@ -0,0 +1,233 @@
+import { useEffect, useState, useCallback } from 'react';
+import { Link } from 'react-router-dom';
+import { CalendarClock, Trash2, X } from 'lucide-react';
+import { maintenanceApi } from '../lib/api';
+import { useDashboardStore } from '../lib/store';
+import type { MaintenanceWindow, ScheduledRemediation, ScheduledRemediationState } from '../types/api';
+
+const stateBadge: Record<ScheduledRemediationState, string> = {
+  Queued: 'bg-gray-100 text-gray-600',
+  Held: 'bg-amber-100 text-amber-700',
+  Applying: 'bg-blue-100 text-blue-700',
+  Applied: 'bg-emerald-100 text-emerald-700',
+  Failed: 'bg-red-100 text-red-700',
+  Canceled: 'bg-gray-100 text-gray-400',
+};
+
+export default function MaintenancePage() {
+  const [windows, setWindows] = useState<MaintenanceWindow[]>([]);
+  const [queue, setQueue] = useState<ScheduledRemediation[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+  const { updateCounter, clusterStatus } = useDashboardStore();
+
+  // New window form
+  const [windowForm, setWindowForm] = useState<MaintenanceWindow>({
+    name: 'weekend',
+    schedule: '0 22 * * 6',
+    duration: '6h',
+    timezone: 'UTC',
+  });
+
+  // Queue form
+  const [queueWindow, setQueueWindow] = useState('');
+  const [queueNames, setQueueNames] = useState('');
+  const [changeRequest, setChangeRequest] = useState('');
+
+  const fetchAll = useCallback(async () => {
+    try {
+      const [w, q] = await Promise.all([maintenanceApi.listWindows(), maintenanceApi.listQueue()]);
+      setWindows(w || []);
+      setQueue(q || []);
+      setError(null);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to fetch maintenance schedule');
+    } finally {
+      setLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    if (clusterStatus?.connected) {
+      fetchAll();
+    }
+  }, [clusterStatus?.connected, updateCounter, fetchAll]);
+
+  const run = async (fn: () => Promise<unknown>) => {
+    try {
+      await fn();
+      setError(null);
+      await fetchAll();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Request failed');
+    }
+  };
+
+  const pending = queue.filter(i => i.state === 'Queued' || i.state === 'Held' || i.state === 'Applying');
+  const history = queue.filter(i => !pending.includes(i)).reverse();
+
+  return (
+    <div className="space-y-6">
+      <div>
+        <h1 className="text-2xl font-bold text-gray-900">Maintenance Windows</h1>
+        <p className="text-sm text-gray-500 mt-1">
+          Queue disruptive remediations to be applied during scheduled windows
+        </p>
+      </div>
+
+      {error && (
+        <div className="card border-red-200 bg-red-50 p-4">
+          <p className="text-sm text-red-700">{error}</p>
+        </div>
+      )}
+
+      <div className="card p-4 space-y-3">
+        <h2 className="font-semibold text-gray-900">Windows</h2>
+        {loading ? (
+          <p className="text-sm text-gray-500">Loading...</p>
+        ) : windows.length === 0 ? (
+          <p className="text-sm text-gray-500">No maintenance windows defined.</p>
+        ) : (
+          <div className="divide-y divide-gray-100">
+            {windows.map(w => (
+              <div key={w.name} className="py-2 flex items-center gap-3 text-sm">
+                <CalendarClock className="h-4 w-4 text-gray-400" />
+                <span className="font-medium text-gray-900">{w.name}</span>
+                <span className="font-mono text-xs text-gray-600">{w.schedule}</span>
+                <span className="text-xs text-gray-500">for {w.duration} ({w.timezone || 'UTC'})</span>
+                {w.open ? (
+                  <span className="badge bg-emerald-100 text-emerald-700">
+                    Open until {w.closes_at && new Date(w.closes_at).toLocaleString()}
+                  </span>
+                ) : w.next_start && (
+                  <span className="text-xs text-gray-500">next {new Date(w.next_start).toLocaleString()}</span>
+                )}
+                <button
+                  className="ml-auto text-gray-400 hover:text-red-600"
+                  title="Delete window"
+                  onClick={() => run(() => maintenanceApi.deleteWindow(w.name))}
+                >
+                  <Trash2 className="h-4 w-4" />
+                </button>
+              </div>
+            ))}
+          </div>
+        )}
+        <div className="flex flex-wrap gap-3 pt-2 border-t border-gray-100">
+          <input
+            className="input w-32"
+            placeholder="Name"
+            value={windowForm.name}
+            onChange={e => setWindowForm({ ...windowForm, name: e.target.value })}
+          />
+          <input
+            className="input w-36 font-mono"
+            placeholder="Cron schedule"
+            value={windowForm.schedule}
+            onChange={e => setWindowForm({ ...windowForm, schedule: e.target.value })}
+          />
+          <input
+            className="input w-24"
+            placeholder="Duration"
+            value={windowForm.duration}
+            onChange={e => setWindowForm({ ...windowForm, duration: e.target.value })}
+          />
+          <input
+            className="input w-40"
+            placeholder="Timezone"
+            value={windowForm.timezone}
+            onChange={e => setWindowForm({ ...windowForm, timezone: e.target.value })}
+          />
+          <button
+            className="btn btn-secondary"
+            disabled={!windowForm.name || !windowForm.schedule || !windowForm.duration}
+            onClick={() => run(() => maintenanceApi.saveWindow(windowForm))}
+          >
+            Save Window
+          </button>
+        </div>
+      </div>
+
+      <div className="card p-4 space-y-3">
+        <h2 className="font-semibold text-gray-900">Queue Remediations</h2>
+        <div className="flex flex-wrap gap-3">
+          <select className="input w-auto" value={queueWindow} onChange={e => setQueueWindow(e.target.value)}>
+            <option value="">Select window</option>
+            {windows.map(w => (
+              <option key={w.name} value={w.name}>{w.name}</option>
+            ))}
+          </select>
+          <input
+            className="input flex-1 min-w-[240px]"
+            placeholder="Remediation names (comma separated)"
+            value={queueNames}
+            onChange={e => setQueueNames(e.target.value)}
+          />
+          <input
+            className="input w-40"
+            placeholder="Change request (if required)"
+            value={changeRequest}
+            onChange={e => setChangeRequest(e.target.value)}
+          />
+          <button
+            className="btn btn-primary"
+            disabled={!queueWindow || !queueNames.trim()}
+            onClick={() => run(async () => {
+              await maintenanceApi.queue(queueWindow, queueNames.split(/[\s,]+/).filter(Boolean), changeRequest || undefined);
+              setQueueNames('');
+              setChangeRequest('');
+            })}
+          >
+            Queue
+          </button>
+        </div>
+
+        {pending.length > 0 && (
+          <div className="divide-y divide-gray-100">
+            {pending.map(item => (
+              <QueueRow key={item.id} item={item} onCancel={() => run(() => maintenanceApi.cancel(item.id))} />
+            ))}
+          </div>
+        )}
+      </div>
+
+      {history.length > 0 && (
+        <div className="card p-4 space-y-3">
+          <h2 className="font-semibold text-gray-900">History</h2>
+          <div className="divide-y divide-gray-100">
+            {history.map(item => (
+              <QueueRow key={item.id} item={item} />
+            ))}
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
+
+function QueueRow({ item, onCancel }: { item: ScheduledRemediation; onCancel?: () => void }) {
+  const cancellable = item.state === 'Queued' || item.state === 'Held';
+  return (
+    <div className="py-2 text-sm">
+      <div className="flex items-center gap-3">
+        <Link
+          to={`/remediation/${encodeURIComponent(item.remediation)}`}
+          className="font-mono text-xs text-primary-600 hover:text-primary-800 hover:underline truncate"
+        >
+          {item.remediation}
+        </Link>
+        <span className={`badge ${stateBadge[item.state]}`}>{item.state}</span>
+        <span className="text-xs text-gray-500">{item.window}</span>
+        {item.finished_at && (
+          <span className="text-xs text-gray-400">{new Date(item.finished_at).toLocaleString()}</span>
+        )}
+        {onCancel && cancellable && (
+          <button className="ml-auto text-gray-400 hover:text-red-600" title="Cancel" onClick={onCancel}>
+            <X className="h-4 w-4" />
+          </button>
+        )}
+      </div>
+      {item.message && <p className="text-xs text-gray-500 mt-0.5">{item.message}</p>}
+    </div>
+  );
+}
@@ -1,8 +1,9 @@
 import { useEffect, useState } from 'react';
 import { useParams, Link } from 'react-router-dom';
 import { ArrowLeft, Shield, RotateCw, Clock } from 'lucide-react';
-import { remediationApi } from '../lib/api';
-import type { RemediationDetail, Severity } from '../types/api';
+import { remediationApi, jobsApi } from '../lib/api';
+import IgnitionContent from '../components/IgnitionContent';
+import type { PatchType, RemediationDetail, RemediationSnapshot, Severity, VerificationResult } from '../types/api';
 
 function severityBadgeClass(severity: Severity): string {
   switch (severity) {
@@ -16,8 +17,16 @@ function severityBadgeClass(severity: Severity): string {
 export default function RemediationDetailPage() {
   const { name } = useParams<{ name: string }>();
   const [detail, setDetail] = useState<RemediationDetail | null>(null);
+  const [snapshot, setSnapshot] = useState<RemediationSnapshot | null>(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
+  const [promoting, setPromoting] = useState(false);
+  const [reapplying, setReapplying] = useState(false);
+  const [verifying, setVerifying] = useState(false);
+  const [patchText, setPatchText] = useState('');
+  const [patchType, setPatchType] = useState<PatchType>('merge');
+  const [customizing, setCustomizing] = useState(false);
+  const [verification, setVerification] = useState<VerificationResult | null>(null);
 
   useEffect(() => {
     if (!name) return;
@@ -29,6 +38,86 @@ export default function RemediationDetailPage() {
     return () => { cancelled = true; };
   }, [name]);
 
+  useEffect(() => {
+    if (!name || !detail?.has_snapshot) return;
+    let cancelled = false;
+    remediationApi.getSnapshot(name)
+      .then(data => { if (!cancelled) setSnapshot(data); })
+      .catch(() => { if (!cancelled) setSnapshot(null); });
+    return () => { cancelled = true; };
+  }, [name, detail?.has_snapshot]);
+
+  const handlePromote = async () => {
+    if (!name) return;
+    setPromoting(true);
+    setError(null);
+    try {
+      await remediationApi.promote(name);
+      setDetail(await remediationApi.getDetail(name));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to promote remediation');
+    } finally {
+      setPromoting(false);
+    }
+  };
+
+  const handleReapply = async () => {
+    if (!name) return;
+    setReapplying(true);
+    setError(null);
+    try {
+      await remediationApi.reapply(name);
+      setDetail(await remediationApi.getDetail(name));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to re-apply remediation');
+    } finally {
+      setReapplying(false);
+    }
+  };
+
+  const handleCustomApply = async () => {
+    if (!name) return;
+    let patch: unknown;
+    try {
+      patch = JSON.parse(patchText);
+    } catch {
+      setError('Patch is not valid JSON');
+      return;
+    }
+    setCustomizing(true);
+    setError(null);
+    try {
+      await remediationApi.apply(name, undefined, { patch, patch_type: patchType });
+      setPatchText('');
+      setDetail(await remediationApi.getDetail(name));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to apply customized remediation');
+    } finally {
+      setCustomizing(false);
+    }
+  };
+
+  const handleVerify = async () => {
+    if (!name) return;
+    setVerifying(true);
+    setVerification(null);
+    setError(null);
+    try {
+      const job = await remediationApi.verify(name);
+      const finished = await jobsApi.wait<VerificationResult>(job.id, 5000);
+      if (finished.result) {
+        setVerification(finished.result);
+      } else if (finished.error) {
+        setError(finished.error);
+      }
+      setDetail(await remediationApi.getDetail(name));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to verify remediation');
+    } finally {
+      setVerifying(false);
+    }
+  };
+
   // Read applied timestamp from localStorage
   const appliedAt = name ? localStorage.getItem(`remediation-applied-${name}`) : null;
 
@@ -61,7 +150,12 @@ export default function RemediationDetailPage() {
       </Link>
 
       <div>
-        <h1 className="text-2xl font-bold text-gray-900 font-mono break-all">{detail.name}</h1>
+        <div className="flex items-start justify-between gap-4">
+          <h1 className="text-2xl font-bold text-gray-900 font-mono break-all">{detail.name}</h1>
+          <button className="btn btn-primary text-xs px-3 py-1.5 shrink-0" disabled={verifying} onClick={handleVerify}>
+            {verifying ? 'Applying and rescanning...' : detail.applied ? 'Rescan and Verify' : 'Apply and Verify'}
+          </button>
+        </div>
         <div className="flex flex-wrap items-center gap-2 mt-2">
           {detail.severity && (
             <span className={`badge ${severityBadgeClass(detail.severity)}`}>{detail.severity}</span>
@@ -80,6 +174,12 @@ export default function RemediationDetailPage() {
         </div>
       </div>
 
+      {verification && (
+        <div className={`card p-4 text-sm ${verification.verified ? 'border-emerald-200 bg-emerald-50 text-emerald-700' : 'border-red-200 bg-red-50 text-red-700'}`}>
+          {verification.message}
+        </div>
+      )}
+
       {/* Metadata card */}
       <div className="card">
         <div className="px-4 py-3 border-b border-gray-200 bg-gray-50">
@@ -112,6 +212,15 @@ export default function RemediationDetailPage() {
               ) : 'Pending'}
             </p>
           </div>
+          {detail.application_state && (
+            <div>
+              <span className="text-gray-500">Operator State</span>
+              <p className="font-medium text-gray-900">{detail.application_state}</p>
+              {detail.error_message && (
+                <p className="text-xs text-red-600 mt-0.5">{detail.error_message}</p>
+              )}
+            </div>
+          )}
           {appliedAt && (
             <div>
               <span className="text-gray-500">Applied At</span>
@@ -124,6 +233,42 @@ export default function RemediationDetailPage() {
         </div>
       </div>
 
+      {/* Impact card */}
+      {detail.impact && (detail.impact.reboot_needed || detail.impact.control_plane_restart) && (
+        <div className="card">
+          <div className="px-4 py-3 border-b border-gray-200 bg-gray-50">
+            <h2 className="font-medium text-sm text-gray-900">Impact</h2>
+            <p className="text-xs text-gray-500 mt-0.5">
+              Estimated rollout: about {detail.impact.estimated_duration}
+              {detail.impact.restarted_components && detail.impact.restarted_components.length > 0 &&
+                ` · restarts ${detail.impact.restarted_components.join(', ')}`}
+            </p>
+          </div>
+          {detail.impact.pools && detail.impact.pools.length > 0 && (
+            <table className="w-full text-sm">
+              <thead className="text-left text-gray-500">
+                <tr>
+                  <th className="px-4 py-2 font-medium">Pool</th>
+                  <th className="px-4 py-2 font-medium">Nodes</th>
+                  <th className="px-4 py-2 font-medium">Max Unavailable</th>
+                  <th className="px-4 py-2 font-medium">Estimate</th>
+                </tr>
+              </thead>
+              <tbody>
+                {detail.impact.pools.map(p => (
+                  <tr key={p.pool} className="border-t border-gray-100">
+                    <td className="px-4 py-2 font-mono">{p.pool}{p.paused && ' (paused)'}</td>
+                    <td className="px-4 py-2">{p.node_count}</td>
+                    <td className="px-4 py-2">{p.max_unavailable}</td>
+                    <td className="px-4 py-2">{p.estimated_duration}</td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          )}
+        </div>
+      )}
+
       {/* YAML card */}
       <div className="card">
         <div className="px-4 py-3 border-b border-gray-200 bg-gray-50">
@@ -139,6 +284,122 @@ export default function RemediationDetailPage() {
           )}
         </div>
       </div>
+
+      {/* Customization card */}
+      <div className="card">
+        <div className="px-4 py-3 border-b border-gray-200 bg-gray-50">
+          <h2 className="font-medium text-sm text-gray-900">
+            Customization
+            {detail.customized && <span className="ml-2 badge bg-violet-100 text-violet-700">Customized</span>}
+          </h2>
+          <p className="text-xs text-gray-500 mt-0.5">
+            Apply a JSON merge or strategic merge patch on top of the remediation object. The patch is kept until the
+            remediation is removed.
+          </p>
+        </div>
+        <div className="p-4 space-y-3">
+          {detail.customization_diff && (
+            <>
+              <p className="text-xs text-gray-500">
+                Applied with a {detail.customization?.type} patch
+                {detail.customization?.customized_at && ` on ${new Date(detail.customization.customized_at).toLocaleString()}`}:
+              </p>
+              <pre className="bg-gray-900 text-gray-100 rounded-lg p-4 overflow-x-auto text-xs font-mono leading-relaxed whitespace-pre">
+                {detail.customization_diff}
+              </pre>
+            </>
+          )}
+          <textarea
+            className="input w-full font-mono text-xs"
+            rows={5}
+            placeholder='{"spec": {"profile": {"type": "WriteRequestBodies"}}}'
+            value={patchText}
+            onChange={e => setPatchText(e.target.value)}
+          />
+          <div className="flex items-center gap-3">
+            <select className="input w-auto" value={patchType} onChange={e => setPatchType(e.target.value as PatchType)}>
+              <option value="merge">JSON merge patch</option>
+              <option value="strategic">Strategic merge patch</option>
+            </select>
+            <button
+              className="btn btn-primary text-xs px-3 py-1.5"
+              disabled={!patchText.trim() || customizing}
+              onClick={handleCustomApply}
+            >
+              {customizing ? 'Applying...' : 'Apply with Patch'}
+            </button>
+          </div>
+        </div>
+      </div>
+
+      {/* Decoded Ignition card */}
+      {detail.ignition && name && (
+        <IgnitionContent name={name} role={detail.role} ignition={detail.ignition} />
+      )}
+
+      {/* Drift card */}
+      {detail.drift && (
+        <div className="card border-orange-200 bg-orange-50 p-4 flex items-start justify-between gap-4">
+          <div>
+            <h2 className="font-medium text-sm text-orange-900">
+              {detail.drift === 'Missing' ? 'Applied object is missing' : 'Applied object has drifted'}
+            </h2>
+            <p className="text-xs text-orange-800 mt-0.5">
+              {detail.drift === 'Missing'
+                ? 'The object created by this remediation was deleted from the cluster.'
+                : 'The live object no longer matches this remediation.'}
+            </p>
+            {detail.drifted_fields && detail.drifted_fields.length > 0 && (
+              <p className="text-xs text-orange-800 mt-1 font-mono">{detail.drifted_fields.join(', ')}</p>
+            )}
+          </div>
+          <button className="btn btn-primary text-xs px-3 py-1.5" disabled={reapplying} onClick={handleReapply}>
+            {reapplying ? 'Re-applying...' : 'Re-apply'}
+          </button>
+        </div>
+      )}
+
+      {/* Outdated version card */}
+      {detail.outdated && detail.outdated_diff && (
+        <div className="card">
+          <div className="px-4 py-3 border-b border-gray-200 bg-gray-50 flex items-center justify-between">
+            <div>
+              <h2 className="font-medium text-sm text-gray-900">New Version Available</h2>
+              <p className="text-xs text-gray-500 mt-0.5">
+                Updated content changed this remediation. Changes from the outdated version:
+              </p>
+            </div>
+            <button className="btn btn-primary text-xs px-3 py-1.5" disabled={promoting} onClick={handlePromote}>
+              {promoting ? 'Promoting...' : detail.applied ? 'Promote and Apply' : 'Promote'}
+            </button>
+          </div>
+          <div className="p-4">
+            <pre className="bg-gray-900 text-gray-100 rounded-lg p-4 overflow-x-auto text-xs font-mono leading-relaxed whitespace-pre">
+              {detail.outdated_diff}
+            </pre>
+          </div>
+        </div>
+      )}
+
+      {/* Prior state snapshot card */}
+      {snapshot && (
+        <div className="card">
+          <div className="px-4 py-3 border-b border-gray-200 bg-gray-50">
+            <h2 className="font-medium text-sm text-gray-900">State Before Apply</h2>
+            <p className="text-xs text-gray-500 mt-0.5">
+              Captured {new Date(snapshot.captured_at).toLocaleString()} in {snapshot.stored_in}. Removing the remediation{' '}
+              {snapshot.existed ? 'restores this object.' : 'deletes the object, which did not exist before.'}
+            </p>
+          </div>
+          {snapshot.object_yaml && (
+            <div className="p-4">
+              <pre className="bg-gray-900 text-gray-100 rounded-lg p-4 overflow-x-auto text-xs font-mono leading-relaxed whitespace-pre">
+                {snapshot.object_yaml}
+              </pre>
+            </div>
+          )}
+        </div>
+      )}
     </div>
   );
 }
@@ -208,6 +208,11 @@ export default function RemediationPage() {
                         {rem.name}
                       </Link>
                       <span className="badge bg-emerald-100 text-emerald-700">Applied</span>
+                      {rem.drift && (
+                        <span className="badge bg-orange-100 text-orange-700" title={rem.drifted_fields?.join(', ')}>
+                          {rem.drift === 'Missing' ? 'Object missing' : 'Drifted'}
+                        </span>
+                      )}
                       {appliedAt && (
                         <span className="inline-flex items-center gap-1 text-xs text-gray-500">
                           <Clock className="h-3 w-3" />
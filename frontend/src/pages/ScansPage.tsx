@@ -1,6 +1,7 @@
 import { useEffect, useState, useCallback, useMemo } from 'react';
 import { Radar, Clock, CheckCircle2, XCircle, AlertTriangle, Plus, Play, Zap, RefreshCw, Trash2 } from 'lucide-react';
 import { scanApi } from '../lib/api';
+import ProfileBundles from '../components/ProfileBundles';
 import { useDashboardStore } from '../lib/store';
 import type { SuiteStatus, ScanStatus, ProfileInfo } from '../types/api';
 
@@ -574,6 +575,9 @@ export default function ScansPage() {
           </div>
         </div>
       )}
+
+      {/* Profile bundles */}
+      {clusterStatus?.connected && <ProfileBundles />}
     </div>
   );
 }
@@ -1,15 +1,33 @@
 import { useEffect, useMemo, useState } from 'react';
-import { Settings, CheckCircle, XCircle, AlertTriangle, Trash2 } from 'lucide-react';
+import { Settings, CheckCircle, XCircle, AlertTriangle, Trash2, Download, Eye } from 'lucide-react';
 import OperatorInstallWizard from '../components/OperatorInstallWizard';
+import InstallStatusPanel from '../components/InstallStatusPanel';
+import OperatorSubscription from '../components/OperatorSubscription';
 import { useDashboardStore } from '../lib/store';
 import { operatorApi } from '../lib/api';
-import type { OperatorStatus } from '../types/api';
+import type { OperatorStatus, UninstallOptions, UninstallPlan } from '../types/api';
+
+// saveBlob downloads blob as filename.
+function saveBlob(blob: Blob, filename: string) {
+  const url = URL.createObjectURL(blob);
+  const a = document.createElement('a');
+  a.href = url;
+  a.download = filename;
+  a.click();
+  URL.revokeObjectURL(url);
+}
 
 export default function SettingsPage() {
   const { clusterStatus, updateCounter, uninstallProgress, clearUninstallProgress } = useDashboardStore();
   const [fetchedOperatorStatus, setFetchedOperatorStatus] = useState<OperatorStatus | null>(null);
   const [confirmUninstall, setConfirmUninstall] = useState(false);
   const [uninstallStarted, setUninstallStarted] = useState(false);
+  const [collecting, setCollecting] = useState(false);
+  const [diagnosticsError, setDiagnosticsError] = useState<string | null>(null);
+  const [uninstallOptions, setUninstallOptions] = useState<UninstallOptions>({ export: true });
+  const [uninstallPlan, setUninstallPlan] = useState<UninstallPlan | null>(null);
+  const [planning, setPlanning] = useState(false);
+  const [exportError, setExportError] = useState<string | null>(null);
 
   useEffect(() => {
     if (clusterStatus?.connected) {
@@ -34,13 +52,52 @@ export default function SettingsPage() {
     setConfirmUninstall(false);
     clearUninstallProgress();
     try {
-      await operatorApi.uninstall();
+      await operatorApi.uninstall(uninstallOptions);
     } catch (err) {
       setUninstallStarted(false);
       console.error('Uninstall request failed:', err);
     }
   };
 
+  const handleDiagnostics = async () => {
+    setCollecting(true);
+    setDiagnosticsError(null);
+    try {
+      saveBlob(await operatorApi.downloadDiagnostics(), 'compliance-diagnostics.tar.gz');
+    } catch (err) {
+      setDiagnosticsError(err instanceof Error ? err.message : 'Failed to collect diagnostics');
+    } finally {
+      setCollecting(false);
+    }
+  };
+
+  const updateUninstallOptions = (update: Partial<UninstallOptions>) => {
+    setUninstallOptions(prev => ({ ...prev, ...update }));
+    setUninstallPlan(null);
+  };
+
+  const handlePreviewUninstall = async () => {
+    setPlanning(true);
+    setExportError(null);
+    try {
+      setUninstallPlan(await operatorApi.planUninstall(uninstallOptions));
+    } catch (err) {
+      setExportError(err instanceof Error ? err.message : 'Failed to preview the uninstall');
+    } finally {
+      setPlanning(false);
+    }
+  };
+
+  const handleDownloadExport = async (saved: boolean) => {
+    setExportError(null);
+    try {
+      const blob = saved ? await operatorApi.downloadUninstallExport() : await operatorApi.downloadExport();
+      saveBlob(blob, 'compliance-export.tar.gz');
+    } catch (err) {
+      setExportError(err instanceof Error ? err.message : 'Failed to download the export');
+    }
+  };
+
   return (
     <div className="space-y-6">
       <div>
@@ -89,10 +146,17 @@ export default function SettingsPage() {
 
       {/* Operator Status */}
       <div className="card">
-        <div className="px-6 py-4 border-b border-gray-200">
+        <div className="px-6 py-4 border-b border-gray-200 flex items-center justify-between">
           <h3 className="font-semibold text-gray-900">Operator Status</h3>
+          {clusterStatus?.connected && (
+            <button className="btn btn-secondary" onClick={handleDiagnostics} disabled={collecting}>
+              <Download className="h-4 w-4" />
+              {collecting ? 'Collecting...' : 'Diagnostics Bundle'}
+            </button>
+          )}
         </div>
         <div className="p-6">
+          {diagnosticsError && <p className="text-sm text-red-600 mb-4">{diagnosticsError}</p>}
           {operatorStatus ? (
             <div className="space-y-4">
               <div className="flex items-center gap-3">
@@ -106,7 +170,10 @@ export default function SettingsPage() {
                     {operatorStatus.installed ? 'Installed' : 'Not Installed'}
                   </p>
                   {operatorStatus.version && (
-                    <p className="text-xs text-gray-500">{operatorStatus.version}</p>
+                    <p className="text-xs text-gray-500">
+                      {operatorStatus.version}
+                      {operatorStatus.source === 'manifests' && ' (installed without OLM)'}
+                    </p>
                   )}
                 </div>
               </div>
@@ -152,8 +219,14 @@ export default function SettingsPage() {
         </div>
       </div>
 
-      {/* Uninstall section (show when installed) */}
-      {operatorStatus?.installed && clusterStatus?.connected && (
+      {/* Persisted step log of an unfinished install or uninstall */}
+      {clusterStatus?.connected && <InstallStatusPanel />}
+
+      {/* Subscription and upgrades (show when installed through OLM) */}
+      {operatorStatus?.installed && operatorStatus.source !== 'manifests' && clusterStatus?.connected && <OperatorSubscription />}
+
+      {/* Uninstall section (show when installed, and after an uninstall until cleared) */}
+      {(operatorStatus?.installed || uninstallProgress.length > 0) && clusterStatus?.connected && (
         <div className="card border-red-200">
           <div className="px-6 py-4 border-b border-red-200 bg-red-50">
             <h3 className="font-semibold text-red-900 flex items-center gap-2">
@@ -165,21 +238,82 @@ export default function SettingsPage() {
             {!uninstalling && uninstallProgress.length === 0 && (
               <>
                 <p className="text-sm text-gray-600 mb-4">
-                  Uninstalling the Compliance Operator will remove all compliance resources,
-                  scan results, and the operator itself from the cluster.
+                  {uninstallOptions.keep_namespace
+                    ? 'Uninstalling will remove only the operator itself, keeping its namespace, scan results and compliance resources.'
+                    : 'Uninstalling the Compliance Operator will remove all compliance resources, scan results, and the operator itself from the cluster.'}
                 </p>
+                <div className="space-y-2 mb-4">
+                  <label className="flex items-center gap-2 text-sm text-gray-700">
+                    <input
+                      type="checkbox"
+                      checked={uninstallOptions.export ?? false}
+                      onChange={e => updateUninstallOptions({ export: e.target.checked })}
+                    />
+                    Export results and remediation state first
+                  </label>
+                  {uninstallOptions.export && (
+                    <label className="flex items-center gap-2 text-sm text-gray-700 ml-6">
+                      <input
+                        type="checkbox"
+                        checked={uninstallOptions.force ?? false}
+                        onChange={e => updateUninstallOptions({ force: e.target.checked })}
+                      />
+                      Continue even if some items cannot be exported
+                    </label>
+                  )}
+                  <label className="flex items-center gap-2 text-sm text-gray-700">
+                    <input
+                      type="checkbox"
+                      checked={uninstallOptions.keep_namespace ?? false}
+                      onChange={e => updateUninstallOptions({ keep_namespace: e.target.checked })}
+                    />
+                    Keep the namespace and remove only operator objects
+                  </label>
+                </div>
+                {exportError && <p className="text-sm text-red-600 mb-4">{exportError}</p>}
+                {uninstallPlan && (
+                  <div className="mb-4">
+                    <h4 className="text-xs font-medium text-gray-500 uppercase tracking-wider mb-2">
+                      Will delete {uninstallPlan.objects.length} objects
+                    </h4>
+                    <div className="max-h-64 overflow-y-auto space-y-1">
+                      {uninstallPlan.objects.map(obj => (
+                        <div key={`${obj.kind}/${obj.namespace ?? ''}/${obj.name}`} className="text-xs">
+                          <span className="font-mono text-gray-900">
+                            {obj.kind} {obj.namespace ? `${obj.namespace}/` : ''}{obj.name}
+                          </span>
+                          {obj.note && <span className="text-amber-700 ml-2">{obj.note}</span>}
+                        </div>
+                      ))}
+                    </div>
+                  </div>
+                )}
                 {!confirmUninstall ? (
-                  <button
-                    className="btn px-4 py-2 bg-red-600 text-white hover:bg-red-700"
-                    onClick={() => setConfirmUninstall(true)}
-                  >
-                    <Trash2 className="h-4 w-4 mr-2" />
-                    Uninstall Operator
-                  </button>
+                  <div className="flex gap-2">
+                    <button
+                      className="btn px-4 py-2 bg-red-600 text-white hover:bg-red-700"
+                      onClick={() => setConfirmUninstall(true)}
+                    >
+                      <Trash2 className="h-4 w-4 mr-2" />
+                      Uninstall Operator
+                    </button>
+                    <button className="btn btn-secondary px-4 py-2" onClick={handlePreviewUninstall} disabled={planning}>
+                      <Eye className="h-4 w-4 mr-2" />
+                      {planning ? 'Previewing...' : 'Preview'}
+                    </button>
+                    <button className="btn btn-secondary px-4 py-2" onClick={() => handleDownloadExport(false)}>
+                      <Download className="h-4 w-4 mr-2" />
+                      Export Now
+                    </button>
+                  </div>
                 ) : (
                   <div className="bg-red-50 border border-red-300 rounded-lg p-4">
                     <p className="text-sm text-red-800 font-medium mb-3">
-                      This action cannot be undone. All compliance data will be permanently deleted.
+                      {uninstallOptions.keep_namespace
+                        ? 'This action cannot be undone. The operator will be removed; its namespace and results are kept.'
+                        : uninstallOptions.export
+                          ? 'This action cannot be undone. Results are exported first, then all compliance data is deleted.'
+                          : 'This action cannot be undone. All compliance data will be permanently deleted.'}
                     </p>
                     <div className="flex gap-2">
                       <button
@@ -223,13 +357,22 @@ export default function SettingsPage() {
                   </div>
                 )}
                 {!uninstalling && uninstallProgress.length > 0 && (
-                  <button
-                    className="btn btn-secondary text-xs mt-3"
-                    onClick={clearUninstallProgress}
-                  >
-                    Clear
-                  </button>
+                  <div className="flex gap-2 mt-3">
+                    {uninstallSucceeded && uninstallOptions.export && (
+                      <button className="btn btn-secondary text-xs" onClick={() => handleDownloadExport(true)}>
+                        <Download className="h-3 w-3 mr-1" />
+                        Download Export
+                      </button>
+                    )}
+                    <button
+                      className="btn btn-secondary text-xs"
+                      onClick={clearUninstallProgress}
+                    >
+                      Clear
+                    </button>
+                  </div>
                 )}
+                {exportError && <p className="text-sm text-red-600 mt-2">{exportError}</p>}
               </div>
             )}
           </div>
@@ -65,22 +65,243 @@ export interface BundleStatus {
 export interface OperatorStatus {
   installed: boolean;
   version?: string;
+  // 'manifests' when installed without OLM
+  source?: InstallSource;
   csv_phase?: string;
   pods?: PodStatus[];
   profile_bundles?: BundleStatus[];
 }
 
+export type InstallPlanApproval = 'Automatic' | 'Manual';
+
+export interface PackageChannel {
+  name: string;
+  current_csv: string;
+  version?: string;
+  default?: boolean;
+  entries?: { name: string; version?: string }[];
+}
+
+export interface InstallPlanInfo {
+  name: string;
+  csvs: string[];
+  approval: string;
+  approved: boolean;
+  phase?: string;
+  requires_approval: boolean;
+  created_at: string;
+  other_csvs?: string[];
+}
+
+export interface SubscriptionInfo {
+  name: string;
+  package: string;
+  channel: string;
+  default_channel?: string;
+  source: string;
+  source_namespace: string;
+  approval: InstallPlanApproval;
+  starting_csv?: string;
+  current_csv?: string;
+  installed_csv?: string;
+  state?: string;
+  upgrade_available: boolean;
+  pending_approval: boolean;
+  channels: PackageChannel[];
+  install_plans: InstallPlanInfo[];
+}
+
 export interface InstallProgress {
   step: string;
   message: string;
   done: boolean;
   error?: string;
+  options?: InstallOptions;
+  wait?: WaitProgress;
+}
+
+export type InstallSource = 'redhat' | 'community' | 'custom' | 'manifests';
+
+export type InstallOperation = 'install' | 'uninstall';
+export type InstallPhase = 'Running' | 'Succeeded' | 'Failed' | 'Interrupted';
+export type InstallStepState = 'Pending' | 'Running' | 'Succeeded' | 'Failed' | 'Skipped';
+
+export interface InstallLogEntry {
+  time: string;
+  message: string;
+  error?: boolean;
+}
+
+export interface InstallStepStatus {
+  name: string;
+  title: string;
+  state: InstallStepState;
+  error?: string;
+  attempts?: number;
+  started_at?: string;
+  finished_at?: string;
+  log: InstallLogEntry[];
+}
+
+export interface InstallState {
+  operation: InstallOperation;
+  phase: InstallPhase;
+  namespace: string;
+  co_ref?: string;
+  options: InstallOptions;
+  resolved?: InstallOptions;
+  uninstall?: UninstallOptions;
+  attempt: number;
+  failed_step?: string;
+  error?: string;
+  resumable: boolean;
+  started_at: string;
+  updated_at: string;
+  finished_at?: string;
+  steps: InstallStepStatus[];
+  holder?: string;
+  heartbeat_at?: string;
+}
+
+export interface UninstallOptions {
+  keep_namespace?: boolean;
+  export?: boolean;
+  force?: boolean;
+}
+
+export interface UninstallObject {
+  step: string;
+  kind: string;
+  name: string;
+  namespace?: string;
+  note?: string;
+}
+
+export interface UninstallPlan {
+  namespace: string;
+  keep_namespace: boolean;
+  export: boolean;
+  objects: UninstallObject[];
+}
+
+export interface Toleration {
+  key?: string;
+  operator?: 'Equal' | 'Exists';
+  value?: string;
+  effect?: 'NoSchedule' | 'PreferNoSchedule' | 'NoExecute';
+  tolerationSeconds?: number;
+}
+
+export interface ResourceRequirements {
+  requests?: Record<string, string>;
+  limits?: Record<string, string>;
+}
+
+export interface SubscriptionConfig {
+  nodeSelector?: Record<string, string>;
+  tolerations?: Toleration[];
+  resources?: ResourceRequirements;
+  env?: { name: string; value?: string }[];
+}
+
+export interface InstallOptions {
+  source?: InstallSource;
+  catalog_source?: string;
+  catalog_source_namespace?: string;
+  channel?: string;
+  starting_csv?: string;
+  approval?: InstallPlanApproval;
+  config?: SubscriptionConfig;
+  disconnected?: boolean;
+  mirror_registry?: string;
+  catalog_image?: string;
+  timeouts?: InstallTimeouts;
+}
+
+export interface InstallTimeouts {
+  csv_seconds?: number;
+  pods_seconds?: number;
+  profile_bundles_seconds?: number;
+}
+
+export interface WaitProgress {
+  percent: number;
+  elapsed_seconds: number;
+  timeout_seconds: number;
+  blocking?: string;
+}
+
+export interface MirrorRule {
+  kind: string;
+  name: string;
+  source: string;
+  mirrors: string[];
+}
+
+export type MirrorImageStatus = 'resolved' | 'unauthorized' | 'missing' | 'unreachable' | 'rejected';
+
+export interface MirrorImageCheck {
+  image: string;
+  mirror: string;
+  status: MirrorImageStatus;
+  message?: string;
+}
+
+export interface MirrorReport {
+  disconnected: boolean;
+  registry?: string;
+  catalog_image?: string;
+  rules: MirrorRule[];
+  images: MirrorImageCheck[];
+  ready: boolean;
+}
+
+export type PreflightStatus = 'pass' | 'warn' | 'fail';
+
+export interface PreflightCheck {
+  name: string;
+  title: string;
+  status: PreflightStatus;
+  message: string;
+  hint?: string;
+}
+
+export interface PreflightReport {
+  checks: PreflightCheck[];
+  status: PreflightStatus;
+  ready: boolean;
+  checked_at: string;
+}
+
+export interface ProfileBundleRewrite {
+  name: string;
+  from: string;
+  to: string;
 }
 
 export interface ProfileInfo {
   name: string;
   title: string;
   description?: string;
+  bundle?: string;
+}
+
+export interface ProfileBundleInfo {
+  name: string;
+  content_image: string;
+  content_file: string;
+  data_stream_status?: string;
+  error_message?: string;
+  custom: boolean;
+  profile_count: number;
+  profiles: ProfileInfo[];
+  created_at: string;
+}
+
+export interface ProfileBundleSpec {
+  name: string;
+  content_image: string;
+  content_file: string;
 }
 
 export interface ScanOptions {
@@ -124,6 +345,98 @@ export interface RemediationInfo {
   applied: boolean;
   reboot_needed: boolean;
   role?: string;
+  gitops_pending?: boolean;
+  outdated?: boolean;
+  application_state?: ApplicationState;
+  error_message?: string;
+  drift?: DriftState;
+  drifted_fields?: string[];
+  customized?: boolean;
+}
+
+export type PatchType = 'merge' | 'strategic';
+
+export interface RemediationPatch {
+  type?: PatchType;
+  patch: unknown;
+  customized_at?: string;
+}
+
+export type DriftState = 'Missing' | 'Drifted';
+
+export interface DriftReport {
+  remediation: string;
+  kind: string;
+  name: string;
+  namespace?: string;
+  drift?: DriftState;
+  fields?: string[];
+}
+
+export type ApplicationState =
+  | 'Applied'
+  | 'Error'
+  | 'NeedsReview'
+  | 'MissingDependencies'
+  | 'Outdated'
+  | 'NotApplied';
+
+export type RemediationMode = 'direct' | 'operator';
+
+export interface RemediationSettings {
+  mode: RemediationMode;
+  require_approval: boolean;
+}
+
+export interface MaintenanceWindow {
+  name: string;
+  schedule: string;
+  duration: string;
+  timezone?: string;
+  next_start?: string;
+  open?: boolean;
+  closes_at?: string;
+}
+
+export type ScheduledRemediationState = 'Queued' | 'Held' | 'Applying' | 'Applied' | 'Failed' | 'Canceled';
+
+export interface ScheduledRemediation {
+  id: string;
+  remediation: string;
+  window: string;
+  state: ScheduledRemediationState;
+  message?: string;
+  estimated_seconds?: number;
+  queued_at: string;
+  started_at?: string;
+  finished_at?: string;
+}
+
+export type ChangeRequestAction = 'apply' | 'remove';
+
+export type ChangeRequestState = 'Pending' | 'Approved' | 'Rejected' | 'Executed' | 'Expired';
+
+export interface ChangeRequestComment {
+  author: string;
+  text: string;
+  created_at: string;
+}
+
+export interface ChangeRequest {
+  id: string;
+  action: ChangeRequestAction;
+  remediations: string[];
+  requester: string;
+  approver?: string;
+  state: ChangeRequestState;
+  comments?: ChangeRequestComment[];
+  created_at: string;
+  expires_at: string;
+  decided_at?: string;
+  executed_at?: string;
+  outcome?: string;
+  patch?: RemediationPatch;
+  patch_sha256?: string;
 }
 
 export interface RemediationDetail {
@@ -133,9 +446,101 @@ export interface RemediationDetail {
   applied: boolean;
   reboot_needed: boolean;
   role?: string;
+  gitops_pending?: boolean;
+  outdated?: boolean;
+  application_state?: ApplicationState;
+  error_message?: string;
+  drift?: DriftState;
+  drifted_fields?: string[];
   object_yaml: string;
   api_version?: string;
   namespace?: string;
+  has_snapshot: boolean;
+  outdated_yaml?: string;
+  outdated_diff?: string;
+  impact?: RemediationImpact;
+  ignition?: IgnitionConfig;
+  customization?: RemediationPatch;
+  customized_yaml?: string;
+  customization_diff?: string;
+}
+
+export interface IgnitionFile {
+  path: string;
+  mode?: string;
+  owner?: string;
+  overwrite?: boolean;
+  encoding?: string;
+  content: string;
+  size: number;
+  binary?: boolean;
+  truncated?: boolean;
+  source?: string;
+  error?: string;
+}
+
+export interface SystemdUnit {
+  name: string;
+  enabled?: boolean;
+  mask?: boolean;
+  contents?: string;
+  dropins?: { name: string; contents?: string }[];
+}
+
+export interface IgnitionConfig {
+  version?: string;
+  files: IgnitionFile[];
+  units: SystemdUnit[];
+}
+
+export interface IgnitionConflict {
+  type: 'file' | 'unit';
+  path: string;
+  remediations: string[];
+  identical: boolean;
+}
+
+export interface RoleIgnitionPreview {
+  role: string;
+  files: (IgnitionFile & { remediation: string; applied: boolean })[];
+  units: (SystemdUnit & { remediation: string; applied: boolean })[];
+  conflicts: IgnitionConflict[];
+}
+
+export interface PoolImpact {
+  pool: string;
+  node_count: number;
+  max_unavailable: string;
+  paused: boolean;
+  estimated_seconds: number;
+  estimated_duration: string;
+  remediations: string[];
+}
+
+export interface RemediationImpact {
+  remediations: string[];
+  pools?: PoolImpact[];
+  nodes_affected: number;
+  reboot_needed: boolean;
+  touches_kubelet: boolean;
+  touches_api_server: boolean;
+  touches_oauth: boolean;
+  control_plane_restart: boolean;
+  restarted_components?: string[];
+  estimated_seconds: number;
+  estimated_duration: string;
+}
+
+export interface RemediationSnapshot {
+  remediation: string;
+  kind: string;
+  api_version: string;
+  name: string;
+  namespace?: string;
+  existed: boolean;
+  captured_at: string;
+  object_yaml?: string;
+  stored_in: string;
 }
 
 export interface RemediationResult {
@@ -145,6 +550,106 @@ export interface RemediationResult {
   error?: string;
 }
 
+export interface GitOpsFile {
+  path: string;
+  remediation?: string;
+}
+
+export interface GitOpsExport {
+  remediations: string[];
+  files: GitOpsFile[];
+}
+
+export interface GitOpsCommitResult {
+  repository: string;
+  commit: string;
+  export: GitOpsExport;
+}
+
+export interface VerificationResult {
+  remediation: string;
+  check_result: string;
+  scan: string;
+  applied: boolean;
+  apply_message?: string;
+  previous_status: CheckStatus;
+  status?: CheckStatus;
+  verified: boolean;
+  message?: string;
+}
+
+export interface RemediationFilter {
+  role?: string;
+  kind?: string;
+  exclude_reboot?: boolean;
+}
+
+export interface SeverityApplyPreview {
+  severity: Severity;
+  filter: RemediationFilter;
+  remediations: RemediationInfo[];
+  impact?: RemediationImpact;
+  confirmation_token?: string;
+  expires_at?: string;
+}
+
+export interface NodeRolloutStatus {
+  name: string;
+  pool: string;
+  current_config?: string;
+  desired_config?: string;
+  state?: string;
+  updated: boolean;
+}
+
+export interface MachineConfigPoolStatus {
+  name: string;
+  paused: boolean;
+  machine_count: number;
+  updated_machine_count: number;
+  ready_machine_count: number;
+  unavailable_machine_count: number;
+  degraded_machine_count: number;
+  current_config?: string;
+  desired_config?: string;
+  updated: boolean;
+  updating: boolean;
+  degraded: boolean;
+  conditions?: Condition[];
+  nodes?: NodeRolloutStatus[];
+}
+
+export interface MachineConfigBatchProgress {
+  step: string;
+  message: string;
+  result?: RemediationResult;
+  node?: NodeRolloutStatus;
+  done: boolean;
+  error?: string;
+}
+
+export type JobState = 'running' | 'succeeded' | 'failed' | 'canceled';
+
+export interface JobStep {
+  name: string;
+  state: JobState;
+  message?: string;
+  error?: string;
+  started_at: string;
+  finished_at?: string;
+}
+
+export interface Job<T = unknown> {
+  id: string;
+  type: string;
+  state: JobState;
+  steps: JobStep[];
+  result?: T;
+  error?: string;
+  created_at: string;
+  finished_at?: string;
+}
+
 export interface StorageInfo {
   has_default_storage_class: boolean;
   storage_class_name?: string;
@@ -178,6 +683,13 @@ export type WSMessageType =
   | 'check_result'
   | 'remediation'
   | 'remediation_result'
+  | 'machineconfig_batch_progress'
+  | 'machineconfigpool_status'
+  | 'job_update'
+  | 'change_request'
+  | 'scheduled_remediation'
+  | 'remediation_drift'
+  | 'operator_subscription'
   | 'error';
 
 export interface WSMessage {
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// confirmationTTL is how long a confirmation token stays valid.
+const confirmationTTL = 5 * time.Minute
+
+// pendingConfirmation is an operation previewed to a user and awaiting the
+// token to be echoed back before it runs.
+type pendingConfirmation struct {
+	scope     string
+	names     []string
+	expiresAt time.Time
+}
+
+// confirmationStore issues single-use tokens that bind a previewed set of
+// remediations to the request that later executes it.
+type confirmationStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{pending: make(map[string]pendingConfirmation)}
+}
+
+// issue records the previewed names for scope and returns a token and its expiry.
+func (c *confirmationStore) issue(scope string, names []string) (string, time.Time) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+	expiresAt := time.Now().Add(confirmationTTL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired tokens so the map doesn't grow without bound.
+	now := time.Now()
+	for t, p := range c.pending {
+		if now.After(p.expiresAt) {
+			delete(c.pending, t)
+		}
+	}
+
+	c.pending[token] = pendingConfirmation{
+		scope:     scope,
+		names:     slices.Sorted(slices.Values(names)),
+		expiresAt: expiresAt,
+	}
+	return token, expiresAt
+}
+
+// redeem consumes a token, checking that it was issued for scope and that the
+// selection has not changed since the preview.
+func (c *confirmationStore) redeem(token, scope string, names []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[token]
+	if !ok {
+		return fmt.Errorf("unknown or already used confirmation token")
+	}
+	delete(c.pending, token)
+
+	if time.Now().After(p.expiresAt) {
+		return fmt.Errorf("confirmation token expired")
+	}
+	if p.scope != scope {
+		return fmt.Errorf("confirmation token was issued for a different request")
+	}
+	if !slices.Equal(p.names, slices.Sorted(slices.Values(names))) {
+		return fmt.Errorf("the set of matching remediations changed since the preview; request a new confirmation token")
+	}
+	return nil
+}
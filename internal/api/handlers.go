@@ -1,37 +1,99 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/sebrandon1/compliance-operator-dashboard/internal/compliance"
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/config"
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/jobs"
 	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
 	"github.com/sebrandon1/compliance-operator-dashboard/internal/ws"
 )
 
 // Handlers holds dependencies for API handlers.
 type Handlers struct {
-	k8sClient     *k8s.Client
-	compliance    *compliance.Service
-	hub           *ws.Hub
-	namespace     string
-	complianceRef string
+	k8sClient  *k8s.Client
+	compliance *compliance.Service
+	// remediationMode is how remediations are applied, from the Service.
+	remediationMode compliance.RemediationMode
+	hub             *ws.Hub
+	jobs            *jobs.Manager
+	confirmations   *confirmationStore
+	namespace       string
+	complianceRef   string
+	gitopsRepo      string
+	// gitopsMu serializes commits to gitopsRepo.
+	gitopsMu sync.Mutex
+	// requireApproval makes apply and remove operations require an approved
+	// change request.
+	requireApproval bool
+	// disconnected, mirrorRegistry and catalogImage are the install
+	// defaults for disconnected clusters.
+	disconnected   bool
+	mirrorRegistry string
+	catalogImage   string
+	// images checks install images, contacting only the configured
+	// mirror registry and the registries in the cluster's mirror rules.
+	images compliance.ImageChecker
+	// stateNamespace holds the persisted install and uninstall step log.
+	stateNamespace string
+	// installTimeouts are the default install phase timeouts.
+	installTimeouts compliance.InstallTimeouts
 }
 
-// NewHandlers creates a new Handlers instance.
-func NewHandlers(client *k8s.Client, svc *compliance.Service, hub *ws.Hub, namespace, complianceRef string) *Handlers {
+// Job types for long-running operations.
+const (
+	jobTypeInstall            = "install"
+	jobTypeUninstall          = "uninstall"
+	jobTypeBatchApply         = "batch-apply"
+	jobTypeMachineConfigBatch = "machineconfig-batch"
+	jobTypeApplyBySeverity    = "apply-by-severity"
+	jobTypeRemediateVerify    = "remediate-verify"
+)
+
+// maxJobHistory is the number of finished jobs kept for GET /api/jobs.
+const maxJobHistory = 50
+
+// NewHandlers creates a new Handlers instance configured from cfg.
+func NewHandlers(client *k8s.Client, svc *compliance.Service, hub *ws.Hub, cfg config.Config) *Handlers {
 	return &Handlers{
-		k8sClient:     client,
-		compliance:    svc,
-		hub:           hub,
-		namespace:     namespace,
-		complianceRef: complianceRef,
+		k8sClient:       client,
+		compliance:      svc,
+		remediationMode: svc.RemediationMode(),
+		hub:             hub,
+		jobs: jobs.NewManager(maxJobHistory, func(job jobs.Job) {
+			hub.Broadcast(ws.Message{
+				Type:    ws.MessageTypeJobUpdate,
+				Payload: job,
+			})
+		}),
+		confirmations:   newConfirmationStore(),
+		namespace:       cfg.Namespace,
+		complianceRef:   cfg.ComplianceOpRef,
+		gitopsRepo:      cfg.GitOpsRepo,
+		requireApproval: cfg.RequireApproval,
+		disconnected:    cfg.Disconnected,
+		mirrorRegistry:  cfg.MirrorRegistry,
+		catalogImage:    cfg.CatalogImage,
+		images:          compliance.ImageChecker{MirrorRegistry: cfg.MirrorRegistry},
+		stateNamespace:  cfg.StateNamespace,
+		installTimeouts: compliance.InstallTimeouts{
+			CSVSeconds:            int64(cfg.CSVTimeout.Seconds()),
+			PodsSeconds:           int64(cfg.PodsTimeout.Seconds()),
+			ProfileBundlesSeconds: int64(cfg.ProfileBundlesTimeout.Seconds()),
+		},
 	}
 }
 
@@ -81,38 +143,152 @@ func (h *Handlers) HandleClusterStatus(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, status)
 }
 
-// HandleOperatorInstall starts the operator installation process.
+// HandleOperatorInstall starts the operator installation process as a job.
+// The optional body is a compliance.InstallOptions.
 func (h *Handlers) HandleOperatorInstall(w http.ResponseWriter, r *http.Request) {
 	if h.k8sClient == nil {
 		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
 		return
 	}
 
-	progress := make(chan compliance.InstallProgress, 32)
+	var opts compliance.InstallOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	h.applyInstallDefaults(&opts)
+	if err := opts.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The job runs with its own context — the request context will be
+	// canceled as soon as the 202 response is sent, but install is long-running.
+	job, started := h.jobs.StartExclusive(jobTypeInstall, func(ctx context.Context, rep *jobs.Reporter) (interface{}, error) {
+		progress := make(chan compliance.InstallProgress, 32)
+		go compliance.Install(ctx, h.k8sClient, h.images, h.namespace, h.stateNamespace, h.complianceRef, opts, progress)
+		return nil, h.forwardInstallProgress(progress, ws.MessageTypeInstallProgress, rep)
+	})
+	if !started {
+		writeJSON(w, http.StatusConflict, job)
+		return
+	}
 
-	// Use a background context — the request context will be canceled
-	// as soon as the 202 response is sent, but install is long-running.
-	installCtx := context.Background()
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Installation started. Follow progress via WebSocket.",
+		"job_id":  job.ID,
+	})
+}
 
-	go func() {
-		compliance.Install(installCtx, h.k8sClient, h.namespace, h.complianceRef, progress)
-	}()
+// HandleGetInstallStatus returns the step log of the most recent install or
+// uninstall, including one left unfinished by a dashboard restart.
+func (h *Handlers) HandleGetInstallStatus(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
 
-	// Stream progress to WebSocket
-	go func() {
-		for p := range progress {
-			h.hub.Broadcast(ws.Message{
-				Type:    ws.MessageTypeInstallProgress,
-				Payload: p,
-			})
-		}
-	}()
+	state, err := compliance.GetInstallState(r.Context(), h.k8sClient, h.stateNamespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if state == nil {
+		writeError(w, http.StatusNotFound, "No install or uninstall has been recorded")
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// ResumeInstallRequest optionally replaces the phase timeouts of the run
+// being resumed, e.g. to give a slow cluster longer.
+type ResumeInstallRequest struct {
+	Timeouts *compliance.InstallTimeouts `json:"timeouts,omitempty"`
+}
+
+// HandleResumeInstall re-runs the most recent failed or interrupted install
+// or uninstall as a job, starting at the step that did not finish.
+func (h *Handlers) HandleResumeInstall(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req ResumeInstallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := (compliance.InstallOptions{Timeouts: req.Timeouts}).Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	state, err := compliance.GetInstallState(r.Context(), h.k8sClient, h.stateNamespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if state == nil {
+		writeError(w, http.StatusNotFound, "No install or uninstall has been recorded")
+		return
+	}
+	if !state.Resumable {
+		writeError(w, http.StatusConflict, fmt.Sprintf("The last %s is %s and cannot be resumed",
+			state.Operation, strings.ToLower(string(state.Phase))))
+		return
+	}
+
+	jobType, msgType := jobTypeInstall, ws.MessageTypeInstallProgress
+	if state.Operation == compliance.OperationUninstall {
+		jobType, msgType = jobTypeUninstall, ws.MessageTypeUninstallProgress
+	}
+	job, started := h.jobs.StartExclusive(jobType, func(ctx context.Context, rep *jobs.Reporter) (interface{}, error) {
+		progress := make(chan compliance.InstallProgress, 32)
+		go compliance.ResumeInstall(ctx, h.k8sClient, h.images, h.stateNamespace, req.Timeouts, progress)
+		return nil, h.forwardInstallProgress(progress, msgType, rep)
+	})
+	if !started {
+		writeJSON(w, http.StatusConflict, job)
+		return
+	}
 
 	writeJSON(w, http.StatusAccepted, map[string]string{
-		"message": "Installation started. Follow progress via WebSocket.",
+		"message": fmt.Sprintf("Resuming %s at step %s. Follow progress via WebSocket.", state.Operation, state.FailedStep),
+		"job_id":  job.ID,
 	})
 }
 
+// HandleDiagnosticsBundle downloads a tar.gz of pod logs, Events,
+// compliance CRs, OLM status and storage information for support, with
+// secret values redacted.
+func (h *Handlers) HandleDiagnosticsBundle(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	bundle, err := compliance.CollectDiagnostics(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err := bundle.WriteTarGz(&buf); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeArchive(w, bundle.DirName()+".tar.gz", buf.Bytes())
+}
+
+// writeArchive sends data as a tar.gz download named filename.
+func writeArchive(w http.ResponseWriter, filename string, data []byte) {
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
 // HandleOperatorStatus returns the current operator status.
 func (h *Handlers) HandleOperatorStatus(w http.ResponseWriter, r *http.Request) {
 	status, err := compliance.GetStatus(r.Context(), h.k8sClient, h.namespace)
@@ -177,6 +353,112 @@ func (h *Handlers) HandleListProfiles(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, profiles)
 }
 
+// HandleListProfileBundles returns every ProfileBundle with its content,
+// parse status and profiles.
+func (h *Handlers) HandleListProfileBundles(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	bundles, err := compliance.ListProfileBundles(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, bundles)
+}
+
+// HandleGetProfileBundle returns a single ProfileBundle with its profiles.
+func (h *Handlers) HandleGetProfileBundle(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	bundle, err := compliance.GetProfileBundle(r.Context(), h.k8sClient, h.namespace, r.PathValue("name"))
+	if err != nil {
+		writeProfileBundleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// HandleCreateProfileBundle creates a custom ProfileBundle.
+func (h *Handlers) HandleCreateProfileBundle(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var spec compliance.ProfileBundleSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	bundle, err := compliance.CreateProfileBundle(r.Context(), h.k8sClient, h.namespace, spec)
+	if err != nil {
+		writeProfileBundleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, bundle)
+}
+
+// HandleUpdateProfileBundle points a custom ProfileBundle at new content.
+func (h *Handlers) HandleUpdateProfileBundle(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var spec compliance.ProfileBundleSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	spec.Name = r.PathValue("name")
+
+	bundle, err := compliance.UpdateProfileBundle(r.Context(), h.k8sClient, h.namespace, spec)
+	if err != nil {
+		writeProfileBundleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// HandleDeleteProfileBundle deletes a custom ProfileBundle and its profiles.
+func (h *Handlers) HandleDeleteProfileBundle(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := compliance.DeleteProfileBundle(r.Context(), h.k8sClient, h.namespace, name); err != nil {
+		writeProfileBundleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("ProfileBundle %s deleted", name),
+	})
+}
+
+// writeProfileBundleError maps ProfileBundle errors to status codes.
+func writeProfileBundleError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "invalid") || strings.Contains(msg, "required"):
+		writeError(w, http.StatusBadRequest, msg)
+	case strings.Contains(msg, "not found"):
+		writeError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "already exists") || strings.Contains(msg, "managed by the operator") || strings.Contains(msg, "in use"):
+		writeError(w, http.StatusConflict, msg)
+	default:
+		writeError(w, http.StatusInternalServerError, msg)
+	}
+}
+
 // HandleCreateRecommendedScans creates scans for the 4 recommended profiles.
 func (h *Handlers) HandleCreateRecommendedScans(w http.ResponseWriter, r *http.Request) {
 	if h.k8sClient == nil {
@@ -255,6 +537,15 @@ func (h *Handlers) HandleGetResultsSummary(w http.ResponseWriter, r *http.Reques
 	writeJSON(w, http.StatusOK, summary)
 }
 
+// ApplyRemediationRequest is the optional JSON body for a single apply.
+type ApplyRemediationRequest struct {
+	// Patch is applied on top of spec.current.object before applying, as a
+	// JSON merge patch or, with patch_type "strategic", a strategic merge
+	// patch.
+	Patch     json.RawMessage `json:"patch,omitempty"`
+	PatchType string          `json:"patch_type,omitempty"`
+}
+
 // HandleApplyRemediation applies a single remediation.
 func (h *Handlers) HandleApplyRemediation(w http.ResponseWriter, r *http.Request) {
 	if h.k8sClient == nil {
@@ -269,9 +560,34 @@ func (h *Handlers) HandleApplyRemediation(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	result, err := compliance.ApplyRemediation(r.Context(), h.k8sClient, h.namespace, name)
+	var req ApplyRemediationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var patch *compliance.RemediationPatch
+	if len(req.Patch) > 0 && string(req.Patch) != "null" {
+		patch = &compliance.RemediationPatch{Type: req.PatchType, Patch: req.Patch}
+		// Reject a bad patch before it uses up the approval
+		if err := compliance.ValidateRemediationPatch(r.Context(), h.k8sClient, h.remediationMode, h.namespace, name, patch); err != nil {
+			writeRemediationPatchError(w, err)
+			return
+		}
+	}
+
+	changeRequest := r.URL.Query().Get("change_request")
+	if !h.requireChangeRequest(w, r, changeRequest, compliance.ChangeRequestApply, []string{name}, patch) {
+		return
+	}
+
+	result, err := compliance.ApplyCustomizedRemediation(r.Context(), h.k8sClient, h.remediationMode, h.namespace, name, patch)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		h.releaseChangeRequest(r.Context(), changeRequest, err)
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid patch") {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err.Error())
 		return
 	}
 
@@ -284,55 +600,41 @@ func (h *Handlers) HandleApplyRemediation(w http.ResponseWriter, r *http.Request
 	writeJSON(w, http.StatusOK, result)
 }
 
-// BatchApplyRequest is the JSON body for batch remediation apply.
-type BatchApplyRequest struct {
-	Names []string `json:"names"`
+// writeRemediationPatchError maps patch validation errors to HTTP statuses.
+func writeRemediationPatchError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "invalid patch"):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
 }
 
-// HandleBatchApplyRemediations applies multiple remediations in a single request.
-func (h *Handlers) HandleBatchApplyRemediations(w http.ResponseWriter, r *http.Request) {
-	if h.k8sClient == nil {
-		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
-		return
-	}
+// HandleGetRemediationMode returns how remediations are applied and whether
+// they need an approved change request.
+func (h *Handlers) HandleGetRemediationMode(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"mode":             h.remediationMode,
+		"require_approval": h.requireApproval,
+	})
+}
 
-	var req BatchApplyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-	if len(req.Names) == 0 {
-		writeError(w, http.StatusBadRequest, "At least one remediation name is required")
+// HandleListOutdatedRemediations lists remediations with a newer version
+// waiting to be promoted, e.g. after a ProfileBundle upgrade.
+func (h *Handlers) HandleListOutdatedRemediations(w http.ResponseWriter, r *http.Request) {
+	remediations, err := compliance.ListOutdatedRemediations(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	var results []compliance.RemediationResult
-	for _, name := range req.Names {
-		result, err := compliance.ApplyRemediation(r.Context(), h.k8sClient, h.namespace, name)
-		if err != nil {
-			res := compliance.RemediationResult{
-				Name:  name,
-				Error: err.Error(),
-			}
-			results = append(results, res)
-			h.hub.Broadcast(ws.Message{
-				Type:    ws.MessageTypeRemediationResult,
-				Payload: res,
-			})
-			continue
-		}
-		results = append(results, *result)
-		h.hub.Broadcast(ws.Message{
-			Type:    ws.MessageTypeRemediationResult,
-			Payload: *result,
-		})
-	}
-
-	writeJSON(w, http.StatusOK, results)
+	writeJSON(w, http.StatusOK, remediations)
 }
 
-// HandleRemoveRemediation removes a previously applied remediation object.
-func (h *Handlers) HandleRemoveRemediation(w http.ResponseWriter, r *http.Request) {
+// HandlePromoteRemediation promotes an outdated remediation to its new version.
+func (h *Handlers) HandlePromoteRemediation(w http.ResponseWriter, r *http.Request) {
 	if h.k8sClient == nil {
 		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
 		return
@@ -344,9 +646,22 @@ func (h *Handlers) HandleRemoveRemediation(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	result, err := compliance.RemoveRemediation(r.Context(), h.k8sClient, h.namespace, name)
+	changeRequest := r.URL.Query().Get("change_request")
+	if !h.requireChangeRequest(w, r, changeRequest, compliance.ChangeRequestApply, []string{name}, nil) {
+		return
+	}
+
+	result, err := compliance.PromoteRemediation(r.Context(), h.k8sClient, h.remediationMode, h.namespace, name)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		h.releaseChangeRequest(r.Context(), changeRequest, err)
+		switch {
+		case strings.Contains(err.Error(), "not outdated"):
+			writeError(w, http.StatusConflict, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			writeError(w, http.StatusNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -358,39 +673,44 @@ func (h *Handlers) HandleRemoveRemediation(w http.ResponseWriter, r *http.Reques
 	writeJSON(w, http.StatusOK, result)
 }
 
-// HandleGetRemediation returns detail for a single remediation including its YAML.
-func (h *Handlers) HandleGetRemediation(w http.ResponseWriter, r *http.Request) {
-	name := r.PathValue("name")
-	if name == "" {
-		writeError(w, http.StatusBadRequest, "Remediation name is required")
-		return
-	}
-
-	detail, err := compliance.GetRemediation(r.Context(), h.k8sClient, h.namespace, name)
+// HandleListDriftedRemediations lists applied remediations whose object was
+// deleted or changed outside the dashboard.
+func (h *Handlers) HandleListDriftedRemediations(w http.ResponseWriter, r *http.Request) {
+	remediations, err := compliance.ListDriftedRemediations(r.Context(), h.k8sClient, h.namespace)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			writeError(w, http.StatusNotFound, err.Error())
-			return
-		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	writeJSON(w, http.StatusOK, detail)
+	writeJSON(w, http.StatusOK, remediations)
 }
 
-// HandleListRemediations lists all available remediations.
-func (h *Handlers) HandleListRemediations(w http.ResponseWriter, r *http.Request) {
-	remediations, err := compliance.ListRemediations(r.Context(), h.k8sClient, h.namespace)
+// HandleCheckDrift runs a drift check now instead of waiting for the next
+// periodic one, returning the remediations whose drift state changed.
+func (h *Handlers) HandleCheckDrift(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	changed, err := compliance.CheckDrift(r.Context(), h.k8sClient, h.namespace)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	writeJSON(w, http.StatusOK, remediations)
+	for _, report := range changed {
+		h.hub.Broadcast(ws.Message{
+			Type:    ws.MessageTypeRemediationDrift,
+			Payload: report,
+		})
+	}
+	if changed == nil {
+		changed = []compliance.DriftReport{}
+	}
+	writeJSON(w, http.StatusOK, changed)
 }
 
-// HandleRescan triggers a rescan of all ComplianceScans in a suite.
-func (h *Handlers) HandleRescan(w http.ResponseWriter, r *http.Request) {
+// HandleReapplyRemediation re-applies a remediation whose object drifted.
+func (h *Handlers) HandleReapplyRemediation(w http.ResponseWriter, r *http.Request) {
 	if h.k8sClient == nil {
 		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
 		return
@@ -398,38 +718,1067 @@ func (h *Handlers) HandleRescan(w http.ResponseWriter, r *http.Request) {
 
 	name := r.PathValue("name")
 	if name == "" {
-		writeError(w, http.StatusBadRequest, "Scan suite name is required")
+		writeError(w, http.StatusBadRequest, "Remediation name is required")
+		return
+	}
+	changeRequest := r.URL.Query().Get("change_request")
+	if !h.requireChangeRequest(w, r, changeRequest, compliance.ChangeRequestApply, []string{name}, nil) {
 		return
 	}
 
-	if err := compliance.RescanSuite(r.Context(), h.k8sClient, h.namespace, name); err != nil {
-		if strings.Contains(err.Error(), "no ComplianceScans found") {
+	result, err := compliance.ReapplyRemediation(r.Context(), h.k8sClient, h.remediationMode, h.namespace, name)
+	if err != nil {
+		h.releaseChangeRequest(r.Context(), changeRequest, err)
+		switch {
+		case strings.Contains(err.Error(), "has not drifted"):
+			writeError(w, http.StatusConflict, err.Error())
+		case strings.Contains(err.Error(), "not found"):
 			writeError(w, http.StatusNotFound, err.Error())
-			return
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{
-		"message": fmt.Sprintf("Rescan triggered for suite %s", name),
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeRemediationResult,
+		Payload: result,
 	})
+	writeJSON(w, http.StatusOK, result)
 }
 
-// HandleDeleteScan deletes a ComplianceSuite and its ScanSettingBinding.
-func (h *Handlers) HandleDeleteScan(w http.ResponseWriter, r *http.Request) {
+// HandleIgnitionPreview returns the combined Ignition files and units each
+// role would receive from MachineConfig remediations, with conflicting
+// paths. The optional role query parameter limits it to one role.
+func (h *Handlers) HandleIgnitionPreview(w http.ResponseWriter, r *http.Request) {
 	if h.k8sClient == nil {
 		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
 		return
 	}
 
-	name := r.PathValue("name")
-	if name == "" {
-		writeError(w, http.StatusBadRequest, "Scan suite name is required")
-		return
-	}
-
-	if err := compliance.DeleteScan(r.Context(), h.k8sClient, h.namespace, name); err != nil {
+	previews, err := compliance.PreviewRoleIgnition(r.Context(), h.k8sClient, h.namespace, r.URL.Query().Get("role"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, previews)
+}
+
+// HandleVerifyRemediation applies a remediation and rescans to confirm its
+// check now passes. It runs as a job whose result is the VerificationResult;
+// the job fails if the check does not flip to PASS.
+func (h *Handlers) HandleVerifyRemediation(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Remediation name is required")
+		return
+	}
+
+	changeRequest := r.URL.Query().Get("change_request")
+	if !h.requireChangeRequest(w, r, changeRequest, compliance.ChangeRequestApply, []string{name}, nil) {
+		return
+	}
+
+	job := h.jobs.Start(jobTypeRemediateVerify, func(ctx context.Context, rep *jobs.Reporter) (interface{}, error) {
+		progress := make(chan compliance.VerificationProgress, 10)
+		var result *compliance.VerificationResult
+		var err error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			result, err = compliance.VerifyRemediation(ctx, h.k8sClient, h.remediationMode, h.namespace, name, progress)
+		}()
+		for p := range progress {
+			rep.Step(p.Step, p.Message)
+		}
+		<-done
+		if result != nil && result.Applied {
+			h.hub.Broadcast(ws.Message{
+				Type:    ws.MessageTypeRemediationResult,
+				Payload: compliance.RemediationResult{Name: name, Applied: true, Message: result.ApplyMessage},
+			})
+		} else if err != nil {
+			h.releaseChangeRequest(context.Background(), changeRequest, err)
+		}
+		return result, err
+	})
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// ImpactRequest is the JSON body for a remediation impact analysis.
+type ImpactRequest struct {
+	Names []string `json:"names"`
+}
+
+// HandleRemediationImpact reports what applying the given remediations would
+// disrupt, so a batch can be reviewed before it is applied.
+func (h *Handlers) HandleRemediationImpact(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req ImpactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Names) == 0 {
+		writeError(w, http.StatusBadRequest, "At least one remediation name is required")
+		return
+	}
+
+	impact, err := compliance.AnalyzeImpact(r.Context(), h.k8sClient, h.namespace, req.Names)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, impact)
+}
+
+// BatchApplyRequest is the JSON body for batch remediation apply.
+type BatchApplyRequest struct {
+	Names []string `json:"names"`
+	// PausePools applies MachineConfig/KubeletConfig remediations with their
+	// MachineConfigPools paused so they roll out together in one reboot cycle.
+	PausePools bool `json:"pause_pools,omitempty"`
+	// ChangeRequest is the approved change request covering Names, required
+	// when two-person approval is enabled.
+	ChangeRequest string `json:"change_request,omitempty"`
+}
+
+// HandleBatchApplyRemediations applies multiple remediations as a background
+// job. The job's result holds the per-item RemediationResults; with
+// pause_pools set, pool and node progress is also streamed over WebSocket.
+func (h *Handlers) HandleBatchApplyRemediations(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req BatchApplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Names) == 0 {
+		writeError(w, http.StatusBadRequest, "At least one remediation name is required")
+		return
+	}
+	if !h.requireChangeRequest(w, r, req.ChangeRequest, compliance.ChangeRequestApply, req.Names, nil) {
+		return
+	}
+
+	if req.PausePools {
+		job := h.jobs.Start(jobTypeMachineConfigBatch, func(ctx context.Context, rep *jobs.Reporter) (interface{}, error) {
+			results, err := h.runMachineConfigBatch(ctx, req.Names, rep)
+			if !anyApplied(results) {
+				h.releaseChangeRequest(context.Background(), req.ChangeRequest, batchFailure(results, err))
+			}
+			return results, err
+		})
+		writeJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	job := h.jobs.Start(jobTypeBatchApply, func(ctx context.Context, rep *jobs.Reporter) (interface{}, error) {
+		var results []compliance.RemediationResult
+		for i, name := range req.Names {
+			if err := ctx.Err(); err != nil {
+				if !anyApplied(results) {
+					h.releaseChangeRequest(context.Background(), req.ChangeRequest, err)
+				}
+				return results, err
+			}
+			rep.Step("apply", fmt.Sprintf("Applying %s (%d/%d)", name, i+1, len(req.Names)))
+
+			result, err := compliance.ApplyRemediation(ctx, h.k8sClient, h.remediationMode, h.namespace, name)
+			if err != nil {
+				result = &compliance.RemediationResult{
+					Name:  name,
+					Error: err.Error(),
+				}
+			}
+			results = append(results, *result)
+			h.hub.Broadcast(ws.Message{
+				Type:    ws.MessageTypeRemediationResult,
+				Payload: *result,
+			})
+		}
+		if !anyApplied(results) {
+			h.releaseChangeRequest(context.Background(), req.ChangeRequest, batchFailure(results, nil))
+		}
+		return results, nil
+	})
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (h *Handlers) runMachineConfigBatch(ctx context.Context, names []string, rep *jobs.Reporter) ([]compliance.RemediationResult, error) {
+	progress := make(chan compliance.MachineConfigBatchProgress, 32)
+	go compliance.ApplyMachineConfigBatch(ctx, h.k8sClient, h.remediationMode, h.namespace, names, progress)
+
+	var results []compliance.RemediationResult
+	var lastErr error
+	for p := range progress {
+		if p.Result != nil {
+			results = append(results, *p.Result)
+			h.hub.Broadcast(ws.Message{
+				Type:    ws.MessageTypeRemediationResult,
+				Payload: *p.Result,
+			})
+		}
+		h.hub.Broadcast(ws.Message{
+			Type:    ws.MessageTypeMachineConfigBatchProgress,
+			Payload: p,
+		})
+		if p.Error != "" {
+			rep.StepError(p.Step, p.Message)
+			lastErr = errors.New(p.Error)
+			continue
+		}
+		rep.Step(p.Step, p.Message)
+	}
+	return results, lastErr
+}
+
+// SeverityApplyRequest is the JSON body for apply-by-severity. Without a
+// confirmation token the request only previews what would be applied.
+type SeverityApplyRequest struct {
+	compliance.RemediationFilter
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+	ChangeRequest     string `json:"change_request,omitempty"`
+}
+
+// SeverityApplyPreview lists the remediations an apply-by-severity request
+// would apply, with the token that must be echoed back to execute it.
+type SeverityApplyPreview struct {
+	Severity          compliance.Severity           `json:"severity"`
+	Filter            compliance.RemediationFilter  `json:"filter"`
+	Remediations      []compliance.RemediationInfo  `json:"remediations"`
+	Impact            *compliance.RemediationImpact `json:"impact,omitempty"`
+	ConfirmationToken string                        `json:"confirmation_token,omitempty"`
+	ExpiresAt         *time.Time                    `json:"expires_at,omitempty"`
+}
+
+// HandleApplyBySeverity previews or applies all remediations of a severity.
+// The first call returns the matching remediations and a confirmation token;
+// echoing the token back starts the apply as a job, streaming per-item
+// results as remediation_result WebSocket messages.
+func (h *Handlers) HandleApplyBySeverity(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	severity := compliance.Severity(strings.ToLower(r.PathValue("level")))
+	switch severity {
+	case compliance.SeverityHigh, compliance.SeverityMedium, compliance.SeverityLow:
+	default:
+		writeError(w, http.StatusBadRequest, "Severity must be one of high, medium, low")
+		return
+	}
+
+	var req SeverityApplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	selected, err := compliance.SelectBySeverity(r.Context(), h.k8sClient, h.namespace, severity, req.RemediationFilter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	names := make([]string, 0, len(selected))
+	for _, rem := range selected {
+		names = append(names, rem.Name)
+	}
+	scope := fmt.Sprintf("severity:%s:%+v", severity, req.RemediationFilter)
+
+	if req.ConfirmationToken == "" {
+		preview := SeverityApplyPreview{
+			Severity:     severity,
+			Filter:       req.RemediationFilter,
+			Remediations: selected,
+		}
+		if len(selected) > 0 {
+			impact, err := compliance.AnalyzeImpact(r.Context(), h.k8sClient, h.namespace, names)
+			if err != nil {
+				slog.Warn("failed to analyze remediation impact", "error", err)
+			}
+			preview.Impact = impact
+
+			token, expiresAt := h.confirmations.issue(scope, names)
+			preview.ConfirmationToken = token
+			preview.ExpiresAt = &expiresAt
+		}
+		writeJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	if err := h.confirmations.redeem(req.ConfirmationToken, scope, names); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if !h.requireChangeRequest(w, r, req.ChangeRequest, compliance.ChangeRequestApply, names, nil) {
+		return
+	}
+
+	// MachineConfig and KubeletConfig items roll out together after the
+	// others are applied.
+	var direct []compliance.RemediationInfo
+	var disruptive []string
+	for _, rem := range selected {
+		if compliance.IsDisruptiveKind(rem.Kind) {
+			disruptive = append(disruptive, rem.Name)
+		} else {
+			direct = append(direct, rem)
+		}
+	}
+
+	job := h.jobs.Start(jobTypeApplyBySeverity, func(ctx context.Context, rep *jobs.Reporter) (interface{}, error) {
+		progress := make(chan compliance.RemediationResult, 32)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- compliance.ApplyBySeverity(ctx, h.k8sClient, h.remediationMode, h.namespace, direct, progress)
+		}()
+
+		var results []compliance.RemediationResult
+		for result := range progress {
+			results = append(results, result)
+			rep.Step("apply", fmt.Sprintf("Processed %s (%d/%d)", result.Name, len(results), len(names)))
+			h.hub.Broadcast(ws.Message{
+				Type:    ws.MessageTypeRemediationResult,
+				Payload: result,
+			})
+		}
+		err := <-errCh
+		if err == nil && len(disruptive) > 0 {
+			var batched []compliance.RemediationResult
+			batched, err = h.runMachineConfigBatch(ctx, disruptive, rep)
+			results = append(results, batched...)
+		}
+		if !anyApplied(results) {
+			h.releaseChangeRequest(context.Background(), req.ChangeRequest, batchFailure(results, err))
+		}
+		return results, err
+	})
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// HandleRemoveRemediation removes a previously applied remediation object.
+func (h *Handlers) HandleRemoveRemediation(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Remediation name is required")
+		return
+	}
+
+	changeRequest := r.URL.Query().Get("change_request")
+	if !h.requireChangeRequest(w, r, changeRequest, compliance.ChangeRequestRemove, []string{name}, nil) {
+		return
+	}
+
+	result, err := compliance.RemoveRemediation(r.Context(), h.k8sClient, h.remediationMode, h.namespace, name)
+	if err != nil {
+		h.releaseChangeRequest(r.Context(), changeRequest, err)
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "no snapshot") {
+			status = http.StatusConflict
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeRemediationResult,
+		Payload: result,
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// CreateChangeRequestBody is the JSON body for opening a change request.
+// Requester is ignored when an authenticating proxy sets X-Forwarded-User.
+type CreateChangeRequestBody struct {
+	Action    compliance.ChangeRequestAction `json:"action"`
+	Names     []string                       `json:"names"`
+	Requester string                         `json:"requester,omitempty"`
+	Comment   string                         `json:"comment,omitempty"`
+	// ExpiresIn is a Go duration such as "4h"; empty uses the default of 24h.
+	ExpiresIn string `json:"expires_in,omitempty"`
+	// Patch and PatchType customize a single apply as in
+	// ApplyRemediationRequest. The apply must send the same patch.
+	Patch     json.RawMessage `json:"patch,omitempty"`
+	PatchType string          `json:"patch_type,omitempty"`
+}
+
+// ChangeRequestDecisionBody is the JSON body for approving, rejecting or
+// commenting on a change request. User is ignored when an authenticating
+// proxy sets X-Forwarded-User.
+type ChangeRequestDecisionBody struct {
+	User    string `json:"user,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// requestUser returns the user behind a request: the X-Forwarded-User header
+// set by an authenticating proxy (e.g. oauth-proxy) if present, otherwise the
+// name supplied in the request body. With approval required the body is not
+// trusted, since anyone could approve their own request under another name.
+// It writes the error response and returns false if there is no user.
+func (h *Handlers) requestUser(w http.ResponseWriter, r *http.Request, fromBody, field string) (string, bool) {
+	if user := r.Header.Get("X-Forwarded-User"); user != "" {
+		return user, true
+	}
+	if h.requireApproval {
+		writeError(w, http.StatusUnauthorized, "Change requests require an authenticating proxy that sets X-Forwarded-User when approval is required")
+		return "", false
+	}
+	user := strings.TrimSpace(fromBody)
+	if user == "" {
+		writeError(w, http.StatusBadRequest, field+" is required")
+		return "", false
+	}
+	return user, true
+}
+
+// writeChangeRequestError maps change request errors to HTTP statuses.
+func writeChangeRequestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, compliance.ErrChangeRequestDenied):
+		writeError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// requireChangeRequest enforces two-person approval when it is enabled: the
+// operation must reference an approved change request covering action on
+// names with patch (nil for none), which is claimed so it cannot be reused. It writes the error
+// response and returns false if the operation may not proceed.
+func (h *Handlers) requireChangeRequest(w http.ResponseWriter, r *http.Request, id string, action compliance.ChangeRequestAction, names []string, patch *compliance.RemediationPatch) bool {
+	if !h.requireApproval {
+		return true
+	}
+	if id == "" {
+		writeError(w, http.StatusForbidden, "An approved change request is required; pass its ID as change_request")
+		return false
+	}
+
+	cr, err := compliance.ClaimChangeRequest(r.Context(), h.k8sClient, h.namespace, id, action, names, patch)
+	if err != nil {
+		if errors.Is(err, compliance.ErrChangeRequestDenied) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return false
+		}
+		writeChangeRequestError(w, err)
+		return false
+	}
+
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeChangeRequest,
+		Payload: cr,
+	})
+	return true
+}
+
+// releaseChangeRequest gives back the approval claimed by requireChangeRequest
+// when the operation failed without changing anything, so it can be retried.
+func (h *Handlers) releaseChangeRequest(ctx context.Context, id string, cause error) {
+	if !h.requireApproval || id == "" {
+		return
+	}
+	cr, err := compliance.ReleaseChangeRequest(ctx, h.k8sClient, h.namespace, id, cause)
+	if err != nil {
+		slog.Warn("failed to release change request", "id", id, "error", err)
+		return
+	}
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeChangeRequest,
+		Payload: cr,
+	})
+}
+
+// batchFailure summarizes why a batch applied nothing.
+func batchFailure(results []compliance.RemediationResult, err error) error {
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			return fmt.Errorf("%s: %s", result.Name, result.Error)
+		}
+	}
+	return fmt.Errorf("no remediation was applied")
+}
+
+// anyApplied reports whether any result changed a remediation.
+func anyApplied(results []compliance.RemediationResult) bool {
+	for _, result := range results {
+		if result.Applied {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleListChangeRequests lists remediation change requests, newest first.
+func (h *Handlers) HandleListChangeRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := compliance.ListChangeRequests(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, requests)
+}
+
+// HandleGetChangeRequest returns a single change request.
+func (h *Handlers) HandleGetChangeRequest(w http.ResponseWriter, r *http.Request) {
+	cr, err := compliance.GetChangeRequest(r.Context(), h.k8sClient, h.namespace, r.PathValue("id"))
+	if err != nil {
+		writeChangeRequestError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cr)
+}
+
+// HandleCreateChangeRequest opens a change request to apply or remove
+// remediations.
+func (h *Handlers) HandleCreateChangeRequest(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req CreateChangeRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	requester, ok := h.requestUser(w, r, req.Requester, "Requester")
+	if !ok {
+		return
+	}
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.ExpiresIn); err != nil || ttl <= 0 {
+			writeError(w, http.StatusBadRequest, "expires_in must be a positive duration such as 4h")
+			return
+		}
+	}
+
+	var patch *compliance.RemediationPatch
+	if len(req.Patch) > 0 && string(req.Patch) != "null" {
+		patch = &compliance.RemediationPatch{Type: req.PatchType, Patch: req.Patch}
+	}
+
+	cr, err := compliance.CreateChangeRequest(r.Context(), h.k8sClient, h.remediationMode, h.namespace, req.Action, req.Names, patch, requester, req.Comment, ttl)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeChangeRequest,
+		Payload: cr,
+	})
+	writeJSON(w, http.StatusCreated, cr)
+}
+
+// HandleApproveChangeRequest approves a pending change request.
+func (h *Handlers) HandleApproveChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideChangeRequest(w, r, compliance.ApproveChangeRequest)
+}
+
+// HandleRejectChangeRequest rejects a pending change request.
+func (h *Handlers) HandleRejectChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideChangeRequest(w, r, compliance.RejectChangeRequest)
+}
+
+// HandleCommentChangeRequest adds a comment to a change request.
+func (h *Handlers) HandleCommentChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideChangeRequest(w, r, compliance.CommentOnChangeRequest)
+}
+
+// decideChangeRequest decodes a ChangeRequestDecisionBody, applies update and
+// broadcasts the updated change request.
+func (h *Handlers) decideChangeRequest(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, client *k8s.Client, namespace, id, user, comment string) (*compliance.ChangeRequest, error)) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req ChangeRequestDecisionBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	user, ok := h.requestUser(w, r, req.User, "User")
+	if !ok {
+		return
+	}
+
+	cr, err := update(r.Context(), h.k8sClient, h.namespace, r.PathValue("id"), user, req.Comment)
+	if err != nil {
+		if strings.Contains(err.Error(), "required") {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeChangeRequestError(w, err)
+		return
+	}
+
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeChangeRequest,
+		Payload: cr,
+	})
+	writeJSON(w, http.StatusOK, cr)
+}
+
+// HandleListMaintenanceWindows lists maintenance windows with their next
+// start and whether they are open.
+func (h *Handlers) HandleListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := compliance.ListMaintenanceWindows(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, windows)
+}
+
+// HandleSaveMaintenanceWindow creates or replaces a maintenance window.
+func (h *Handlers) HandleSaveMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var window compliance.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	window.Name = r.PathValue("name")
+
+	saved, err := compliance.SaveMaintenanceWindow(r.Context(), h.k8sClient, h.namespace, window)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "required") {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// HandleDeleteMaintenanceWindow deletes a maintenance window with no queued
+// remediations.
+func (h *Handlers) HandleDeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := compliance.DeleteMaintenanceWindow(r.Context(), h.k8sClient, h.namespace, name); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			writeError(w, http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "still has queued"):
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("Maintenance window %s deleted", name),
+	})
+}
+
+// HandleListScheduledRemediations lists queued remediations and recent
+// history.
+func (h *Handlers) HandleListScheduledRemediations(w http.ResponseWriter, r *http.Request) {
+	items, err := compliance.ListScheduledRemediations(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// QueueRemediationsRequest is the JSON body for queuing remediations for a
+// maintenance window.
+type QueueRemediationsRequest struct {
+	Window string   `json:"window"`
+	Names  []string `json:"names"`
+	// ChangeRequest is the approved change request covering Names, required
+	// when two-person approval is enabled.
+	ChangeRequest string `json:"change_request,omitempty"`
+}
+
+// HandleQueueRemediations queues remediations to be applied in the next
+// opening of a maintenance window.
+func (h *Handlers) HandleQueueRemediations(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req QueueRemediationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Window == "" || len(req.Names) == 0 {
+		writeError(w, http.StatusBadRequest, "A window and at least one remediation name are required")
+		return
+	}
+	if !h.requireChangeRequest(w, r, req.ChangeRequest, compliance.ChangeRequestApply, req.Names, nil) {
+		return
+	}
+
+	queued, err := compliance.QueueRemediations(r.Context(), h.k8sClient, h.namespace, req.Window, req.Names)
+	if err != nil {
+		h.releaseChangeRequest(r.Context(), req.ChangeRequest, err)
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for _, item := range queued {
+		h.hub.Broadcast(ws.Message{
+			Type:    ws.MessageTypeScheduledRemediation,
+			Payload: item,
+		})
+	}
+	writeJSON(w, http.StatusCreated, queued)
+}
+
+// HandleCancelScheduledRemediation cancels a queued or held remediation.
+func (h *Handlers) HandleCancelScheduledRemediation(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	item, err := compliance.CancelScheduledRemediation(r.Context(), h.k8sClient, h.namespace, r.PathValue("id"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			writeError(w, http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "cannot be canceled"):
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeScheduledRemediation,
+		Payload: item,
+	})
+	writeJSON(w, http.StatusOK, item)
+}
+
+// HandleGetRemediation returns detail for a single remediation including its YAML.
+func (h *Handlers) HandleGetRemediation(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Remediation name is required")
+		return
+	}
+
+	detail, err := compliance.GetRemediation(r.Context(), h.k8sClient, h.namespace, name)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// GitOpsExportRequest is the JSON body for exporting remediations.
+type GitOpsExportRequest struct {
+	Names []string `json:"names"`
+	// Path is the repository subdirectory to commit into (git export only).
+	Path string `json:"path,omitempty"`
+	// Message is the commit message (git export only).
+	Message string `json:"message,omitempty"`
+}
+
+// GitOpsCommitResult is the response to committing an export to git.
+type GitOpsCommitResult struct {
+	Repository string                   `json:"repository"`
+	Commit     string                   `json:"commit"`
+	Export     *compliance.GitOpsExport `json:"export"`
+}
+
+func (h *Handlers) decodeGitOpsExport(w http.ResponseWriter, r *http.Request) (*GitOpsExportRequest, *compliance.GitOpsExport, bool) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return nil, nil, false
+	}
+
+	var req GitOpsExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, nil, false
+	}
+	if len(req.Names) == 0 {
+		writeError(w, http.StatusBadRequest, "At least one remediation name is required")
+		return nil, nil, false
+	}
+
+	export, err := compliance.BuildGitOpsExport(r.Context(), h.k8sClient, h.namespace, req.Names)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return nil, nil, false
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil, nil, false
+	}
+	return &req, export, true
+}
+
+// HandleExportRemediations downloads the selected remediations as a
+// Kustomize layout in a tar.gz and marks them as pending via GitOps.
+func (h *Handlers) HandleExportRemediations(w http.ResponseWriter, r *http.Request) {
+	_, export, ok := h.decodeGitOpsExport(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteTarGz(&buf); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := compliance.MarkGitOpsPending(r.Context(), h.k8sClient, h.namespace, export.Remediations); err != nil {
+		slog.Warn("failed to mark remediations as pending via GitOps", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="compliance-remediations.tar.gz"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// HandleCommitRemediations commits the selected remediations to the
+// configured GitOps repository and marks them as pending via GitOps.
+func (h *Handlers) HandleCommitRemediations(w http.ResponseWriter, r *http.Request) {
+	if h.gitopsRepo == "" {
+		writeError(w, http.StatusNotImplemented, "No GitOps repository configured (set --gitops-repo)")
+		return
+	}
+
+	req, export, ok := h.decodeGitOpsExport(w, r)
+	if !ok {
+		return
+	}
+
+	h.gitopsMu.Lock()
+	commit, err := compliance.CommitGitOpsExport(r.Context(), h.gitopsRepo, req.Path, req.Message, export)
+	h.gitopsMu.Unlock()
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid subdirectory") || strings.Contains(err.Error(), "no changes") {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := compliance.MarkGitOpsPending(r.Context(), h.k8sClient, h.namespace, export.Remediations); err != nil {
+		slog.Warn("failed to mark remediations as pending via GitOps", "error", err)
+	}
+
+	writeJSON(w, http.StatusOK, GitOpsCommitResult{
+		Repository: h.gitopsRepo,
+		Commit:     commit,
+		Export:     export,
+	})
+}
+
+// HandleGetRemediationSnapshot returns the prior object state captured when a
+// remediation was applied.
+func (h *Handlers) HandleGetRemediationSnapshot(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Remediation name is required")
+		return
+	}
+
+	snapshot, err := compliance.GetRemediationSnapshot(r.Context(), h.k8sClient, h.namespace, name)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No snapshot stored for remediation %s", name))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// HandleListRemediations lists all available remediations.
+func (h *Handlers) HandleListRemediations(w http.ResponseWriter, r *http.Request) {
+	remediations, err := compliance.ListRemediations(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, remediations)
+}
+
+// HandleListMachineConfigPools returns the rollout status of all MachineConfigPools.
+func (h *Handlers) HandleListMachineConfigPools(w http.ResponseWriter, r *http.Request) {
+	pools, err := compliance.ListMachineConfigPools(r.Context(), h.k8sClient)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pools)
+}
+
+// HandleGetMachineConfigPool returns the rollout status of a single MachineConfigPool.
+func (h *Handlers) HandleGetMachineConfigPool(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "MachineConfigPool name is required")
+		return
+	}
+
+	pool, err := compliance.GetMachineConfigPool(r.Context(), h.k8sClient, name)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pool)
+}
+
+// HandlePauseMachineConfigPool pauses a MachineConfigPool.
+func (h *Handlers) HandlePauseMachineConfigPool(w http.ResponseWriter, r *http.Request) {
+	h.setMachineConfigPoolPaused(w, r, true)
+}
+
+// HandleResumeMachineConfigPool resumes a paused MachineConfigPool.
+func (h *Handlers) HandleResumeMachineConfigPool(w http.ResponseWriter, r *http.Request) {
+	h.setMachineConfigPoolPaused(w, r, false)
+}
+
+func (h *Handlers) setMachineConfigPoolPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "MachineConfigPool name is required")
+		return
+	}
+
+	if err := compliance.SetMachineConfigPoolPaused(r.Context(), h.k8sClient, name, paused); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	action := "resumed"
+	if paused {
+		action = "paused"
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("MachineConfigPool %s %s", name, action),
+	})
+}
+
+// HandleRescan triggers a rescan of all ComplianceScans in a suite.
+func (h *Handlers) HandleRescan(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Scan suite name is required")
+		return
+	}
+
+	if err := compliance.RescanSuite(r.Context(), h.k8sClient, h.namespace, name); err != nil {
+		if strings.Contains(err.Error(), "no ComplianceScans found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("Rescan triggered for suite %s", name),
+	})
+}
+
+// HandleDeleteScan deletes a ComplianceSuite and its ScanSettingBinding.
+func (h *Handlers) HandleDeleteScan(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Scan suite name is required")
+		return
+	}
+
+	if err := compliance.DeleteScan(r.Context(), h.k8sClient, h.namespace, name); err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -439,32 +1788,364 @@ func (h *Handlers) HandleDeleteScan(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// HandleUninstallOperator starts the operator uninstallation process.
+// HandleUninstallOperator starts the operator uninstallation process as a job.
+// With dryRun=true it returns what the uninstall would delete instead;
+// keepNamespace=true removes only the operator's objects and export=true
+// saves results and remediation state before anything is deleted;
+// force=true continues when that export is incomplete.
 func (h *Handlers) HandleUninstallOperator(w http.ResponseWriter, r *http.Request) {
 	if h.k8sClient == nil {
 		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
 		return
 	}
 
-	progress := make(chan compliance.InstallProgress, 32)
+	q := r.URL.Query()
+	opts := compliance.UninstallOptions{
+		KeepNamespace: q.Get("keepNamespace") == "true",
+		Export:        q.Get("export") == "true",
+		Force:         q.Get("force") == "true",
+	}
+	if q.Get("dryRun") == "true" {
+		plan, err := compliance.PlanUninstall(r.Context(), h.k8sClient, h.namespace, opts)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, plan)
+		return
+	}
 
-	uninstallCtx := context.Background()
+	job, started := h.jobs.StartExclusive(jobTypeUninstall, func(ctx context.Context, rep *jobs.Reporter) (interface{}, error) {
+		progress := make(chan compliance.InstallProgress, 32)
+		go compliance.Uninstall(ctx, h.k8sClient, h.namespace, h.stateNamespace, opts, progress)
+		return nil, h.forwardInstallProgress(progress, ws.MessageTypeUninstallProgress, rep)
+	})
+	if !started {
+		writeJSON(w, http.StatusConflict, job)
+		return
+	}
 
-	go func() {
-		compliance.Uninstall(uninstallCtx, h.k8sClient, h.namespace, progress)
-	}()
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Uninstallation started. Follow progress via WebSocket.",
+		"job_id":  job.ID,
+	})
+}
 
-	go func() {
-		for p := range progress {
-			h.hub.Broadcast(ws.Message{
-				Type:    ws.MessageTypeUninstallProgress,
-				Payload: p,
-			})
+// HandleExportComplianceState downloads a tar.gz of the scan results,
+// remediation state and the dashboard's change requests, snapshots and
+// maintenance windows, as an uninstall with export saves them.
+func (h *Handlers) HandleExportComplianceState(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	export, err := compliance.ExportComplianceState(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err := export.WriteTarGz(&buf); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeArchive(w, export.DirName()+".tar.gz", buf.Bytes())
+}
+
+// HandleGetUninstallExport downloads the archive exported before the last
+// uninstall.
+func (h *Handlers) HandleGetUninstallExport(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	name, data, err := compliance.GetUninstallExport(r.Context(), h.k8sClient, h.stateNamespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if name == "" {
+		writeError(w, http.StatusNotFound, "No uninstall export has been saved")
+		return
+	}
+	writeArchive(w, name, data)
+}
+
+// HandleGetSubscription returns the operator's Subscription, its upgrade
+// state, the channels the catalog offers and InstallPlans awaiting approval.
+func (h *Handlers) HandleGetSubscription(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	info, err := compliance.GetSubscription(r.Context(), h.k8sClient, h.namespace)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// HandleUpdateSubscription switches the Subscription's channel or install
+// plan approval mode.
+func (h *Handlers) HandleUpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var update compliance.SubscriptionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	info, err := compliance.UpdateSubscription(r.Context(), h.k8sClient, h.namespace, update)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+
+	h.hub.Broadcast(ws.Message{
+		Type:    ws.MessageTypeSubscription,
+		Payload: info,
+	})
+	writeJSON(w, http.StatusOK, info)
+}
+
+// ApproveInstallPlanRequest is the optional body of an InstallPlan approval.
+type ApproveInstallPlanRequest struct {
+	// ConfirmCSVs lists the other operators' CSVs in the plan the caller
+	// agrees to install along with the Compliance Operator.
+	ConfirmCSVs []string `json:"confirm_csvs,omitempty"`
+}
+
+// HandleApproveInstallPlan approves a pending InstallPlan for the operator.
+func (h *Handlers) HandleApproveInstallPlan(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req ApproveInstallPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	plan, err := compliance.ApproveInstallPlan(r.Context(), h.k8sClient, h.namespace, r.PathValue("name"), req.ConfirmCSVs)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+
+	if info, err := compliance.GetSubscription(r.Context(), h.k8sClient, h.namespace); err == nil {
+		h.hub.Broadcast(ws.Message{
+			Type:    ws.MessageTypeSubscription,
+			Payload: info,
+		})
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// writeSubscriptionError maps Subscription and InstallPlan errors to status
+// codes.
+func writeSubscriptionError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "invalid subscription update"):
+		writeError(w, http.StatusBadRequest, msg)
+	case strings.Contains(msg, "not found"):
+		writeError(w, http.StatusNotFound, msg)
+	case strings.Contains(msg, "already approved"), strings.Contains(msg, "does not install"),
+		strings.Contains(msg, "also installs"):
+		writeError(w, http.StatusConflict, msg)
+	default:
+		writeError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+// applyInstallDefaults fills install options the request left empty from the
+// server's disconnected-install flags.
+func (h *Handlers) applyInstallDefaults(opts *compliance.InstallOptions) {
+	if h.disconnected {
+		opts.Disconnected = true
+	}
+	if opts.Disconnected && opts.MirrorRegistry == "" {
+		opts.MirrorRegistry = h.mirrorRegistry
+	}
+	if opts.CatalogImage == "" && (opts.Source == "" || opts.Source == compliance.InstallSourceCommunity) {
+		opts.CatalogImage = h.catalogImage
+	}
+
+	timeouts := h.installTimeouts
+	if t := opts.Timeouts; t != nil {
+		if t.CSVSeconds != 0 {
+			timeouts.CSVSeconds = t.CSVSeconds
+		}
+		if t.PodsSeconds != 0 {
+			timeouts.PodsSeconds = t.PodsSeconds
+		}
+		if t.ProfileBundlesSeconds != 0 {
+			timeouts.ProfileBundlesSeconds = t.ProfileBundlesSeconds
+		}
+	}
+	if timeouts != (compliance.InstallTimeouts{}) {
+		opts.Timeouts = &timeouts
+	}
+}
+
+// installOptionsFromQuery reads the install options a preflight should
+// assume from query parameters, filling the rest from the server defaults.
+func (h *Handlers) installOptionsFromQuery(r *http.Request) (compliance.InstallOptions, error) {
+	q := r.URL.Query()
+	opts := compliance.InstallOptions{
+		Source:                 compliance.InstallSource(q.Get("source")),
+		CatalogSource:          q.Get("catalog_source"),
+		CatalogSourceNamespace: q.Get("catalog_source_namespace"),
+		MirrorRegistry:         q.Get("registry"),
+		CatalogImage:           q.Get("catalog_image"),
+		Disconnected:           q.Get("disconnected") == "true",
+	}
+	if opts.MirrorRegistry != "" {
+		opts.Disconnected = true
+	}
+	h.applyInstallDefaults(&opts)
+	return opts, opts.Validate()
+}
+
+// HandleOperatorPreflight runs the install preflight checks without changing
+// the cluster. It takes the same query parameters as the mirror preflight.
+func (h *Handlers) HandleOperatorPreflight(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	opts, err := h.installOptionsFromQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := compliance.RunPreflight(r.Context(), h.k8sClient, h.images, h.namespace, h.complianceRef, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// HandleMirrorPreflight reports the image mirrors an install would use and
+// whether the mirrored images resolve. Query parameters override the
+// server's defaults.
+func (h *Handlers) HandleMirrorPreflight(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	opts, err := h.installOptionsFromQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := compliance.MirrorPreflight(r.Context(), h.k8sClient, h.images, h.namespace, h.complianceRef, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// RewriteBundlesRequest is the optional body for rewriting ProfileBundle
+// content images.
+type RewriteBundlesRequest struct {
+	Registry string `json:"registry"`
+}
+
+// HandleRewriteProfileBundles points existing ProfileBundles' content images
+// at their mirrors.
+func (h *Handlers) HandleRewriteProfileBundles(w http.ResponseWriter, r *http.Request) {
+	if h.k8sClient == nil {
+		writeError(w, http.StatusServiceUnavailable, "Not connected to Kubernetes cluster")
+		return
+	}
+
+	var req RewriteBundlesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Registry == "" {
+		req.Registry = h.mirrorRegistry
+	}
+
+	rewrites, err := compliance.RewriteProfileBundleImages(r.Context(), h.k8sClient, h.namespace, req.Registry)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid mirror") {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, rewrites)
+}
+
+// forwardInstallProgress streams install/uninstall progress to the WebSocket
+// hub and the job's step log until the channel closes. It returns the last
+// reported error, if any.
+func (h *Handlers) forwardInstallProgress(progress <-chan compliance.InstallProgress, msgType ws.MessageType, rep *jobs.Reporter) error {
+	var lastErr error
+	for p := range progress {
+		h.hub.Broadcast(ws.Message{
+			Type:    msgType,
+			Payload: p,
+		})
+		if p.Error != "" {
+			rep.StepError(p.Step, p.Message)
+			lastErr = errors.New(p.Error)
+			continue
 		}
-	}()
+		rep.Step(p.Step, p.Message)
+	}
+	return lastErr
+}
+
+// HandleListJobs returns all tracked jobs, newest first.
+func (h *Handlers) HandleListJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.jobs.List())
+}
+
+// HandleGetJob returns a single job with its step log.
+func (h *Handlers) HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	job, ok := h.jobs.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("job %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
 
+// HandleCancelJob requests cancellation of a running job.
+func (h *Handlers) HandleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.jobs.Cancel(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
 	writeJSON(w, http.StatusAccepted, map[string]string{
-		"message": "Uninstallation started. Follow progress via WebSocket.",
+		"message": fmt.Sprintf("Cancellation requested for job %s", id),
 	})
 }
 
@@ -30,8 +30,10 @@ func NewServer(cfg config.Config, svc *compliance.Service, hub *ws.Hub) *Server
 		k8sClient = svc.K8sClient()
 	}
 
+	handlers := NewHandlers(k8sClient, svc, hub, cfg)
+
 	return &Server{
-		handlers: NewHandlers(k8sClient, svc, hub, cfg.Namespace, cfg.ComplianceOpRef),
+		handlers: handlers,
 		hub:      hub,
 	}
 }
@@ -47,22 +49,70 @@ func (s *Server) Handler() http.Handler {
 	// API routes (Go 1.22+ method routing)
 	mux.HandleFunc("GET /api/cluster/status", s.handlers.HandleClusterStatus)
 	mux.HandleFunc("POST /api/operator/install", s.handlers.HandleOperatorInstall)
+	mux.HandleFunc("GET /api/operator/install/status", s.handlers.HandleGetInstallStatus)
+	mux.HandleFunc("POST /api/operator/install/resume", s.handlers.HandleResumeInstall)
 	mux.HandleFunc("GET /api/operator/status", s.handlers.HandleOperatorStatus)
+	mux.HandleFunc("GET /api/operator/diagnostics/bundle", s.handlers.HandleDiagnosticsBundle)
+	mux.HandleFunc("GET /api/operator/preflight", s.handlers.HandleOperatorPreflight)
 	mux.HandleFunc("DELETE /api/operator", s.handlers.HandleUninstallOperator)
+	mux.HandleFunc("GET /api/operator/export", s.handlers.HandleExportComplianceState)
+	mux.HandleFunc("GET /api/operator/uninstall/export", s.handlers.HandleGetUninstallExport)
+	mux.HandleFunc("GET /api/operator/subscription", s.handlers.HandleGetSubscription)
+	mux.HandleFunc("PATCH /api/operator/subscription", s.handlers.HandleUpdateSubscription)
+	mux.HandleFunc("POST /api/operator/installplans/{name}/approve", s.handlers.HandleApproveInstallPlan)
+	mux.HandleFunc("GET /api/operator/mirror", s.handlers.HandleMirrorPreflight)
+	mux.HandleFunc("POST /api/operator/mirror/bundles", s.handlers.HandleRewriteProfileBundles)
 	mux.HandleFunc("POST /api/scans/recommended", s.handlers.HandleCreateRecommendedScans)
 	mux.HandleFunc("POST /api/scans/{name}/rescan", s.handlers.HandleRescan)
 	mux.HandleFunc("DELETE /api/scans/{name}", s.handlers.HandleDeleteScan)
 	mux.HandleFunc("POST /api/scans", s.handlers.HandleCreateScan)
 	mux.HandleFunc("GET /api/scans", s.handlers.HandleListScans)
 	mux.HandleFunc("GET /api/profiles", s.handlers.HandleListProfiles)
+	mux.HandleFunc("GET /api/profilebundles", s.handlers.HandleListProfileBundles)
+	mux.HandleFunc("POST /api/profilebundles", s.handlers.HandleCreateProfileBundle)
+	mux.HandleFunc("GET /api/profilebundles/{name}", s.handlers.HandleGetProfileBundle)
+	mux.HandleFunc("PUT /api/profilebundles/{name}", s.handlers.HandleUpdateProfileBundle)
+	mux.HandleFunc("DELETE /api/profilebundles/{name}", s.handlers.HandleDeleteProfileBundle)
 	mux.HandleFunc("GET /api/results/summary", s.handlers.HandleGetResultsSummary)
 	mux.HandleFunc("GET /api/results/{name}", s.handlers.HandleGetCheckResult)
 	mux.HandleFunc("GET /api/results", s.handlers.HandleGetResults)
+	mux.HandleFunc("POST /api/remediate/severity/{level}", s.handlers.HandleApplyBySeverity)
 	mux.HandleFunc("POST /api/remediate/{name}", s.handlers.HandleApplyRemediation)
 	mux.HandleFunc("POST /api/remediate", s.handlers.HandleBatchApplyRemediations)
 	mux.HandleFunc("DELETE /api/remediate/{name}", s.handlers.HandleRemoveRemediation)
+	mux.HandleFunc("GET /api/remediations/mode", s.handlers.HandleGetRemediationMode)
+	mux.HandleFunc("GET /api/remediations/outdated", s.handlers.HandleListOutdatedRemediations)
+	mux.HandleFunc("POST /api/remediations/{name}/promote", s.handlers.HandlePromoteRemediation)
+	mux.HandleFunc("GET /api/remediations/drift", s.handlers.HandleListDriftedRemediations)
+	mux.HandleFunc("POST /api/remediations/drift/check", s.handlers.HandleCheckDrift)
+	mux.HandleFunc("GET /api/remediations/ignition", s.handlers.HandleIgnitionPreview)
+	mux.HandleFunc("POST /api/remediations/{name}/reapply", s.handlers.HandleReapplyRemediation)
+	mux.HandleFunc("POST /api/remediations/{name}/verify", s.handlers.HandleVerifyRemediation)
 	mux.HandleFunc("GET /api/remediations/{name}", s.handlers.HandleGetRemediation)
+	mux.HandleFunc("GET /api/remediations/{name}/snapshot", s.handlers.HandleGetRemediationSnapshot)
 	mux.HandleFunc("GET /api/remediations", s.handlers.HandleListRemediations)
+	mux.HandleFunc("POST /api/remediations/impact", s.handlers.HandleRemediationImpact)
+	mux.HandleFunc("POST /api/remediations/export", s.handlers.HandleExportRemediations)
+	mux.HandleFunc("POST /api/remediations/export/git", s.handlers.HandleCommitRemediations)
+	mux.HandleFunc("GET /api/change-requests", s.handlers.HandleListChangeRequests)
+	mux.HandleFunc("POST /api/change-requests", s.handlers.HandleCreateChangeRequest)
+	mux.HandleFunc("GET /api/change-requests/{id}", s.handlers.HandleGetChangeRequest)
+	mux.HandleFunc("POST /api/change-requests/{id}/approve", s.handlers.HandleApproveChangeRequest)
+	mux.HandleFunc("POST /api/change-requests/{id}/reject", s.handlers.HandleRejectChangeRequest)
+	mux.HandleFunc("POST /api/change-requests/{id}/comments", s.handlers.HandleCommentChangeRequest)
+	mux.HandleFunc("GET /api/maintenance/windows", s.handlers.HandleListMaintenanceWindows)
+	mux.HandleFunc("PUT /api/maintenance/windows/{name}", s.handlers.HandleSaveMaintenanceWindow)
+	mux.HandleFunc("DELETE /api/maintenance/windows/{name}", s.handlers.HandleDeleteMaintenanceWindow)
+	mux.HandleFunc("GET /api/maintenance/queue", s.handlers.HandleListScheduledRemediations)
+	mux.HandleFunc("POST /api/maintenance/queue", s.handlers.HandleQueueRemediations)
+	mux.HandleFunc("DELETE /api/maintenance/queue/{id}", s.handlers.HandleCancelScheduledRemediation)
+	mux.HandleFunc("GET /api/machineconfigpools/{name}", s.handlers.HandleGetMachineConfigPool)
+	mux.HandleFunc("GET /api/machineconfigpools", s.handlers.HandleListMachineConfigPools)
+	mux.HandleFunc("POST /api/machineconfigpools/{name}/pause", s.handlers.HandlePauseMachineConfigPool)
+	mux.HandleFunc("POST /api/machineconfigpools/{name}/resume", s.handlers.HandleResumeMachineConfigPool)
+	mux.HandleFunc("GET /api/jobs/{id}", s.handlers.HandleGetJob)
+	mux.HandleFunc("DELETE /api/jobs/{id}", s.handlers.HandleCancelJob)
+	mux.HandleFunc("GET /api/jobs", s.handlers.HandleListJobs)
 	mux.HandleFunc("GET /ws/watch", s.handlers.HandleWebSocket)
 
 	// Serve embedded frontend (SPA fallback)
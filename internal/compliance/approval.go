@@ -0,0 +1,306 @@
+package compliance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// Change requests are stored as ConfigMaps in the compliance namespace so
+// they survive dashboard restarts and are visible to every replica.
+const (
+	changeRequestPrefix = "remediation-cr-"
+	changeRequestLabel  = "compliance-dashboard/change-request"
+	changeRequestKey    = "request.json"
+)
+
+// DefaultChangeRequestTTL is how long a change request stays valid when the
+// requester does not set an expiry.
+const DefaultChangeRequestTTL = 24 * time.Hour
+
+// maxChangeRequestTTL caps how far in the future a change request may expire.
+const maxChangeRequestTTL = 7 * 24 * time.Hour
+
+// ErrChangeRequestDenied is wrapped by errors returned when a change request
+// cannot be approved, rejected or executed in its current state.
+var ErrChangeRequestDenied = errors.New("change request denied")
+
+// CreateChangeRequest records a request to apply or remove remediations. It
+// stays Pending until a different user approves or rejects it. A patch
+// customizes a single apply; it is validated now and must be passed again,
+// unchanged, when the request is claimed.
+func CreateChangeRequest(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace string, action ChangeRequestAction, names []string, patch *RemediationPatch, requester, comment string, ttl time.Duration) (*ChangeRequest, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	switch action {
+	case ChangeRequestApply, ChangeRequestRemove:
+	default:
+		return nil, fmt.Errorf("invalid action %q: must be %q or %q", action, ChangeRequestApply, ChangeRequestRemove)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one remediation name is required")
+	}
+	// Removal is per remediation, so a remove request covers exactly one
+	if action == ChangeRequestRemove && len(names) > 1 {
+		return nil, fmt.Errorf("a remove change request must name a single remediation")
+	}
+	if patch != nil && (action != ChangeRequestApply || len(names) > 1) {
+		return nil, fmt.Errorf("a patch can only be approved for applying a single remediation")
+	}
+	if requester == "" {
+		return nil, fmt.Errorf("requester is required")
+	}
+	if ttl <= 0 {
+		ttl = DefaultChangeRequestTTL
+	}
+	if ttl > maxChangeRequestTTL {
+		return nil, fmt.Errorf("expiry must be at most %s", maxChangeRequestTTL)
+	}
+
+	for _, name := range names {
+		if _, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Get(ctx, name, metav1.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("getting remediation %s: %w", name, err)
+		}
+	}
+	if patch != nil {
+		if err := ValidateRemediationPatch(ctx, client, mode, namespace, names[0], patch); err != nil {
+			return nil, err
+		}
+	}
+
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	now := time.Now().UTC()
+	cr := &ChangeRequest{
+		ID:           hex.EncodeToString(b),
+		Action:       action,
+		Remediations: slices.Sorted(slices.Values(names)),
+		Requester:    requester,
+		State:        ChangeRequestPending,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+		PatchSHA256:  patchDigest(patch),
+	}
+	if patch != nil {
+		stored := *patch
+		if stored.Type == "" {
+			stored.Type = PatchTypeMerge
+		}
+		cr.Patch = &stored
+	}
+	if comment != "" {
+		cr.Comments = []ChangeRequestComment{{Author: requester, Text: comment, CreatedAt: now}}
+	}
+
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return nil, fmt.Errorf("encoding change request: %w", err)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      changeRequestPrefix + cr.ID,
+			Namespace: namespace,
+			Labels:    map[string]string{changeRequestLabel: "true"},
+		},
+		Data: map[string]string{changeRequestKey: string(data)},
+	}
+	if _, err := client.Clientset.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("storing change request: %w", err)
+	}
+	return cr, nil
+}
+
+// ListChangeRequests returns all change requests, newest first.
+func ListChangeRequests(ctx context.Context, client *k8s.Client, namespace string) ([]ChangeRequest, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	list, err := client.Clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: changeRequestLabel + "=true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing change requests: %w", err)
+	}
+
+	requests := []ChangeRequest{}
+	for i := range list.Items {
+		cr, err := decodeChangeRequest(&list.Items[i])
+		if err != nil {
+			continue
+		}
+		requests = append(requests, *cr)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].CreatedAt.After(requests[j].CreatedAt) })
+	return requests, nil
+}
+
+// GetChangeRequest returns a single change request.
+func GetChangeRequest(ctx context.Context, client *k8s.Client, namespace, id string) (*ChangeRequest, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	_, cr, err := loadChangeRequest(ctx, client, namespace, id)
+	return cr, err
+}
+
+// ApproveChangeRequest approves a pending change request. The approver must
+// be someone other than the requester.
+func ApproveChangeRequest(ctx context.Context, client *k8s.Client, namespace, id, approver, comment string) (*ChangeRequest, error) {
+	return decideChangeRequest(ctx, client, namespace, id, approver, comment, ChangeRequestApproved)
+}
+
+// RejectChangeRequest rejects a pending change request. Like approval, it
+// must come from someone other than the requester.
+func RejectChangeRequest(ctx context.Context, client *k8s.Client, namespace, id, approver, comment string) (*ChangeRequest, error) {
+	return decideChangeRequest(ctx, client, namespace, id, approver, comment, ChangeRequestRejected)
+}
+
+func decideChangeRequest(ctx context.Context, client *k8s.Client, namespace, id, approver, comment string, state ChangeRequestState) (*ChangeRequest, error) {
+	if approver == "" {
+		return nil, fmt.Errorf("approver is required")
+	}
+	return updateChangeRequest(ctx, client, namespace, id, func(cr *ChangeRequest, now time.Time) error {
+		if cr.State != ChangeRequestPending {
+			return fmt.Errorf("%w: change request %s is %s", ErrChangeRequestDenied, cr.ID, cr.State)
+		}
+		if strings.EqualFold(approver, cr.Requester) {
+			return fmt.Errorf("%w: change request %s must be approved by someone other than its requester", ErrChangeRequestDenied, cr.ID)
+		}
+		cr.State = state
+		cr.Approver = approver
+		cr.DecidedAt = &now
+		if comment != "" {
+			cr.Comments = append(cr.Comments, ChangeRequestComment{Author: approver, Text: comment, CreatedAt: now})
+		}
+		return nil
+	})
+}
+
+// CommentOnChangeRequest adds a comment to a change request.
+func CommentOnChangeRequest(ctx context.Context, client *k8s.Client, namespace, id, author, text string) (*ChangeRequest, error) {
+	if author == "" || text == "" {
+		return nil, fmt.Errorf("author and comment text are required")
+	}
+	return updateChangeRequest(ctx, client, namespace, id, func(cr *ChangeRequest, now time.Time) error {
+		cr.Comments = append(cr.Comments, ChangeRequestComment{Author: author, Text: text, CreatedAt: now})
+		return nil
+	})
+}
+
+// ClaimChangeRequest marks an approved change request as executed so the
+// caller may perform action on names with patch. It fails unless the
+// request is approved, unexpired, and for exactly that action, set of
+// remediations and patch (nil for none): what runs is what was approved. A
+// request can be claimed only once.
+func ClaimChangeRequest(ctx context.Context, client *k8s.Client, namespace, id string, action ChangeRequestAction, names []string, patch *RemediationPatch) (*ChangeRequest, error) {
+	return updateChangeRequest(ctx, client, namespace, id, func(cr *ChangeRequest, now time.Time) error {
+		if cr.State != ChangeRequestApproved {
+			return fmt.Errorf("%w: change request %s is %s, not %s", ErrChangeRequestDenied, cr.ID, cr.State, ChangeRequestApproved)
+		}
+		if cr.Action != action {
+			return fmt.Errorf("%w: change request %s is for %s, not %s", ErrChangeRequestDenied, cr.ID, cr.Action, action)
+		}
+		if !slices.Equal(cr.Remediations, slices.Sorted(slices.Values(names))) {
+			return fmt.Errorf("%w: change request %s was approved for %s, not %s", ErrChangeRequestDenied, cr.ID,
+				strings.Join(cr.Remediations, ", "), strings.Join(names, ", "))
+		}
+		if patchDigest(patch) != cr.PatchSHA256 {
+			if cr.PatchSHA256 == "" {
+				return fmt.Errorf("%w: change request %s was approved without a patch", ErrChangeRequestDenied, cr.ID)
+			}
+			return fmt.Errorf("%w: change request %s was approved with a different patch", ErrChangeRequestDenied, cr.ID)
+		}
+		cr.State = ChangeRequestExecuted
+		cr.ExecutedAt = &now
+		return nil
+	})
+}
+
+// ReleaseChangeRequest returns a claimed change request to Approved after the
+// operation it was claimed for failed without changing anything, so the
+// approval can be used for a retry. The failure is recorded as its outcome.
+func ReleaseChangeRequest(ctx context.Context, client *k8s.Client, namespace, id string, cause error) (*ChangeRequest, error) {
+	return updateChangeRequest(ctx, client, namespace, id, func(cr *ChangeRequest, now time.Time) error {
+		if cr.State != ChangeRequestExecuted {
+			return fmt.Errorf("%w: change request %s is %s, not %s", ErrChangeRequestDenied, cr.ID, cr.State, ChangeRequestExecuted)
+		}
+		cr.State = ChangeRequestApproved
+		cr.ExecutedAt = nil
+		cr.Outcome = fmt.Sprintf("Execution at %s failed: %v", now.Format(time.RFC3339), cause)
+		return nil
+	})
+}
+
+// updateChangeRequest loads a change request, applies mutate and writes it
+// back. The write carries the ConfigMap's resourceVersion, so concurrent
+// updates (e.g. two claims) cannot both succeed.
+func updateChangeRequest(ctx context.Context, client *k8s.Client, namespace, id string, mutate func(*ChangeRequest, time.Time) error) (*ChangeRequest, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	cm, cr, err := loadChangeRequest(ctx, client, namespace, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutate(cr, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return nil, fmt.Errorf("encoding change request: %w", err)
+	}
+	cm.Data[changeRequestKey] = string(data)
+	if _, err := client.Clientset.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		if k8serrors.IsConflict(err) {
+			return nil, fmt.Errorf("%w: change request %s was modified concurrently; retry", ErrChangeRequestDenied, id)
+		}
+		return nil, fmt.Errorf("updating change request %s: %w", id, err)
+	}
+	return cr, nil
+}
+
+func loadChangeRequest(ctx context.Context, client *k8s.Client, namespace, id string) (*corev1.ConfigMap, *ChangeRequest, error) {
+	cm, err := client.Clientset.CoreV1().ConfigMaps(namespace).Get(ctx, changeRequestPrefix+id, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting change request %s: %w", id, err)
+	}
+	if cm.Labels[changeRequestLabel] != "true" {
+		return nil, nil, k8serrors.NewNotFound(corev1.Resource("configmaps"), cm.Name)
+	}
+	cr, err := decodeChangeRequest(cm)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cm, cr, nil
+}
+
+// decodeChangeRequest parses a stored change request. Pending and approved
+// requests past their expiry are reported as Expired.
+func decodeChangeRequest(cm *corev1.ConfigMap) (*ChangeRequest, error) {
+	var cr ChangeRequest
+	if err := json.Unmarshal([]byte(cm.Data[changeRequestKey]), &cr); err != nil {
+		return nil, fmt.Errorf("decoding change request %s: %w", cm.Name, err)
+	}
+	if (cr.State == ChangeRequestPending || cr.State == ChangeRequestApproved) && time.Now().After(cr.ExpiresAt) {
+		cr.State = ChangeRequestExpired
+	}
+	return &cr, nil
+}
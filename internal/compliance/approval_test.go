@@ -0,0 +1,193 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangeRequestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(
+		newConfigMapRemediation("rem-a", ns, "cm-a", nil),
+		newConfigMapRemediation("rem-b", ns, "cm-b", nil),
+	)
+
+	cr, err := CreateChangeRequest(ctx, client, RemediationModeDirect, ns, ChangeRequestApply, []string{"rem-b", "rem-a"}, nil, "alice", "CIS hardening", 0)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if cr.State != ChangeRequestPending || len(cr.Comments) != 1 {
+		t.Errorf("unexpected change request: %+v", cr)
+	}
+	if got := cr.ExpiresAt.Sub(cr.CreatedAt); got != DefaultChangeRequestTTL {
+		t.Errorf("expiry = %s, want %s", got, DefaultChangeRequestTTL)
+	}
+
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-a"}, nil); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("claiming a pending request: expected denial, got %v", err)
+	}
+	if _, err := ApproveChangeRequest(ctx, client, ns, cr.ID, "Alice", ""); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("self-approval: expected denial, got %v", err)
+	}
+
+	cr, err = ApproveChangeRequest(ctx, client, ns, cr.ID, "bob", "looks good")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if cr.State != ChangeRequestApproved || cr.Approver != "bob" || cr.DecidedAt == nil || len(cr.Comments) != 2 {
+		t.Errorf("unexpected approved request: %+v", cr)
+	}
+	if _, err := RejectChangeRequest(ctx, client, ns, cr.ID, "carol", ""); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("rejecting an approved request: expected denial, got %v", err)
+	}
+
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestRemove, []string{"rem-a"}, nil); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("wrong action: expected denial, got %v", err)
+	}
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-a"}, nil); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("partial set: expected denial, got %v", err)
+	}
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-a", "rem-b", "rem-c"}, nil); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("uncovered remediation: expected denial, got %v", err)
+	}
+
+	cr, err = ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-b", "rem-a"}, nil)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if cr.State != ChangeRequestExecuted || cr.ExecutedAt == nil {
+		t.Errorf("unexpected executed request: %+v", cr)
+	}
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-a", "rem-b"}, nil); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("second claim: expected denial, got %v", err)
+	}
+
+	// A failed operation releases the claim so the approval can be retried.
+	cr, err = ReleaseChangeRequest(ctx, client, ns, cr.ID, errors.New("apiserver unavailable"))
+	if err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if cr.State != ChangeRequestApproved || cr.ExecutedAt != nil || !strings.Contains(cr.Outcome, "apiserver unavailable") {
+		t.Errorf("unexpected released request: %+v", cr)
+	}
+	if _, err := ReleaseChangeRequest(ctx, client, ns, cr.ID, errors.New("again")); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("releasing an unclaimed request: expected denial, got %v", err)
+	}
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-a", "rem-b"}, nil); err != nil {
+		t.Fatalf("claim after release: %v", err)
+	}
+
+	list, err := ListChangeRequests(ctx, client, ns)
+	if err != nil || len(list) != 1 || list[0].State != ChangeRequestExecuted {
+		t.Errorf("unexpected list %+v (err %v)", list, err)
+	}
+}
+
+func TestChangeRequestRejectAndExpire(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(newConfigMapRemediation("rem-a", ns, "cm-a", nil))
+
+	cr, err := CreateChangeRequest(ctx, client, RemediationModeDirect, ns, ChangeRequestRemove, []string{"rem-a"}, nil, "alice", "", time.Hour)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	cr, err = RejectChangeRequest(ctx, client, ns, cr.ID, "bob", "not during freeze")
+	if err != nil || cr.State != ChangeRequestRejected {
+		t.Fatalf("reject: %+v (err %v)", cr, err)
+	}
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestRemove, []string{"rem-a"}, nil); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("claiming a rejected request: expected denial, got %v", err)
+	}
+
+	expiring, err := CreateChangeRequest(ctx, client, RemediationModeDirect, ns, ChangeRequestApply, []string{"rem-a"}, nil, "alice", "", time.Nanosecond)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	got, err := GetChangeRequest(ctx, client, ns, expiring.ID)
+	if err != nil || got.State != ChangeRequestExpired {
+		t.Errorf("expected Expired, got %+v (err %v)", got, err)
+	}
+	if _, err := ApproveChangeRequest(ctx, client, ns, expiring.ID, "bob", ""); !errors.Is(err, ErrChangeRequestDenied) {
+		t.Errorf("approving an expired request: expected denial, got %v", err)
+	}
+}
+
+func TestCreateChangeRequest_Validation(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(newConfigMapRemediation("rem-a", ns, "cm-a", nil))
+
+	tests := []struct {
+		name      string
+		action    ChangeRequestAction
+		names     []string
+		requester string
+		ttl       time.Duration
+	}{
+		{"invalid action", "delete", []string{"rem-a"}, "alice", 0},
+		{"no names", ChangeRequestApply, nil, "alice", 0},
+		{"no requester", ChangeRequestApply, []string{"rem-a"}, "", 0},
+		{"remove several", ChangeRequestRemove, []string{"rem-a", "rem-b"}, "alice", 0},
+		{"missing remediation", ChangeRequestApply, []string{"missing"}, "alice", 0},
+		{"expiry too long", ChangeRequestApply, []string{"rem-a"}, "alice", 30 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateChangeRequest(ctx, client, RemediationModeDirect, ns, tt.action, tt.names, nil, tt.requester, "", tt.ttl); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestChangeRequestPatch(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(
+		newConfigMapRemediation("rem-a", ns, "cm-a", map[string]any{"key": "value"}),
+		newConfigMapRemediation("rem-b", ns, "cm-b", nil),
+	)
+	patch := &RemediationPatch{Patch: json.RawMessage(`{"data": {"key": "custom"}}`)}
+
+	if _, err := CreateChangeRequest(ctx, client, RemediationModeDirect, ns, ChangeRequestApply, []string{"rem-a"},
+		&RemediationPatch{Patch: json.RawMessage(`{"kind":"Secret"}`)}, "alice", "", 0); err == nil || !strings.Contains(err.Error(), "invalid patch") {
+		t.Errorf("expected an invalid patch to be rejected at creation, got %v", err)
+	}
+	if _, err := CreateChangeRequest(ctx, client, RemediationModeDirect, ns, ChangeRequestApply, []string{"rem-a", "rem-b"}, patch, "alice", "", 0); err == nil {
+		t.Error("expected a patch on a batch request to be rejected")
+	}
+
+	cr, err := CreateChangeRequest(ctx, client, RemediationModeDirect, ns, ChangeRequestApply, []string{"rem-a"}, patch, "alice", "", 0)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if cr.PatchSHA256 == "" || cr.Patch == nil || cr.Patch.Type != PatchTypeMerge {
+		t.Fatalf("patch not recorded: %+v", cr)
+	}
+	if _, err := ApproveChangeRequest(ctx, client, ns, cr.ID, "bob", ""); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	for name, claimed := range map[string]*RemediationPatch{
+		"no patch":        nil,
+		"different patch": {Patch: json.RawMessage(`{"data":{"key":"other"}}`)},
+		"different type":  {Type: PatchTypeStrategic, Patch: patch.Patch},
+	} {
+		if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-a"}, claimed); !errors.Is(err, ErrChangeRequestDenied) {
+			t.Errorf("%s: expected denial, got %v", name, err)
+		}
+	}
+
+	// Formatting does not matter, only the patch itself.
+	same := &RemediationPatch{Type: PatchTypeMerge, Patch: json.RawMessage(`{"data":{"key":"custom"}}`)}
+	if _, err := ClaimChangeRequest(ctx, client, ns, cr.ID, ChangeRequestApply, []string{"rem-a"}, same); err != nil {
+		t.Fatalf("claim with the approved patch: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package compliance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed standard five-field cron expression (minute,
+// hour, day of month, month, day of week), the same syntax ScanSettings use.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny/dowAny record a "*" field: when both day fields are restricted,
+	// cron matches a day that satisfies either of them.
+	domAny, dowAny bool
+}
+
+// parseCron parses a five-field cron expression. Each field accepts "*",
+// numbers, ranges (1-5), lists (1,3,5) and steps (*/15, 0-30/10). Day of
+// week is 0-7 with both 0 and 7 meaning Sunday.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute in %q: %w", expr, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour in %q: %w", expr, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day of month in %q: %w", expr, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month in %q: %w", expr, err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day of week in %q: %w", expr, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return &s, nil
+}
+
+// parseCronField returns a bitmask of the values a field matches.
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start = n
+			if step == 1 {
+				end = n
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// cronSearchLimit bounds how far ahead next looks for a match, so impossible
+// expressions such as "0 0 31 2 *" terminate.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// next returns the first time strictly after t that matches the schedule,
+// evaluated in t's location, or the zero time if there is none.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
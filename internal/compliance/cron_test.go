@@ -0,0 +1,60 @@
+package compliance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q): expected error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Wednesday 2026-01-07 10:30 UTC
+	from := time.Date(2026, 1, 7, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 7, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 7, 10, 45, 0, 0, time.UTC)},
+		{"0 1 * * *", time.Date(2026, 1, 8, 1, 0, 0, 0, time.UTC)},
+		// Saturday 22:00
+		{"0 22 * * 6", time.Date(2026, 1, 10, 22, 0, 0, 0, time.UTC)},
+		// Sunday as 7
+		{"0 2 * * 7", time.Date(2026, 1, 11, 2, 0, 0, 0, time.UTC)},
+		{"0 0 1 3 *", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2026, 1, 7, 13, 0, 0, 0, time.UTC)},
+		// Day of month and day of week both restricted: either matches
+		{"0 0 15 * 5", time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)},
+		// Never matches
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got := s.next(from); !got.Equal(tt.want) {
+				t.Errorf("next = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// annotationCustomization holds the patch a remediation was applied with, so
+// every later apply, drift check and export uses the customized object.
+const annotationCustomization = "compliance-dashboard/customization"
+
+// Patch types accepted when customizing a remediation.
+const (
+	PatchTypeMerge     = "merge"
+	PatchTypeStrategic = "strategic"
+)
+
+// customizeObject applies a patch to a copy of obj and validates that the
+// result still describes the same object: a patch may change its content
+// but not its kind or identity, which snapshots and rollback depend on.
+func customizeObject(obj map[string]interface{}, patch *RemediationPatch) (map[string]interface{}, error) {
+	if len(patch.Patch) == 0 || string(patch.Patch) == "null" {
+		return nil, fmt.Errorf("patch is empty")
+	}
+	var patchMap map[string]interface{}
+	if err := json.Unmarshal(patch.Patch, &patchMap); err != nil {
+		return nil, fmt.Errorf("patch must be a JSON object: %w", err)
+	}
+
+	original := &unstructured.Unstructured{Object: obj}
+	var patched map[string]interface{}
+	switch patch.Type {
+	case "", PatchTypeMerge:
+		doc, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("encoding object: %w", err)
+		}
+		merged, err := jsonpatch.MergePatch(doc, patch.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("applying merge patch: %w", err)
+		}
+		if err := json.Unmarshal(merged, &patched); err != nil {
+			return nil, fmt.Errorf("applying merge patch: %w", err)
+		}
+	case PatchTypeStrategic:
+		typed, err := scheme.Scheme.New(original.GroupVersionKind())
+		if err != nil {
+			return nil, fmt.Errorf("strategic merge patch is not supported for %s; use a merge patch", original.GetKind())
+		}
+		meta, err := strategicpatch.NewPatchMetaFromStruct(typed)
+		if err != nil {
+			return nil, fmt.Errorf("building patch metadata for %s: %w", original.GetKind(), err)
+		}
+		merged, err := strategicpatch.StrategicMergeMapPatchUsingLookupPatchMeta(copyObject(obj), patchMap, meta)
+		if err != nil {
+			return nil, fmt.Errorf("applying strategic merge patch: %w", err)
+		}
+		patched = merged
+	default:
+		return nil, fmt.Errorf("invalid patch type %q: must be %q or %q", patch.Type, PatchTypeMerge, PatchTypeStrategic)
+	}
+	if patched == nil {
+		return nil, fmt.Errorf("patch removes the whole object")
+	}
+
+	result := &unstructured.Unstructured{Object: patched}
+	switch {
+	case result.GetAPIVersion() != original.GetAPIVersion() || result.GetKind() != original.GetKind():
+		return nil, fmt.Errorf("patch must not change apiVersion or kind")
+	case result.GetName() != original.GetName() || result.GetNamespace() != original.GetNamespace():
+		return nil, fmt.Errorf("patch must not change metadata.name or metadata.namespace")
+	}
+	// Round-trip through JSON so the object only holds JSON types, as the
+	// dynamic client requires.
+	data, err := json.Marshal(patched)
+	if err != nil {
+		return nil, fmt.Errorf("encoding patched object: %w", err)
+	}
+	if err := result.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("patched object is invalid: %w", err)
+	}
+	return result.Object, nil
+}
+
+func copyObject(obj map[string]interface{}) map[string]interface{} {
+	return (&unstructured.Unstructured{Object: obj}).DeepCopy().Object
+}
+
+// remediationCustomization returns the patch stored on a remediation, or nil.
+func remediationCustomization(rem *unstructured.Unstructured) (*RemediationPatch, error) {
+	raw := rem.GetAnnotations()[annotationCustomization]
+	if raw == "" {
+		return nil, nil
+	}
+	var patch RemediationPatch
+	if err := json.Unmarshal([]byte(raw), &patch); err != nil {
+		return nil, fmt.Errorf("remediation %s has an invalid customization: %w", rem.GetName(), err)
+	}
+	return &patch, nil
+}
+
+// setRemediationCustomization validates a patch against the remediation's
+// object and stores it in the annotation. Only the in-memory remediation is
+// changed; the caller persists it.
+func setRemediationCustomization(rem *unstructured.Unstructured, patch *RemediationPatch) error {
+	obj, found, err := unstructured.NestedMap(rem.Object, "spec", "current", "object")
+	if err != nil || !found {
+		return fmt.Errorf("remediation %s has no spec.current.object", rem.GetName())
+	}
+	if _, err := customizeObject(obj, patch); err != nil {
+		return fmt.Errorf("invalid patch for remediation %s: %w", rem.GetName(), err)
+	}
+
+	stored := *patch
+	if stored.Type == "" {
+		stored.Type = PatchTypeMerge
+	}
+	stored.CustomizedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("encoding patch: %w", err)
+	}
+	annotations := rem.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[annotationCustomization] = string(data)
+	rem.SetAnnotations(annotations)
+	return nil
+}
+
+// ValidateRemediationPatch checks that patch applies cleanly to the named
+// remediation's object without changing anything, so a bad patch is
+// rejected before a change request is created or claimed for it.
+func ValidateRemediationPatch(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace, name string, patch *RemediationPatch) error {
+	if client == nil {
+		return fmt.Errorf("kubernetes client is nil")
+	}
+	if mode == RemediationModeOperator {
+		return fmt.Errorf("invalid patch for remediation %s: patches are not supported in operator remediation mode", name)
+	}
+	rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting remediation %s: %w", name, err)
+	}
+	return setRemediationCustomization(rem, patch)
+}
+
+// patchDigest returns the SHA-256 of a patch's type and compacted JSON, or ""
+// for no patch. Change requests record it so the patch that runs is the one
+// that was approved.
+func patchDigest(patch *RemediationPatch) string {
+	if patch == nil {
+		return ""
+	}
+	typ := patch.Type
+	if typ == "" {
+		typ = PatchTypeMerge
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, patch.Patch); err != nil {
+		compact.Write(patch.Patch)
+	}
+	sum := sha256.Sum256(append([]byte(typ+"\x00"), compact.Bytes()...))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,177 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newAuditConfigRemediation(name, ns string) *unstructured.Unstructured {
+	return newRemediation(name, ns, map[string]any{
+		"spec": map[string]any{
+			"apply": false,
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      "audit-config",
+						"namespace": ns,
+					},
+					"data": map[string]any{
+						"profile": "Default",
+						"tls":     "VersionTLS12",
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestCustomizeObject(t *testing.T) {
+	obj := newAuditConfigRemediation("rem", "ns").Object["spec"].(map[string]any)["current"].(map[string]any)["object"].(map[string]any)
+
+	tests := []struct {
+		name    string
+		patch   RemediationPatch
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name:  "merge patch changes and deletes keys",
+			patch: RemediationPatch{Patch: json.RawMessage(`{"data":{"profile":"WriteRequestBodies","tls":null}}`)},
+			want:  map[string]any{"profile": "WriteRequestBodies"},
+		},
+		{
+			name:  "strategic patch on a built-in kind",
+			patch: RemediationPatch{Type: PatchTypeStrategic, Patch: json.RawMessage(`{"data":{"tls":"VersionTLS13"}}`)},
+			want:  map[string]any{"profile": "Default", "tls": "VersionTLS13"},
+		},
+		{
+			name:    "kind cannot change",
+			patch:   RemediationPatch{Patch: json.RawMessage(`{"kind":"Secret"}`)},
+			wantErr: "apiVersion or kind",
+		},
+		{
+			name:    "name cannot change",
+			patch:   RemediationPatch{Patch: json.RawMessage(`{"metadata":{"name":"other"}}`)},
+			wantErr: "metadata.name",
+		},
+		{
+			name:    "patch must be an object",
+			patch:   RemediationPatch{Patch: json.RawMessage(`["a"]`)},
+			wantErr: "JSON object",
+		},
+		{
+			name:    "unknown patch type",
+			patch:   RemediationPatch{Type: "json", Patch: json.RawMessage(`{}`)},
+			wantErr: "invalid patch type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := customizeObject(obj, &tt.patch)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, _ := got["data"].(map[string]any)
+			if len(data) != len(tt.want) {
+				t.Fatalf("data = %v, want %v", data, tt.want)
+			}
+			for k, v := range tt.want {
+				if data[k] != v {
+					t.Errorf("data[%s] = %v, want %v", k, data[k], v)
+				}
+			}
+		})
+	}
+
+	// The original object must not be modified.
+	if obj["data"].(map[string]any)["tls"] != "VersionTLS12" {
+		t.Error("customizeObject modified its input")
+	}
+
+	t.Run("strategic patch on a CRD kind is rejected", func(t *testing.T) {
+		mc := map[string]any{
+			"apiVersion": "machineconfiguration.openshift.io/v1",
+			"kind":       "MachineConfig",
+			"metadata":   map[string]any{"name": "75-x"},
+		}
+		_, err := customizeObject(mc, &RemediationPatch{Type: PatchTypeStrategic, Patch: json.RawMessage(`{"spec":{}}`)})
+		if err == nil || !strings.Contains(err.Error(), "use a merge patch") {
+			t.Fatalf("expected unsupported strategic patch error, got %v", err)
+		}
+	})
+}
+
+func TestApplyCustomizedRemediation(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	cmGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	client := newTestClient(newAuditConfigRemediation("rem-audit", ns))
+	patch := &RemediationPatch{Patch: json.RawMessage(`{"data":{"profile":"WriteRequestBodies"}}`)}
+
+	if _, err := ApplyCustomizedRemediation(ctx, client, RemediationModeDirect, ns, "rem-audit", &RemediationPatch{Patch: json.RawMessage(`{"kind":"Secret"}`)}); err == nil || !strings.Contains(err.Error(), "invalid patch") {
+		t.Fatalf("expected invalid patch error, got %v", err)
+	}
+	if _, err := client.Dynamic.Resource(cmGVR).Namespace(ns).Get(ctx, "audit-config", metav1.GetOptions{}); err == nil {
+		t.Fatal("an invalid patch must not apply anything")
+	}
+
+	result, err := ApplyCustomizedRemediation(ctx, client, RemediationModeDirect, ns, "rem-audit", patch)
+	if err != nil {
+		t.Fatalf("ApplyCustomizedRemediation: %v", err)
+	}
+	if !result.Applied || !strings.Contains(result.Message, "customizations") {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	cm, err := client.Dynamic.Resource(cmGVR).Namespace(ns).Get(ctx, "audit-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("ConfigMap not created: %v", err)
+	}
+	if profile, _, _ := unstructured.NestedString(cm.Object, "data", "profile"); profile != "WriteRequestBodies" {
+		t.Errorf("profile = %q, want the customized value", profile)
+	}
+
+	detail, err := GetRemediation(ctx, client, ns, "rem-audit")
+	if err != nil {
+		t.Fatalf("GetRemediation: %v", err)
+	}
+	if !detail.Customized || detail.Customization == nil || detail.Customization.Type != PatchTypeMerge {
+		t.Errorf("expected the stored customization, got %+v", detail.Customization)
+	}
+	if !strings.Contains(detail.CustomizationDiff, "+  profile: WriteRequestBodies") {
+		t.Errorf("unexpected customization diff:\n%s", detail.CustomizationDiff)
+	}
+
+	// The stored patch is reused when the remediation is applied again.
+	if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-audit"); err != nil {
+		t.Fatalf("ApplyRemediation: %v", err)
+	}
+	cm, _ = client.Dynamic.Resource(cmGVR).Namespace(ns).Get(ctx, "audit-config", metav1.GetOptions{})
+	if profile, _, _ := unstructured.NestedString(cm.Object, "data", "profile"); profile != "WriteRequestBodies" {
+		t.Errorf("re-apply lost the customization: profile = %q", profile)
+	}
+
+	// Removal clears it.
+	if _, err := RemoveRemediation(ctx, client, RemediationModeDirect, ns, "rem-audit"); err != nil {
+		t.Fatalf("RemoveRemediation: %v", err)
+	}
+	rem, _ := client.Dynamic.Resource(complianceRemediationGVR).Namespace(ns).Get(ctx, "rem-audit", metav1.GetOptions{})
+	if _, ok := rem.GetAnnotations()[annotationCustomization]; ok {
+		t.Error("expected the customization to be cleared on removal")
+	}
+}
@@ -0,0 +1,394 @@
+package compliance
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	// diagnosticsLogTailLines and diagnosticsLogLimitBytes bound each
+	// container log in a diagnostics bundle.
+	diagnosticsLogTailLines  = 5000
+	diagnosticsLogLimitBytes = 5 << 20
+
+	redacted = "<redacted>"
+)
+
+// diagnosticsKinds are the compliance CRs whose YAML a bundle includes.
+var diagnosticsKinds = []struct {
+	dir string
+	gvr schema.GroupVersionResource
+}{
+	{"compliancesuites", complianceSuiteGVR},
+	{"compliancescans", complianceScanGVR},
+	{"scansettingbindings", scanSettingBindingGVR},
+	{"scansettings", scanSettingGVR},
+	{"profilebundles", profileBundleGVR},
+}
+
+var (
+	// sensitiveNameRe matches field and environment variable names whose
+	// values are redacted.
+	sensitiveNameRe = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|api_?key|private_?key|access_?key)`)
+
+	bearerRe      = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	assignmentRe  = regexp.MustCompile(`(?i)\b(password|passwd|pwd|token|secret|api[_-]?key|access[_-]?key|client[_-]?secret)(["']?\s*[:=]\s*["']?)[^\s"',;&]+`)
+	urlUserinfoRe = regexp.MustCompile(`(://)[^/\s:@]+:[^/\s@]+@`)
+	privateKeyRe  = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// DiagnosticsFile is one file in a diagnostics bundle.
+type DiagnosticsFile struct {
+	Path    string
+	Content []byte
+}
+
+// DiagnosticsBundle is the support data collected from the operator
+// namespace. Errors lists what could not be collected; collection carries on
+// past individual failures.
+type DiagnosticsBundle struct {
+	Namespace   string
+	CollectedAt time.Time
+	Files       []DiagnosticsFile
+	Errors      []string
+}
+
+// CollectDiagnostics gathers pod logs, Events, compliance CR YAML, OLM
+// status and storage information from the operator namespace. Secret values
+// are redacted from everything collected, and Secrets themselves are never
+// read.
+func CollectDiagnostics(ctx context.Context, client *k8s.Client, namespace string) (*DiagnosticsBundle, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	b := &DiagnosticsBundle{Namespace: namespace, CollectedAt: time.Now().UTC()}
+
+	b.collectPods(ctx, client)
+	b.collectEvents(ctx, client)
+	for _, kind := range diagnosticsKinds {
+		b.collectObjects(ctx, client, kind.dir, kind.gvr)
+	}
+	b.collectOLM(ctx, client)
+	b.collectStorage(ctx, client)
+	return b, nil
+}
+
+func (b *DiagnosticsBundle) addError(format string, args ...any) {
+	b.Errors = append(b.Errors, fmt.Sprintf(format, args...))
+}
+
+func (b *DiagnosticsBundle) addText(name, text string) {
+	b.Files = append(b.Files, DiagnosticsFile{Path: name, Content: []byte(redactText(text))})
+}
+
+func (b *DiagnosticsBundle) addYAML(name string, v any) {
+	out, err := sigsyaml.Marshal(v)
+	if err != nil {
+		b.addError("rendering %s: %v", name, err)
+		return
+	}
+	b.addText(name, string(out))
+}
+
+// collectPods writes a status summary of every pod and the logs of each of
+// its containers, including the previous run of restarted containers.
+func (b *DiagnosticsBundle) collectPods(ctx context.Context, client *k8s.Client) {
+	pods, err := client.Clientset.CoreV1().Pods(b.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError("listing pods: %v", err)
+		return
+	}
+
+	summaries := make([]map[string]any, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		summaries = append(summaries, map[string]any{
+			"name":              pod.Name,
+			"node":              pod.Spec.NodeName,
+			"phase":             pod.Status.Phase,
+			"conditions":        pod.Status.Conditions,
+			"containerStatuses": append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...),
+		})
+
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		restarted := map[string]bool{}
+		for _, cs := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+			restarted[cs.Name] = cs.RestartCount > 0
+		}
+		for _, c := range containers {
+			b.collectLog(ctx, client, pod.Name, c.Name, false)
+			if restarted[c.Name] {
+				b.collectLog(ctx, client, pod.Name, c.Name, true)
+			}
+		}
+	}
+	b.addYAML("pods.yaml", summaries)
+}
+
+func (b *DiagnosticsBundle) collectLog(ctx context.Context, client *k8s.Client, pod, container string, previous bool) {
+	tail := int64(diagnosticsLogTailLines)
+	limit := int64(diagnosticsLogLimitBytes)
+	req := client.Clientset.CoreV1().Pods(b.Namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		TailLines:  &tail,
+		LimitBytes: &limit,
+	})
+	logs, err := req.DoRaw(ctx)
+	if err != nil {
+		b.addError("logs of %s/%s: %v", pod, container, err)
+		return
+	}
+	name := container + ".log"
+	if previous {
+		name = container + ".previous.log"
+	}
+	b.addText(path.Join("logs", pod, name), string(logs))
+}
+
+// collectEvents writes the namespace Events, oldest first.
+func (b *DiagnosticsBundle) collectEvents(ctx context.Context, client *k8s.Client) {
+	events, err := client.Clientset.CoreV1().Events(b.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError("listing events: %v", err)
+		return
+	}
+	items := events.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return eventTime(&items[i]).Before(eventTime(&items[j]))
+	})
+
+	var sb strings.Builder
+	for _, e := range items {
+		fmt.Fprintf(&sb, "%s\t%s\t%s/%s\t%s\t%s (x%d)\n",
+			eventTime(&e).UTC().Format(time.RFC3339), e.Type, e.InvolvedObject.Kind, e.InvolvedObject.Name,
+			e.Reason, e.Message, max(e.Count, 1))
+	}
+	b.addText("events.txt", sb.String())
+}
+
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// collectObjects writes one YAML file per object of a kind.
+func (b *DiagnosticsBundle) collectObjects(ctx context.Context, client *k8s.Client, dir string, gvr schema.GroupVersionResource) {
+	list, err := client.Dynamic.Resource(gvr).Namespace(b.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError("listing %s: %v", dir, err)
+		return
+	}
+	for i := range list.Items {
+		obj := &list.Items[i]
+		b.addYAML(path.Join("compliance", dir, obj.GetName()+".yaml"), redactObject(cleanObject(obj)))
+	}
+}
+
+// collectOLM writes the Subscription and the status of the CSVs and
+// InstallPlans in the namespace.
+func (b *DiagnosticsBundle) collectOLM(ctx context.Context, client *k8s.Client) {
+	sub, err := client.Dynamic.Resource(subscriptionGVR).Namespace(b.Namespace).
+		Get(ctx, subscriptionName, metav1.GetOptions{})
+	if err != nil {
+		b.addError("getting Subscription: %v", err)
+	} else {
+		b.addYAML("olm/subscription.yaml", redactObject(cleanObject(sub)))
+	}
+
+	csvs, err := client.Dynamic.Resource(csvGVR).Namespace(b.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError("listing ClusterServiceVersions: %v", err)
+	} else {
+		for _, csv := range csvs.Items {
+			status, _, _ := unstructured.NestedMap(csv.Object, "status")
+			b.addYAML(path.Join("olm", "csvs", csv.GetName()+".yaml"), redactObject(map[string]any{
+				"name":   csv.GetName(),
+				"status": status,
+			}))
+		}
+	}
+
+	plans, err := client.Dynamic.Resource(installPlanGVR).Namespace(b.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError("listing InstallPlans: %v", err)
+		return
+	}
+	for _, plan := range plans.Items {
+		spec, _, _ := unstructured.NestedMap(plan.Object, "spec")
+		status, _, _ := unstructured.NestedMap(plan.Object, "status")
+		// The plan steps embed full manifests; keep only what each step is.
+		if steps, ok := status["plan"].([]any); ok {
+			summary := make([]any, 0, len(steps))
+			for _, s := range steps {
+				step, _ := s.(map[string]any)
+				resource, _ := step["resource"].(map[string]any)
+				summary = append(summary, map[string]any{
+					"kind":   resource["kind"],
+					"name":   resource["name"],
+					"status": step["status"],
+				})
+			}
+			status["plan"] = summary
+		}
+		b.addYAML(path.Join("olm", "installplans", plan.GetName()+".yaml"), redactObject(map[string]any{
+			"name":   plan.GetName(),
+			"spec":   spec,
+			"status": status,
+		}))
+	}
+}
+
+// collectStorage writes DetectStorage's findings with the namespace's PVCs
+// and the cluster's StorageClasses.
+func (b *DiagnosticsBundle) collectStorage(ctx context.Context, client *k8s.Client) {
+	out := map[string]any{}
+	if info, err := DetectStorage(ctx, client); err != nil {
+		b.addError("detecting storage: %v", err)
+	} else {
+		out["detected"] = info
+	}
+
+	if pvcs, err := client.Clientset.CoreV1().PersistentVolumeClaims(b.Namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("listing PersistentVolumeClaims: %v", err)
+	} else {
+		claims := make([]map[string]any, 0, len(pvcs.Items))
+		for _, pvc := range pvcs.Items {
+			claims = append(claims, map[string]any{
+				"name":         pvc.Name,
+				"phase":        pvc.Status.Phase,
+				"storageClass": pvc.Spec.StorageClassName,
+				"volume":       pvc.Spec.VolumeName,
+				"requested":    pvc.Spec.Resources.Requests.Storage().String(),
+				"capacity":     pvc.Status.Capacity.Storage().String(),
+			})
+		}
+		out["persistentVolumeClaims"] = claims
+	}
+
+	if scs, err := client.Clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("listing StorageClasses: %v", err)
+	} else {
+		classes := make([]map[string]any, 0, len(scs.Items))
+		for _, sc := range scs.Items {
+			classes = append(classes, map[string]any{
+				"name":              sc.Name,
+				"provisioner":       sc.Provisioner,
+				"default":           sc.Annotations[defaultSCAnnotation] == "true",
+				"reclaimPolicy":     sc.ReclaimPolicy,
+				"volumeBindingMode": sc.VolumeBindingMode,
+			})
+		}
+		out["storageClasses"] = classes
+	}
+	b.addYAML("storage.yaml", out)
+}
+
+// cleanObject drops managedFields and the last-applied annotation, which
+// add noise and can repeat redacted values.
+func cleanObject(obj *unstructured.Unstructured) map[string]any {
+	out := obj.DeepCopy().Object
+	unstructured.RemoveNestedField(out, "metadata", "managedFields")
+	unstructured.RemoveNestedField(out, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
+	return out
+}
+
+// redactObject replaces the values of sensitive-looking fields and of
+// environment variables with sensitive-looking names.
+func redactObject(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		if name, ok := t["name"].(string); ok && sensitiveNameRe.MatchString(name) {
+			if _, ok := t["value"].(string); ok {
+				t["value"] = redacted
+			}
+		}
+		for k, val := range t {
+			if s, ok := val.(string); ok && s != "" && sensitiveNameRe.MatchString(k) && !strings.HasSuffix(k, "Name") && !strings.HasSuffix(k, "Ref") {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactObject(val)
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = redactObject(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// redactText masks bearer tokens, key=value secrets, URL credentials and
+// private keys in free text such as logs.
+func redactText(s string) string {
+	s = privateKeyRe.ReplaceAllString(s, redacted)
+	s = bearerRe.ReplaceAllString(s, "${1}"+redacted)
+	s = assignmentRe.ReplaceAllString(s, "${1}${2}"+redacted)
+	return urlUserinfoRe.ReplaceAllString(s, "${1}"+redacted+"@")
+}
+
+// WriteTarGz writes the bundle as a gzipped tarball rooted at
+// compliance-diagnostics-<time>/, with errors.txt listing anything that
+// could not be collected.
+func (b *DiagnosticsBundle) WriteTarGz(w io.Writer) error {
+	return writeTarGz(w, b.DirName(), b.CollectedAt, b.Files, b.Errors)
+}
+
+// writeTarGz writes files under root as a gzipped tarball, adding
+// errors.txt when anything could not be collected.
+func writeTarGz(w io.Writer, root string, modTime time.Time, files []DiagnosticsFile, errs []string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if len(errs) > 0 {
+		files = append(files[:len(files):len(files)], DiagnosticsFile{Path: "errors.txt", Content: []byte(strings.Join(errs, "\n") + "\n")})
+	}
+	for _, f := range files {
+		hdr := &tar.Header{
+			Name:    path.Join(root, f.Path),
+			Mode:    0o644,
+			Size:    int64(len(f.Content)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+		if _, err := tw.Write(f.Content); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// DirName is the bundle's top-level directory and file name stem.
+func (b *DiagnosticsBundle) DirName() string {
+	return "compliance-diagnostics-" + b.CollectedAt.Format("20060102-150405")
+}
@@ -0,0 +1,306 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// Drift found by the reconciler is recorded on the remediation so listing
+// remediations does not need to read every live object.
+const (
+	annotationDrift       = "compliance-dashboard/drift"
+	annotationDriftFields = "compliance-dashboard/drift-fields"
+)
+
+// Drift states of an applied remediation's object.
+const (
+	DriftMissing = "Missing"
+	DriftDrifted = "Drifted"
+)
+
+// maxDriftFields caps how many differing paths are recorded.
+const maxDriftFields = 10
+
+// driftInterval is how often the reconciler compares applied remediations
+// with their live objects.
+var driftInterval = 5 * time.Minute
+
+// CheckDrift compares every applied remediation's desired object with the
+// live object at its resolved GVR, records the result on the remediation and
+// returns the reports whose drift state changed since the last check.
+func CheckDrift(ctx context.Context, client *k8s.Client, namespace string) ([]DriftReport, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	list, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing remediations: %w", err)
+	}
+
+	var changed []DriftReport
+	for i := range list.Items {
+		rem := &list.Items[i]
+		var report *DriftReport
+		if remediationApplied(rem) && rem.GetAnnotations()[annotationGitOpsPending] == "" {
+			report, err = detectDrift(ctx, client, namespace, rem)
+			if err != nil {
+				slog.Debug("drift check skipped", "remediation", rem.GetName(), "error", err)
+				continue
+			}
+		} else {
+			report = &DriftReport{Remediation: rem.GetName()}
+		}
+
+		annotations := rem.GetAnnotations()
+		fields := strings.Join(report.Fields, ",")
+		if annotations[annotationDrift] == report.Drift && annotations[annotationDriftFields] == fields {
+			continue
+		}
+		if err := recordDrift(ctx, client, namespace, rem.GetName(), report.Drift, fields); err != nil {
+			slog.Warn("failed to record remediation drift", "remediation", rem.GetName(), "error", err)
+			continue
+		}
+		changed = append(changed, *report)
+	}
+	return changed, nil
+}
+
+// detectDrift compares one remediation's desired object with the live one.
+func detectDrift(ctx context.Context, client *k8s.Client, namespace string, rem *unstructured.Unstructured) (*DriftReport, error) {
+	target, err := resolveRemediationTarget(rem, namespace)
+	if err != nil {
+		return nil, err
+	}
+	report := &DriftReport{
+		Remediation: rem.GetName(),
+		Kind:        target.Kind,
+		Name:        target.Name,
+		Namespace:   target.Namespace,
+	}
+
+	live, err := target.resource(client).Get(ctx, target.Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		report.Drift = DriftMissing
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting %s %s: %w", target.Kind, target.Name, err)
+	}
+
+	report.Fields = driftedFields(target.Object.Object, live.Object)
+	if len(report.Fields) > 0 {
+		report.Drift = DriftDrifted
+	}
+	return report, nil
+}
+
+// driftedFields returns the paths where live differs from desired. Only
+// fields the remediation sets are compared, so values defaulted by the API
+// server are not drift. Server-managed metadata and status are ignored; of
+// metadata only the remediation's labels and annotations are checked.
+func driftedFields(desired, live map[string]interface{}) []string {
+	var fields []string
+	for key, want := range desired {
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			wantMeta, _ := want.(map[string]interface{})
+			liveMeta, _ := live["metadata"].(map[string]interface{})
+			for _, sub := range []string{"labels", "annotations"} {
+				if w, ok := wantMeta[sub]; ok {
+					compareDrift("metadata."+sub, w, liveMeta[sub], &fields)
+				}
+			}
+			continue
+		}
+		compareDrift(key, want, live[key], &fields)
+	}
+	sort.Strings(fields)
+	if len(fields) > maxDriftFields {
+		fields = append(fields[:maxDriftFields], fmt.Sprintf("and %d more", len(fields)-maxDriftFields))
+	}
+	return fields
+}
+
+func compareDrift(path string, want, got interface{}, fields *[]string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			*fields = append(*fields, path)
+			return
+		}
+		for key, v := range w {
+			compareDrift(path+"."+key, v, g[key], fields)
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			*fields = append(*fields, path)
+			return
+		}
+		for i := range w {
+			compareDrift(path+"["+strconv.Itoa(i)+"]", w[i], g[i], fields)
+		}
+	default:
+		if !driftValueEqual(want, got) {
+			*fields = append(*fields, path)
+		}
+	}
+}
+
+// driftValueEqual compares scalars, treating numbers of different Go types
+// (int64 from the API, float64 from JSON) as equal when their values are.
+func driftValueEqual(a, b interface{}) bool {
+	if af, ok := driftNumber(a); ok {
+		bf, ok := driftNumber(b)
+		return ok && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func driftNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// recordDrift stores (or clears) the drift annotations on a remediation.
+func recordDrift(ctx context.Context, client *k8s.Client, namespace, name, drift, fields string) error {
+	value := func(s string) string {
+		if s == "" {
+			return "null"
+		}
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%s,%q:%s}}}`,
+		annotationDrift, value(drift), annotationDriftFields, value(fields)))
+	_, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// remediationDrift returns the drift state and fields recorded on a
+// remediation.
+func remediationDrift(rem *unstructured.Unstructured) (string, []string) {
+	annotations := rem.GetAnnotations()
+	var fields []string
+	if f := annotations[annotationDriftFields]; f != "" {
+		fields = strings.Split(f, ",")
+	}
+	return annotations[annotationDrift], fields
+}
+
+// DriftReconciler periodically checks applied remediations for drift.
+type DriftReconciler struct {
+	client    *k8s.Client
+	namespace string
+	notify    func(DriftReport)
+}
+
+// NewDriftReconciler creates a reconciler. notify is called for each
+// remediation whose drift state changed, including when drift clears.
+func NewDriftReconciler(client *k8s.Client, namespace string, notify func(DriftReport)) *DriftReconciler {
+	return &DriftReconciler{client: client, namespace: namespace, notify: notify}
+}
+
+// Run checks for drift every driftInterval until ctx is canceled.
+func (d *DriftReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(driftInterval)
+	defer ticker.Stop()
+	for {
+		changed, err := CheckDrift(ctx, d.client, d.namespace)
+		if err != nil {
+			slog.Debug("drift check failed", "error", err)
+		}
+		for _, report := range changed {
+			d.notify(report)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListDriftedRemediations returns applied remediations whose object was
+// found missing or changed by the last drift check.
+func ListDriftedRemediations(ctx context.Context, client *k8s.Client, namespace string) ([]RemediationInfo, error) {
+	all, err := ListRemediations(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	drifted := []RemediationInfo{}
+	for _, rem := range all {
+		if rem.Drift != "" {
+			drifted = append(drifted, rem)
+		}
+	}
+	return drifted, nil
+}
+
+// ReapplyRemediation applies a drifted remediation again, recreating or
+// restoring its object. The snapshot from the first apply is kept. In
+// operator mode spec.apply is already set, so setting it again would change
+// nothing; the object is written from spec.current.object instead.
+func ReapplyRemediation(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace, name string) (*RemediationResult, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting remediation %s: %w", name, err)
+	}
+	if drift, _ := remediationDrift(rem); drift == "" {
+		return nil, fmt.Errorf("remediation %s has not drifted", name)
+	}
+	if mode != RemediationModeOperator {
+		return ApplyRemediation(ctx, client, mode, namespace, name)
+	}
+
+	result := &RemediationResult{Name: name}
+	target, err := resolveRemediationTarget(rem, namespace)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	if err := writeRemediationObject(ctx, client, target); err != nil {
+		result.Error = fmt.Sprintf("restoring object: %v", err)
+		return result, fmt.Errorf("restoring object of remediation %s: %w", name, err)
+	}
+	setRemediationApplied(ctx, client, namespace, rem, true)
+
+	result.Applied = true
+	result.Message = fmt.Sprintf("Restored %s %s from the remediation", target.Kind, target.Name)
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDriftedFields(t *testing.T) {
+	desired := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":   "cm",
+			"labels": map[string]interface{}{"app": "x"},
+		},
+		"data": map[string]interface{}{"a": "1", "b": "2"},
+		"spec": map[string]interface{}{
+			"replicas": float64(3),
+			"items":    []interface{}{"x", "y"},
+		},
+	}
+
+	t.Run("matching with server defaults", func(t *testing.T) {
+		live := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":            "cm",
+				"resourceVersion": "42",
+				"labels":          map[string]interface{}{"app": "x", "extra": "y"},
+			},
+			"data": map[string]interface{}{"a": "1", "b": "2", "c": "3"},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"items":    []interface{}{"x", "y"},
+				"default":  true,
+			},
+			"status": map[string]interface{}{"ready": true},
+		}
+		if fields := driftedFields(desired, live); len(fields) != 0 {
+			t.Errorf("expected no drift, got %v", fields)
+		}
+	})
+
+	t.Run("changed values", func(t *testing.T) {
+		live := map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "cm"},
+			"data":     map[string]interface{}{"a": "changed", "b": "2"},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"items":    []interface{}{"x"},
+			},
+		}
+		want := []string{"data.a", "metadata.labels", "spec.items", "spec.replicas"}
+		if fields := driftedFields(desired, live); !reflect.DeepEqual(fields, want) {
+			t.Errorf("fields = %v, want %v", fields, want)
+		}
+	})
+}
+
+func TestCheckDrift(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	client := newTestClient(
+		newConfigMapRemediation("rem-ok", ns, "cm-ok", map[string]any{"key": "value"}),
+		newConfigMapRemediation("rem-edit", ns, "cm-edit", map[string]any{"key": "value"}),
+		newConfigMapRemediation("rem-gone", ns, "cm-gone", map[string]any{"key": "value"}),
+		newConfigMapRemediation("rem-unapplied", ns, "cm-unapplied", map[string]any{"key": "value"}),
+	)
+	for _, name := range []string{"rem-ok", "rem-edit", "rem-gone"} {
+		if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, name); err != nil {
+			t.Fatalf("apply %s: %v", name, err)
+		}
+	}
+
+	cms := client.Dynamic.Resource(configMapGVR).Namespace(ns)
+	edited, _ := cms.Get(ctx, "cm-edit", metav1.GetOptions{})
+	_ = unstructured.SetNestedField(edited.Object, "tampered", "data", "key")
+	if _, err := cms.Update(ctx, edited, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cms.Delete(ctx, "cm-gone", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := CheckDrift(ctx, client, ns)
+	if err != nil {
+		t.Fatalf("CheckDrift: %v", err)
+	}
+	got := map[string]DriftReport{}
+	for _, r := range changed {
+		got[r.Remediation] = r
+	}
+	if len(got) != 2 || got["rem-gone"].Drift != DriftMissing || got["rem-edit"].Drift != DriftDrifted {
+		t.Fatalf("unexpected reports: %+v", changed)
+	}
+	if !reflect.DeepEqual(got["rem-edit"].Fields, []string{"data.key"}) {
+		t.Errorf("fields = %v", got["rem-edit"].Fields)
+	}
+
+	infos, _ := ListDriftedRemediations(ctx, client, ns)
+	if len(infos) != 2 {
+		t.Errorf("expected 2 drifted remediations, got %+v", infos)
+	}
+
+	// A second check with nothing changed reports nothing
+	if changed, _ := CheckDrift(ctx, client, ns); len(changed) != 0 {
+		t.Errorf("expected no changes, got %+v", changed)
+	}
+
+	if _, err := ReapplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-ok"); err == nil || !strings.Contains(err.Error(), "has not drifted") {
+		t.Errorf("expected not drifted error, got %v", err)
+	}
+	if _, err := ReapplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-gone"); err != nil {
+		t.Fatalf("reapply: %v", err)
+	}
+	if _, err := cms.Get(ctx, "cm-gone", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected object recreated: %v", err)
+	}
+
+	// Drift on rem-gone was cleared by the re-apply and stays clear
+	changed, _ = CheckDrift(ctx, client, ns)
+	for _, r := range changed {
+		if r.Remediation == "rem-gone" {
+			t.Errorf("unexpected report after re-apply: %+v", r)
+		}
+	}
+	infos, _ = ListDriftedRemediations(ctx, client, ns)
+	if len(infos) != 1 || infos[0].Name != "rem-edit" {
+		t.Errorf("expected only rem-edit drifted, got %+v", infos)
+	}
+}
+
+func TestReapplyRemediation_ResetsDriftedField(t *testing.T) {
+	for _, operator := range []bool{false, true} {
+		t.Run(fmt.Sprintf("operator=%t", operator), func(t *testing.T) {
+			ctx := context.Background()
+			ns := "openshift-compliance"
+			client := newTestClient(newConfigMapRemediation("rem-edit", ns, "cm-edit", map[string]any{"key": "value"}))
+			if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-edit"); err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			mode := RemediationModeDirect
+			if operator {
+				mode = RemediationModeOperator
+			}
+
+			cms := client.Dynamic.Resource(configMapGVR).Namespace(ns)
+			edited, _ := cms.Get(ctx, "cm-edit", metav1.GetOptions{})
+			_ = unstructured.SetNestedField(edited.Object, "tampered", "data", "key")
+			if _, err := cms.Update(ctx, edited, metav1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if changed, err := CheckDrift(ctx, client, ns); err != nil || len(changed) != 1 {
+				t.Fatalf("CheckDrift = %+v, %v", changed, err)
+			}
+
+			result, err := ReapplyRemediation(ctx, client, mode, ns, "rem-edit")
+			if err != nil || !result.Applied {
+				t.Fatalf("reapply = %+v, %v", result, err)
+			}
+			live, _ := cms.Get(ctx, "cm-edit", metav1.GetOptions{})
+			if got, _, _ := unstructured.NestedString(live.Object, "data", "key"); got != "value" {
+				t.Errorf("data.key = %q after re-apply, want value", got)
+			}
+			if changed, _ := CheckDrift(ctx, client, ns); len(changed) != 0 {
+				t.Errorf("expected drift to be cleared, got %+v", changed)
+			}
+		})
+	}
+}
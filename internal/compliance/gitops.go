@@ -0,0 +1,294 @@
+package compliance
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	// annotationGitOpsPending marks a remediation that was exported for a
+	// GitOps tool to apply. The value is the export time.
+	annotationGitOpsPending = "compliance-dashboard/gitops-pending"
+
+	// gitOpsExportDir is the top-level directory of an exported archive and
+	// the default subdirectory when committing to a repository.
+	gitOpsExportDir = "compliance-remediations"
+
+	kustomizationFile = "kustomization.yaml"
+)
+
+// GitOpsFile is a single manifest in a GitOps export.
+type GitOpsFile struct {
+	Path        string `json:"path"`
+	Remediation string `json:"remediation,omitempty"`
+	Content     []byte `json:"-"`
+}
+
+// GitOpsExport is a Kustomize layout of remediation objects: one file per
+// object under <kind>/[<role>/], plus a kustomization.yaml listing them.
+type GitOpsExport struct {
+	Remediations []string     `json:"remediations"`
+	Files        []GitOpsFile `json:"files"`
+}
+
+// kustomization is the subset of a Kustomization written by an export.
+type kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Resources  []string `json:"resources"`
+}
+
+// BuildGitOpsExport renders the spec.current.object of each named
+// remediation into a Kustomize layout. Nothing is written to the cluster.
+func BuildGitOpsExport(ctx context.Context, client *k8s.Client, namespace string, names []string) (*GitOpsExport, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no remediations selected")
+	}
+
+	export := &GitOpsExport{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting remediation %s: %w", name, err)
+		}
+
+		target, err := resolveRemediationTarget(rem, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if target.Namespace != "" {
+			target.Object.SetNamespace(target.Namespace)
+		}
+
+		jsonBytes, err := json.Marshal(target.Object.Object)
+		if err != nil {
+			return nil, fmt.Errorf("encoding remediation %s: %w", name, err)
+		}
+		yamlBytes, err := sigsyaml.JSONToYAML(jsonBytes)
+		if err != nil {
+			return nil, fmt.Errorf("encoding remediation %s: %w", name, err)
+		}
+
+		dir := strings.ToLower(target.Kind)
+		if IsDisruptiveKind(target.Kind) {
+			dir = path.Join(dir, detectRole(name, *rem))
+		}
+
+		export.Remediations = append(export.Remediations, name)
+		export.Files = append(export.Files, GitOpsFile{
+			Path:        path.Join(dir, name+".yaml"),
+			Remediation: name,
+			Content:     yamlBytes,
+		})
+	}
+
+	sort.Slice(export.Files, func(i, j int) bool { return export.Files[i].Path < export.Files[j].Path })
+
+	resources := make([]string, 0, len(export.Files))
+	for _, f := range export.Files {
+		resources = append(resources, f.Path)
+	}
+	kustomizationYAML, err := renderKustomization(resources)
+	if err != nil {
+		return nil, err
+	}
+	export.Files = append(export.Files, GitOpsFile{Path: kustomizationFile, Content: kustomizationYAML})
+
+	return export, nil
+}
+
+func renderKustomization(resources []string) ([]byte, error) {
+	out, err := sigsyaml.Marshal(kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  resources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", kustomizationFile, err)
+	}
+	return out, nil
+}
+
+// WriteTarGz writes the export as a gzipped tarball rooted at
+// compliance-remediations/.
+func (e *GitOpsExport) WriteTarGz(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	for _, f := range e.Files {
+		hdr := &tar.Header{
+			Name:    path.Join(gitOpsExportDir, f.Path),
+			Mode:    0o644,
+			Size:    int64(len(f.Content)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+		if _, err := tw.Write(f.Content); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// CommitGitOpsExport writes the export into subdir of the git repository at
+// repoPath and commits it. Manifests from earlier exports in the same subdir
+// are kept, and kustomization.yaml is regenerated to list all of them.
+// It returns the new commit SHA. Callers must not commit to the same
+// repository concurrently.
+func CommitGitOpsExport(ctx context.Context, repoPath, subdir, message string, export *GitOpsExport) (string, error) {
+	if repoPath == "" {
+		return "", fmt.Errorf("no GitOps repository configured")
+	}
+	if subdir == "" {
+		subdir = gitOpsExportDir
+	}
+	subdir = filepath.Clean(subdir)
+	if !filepath.IsLocal(subdir) {
+		return "", fmt.Errorf("invalid subdirectory %q: must be relative to the repository", subdir)
+	}
+	if message == "" {
+		message = fmt.Sprintf("Add %d compliance remediation(s)", len(export.Remediations))
+	}
+
+	if _, err := runGit(ctx, repoPath, "rev-parse", "--show-toplevel"); err != nil {
+		return "", fmt.Errorf("%s is not a git repository: %w", repoPath, err)
+	}
+
+	root := filepath.Join(repoPath, subdir)
+	for _, f := range export.Files {
+		if f.Path == kustomizationFile {
+			continue
+		}
+		dest := filepath.Join(root, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return "", fmt.Errorf("creating %s: %w", filepath.Dir(dest), err)
+		}
+		if err := os.WriteFile(dest, f.Content, 0o644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", dest, err)
+		}
+	}
+
+	resources, err := listManifests(root)
+	if err != nil {
+		return "", err
+	}
+	kustomizationYAML, err := renderKustomization(resources)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(root, kustomizationFile), kustomizationYAML, 0o644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", kustomizationFile, err)
+	}
+
+	if _, err := runGit(ctx, repoPath, "add", "--", subdir); err != nil {
+		return "", err
+	}
+	// Limit the check and the commit to subdir, so changes someone else has
+	// staged in the repository are neither counted nor committed.
+	if _, err := runGit(ctx, repoPath, "diff", "--cached", "--quiet", "--", subdir); err == nil {
+		return "", fmt.Errorf("no changes to commit: the repository already contains these remediations")
+	}
+	if _, err := runGit(ctx, repoPath, "commit", "-m", message, "--", subdir); err != nil {
+		return "", err
+	}
+
+	sha, err := runGit(ctx, repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return sha, nil
+}
+
+// listManifests returns the slash-separated paths of all YAML files under
+// root, excluding kustomization.yaml itself.
+func listManifests(root string) ([]string, error) {
+	var resources []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".yaml") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == kustomizationFile {
+			return nil
+		}
+		resources = append(resources, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing manifests in %s: %w", root, err)
+	}
+	sort.Strings(resources)
+	return resources, nil
+}
+
+func runGit(ctx context.Context, repoPath string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// MarkGitOpsPending annotates the named remediations as exported, so the
+// dashboard shows them as pending via GitOps rather than unapplied.
+func MarkGitOpsPending(ctx context.Context, client *k8s.Client, namespace string, names []string) error {
+	if client == nil {
+		return fmt.Errorf("kubernetes client is nil")
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`,
+		annotationGitOpsPending, time.Now().UTC().Format(time.RFC3339)))
+	for _, name := range names {
+		_, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("marking remediation %s as pending via GitOps: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package compliance
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildGitOpsExport(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	client := newTestClient(
+		newMachineConfigRemediation("rem-mc-worker", ns, "worker"),
+		newConfigMapRemediation("rem-cm", ns, "target-cm", map[string]any{"key": "value"}),
+	)
+
+	export, err := BuildGitOpsExport(ctx, client, ns, []string{"rem-mc-worker", "rem-cm", "rem-cm"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(export.Remediations) != 2 {
+		t.Errorf("expected 2 remediations (duplicates dropped), got %v", export.Remediations)
+	}
+
+	paths := make(map[string]string)
+	for _, f := range export.Files {
+		paths[f.Path] = string(f.Content)
+	}
+
+	if _, ok := paths["machineconfig/worker/rem-mc-worker.yaml"]; !ok {
+		t.Errorf("expected MachineConfig grouped by kind and role, got %v", export.Files)
+	}
+	cm, ok := paths["configmap/rem-cm.yaml"]
+	if !ok {
+		t.Fatalf("expected ConfigMap grouped by kind, got %v", export.Files)
+	}
+	if !strings.Contains(cm, "namespace: "+ns) {
+		t.Errorf("expected namespaced object to carry its namespace:\n%s", cm)
+	}
+
+	k, ok := paths["kustomization.yaml"]
+	if !ok {
+		t.Fatal("expected kustomization.yaml")
+	}
+	for _, want := range []string{"kind: Kustomization", "- configmap/rem-cm.yaml", "- machineconfig/worker/rem-mc-worker.yaml"} {
+		if !strings.Contains(k, want) {
+			t.Errorf("kustomization.yaml missing %q:\n%s", want, k)
+		}
+	}
+}
+
+func TestBuildGitOpsExport_Errors(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+
+	if _, err := BuildGitOpsExport(ctx, client, "openshift-compliance", nil); err == nil {
+		t.Error("expected error for empty selection")
+	}
+	if _, err := BuildGitOpsExport(ctx, client, "openshift-compliance", []string{"missing"}); err == nil {
+		t.Error("expected error for missing remediation")
+	}
+	if _, err := BuildGitOpsExport(ctx, nil, "openshift-compliance", []string{"x"}); err == nil {
+		t.Error("expected error for nil client")
+	}
+}
+
+func TestGitOpsExport_WriteTarGz(t *testing.T) {
+	export := &GitOpsExport{Files: []GitOpsFile{
+		{Path: "configmap/a.yaml", Content: []byte("a")},
+		{Path: "kustomization.yaml", Content: []byte("k")},
+	}}
+
+	var buf bytes.Buffer
+	if err := export.WriteTarGz(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+
+	want := []string{"compliance-remediations/configmap/a.yaml", "compliance-remediations/kustomization.yaml"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("archive entries = %v, want %v", names, want)
+	}
+}
+
+func TestCommitGitOpsExport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+	} {
+		if _, err := runGit(ctx, repo, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	first := &GitOpsExport{
+		Remediations: []string{"a"},
+		Files:        []GitOpsFile{{Path: "configmap/a.yaml", Remediation: "a", Content: []byte("kind: ConfigMap\n")}},
+	}
+	sha, err := CommitGitOpsExport(ctx, repo, "", "", first)
+	if err != nil {
+		t.Fatalf("first commit: %v", err)
+	}
+	if sha == "" {
+		t.Error("expected commit SHA")
+	}
+
+	second := &GitOpsExport{
+		Remediations: []string{"b"},
+		Files:        []GitOpsFile{{Path: "machineconfig/worker/b.yaml", Remediation: "b", Content: []byte("kind: MachineConfig\n")}},
+	}
+	if _, err := CommitGitOpsExport(ctx, repo, "", "second", second); err != nil {
+		t.Fatalf("second commit: %v", err)
+	}
+
+	k, err := os.ReadFile(filepath.Join(repo, gitOpsExportDir, kustomizationFile))
+	if err != nil {
+		t.Fatalf("reading kustomization: %v", err)
+	}
+	for _, want := range []string{"- configmap/a.yaml", "- machineconfig/worker/b.yaml"} {
+		if !strings.Contains(string(k), want) {
+			t.Errorf("kustomization.yaml missing %q after incremental export:\n%s", want, k)
+		}
+	}
+
+	// A change staged outside the export directory is left out of the commit.
+	if err := os.WriteFile(filepath.Join(repo, "unrelated.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatalf("writing unrelated file: %v", err)
+	}
+	if _, err := runGit(ctx, repo, "add", "unrelated.txt"); err != nil {
+		t.Fatalf("staging unrelated file: %v", err)
+	}
+	if _, err := CommitGitOpsExport(ctx, repo, "", "again", second); err == nil {
+		t.Error("expected error when nothing changed in the export directory")
+	}
+	third := &GitOpsExport{
+		Remediations: []string{"c"},
+		Files:        []GitOpsFile{{Path: "configmap/c.yaml", Remediation: "c", Content: []byte("kind: ConfigMap\n")}},
+	}
+	if _, err := CommitGitOpsExport(ctx, repo, "", "third", third); err != nil {
+		t.Fatalf("third commit: %v", err)
+	}
+	if files, _ := runGit(ctx, repo, "show", "--name-only", "--format=", "HEAD"); strings.Contains(files, "unrelated.txt") {
+		t.Errorf("commit picked up a file staged outside the export directory:\n%s", files)
+	}
+
+	if _, err := CommitGitOpsExport(ctx, repo, "", "again", second); err == nil {
+		t.Error("expected error when nothing changed")
+	}
+	if _, err := CommitGitOpsExport(ctx, repo, "../outside", "", second); err == nil {
+		t.Error("expected error for subdirectory outside the repository")
+	}
+	if _, err := CommitGitOpsExport(ctx, t.TempDir(), "", "", second); err == nil {
+		t.Error("expected error for non-git directory")
+	}
+}
+
+func TestMarkGitOpsPending(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(newConfigMapRemediation("rem-cm", ns, "target-cm", nil))
+
+	if err := MarkGitOpsPending(ctx, client, ns, []string{"rem-cm"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	infos, err := ListRemediations(ctx, client, ns)
+	if err != nil {
+		t.Fatalf("ListRemediations: %v", err)
+	}
+	if len(infos) != 1 || !infos[0].GitOpsPending {
+		t.Fatalf("expected remediation to be pending via GitOps, got %+v", infos)
+	}
+
+	// A direct apply supersedes the export
+	if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-cm"); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	infos, _ = ListRemediations(ctx, client, ns)
+	if infos[0].GitOpsPending {
+		t.Error("expected GitOps pending flag to be cleared by direct apply")
+	}
+}
@@ -0,0 +1,369 @@
+package compliance
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// maxIgnitionContent bounds the decoded size of a single file, so a large
+// or malicious payload cannot blow up a detail response.
+const maxIgnitionContent = 1 << 20
+
+// maxIgnitionDecompressed bounds how much of a gzip-compressed file is
+// decompressed, so a small payload cannot expand without limit.
+const maxIgnitionDecompressed = 64 << 20
+
+// DecodeIgnition returns the files and systemd units a MachineConfig writes,
+// with data: URL contents decoded to plaintext. It returns nil for objects
+// without an Ignition config.
+func DecodeIgnition(obj *unstructured.Unstructured) *IgnitionConfig {
+	if obj == nil || obj.GetKind() != "MachineConfig" {
+		return nil
+	}
+	config, found, _ := unstructured.NestedMap(obj.Object, "spec", "config")
+	if !found {
+		return nil
+	}
+
+	ign := &IgnitionConfig{Files: []IgnitionFile{}, Units: []SystemdUnit{}}
+	ign.Version, _, _ = unstructured.NestedString(config, "ignition", "version")
+
+	files, _, _ := unstructured.NestedSlice(config, "storage", "files")
+	for _, f := range files {
+		if file, ok := f.(map[string]interface{}); ok {
+			ign.Files = append(ign.Files, decodeIgnitionFile(file))
+		}
+	}
+
+	units, _, _ := unstructured.NestedSlice(config, "systemd", "units")
+	for _, u := range units {
+		unit, ok := u.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		su := SystemdUnit{}
+		su.Name, _ = unit["name"].(string)
+		su.Contents, _ = unit["contents"].(string)
+		if enabled, ok := unit["enabled"].(bool); ok {
+			su.Enabled = &enabled
+		}
+		su.Mask, _ = unit["mask"].(bool)
+		dropins, _ := unit["dropins"].([]interface{})
+		for _, d := range dropins {
+			if dropin, ok := d.(map[string]interface{}); ok {
+				name, _ := dropin["name"].(string)
+				contents, _ := dropin["contents"].(string)
+				su.Dropins = append(su.Dropins, SystemdDropin{Name: name, Contents: contents})
+			}
+		}
+		ign.Units = append(ign.Units, su)
+	}
+
+	sort.Slice(ign.Files, func(i, j int) bool { return ign.Files[i].Path < ign.Files[j].Path })
+	sort.Slice(ign.Units, func(i, j int) bool { return ign.Units[i].Name < ign.Units[j].Name })
+	return ign
+}
+
+func decodeIgnitionFile(file map[string]interface{}) IgnitionFile {
+	f := IgnitionFile{}
+	f.Path, _ = file["path"].(string)
+	if overwrite, ok := file["overwrite"].(bool); ok {
+		f.Overwrite = &overwrite
+	}
+
+	// Ignition stores the mode as a decimal integer (420 is 0644).
+	if mode, ok := driftNumber(file["mode"]); ok {
+		f.Mode = fmt.Sprintf("%04o", int64(mode))
+	}
+	f.Owner = ignitionOwner(file)
+
+	source, _, _ := unstructured.NestedString(file, "contents", "source")
+	compression, _, _ := unstructured.NestedString(file, "contents", "compression")
+	f.Source = source
+	if source == "" {
+		return f
+	}
+	if !strings.HasPrefix(source, "data:") {
+		f.Error = "remote source is not fetched"
+		return f
+	}
+
+	data, encoding, err := decodeDataURL(source)
+	if err != nil {
+		f.Error = err.Error()
+		return f
+	}
+	var contents *decodedContents
+	if compression == "gzip" {
+		contents, err = gunzip(data)
+		encoding += "+gzip"
+	} else {
+		contents, err = readContents(bytes.NewReader(data), len(data))
+	}
+	if err != nil {
+		f.Error = err.Error()
+		return f
+	}
+
+	f.Encoding = encoding
+	f.Size = contents.size
+	f.digest = contents.digest
+	// The source is no longer needed once it decoded cleanly.
+	f.Source = ""
+	truncated := contents.size > len(contents.head)
+	head := contents.head
+	if truncated {
+		// Cut at a rune boundary so a cap landing mid-character does not
+		// make text look binary.
+		for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	switch {
+	case !utf8.Valid(head):
+		f.Binary = true
+	case truncated:
+		f.Content = string(head)
+		f.Truncated = true
+	default:
+		f.Content = string(head)
+	}
+	return f
+}
+
+// ignitionOwner formats a file's user and group as "user:group", preferring
+// names over numeric ids. Either side is omitted when unset, which Ignition
+// treats as root.
+func ignitionOwner(file map[string]interface{}) string {
+	part := func(key string) string {
+		if name, _, _ := unstructured.NestedString(file, key, "name"); name != "" {
+			return name
+		}
+		if id, ok := driftNumber(nestedValue(file, key, "id")); ok {
+			return strconv.FormatInt(int64(id), 10)
+		}
+		return ""
+	}
+	user, group := part("user"), part("group")
+	if user == "" && group == "" {
+		return ""
+	}
+	if user == "" {
+		user = "root"
+	}
+	if group == "" {
+		return user
+	}
+	return user + ":" + group
+}
+
+func nestedValue(obj map[string]interface{}, fields ...string) interface{} {
+	v, _, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+	return v
+}
+
+// decodeDataURL decodes an RFC 2397 data: URL, returning the payload and
+// whether it was "base64" or "url" encoded.
+func decodeDataURL(source string) ([]byte, string, error) {
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(source, "data:"), ",")
+	if !ok {
+		return nil, "", fmt.Errorf("malformed data URL: missing ','")
+	}
+
+	if strings.HasSuffix(meta, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			// Some generators percent-encode the base64 payload as well.
+			unescaped, uerr := url.PathUnescape(payload)
+			if uerr != nil {
+				return nil, "", fmt.Errorf("decoding base64 data URL: %w", err)
+			}
+			if data, err = base64.StdEncoding.DecodeString(unescaped); err != nil {
+				return nil, "", fmt.Errorf("decoding base64 data URL: %w", err)
+			}
+		}
+		return data, "base64", nil
+	}
+
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding data URL: %w", err)
+	}
+	return []byte(data), "url", nil
+}
+
+// decodedContents summarizes a file's decoded contents: the first
+// maxIgnitionContent bytes, and the size and SHA-256 of all of them.
+type decodedContents struct {
+	head   []byte
+	size   int
+	digest string
+}
+
+// readContents reads r to the end, failing once more than limit bytes have
+// been read.
+func readContents(r io.Reader, limit int) (*decodedContents, error) {
+	hash := sha256.New()
+	var head bytes.Buffer
+	var size int
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			size += n
+			if size > limit {
+				return nil, fmt.Errorf("decoded contents exceed %d bytes", limit)
+			}
+			hash.Write(buf[:n])
+			if room := maxIgnitionContent - head.Len(); room > 0 {
+				head.Write(buf[:min(n, room)])
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &decodedContents{head: head.Bytes(), size: size, digest: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func gunzip(data []byte) (*decodedContents, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip contents: %w", err)
+	}
+	defer r.Close()
+	contents, err := readContents(r, maxIgnitionDecompressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip contents: %w", err)
+	}
+	return contents, nil
+}
+
+// PreviewRoleIgnition combines the files and units of every MachineConfig
+// remediation targeting each role, applied or not and with any customization,
+// and reports the paths and units written by more than one remediation. An
+// empty role previews all roles.
+func PreviewRoleIgnition(ctx context.Context, client *k8s.Client, namespace, role string) ([]RoleIgnitionPreview, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	list, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing remediations: %w", err)
+	}
+
+	byRole := make(map[string]*RoleIgnitionPreview)
+	for i := range list.Items {
+		rem := &list.Items[i]
+		obj, found, _ := unstructured.NestedMap(rem.Object, "spec", "current", "object")
+		if !found {
+			continue
+		}
+		if customization, _ := remediationCustomization(rem); customization != nil {
+			if patched, err := customizeObject(obj, customization); err == nil {
+				obj = patched
+			}
+		}
+		ign := DecodeIgnition(&unstructured.Unstructured{Object: obj})
+		if ign == nil {
+			continue
+		}
+		remRole := detectRole(rem.GetName(), *rem)
+		if role != "" && remRole != role {
+			continue
+		}
+
+		preview := byRole[remRole]
+		if preview == nil {
+			preview = &RoleIgnitionPreview{Role: remRole, Files: []RoleIgnitionFile{}, Units: []RoleSystemdUnit{}, Conflicts: []IgnitionConflict{}}
+			byRole[remRole] = preview
+		}
+		applied := remediationApplied(rem)
+		for _, f := range ign.Files {
+			preview.Files = append(preview.Files, RoleIgnitionFile{IgnitionFile: f, Remediation: rem.GetName(), Applied: applied})
+		}
+		for _, u := range ign.Units {
+			preview.Units = append(preview.Units, RoleSystemdUnit{SystemdUnit: u, Remediation: rem.GetName(), Applied: applied})
+		}
+	}
+
+	previews := make([]RoleIgnitionPreview, 0, len(byRole))
+	for _, preview := range byRole {
+		sort.SliceStable(preview.Files, func(i, j int) bool { return preview.Files[i].Path < preview.Files[j].Path })
+		sort.SliceStable(preview.Units, func(i, j int) bool { return preview.Units[i].Name < preview.Units[j].Name })
+		preview.Conflicts = ignitionConflicts(preview)
+		previews = append(previews, *preview)
+	}
+	sort.Slice(previews, func(i, j int) bool { return previews[i].Role < previews[j].Role })
+	return previews, nil
+}
+
+// ignitionConflicts finds file paths and unit names written by more than
+// one remediation. Files and units must already be sorted.
+func ignitionConflicts(preview *RoleIgnitionPreview) []IgnitionConflict {
+	conflicts := []IgnitionConflict{}
+	add := func(typ, key string, sources []string, contents []string) {
+		if len(sources) < 2 {
+			return
+		}
+		identical := true
+		for _, c := range contents[1:] {
+			if c != contents[0] {
+				identical = false
+			}
+		}
+		conflicts = append(conflicts, IgnitionConflict{Type: typ, Path: key, Remediations: sources, Identical: identical})
+	}
+
+	for i := 0; i < len(preview.Files); {
+		j := i
+		var sources, contents []string
+		for ; j < len(preview.Files) && preview.Files[j].Path == preview.Files[i].Path; j++ {
+			f := preview.Files[j]
+			sources = append(sources, f.Remediation)
+			// Decoded files are compared by digest: Content is empty for
+			// binary files and cut short for large ones. Files that could
+			// not be decoded fall back to their source.
+			data := f.digest
+			if data == "" {
+				data = f.Source
+			}
+			contents = append(contents, data+"\x00"+f.Mode+"\x00"+f.Owner)
+		}
+		add("file", preview.Files[i].Path, sources, contents)
+		i = j
+	}
+	for i := 0; i < len(preview.Units); {
+		j := i
+		var sources, contents []string
+		for ; j < len(preview.Units) && preview.Units[j].Name == preview.Units[i].Name; j++ {
+			u := preview.Units[j]
+			sources = append(sources, u.Remediation)
+			contents = append(contents, u.Contents)
+		}
+		add("unit", preview.Units[i].Name, sources, contents)
+		i = j
+	}
+	return conflicts
+}
@@ -0,0 +1,280 @@
+package compliance
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newIgnitionMachineConfig(files []any, units []any) map[string]any {
+	return map[string]any{
+		"apiVersion": "machineconfiguration.openshift.io/v1",
+		"kind":       "MachineConfig",
+		"metadata": map[string]any{
+			"name":   "75-test",
+			"labels": map[string]any{"machineconfiguration.openshift.io/role": "worker"},
+		},
+		"spec": map[string]any{
+			"config": map[string]any{
+				"ignition": map[string]any{"version": "3.1.0"},
+				"storage":  map[string]any{"files": files},
+				"systemd":  map[string]any{"units": units},
+			},
+		},
+	}
+}
+
+func ignitionFile(path, source string, mode int64) map[string]any {
+	return map[string]any{
+		"path":     path,
+		"mode":     mode,
+		"contents": map[string]any{"source": source},
+	}
+}
+
+func TestDecodeDataURL(t *testing.T) {
+	tests := []struct {
+		source   string
+		want     string
+		encoding string
+		wantErr  bool
+	}{
+		{"data:,net.ipv4.ip_forward%20%3D%200%0A", "net.ipv4.ip_forward = 0\n", "url", false},
+		{"data:text/plain;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte("hello\n")), "hello\n", "base64", false},
+		{"data:;base64,aGVsbG8%3D", "hello", "base64", false},
+		{"data:text/plain", "", "", true},
+		{"data:;base64,!!!", "", "", true},
+	}
+	for _, tt := range tests {
+		got, encoding, err := decodeDataURL(tt.source)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("decodeDataURL(%q) expected error", tt.source)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("decodeDataURL(%q) error: %v", tt.source, err)
+			continue
+		}
+		if string(got) != tt.want || encoding != tt.encoding {
+			t.Errorf("decodeDataURL(%q) = %q, %q; want %q, %q", tt.source, got, encoding, tt.want, tt.encoding)
+		}
+	}
+}
+
+func TestDecodeIgnition(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write([]byte("compressed\n"))
+	_ = w.Close()
+
+	owned := ignitionFile("/etc/owned", "data:,x", 384)
+	owned["user"] = map[string]any{"name": "core"}
+	owned["group"] = map[string]any{"id": int64(1000)}
+	compressed := ignitionFile("/etc/compressed", "data:;base64,"+base64.StdEncoding.EncodeToString(gz.Bytes()), 420)
+	compressed["contents"].(map[string]any)["compression"] = "gzip"
+
+	obj := newIgnitionMachineConfig([]any{
+		ignitionFile("/etc/sysctl.d/75-forward.conf", "data:,net.ipv4.ip_forward%3D0", 420),
+		owned,
+		compressed,
+		ignitionFile("/etc/remote", "https://example.com/file", 420),
+	}, []any{
+		map[string]any{"name": "auditd.service", "enabled": true},
+		map[string]any{"name": "kdump.service", "mask": true},
+	})
+
+	ign := DecodeIgnition(&unstructured.Unstructured{Object: obj})
+	if ign == nil {
+		t.Fatal("expected an Ignition config")
+	}
+	if ign.Version != "3.1.0" {
+		t.Errorf("version = %q", ign.Version)
+	}
+	if len(ign.Files) != 4 {
+		t.Fatalf("expected 4 files, got %d", len(ign.Files))
+	}
+
+	files := make(map[string]IgnitionFile)
+	for _, f := range ign.Files {
+		files[f.Path] = f
+	}
+	if f := files["/etc/sysctl.d/75-forward.conf"]; f.Content != "net.ipv4.ip_forward=0" || f.Mode != "0644" || f.Encoding != "url" || f.Source != "" {
+		t.Errorf("unexpected sysctl file: %+v", f)
+	}
+	if f := files["/etc/owned"]; f.Owner != "core:1000" || f.Mode != "0600" {
+		t.Errorf("unexpected owned file: %+v", f)
+	}
+	if f := files["/etc/compressed"]; f.Content != "compressed\n" || f.Encoding != "base64+gzip" {
+		t.Errorf("unexpected compressed file: %+v", f)
+	}
+	if f := files["/etc/remote"]; f.Error == "" || f.Source == "" || f.Content != "" {
+		t.Errorf("remote file should keep its source with an error: %+v", f)
+	}
+
+	if len(ign.Units) != 2 || ign.Units[0].Name != "auditd.service" || ign.Units[0].Enabled == nil || !*ign.Units[0].Enabled {
+		t.Errorf("unexpected units: %+v", ign.Units)
+	}
+	if !ign.Units[1].Mask {
+		t.Errorf("expected kdump.service to be masked")
+	}
+
+	if DecodeIgnition(&unstructured.Unstructured{Object: map[string]any{"kind": "ConfigMap"}}) != nil {
+		t.Error("expected nil for non-MachineConfig objects")
+	}
+}
+
+func TestPreviewRoleIgnition(t *testing.T) {
+	ns := "openshift-compliance"
+	remWith := func(name, role string, files []any, units []any) *unstructured.Unstructured {
+		obj := newIgnitionMachineConfig(files, units)
+		obj["metadata"].(map[string]any)["labels"] = map[string]any{"machineconfiguration.openshift.io/role": role}
+		return newRemediation(name, ns, map[string]any{
+			"spec": map[string]any{"apply": false, "current": map[string]any{"object": obj}},
+		})
+	}
+
+	client := newTestClient(
+		remWith("ocp4-a-worker", "worker", []any{ignitionFile("/etc/a", "data:,one", 420), ignitionFile("/etc/shared", "data:,x", 420)}, nil),
+		remWith("ocp4-b-worker", "worker", []any{ignitionFile("/etc/shared", "data:,y", 420)}, []any{map[string]any{"name": "auditd.service"}}),
+		remWith("ocp4-c-worker", "worker", nil, []any{map[string]any{"name": "auditd.service"}}),
+		remWith("ocp4-a-master", "master", []any{ignitionFile("/etc/shared", "data:,x", 420)}, nil),
+	)
+
+	previews, err := PreviewRoleIgnition(context.Background(), client, ns, "")
+	if err != nil {
+		t.Fatalf("PreviewRoleIgnition: %v", err)
+	}
+	if len(previews) != 2 || previews[0].Role != "master" || previews[1].Role != "worker" {
+		t.Fatalf("unexpected roles: %+v", previews)
+	}
+	if len(previews[0].Conflicts) != 0 {
+		t.Errorf("master should have no conflicts: %+v", previews[0].Conflicts)
+	}
+
+	worker := previews[1]
+	if len(worker.Files) != 3 || len(worker.Units) != 2 {
+		t.Fatalf("unexpected worker preview: %+v", worker)
+	}
+	if len(worker.Conflicts) != 2 {
+		t.Fatalf("expected 2 worker conflicts, got %+v", worker.Conflicts)
+	}
+	for _, c := range worker.Conflicts {
+		switch c.Type {
+		case "file":
+			if c.Path != "/etc/shared" || c.Identical || len(c.Remediations) != 2 {
+				t.Errorf("unexpected file conflict: %+v", c)
+			}
+		case "unit":
+			if c.Path != "auditd.service" || !c.Identical {
+				t.Errorf("unexpected unit conflict: %+v", c)
+			}
+		}
+	}
+
+	only, err := PreviewRoleIgnition(context.Background(), client, ns, "master")
+	if err != nil {
+		t.Fatalf("PreviewRoleIgnition(master): %v", err)
+	}
+	if len(only) != 1 || only[0].Role != "master" {
+		t.Errorf("expected only master, got %+v", only)
+	}
+}
+
+func TestIgnitionConflicts_ComparesFullContents(t *testing.T) {
+	ns := "openshift-compliance"
+	b64 := func(data []byte) string { return "data:;base64," + base64.StdEncoding.EncodeToString(data) }
+	long := strings.Repeat("x", maxIgnitionContent+10)
+	rem := func(name string, files ...any) *unstructured.Unstructured {
+		return newRemediation(name, ns, map[string]any{
+			"spec": map[string]any{"apply": false, "current": map[string]any{"object": newIgnitionMachineConfig(files, nil)}},
+		})
+	}
+
+	client := newTestClient(
+		rem("ocp4-a-worker",
+			ignitionFile("/etc/binary", b64([]byte{0xff, 0x01}), 420),
+			ignitionFile("/etc/long", b64([]byte(long+"a")), 420),
+			ignitionFile("/etc/same", b64([]byte{0xff, 0x02}), 420)),
+		rem("ocp4-b-worker",
+			ignitionFile("/etc/binary", b64([]byte{0xff, 0x03}), 420),
+			ignitionFile("/etc/long", b64([]byte(long+"b")), 420),
+			ignitionFile("/etc/same", "data:,%FF%02", 420)),
+	)
+
+	previews, err := PreviewRoleIgnition(context.Background(), client, ns, "worker")
+	if err != nil || len(previews) != 1 {
+		t.Fatalf("PreviewRoleIgnition = %+v, %v", previews, err)
+	}
+	identical := map[string]bool{}
+	for _, c := range previews[0].Conflicts {
+		identical[c.Path] = c.Identical
+	}
+	if len(identical) != 3 {
+		t.Fatalf("expected 3 conflicts, got %+v", previews[0].Conflicts)
+	}
+	if identical["/etc/binary"] {
+		t.Error("binary files with different contents reported identical")
+	}
+	if identical["/etc/long"] {
+		t.Error("long files differing after the preview cut-off reported identical")
+	}
+	if !identical["/etc/same"] {
+		t.Error("files with the same contents reported different")
+	}
+}
+
+func TestDecodeIgnition_LargeGzip(t *testing.T) {
+	gzipped := func(data []byte) string {
+		var gz bytes.Buffer
+		w := gzip.NewWriter(&gz)
+		_, _ = w.Write(data)
+		_ = w.Close()
+		return "data:;base64," + base64.StdEncoding.EncodeToString(gz.Bytes())
+	}
+	file := func(path string, data []byte) any {
+		f := ignitionFile(path, gzipped(data), 420)
+		f["contents"].(map[string]any)["compression"] = "gzip"
+		return f
+	}
+
+	large := []byte(strings.Repeat("y", 3*maxIgnitionContent))
+	differsAtEnd := append([]byte(strings.Repeat("y", 3*maxIgnitionContent-1)), 'z')
+	bomb := make([]byte, maxIgnitionDecompressed+1)
+
+	ign := DecodeIgnition(&unstructured.Unstructured{Object: newIgnitionMachineConfig([]any{
+		file("/etc/large", large),
+		file("/etc/differs", differsAtEnd),
+		file("/etc/bomb", bomb),
+	}, nil)})
+	if ign == nil || len(ign.Files) != 3 {
+		t.Fatal("expected 3 files")
+	}
+	files := make(map[string]IgnitionFile)
+	for _, f := range ign.Files {
+		files[f.Path] = f
+	}
+
+	f, other, bombFile := files["/etc/large"], files["/etc/differs"], files["/etc/bomb"]
+	if f.Size != len(large) || !f.Truncated || len(f.Content) != maxIgnitionContent || f.Error != "" {
+		t.Errorf("large file: size=%d truncated=%v content=%d error=%q", f.Size, f.Truncated, len(f.Content), f.Error)
+	}
+	sum := sha256.Sum256(large)
+	if f.digest != hex.EncodeToString(sum[:]) {
+		t.Error("expected the digest of the full decompressed contents")
+	}
+	if f.digest == other.digest {
+		t.Error("expected files differing past the displayed content to have different digests")
+	}
+	if bombFile.Error == "" || bombFile.Content != "" || bombFile.Size != 0 {
+		t.Errorf("expected decompression past the cap to fail: size=%d error=%q", bombFile.Size, bombFile.Error)
+	}
+}
@@ -0,0 +1,261 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// Rough per-step durations used to estimate rollout time. A node update is a
+// drain, reboot and rejoin; an operator-managed control-plane rollout
+// replaces the static pods or deployment on each master in turn.
+const (
+	estimatedNodeUpdate          = 10 * time.Minute
+	estimatedControlPlaneRollout = 15 * time.Minute
+)
+
+// Control-plane components restarted by config changes.
+const (
+	componentKubelet            = "kubelet"
+	componentCRIO               = "crio"
+	componentKubeAPIServer      = "kube-apiserver"
+	componentOpenShiftAPIServer = "openshift-apiserver"
+	componentOAuth              = "oauth-openshift"
+)
+
+// AnalyzeImpact reports what applying the named remediations would disrupt:
+// the MachineConfigPools and nodes that roll out, each pool's maxUnavailable,
+// an estimated rollout duration, and whether kubelet, API server or OAuth
+// config is touched. Pools roll out in parallel, so the overall estimate is
+// the slowest pool (or control-plane rollout).
+func AnalyzeImpact(ctx context.Context, client *k8s.Client, namespace string, names []string) (*RemediationImpact, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	// Non-OpenShift clusters have no pools; that only means no node impact
+	var pools []unstructured.Unstructured
+	if list, err := client.Dynamic.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{}); err == nil {
+		pools = list.Items
+	}
+
+	impact := &RemediationImpact{Remediations: names}
+	poolImpacts := make(map[string]*PoolImpact)
+	components := make(map[string]bool)
+
+	for _, name := range names {
+		rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting remediation %s: %w", name, err)
+		}
+		target, err := resolveRemediationTarget(rem, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if IsDisruptiveKind(target.Kind) {
+			impact.RebootNeeded = true
+		}
+
+		matched := targetPools(pools, target.Kind, target.Object, detectRole(name, *rem))
+		switch target.Kind {
+		case "MachineConfig":
+			if touchesKubelet(target.Object) {
+				components[componentKubelet] = true
+			}
+		case "KubeletConfig":
+			components[componentKubelet] = true
+		case "ContainerRuntimeConfig":
+			components[componentCRIO] = true
+		case "APIServer":
+			components[componentKubeAPIServer] = true
+			components[componentOpenShiftAPIServer] = true
+		case "KubeAPIServer":
+			components[componentKubeAPIServer] = true
+		case "OAuth":
+			components[componentOAuth] = true
+		}
+
+		for i := range matched {
+			pi, ok := poolImpacts[matched[i].GetName()]
+			if !ok {
+				pi = newPoolImpact(&matched[i])
+				poolImpacts[pi.Pool] = pi
+			}
+			pi.Remediations = append(pi.Remediations, name)
+		}
+	}
+
+	var longest time.Duration
+	for _, pi := range poolImpacts {
+		impact.Pools = append(impact.Pools, *pi)
+		impact.NodesAffected += pi.NodeCount
+		if d := time.Duration(pi.EstimatedSeconds) * time.Second; d > longest {
+			longest = d
+		}
+		if pi.Pool == "master" {
+			impact.ControlPlaneRestart = true
+		}
+	}
+	sort.Slice(impact.Pools, func(i, j int) bool { return impact.Pools[i].Pool < impact.Pools[j].Pool })
+	if len(impact.Pools) > 0 {
+		impact.RebootNeeded = true
+	}
+
+	for c := range components {
+		impact.RestartedComponents = append(impact.RestartedComponents, c)
+	}
+	sort.Strings(impact.RestartedComponents)
+	impact.TouchesKubelet = components[componentKubelet]
+	impact.TouchesAPIServer = components[componentKubeAPIServer] || components[componentOpenShiftAPIServer]
+	impact.TouchesOAuth = components[componentOAuth]
+	if impact.TouchesAPIServer || impact.TouchesOAuth {
+		impact.ControlPlaneRestart = true
+		longest = max(longest, estimatedControlPlaneRollout)
+	}
+
+	impact.EstimatedSeconds = int64(longest.Seconds())
+	impact.EstimatedDuration = longest.String()
+	return impact, nil
+}
+
+// newPoolImpact computes a pool's node count, maxUnavailable and rollout
+// estimate. The MCO updates ceil(nodes/maxUnavailable) batches in sequence.
+func newPoolImpact(mcp *unstructured.Unstructured) *PoolImpact {
+	nodes, _, _ := unstructured.NestedInt64(mcp.Object, "status", "machineCount")
+	paused, _, _ := unstructured.NestedBool(mcp.Object, "spec", "paused")
+
+	maxUnavailable := intstr.FromInt32(1)
+	if raw, found, _ := unstructured.NestedFieldNoCopy(mcp.Object, "spec", "maxUnavailable"); found {
+		switch v := raw.(type) {
+		case int64:
+			maxUnavailable = intstr.FromInt32(int32(v))
+		case float64:
+			maxUnavailable = intstr.FromInt32(int32(v))
+		case string:
+			maxUnavailable = intstr.Parse(v)
+		}
+	}
+	perBatch, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, int(nodes), false)
+	if err != nil || perBatch < 1 {
+		perBatch = 1
+	}
+
+	batches := (int(nodes) + perBatch - 1) / perBatch
+	estimate := time.Duration(batches) * estimatedNodeUpdate
+
+	return &PoolImpact{
+		Pool:              mcp.GetName(),
+		NodeCount:         int(nodes),
+		MaxUnavailable:    maxUnavailable.String(),
+		Paused:            paused,
+		EstimatedSeconds:  int64(estimate.Seconds()),
+		EstimatedDuration: estimate.String(),
+	}
+}
+
+// targetPools returns the pools that roll out an object of the given kind:
+// those selecting a MachineConfig, or those a KubeletConfig or
+// ContainerRuntimeConfig selects.
+func targetPools(pools []unstructured.Unstructured, kind string, obj *unstructured.Unstructured, role string) []unstructured.Unstructured {
+	switch kind {
+	case "MachineConfig":
+		return poolsForMachineConfig(pools, obj, role)
+	case "KubeletConfig", "ContainerRuntimeConfig":
+		return poolsForSelector(pools, obj, "spec", "machineConfigPoolSelector")
+	}
+	return nil
+}
+
+// poolsForMachineConfig returns the pools whose machineConfigSelector
+// matches the MachineConfig's labels, falling back to the pool named after
+// its role.
+func poolsForMachineConfig(pools []unstructured.Unstructured, mc *unstructured.Unstructured, role string) []unstructured.Unstructured {
+	var matched []unstructured.Unstructured
+	mcLabels := labels.Set(mc.GetLabels())
+	for _, pool := range pools {
+		if selector, ok := labelSelectorAt(pool.Object, "spec", "machineConfigSelector"); ok && selector.Matches(mcLabels) {
+			matched = append(matched, pool)
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	for _, pool := range pools {
+		if pool.GetName() == role {
+			return []unstructured.Unstructured{pool}
+		}
+	}
+	return nil
+}
+
+// poolsForSelector returns the pools whose labels match the label selector at
+// fields of obj (e.g. a KubeletConfig's spec.machineConfigPoolSelector).
+func poolsForSelector(pools []unstructured.Unstructured, obj *unstructured.Unstructured, fields ...string) []unstructured.Unstructured {
+	selector, ok := labelSelectorAt(obj.Object, fields...)
+	if !ok {
+		return nil
+	}
+
+	var matched []unstructured.Unstructured
+	for _, pool := range pools {
+		if selector.Matches(labels.Set(pool.GetLabels())) {
+			matched = append(matched, pool)
+		}
+	}
+	return matched
+}
+
+// labelSelectorAt converts the label selector at fields of obj, with both
+// matchLabels and matchExpressions. ok is false when there is no selector,
+// it is empty or it is invalid, so it never selects everything.
+func labelSelectorAt(obj map[string]any, fields ...string) (labels.Selector, bool) {
+	raw, found, _ := unstructured.NestedMap(obj, fields...)
+	if !found || len(raw) == 0 {
+		return nil, false
+	}
+	var ls metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &ls); err != nil {
+		return nil, false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&ls)
+	if err != nil || selector.Empty() {
+		return nil, false
+	}
+	return selector, true
+}
+
+// touchesKubelet reports whether a MachineConfig writes kubelet config files
+// or units.
+func touchesKubelet(mc *unstructured.Unstructured) bool {
+	if files, _, _ := unstructured.NestedSlice(mc.Object, "spec", "config", "storage", "files"); files != nil {
+		for _, f := range files {
+			if fm, ok := f.(map[string]interface{}); ok {
+				if p, _ := fm["path"].(string); strings.Contains(p, "kubelet") {
+					return true
+				}
+			}
+		}
+	}
+	if units, _, _ := unstructured.NestedSlice(mc.Object, "spec", "config", "systemd", "units"); units != nil {
+		for _, u := range units {
+			if um, ok := u.(map[string]interface{}); ok {
+				if n, _ := um["name"].(string); strings.HasPrefix(n, "kubelet") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
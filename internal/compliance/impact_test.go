@@ -0,0 +1,170 @@
+package compliance
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAnalyzeImpact(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	worker := newMachineConfigPool("worker", false, 6, 6, "True")
+	_ = unstructured.SetNestedField(worker.Object, "50%", "spec", "maxUnavailable")
+	worker.SetLabels(map[string]string{"pools.operator.machineconfiguration.openshift.io/worker": ""})
+	master := newMachineConfigPool("master", false, 3, 3, "True")
+
+	kubeletRem := newRemediation("rem-kubelet", ns, map[string]any{
+		"spec": map[string]any{
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "machineconfiguration.openshift.io/v1",
+					"kind":       "KubeletConfig",
+					"metadata":   map[string]any{"name": "kubelet-config"},
+					"spec": map[string]any{
+						"machineConfigPoolSelector": map[string]any{
+							"matchLabels": map[string]any{"pools.operator.machineconfiguration.openshift.io/worker": ""},
+						},
+					},
+				},
+			},
+		},
+	})
+	apiServerRem := newRemediation("rem-apiserver", ns, map[string]any{
+		"spec": map[string]any{
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "config.openshift.io/v1",
+					"kind":       "APIServer",
+					"metadata":   map[string]any{"name": "cluster"},
+				},
+			},
+		},
+	})
+
+	client := newTestClient(
+		worker, master,
+		newMachineConfigRemediation("rem-mc-worker", ns, "worker"),
+		newMachineConfigRemediation("rem-mc-master", ns, "master"),
+		kubeletRem, apiServerRem,
+		newConfigMapRemediation("rem-cm", ns, "cm", nil),
+	)
+
+	t.Run("MachineConfig and KubeletConfig on worker", func(t *testing.T) {
+		impact, err := AnalyzeImpact(ctx, client, ns, []string{"rem-mc-worker", "rem-kubelet"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(impact.Pools) != 1 || impact.Pools[0].Pool != "worker" {
+			t.Fatalf("expected only the worker pool, got %+v", impact.Pools)
+		}
+		pool := impact.Pools[0]
+		if pool.NodeCount != 6 || pool.MaxUnavailable != "50%" {
+			t.Errorf("unexpected pool impact: %+v", pool)
+		}
+		// 6 nodes, 3 at a time: 2 batches
+		if want := int64(2 * estimatedNodeUpdate.Seconds()); pool.EstimatedSeconds != want {
+			t.Errorf("EstimatedSeconds = %d, want %d", pool.EstimatedSeconds, want)
+		}
+		if len(pool.Remediations) != 2 {
+			t.Errorf("expected both remediations on the pool, got %v", pool.Remediations)
+		}
+		if !impact.RebootNeeded || !impact.TouchesKubelet || impact.ControlPlaneRestart {
+			t.Errorf("unexpected flags: %+v", impact)
+		}
+		if impact.NodesAffected != 6 {
+			t.Errorf("NodesAffected = %d, want 6", impact.NodesAffected)
+		}
+	})
+
+	t.Run("master pool restarts the control plane", func(t *testing.T) {
+		impact, err := AnalyzeImpact(ctx, client, ns, []string{"rem-mc-master"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !impact.ControlPlaneRestart || impact.Pools[0].MaxUnavailable != "1" {
+			t.Errorf("unexpected impact: %+v", impact)
+		}
+	})
+
+	t.Run("APIServer config", func(t *testing.T) {
+		impact, err := AnalyzeImpact(ctx, client, ns, []string{"rem-apiserver"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if impact.RebootNeeded || !impact.TouchesAPIServer || !impact.ControlPlaneRestart {
+			t.Errorf("unexpected flags: %+v", impact)
+		}
+		if impact.EstimatedSeconds != int64(estimatedControlPlaneRollout.Seconds()) {
+			t.Errorf("EstimatedSeconds = %d", impact.EstimatedSeconds)
+		}
+	})
+
+	t.Run("ConfigMap has no impact", func(t *testing.T) {
+		impact, err := AnalyzeImpact(ctx, client, ns, []string{"rem-cm"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if impact.RebootNeeded || impact.ControlPlaneRestart || len(impact.Pools) != 0 || impact.EstimatedSeconds != 0 {
+			t.Errorf("expected no impact, got %+v", impact)
+		}
+	})
+
+	t.Run("missing remediation", func(t *testing.T) {
+		if _, err := AnalyzeImpact(ctx, client, ns, []string{"missing"}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestAnalyzeImpact_MatchExpressions(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	roleIn := func(roles ...any) map[string]any {
+		return map[string]any{"matchExpressions": []any{map[string]any{
+			"key": "machineconfiguration.openshift.io/role", "operator": "In", "values": roles,
+		}}}
+	}
+	worker := newMachineConfigPool("worker", false, 3, 3, "True")
+	_ = unstructured.SetNestedMap(worker.Object, roleIn("worker"), "spec", "machineConfigSelector")
+	infra := newMachineConfigPool("infra", false, 2, 2, "True")
+	_ = unstructured.SetNestedMap(infra.Object, roleIn("worker", "infra"), "spec", "machineConfigSelector")
+	infra.SetLabels(map[string]string{"pools.operator.machineconfiguration.openshift.io/infra": ""})
+
+	kubeletRem := newRemediation("rem-kubelet", ns, map[string]any{
+		"spec": map[string]any{
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "machineconfiguration.openshift.io/v1",
+					"kind":       "KubeletConfig",
+					"metadata":   map[string]any{"name": "kubelet-config"},
+					"spec": map[string]any{
+						"machineConfigPoolSelector": map[string]any{"matchExpressions": []any{map[string]any{
+							"key": "pools.operator.machineconfiguration.openshift.io/infra", "operator": "Exists",
+						}}},
+					},
+				},
+			},
+		},
+	})
+	client := newTestClient(worker, infra, kubeletRem, newMachineConfigRemediation("rem-mc-worker", ns, "worker"))
+
+	impact, err := AnalyzeImpact(ctx, client, ns, []string{"rem-mc-worker"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(impact.Pools) != 2 || impact.NodesAffected != 5 {
+		t.Errorf("expected the worker and infra pools with 5 nodes, got %+v", impact)
+	}
+
+	impact, err = AnalyzeImpact(ctx, client, ns, []string{"rem-kubelet"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(impact.Pools) != 1 || impact.Pools[0].Pool != "infra" {
+		t.Errorf("expected only the infra pool, got %+v", impact.Pools)
+	}
+}
@@ -0,0 +1,265 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// The Red Hat catalog and the default channel of each known source.
+const (
+	redHatCatalog           = "redhat-operators"
+	redHatDefaultChannel    = "stable"
+	communityDefaultChannel = "alpha"
+)
+
+var envVarNameRe = regexp.MustCompile(`^[-._a-zA-Z][-._a-zA-Z0-9]*$`)
+
+// Validate checks the options without contacting the cluster.
+func (o InstallOptions) Validate() error {
+	switch o.Source {
+	case "", InstallSourceRedHat, InstallSourceCommunity, InstallSourceManifests:
+		if o.CatalogSource != "" || o.CatalogSourceNamespace != "" {
+			return fmt.Errorf("invalid install options: catalog_source requires source %q", InstallSourceCustom)
+		}
+	case InstallSourceCustom:
+		if o.CatalogSource == "" {
+			return fmt.Errorf("invalid install options: source %q requires catalog_source", InstallSourceCustom)
+		}
+	default:
+		return fmt.Errorf("invalid install options: source must be %q, %q, %q or %q",
+			InstallSourceRedHat, InstallSourceCommunity, InstallSourceCustom, InstallSourceManifests)
+	}
+	if o.Source == InstallSourceManifests {
+		switch {
+		case o.Channel != "":
+			return fmt.Errorf("invalid install options: channel requires OLM and cannot be used with source %q", InstallSourceManifests)
+		case o.StartingCSV != "":
+			return fmt.Errorf("invalid install options: starting_csv requires OLM and cannot be used with source %q", InstallSourceManifests)
+		case o.Approval == ApprovalManual:
+			return fmt.Errorf("invalid install options: manual approval requires OLM and cannot be used with source %q", InstallSourceManifests)
+		}
+	}
+
+	if o.CatalogImage != "" {
+		if o.Source != "" && o.Source != InstallSourceCommunity {
+			return fmt.Errorf("invalid install options: catalog_image requires source %q", InstallSourceCommunity)
+		}
+		if strings.Contains(o.CatalogImage, "://") || strings.ContainsAny(o.CatalogImage, " \t") {
+			return fmt.Errorf("invalid install options: catalog_image %q is not an image reference", o.CatalogImage)
+		}
+	}
+	if o.MirrorRegistry != "" {
+		if !o.Disconnected {
+			return fmt.Errorf("invalid install options: mirror_registry requires disconnected")
+		}
+		if strings.Contains(o.MirrorRegistry, "://") || strings.ContainsAny(o.MirrorRegistry, " @\t") {
+			return fmt.Errorf("invalid install options: mirror_registry must be a registry host and optional path, such as mirror.example.com:5000/ocp")
+		}
+	}
+
+	switch o.Approval {
+	case "", ApprovalAutomatic, ApprovalManual:
+	default:
+		return fmt.Errorf("invalid install options: approval must be %q or %q", ApprovalAutomatic, ApprovalManual)
+	}
+	if o.StartingCSV != "" && !strings.HasPrefix(o.StartingCSV, operatorName+".") {
+		return fmt.Errorf("invalid install options: starting_csv %q is not a %s CSV", o.StartingCSV, operatorName)
+	}
+	if o.Config != nil {
+		if err := o.Config.validate(); err != nil {
+			return fmt.Errorf("invalid install options: %w", err)
+		}
+	}
+	if o.Timeouts != nil {
+		if err := o.Timeouts.validate(); err != nil {
+			return fmt.Errorf("invalid install options: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *SubscriptionConfig) validate() error {
+	for key := range c.NodeSelector {
+		if key == "" {
+			return fmt.Errorf("config.nodeSelector has an empty key")
+		}
+	}
+
+	for i, t := range c.Tolerations {
+		switch t.Operator {
+		case "", corev1.TolerationOpEqual:
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				return fmt.Errorf("config.tolerations[%d]: value must be empty with operator Exists", i)
+			}
+		default:
+			return fmt.Errorf("config.tolerations[%d]: operator must be Equal or Exists", i)
+		}
+		switch t.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("config.tolerations[%d]: unknown effect %q", i, t.Effect)
+		}
+		if t.Key == "" && t.Operator != corev1.TolerationOpExists {
+			return fmt.Errorf("config.tolerations[%d]: an empty key requires operator Exists", i)
+		}
+	}
+
+	if r := c.Resources; r != nil {
+		for name, limit := range r.Limits {
+			if request, ok := r.Requests[name]; ok && request.Cmp(limit) > 0 {
+				return fmt.Errorf("config.resources: %s request %s exceeds limit %s", name, request.String(), limit.String())
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(c.Env))
+	for i, env := range c.Env {
+		if !envVarNameRe.MatchString(env.Name) {
+			return fmt.Errorf("config.env[%d]: invalid name %q", i, env.Name)
+		}
+		if seen[env.Name] {
+			return fmt.Errorf("config.env[%d]: duplicate name %q", i, env.Name)
+		}
+		seen[env.Name] = true
+	}
+	return nil
+}
+
+// resolveInstallOptions fills in the source, catalog and channel the install
+// will use. With no source given the Red Hat catalog is preferred when it
+// ships the operator, as before options existed, unless a community catalog
+// image was given. Clusters without OLM use the bundled manifests.
+func resolveInstallOptions(ctx context.Context, client *k8s.Client, coRef string, opts InstallOptions) (InstallOptions, error) {
+	resolved := opts
+	if opts.Source != InstallSourceManifests {
+		// Assume OLM when its presence cannot be checked, as before.
+		if olm, err := OLMInstalled(ctx, client); err == nil && !olm {
+			if opts.Source != "" {
+				return resolved, fmt.Errorf("the cluster has no OLM; leave the source empty or use %q to install from the bundled manifests", InstallSourceManifests)
+			}
+			resolved.Source = InstallSourceManifests
+			resolved.CatalogImage = ""
+		}
+	}
+	if resolved.Source == InstallSourceManifests {
+		return resolved, checkManifestRef(coRef)
+	}
+	if resolved.Approval == "" {
+		resolved.Approval = ApprovalAutomatic
+	}
+
+	switch opts.Source {
+	case "":
+		useRedHat := false
+		if opts.CatalogImage == "" {
+			useRedHat, _ = CheckRedHatOperator(ctx, client)
+		}
+		if useRedHat {
+			resolved.Source = InstallSourceRedHat
+		} else {
+			resolved.Source = InstallSourceCommunity
+		}
+	case InstallSourceRedHat:
+		if ok, _ := CheckRedHatOperator(ctx, client); !ok {
+			return resolved, fmt.Errorf("the %s catalog does not provide %s", redHatCatalog, operatorName)
+		}
+	}
+
+	switch resolved.Source {
+	case InstallSourceRedHat:
+		resolved.CatalogSource = redHatCatalog
+		resolved.CatalogSourceNamespace = marketplaceNS
+		if resolved.Channel == "" {
+			resolved.Channel = redHatDefaultChannel
+		}
+	case InstallSourceCommunity:
+		resolved.CatalogSource = operatorName
+		resolved.CatalogSourceNamespace = marketplaceNS
+		if resolved.Channel == "" {
+			resolved.Channel = communityDefaultChannel
+		}
+		if resolved.CatalogImage == "" {
+			if coRef == "" {
+				return resolved, fmt.Errorf("a community install needs --co-ref or a catalog image")
+			}
+			resolved.CatalogImage = fmt.Sprintf("%s:%s", communityCatalogImage, coRef)
+		}
+		if resolved.Disconnected {
+			rules, err := ListMirrorRules(ctx, client)
+			if err != nil {
+				return resolved, err
+			}
+			resolved.CatalogImage = MirrorImage(resolved.CatalogImage, resolved.MirrorRegistry, rules)
+		}
+	case InstallSourceCustom:
+		if resolved.CatalogSourceNamespace == "" {
+			resolved.CatalogSourceNamespace = marketplaceNS
+		}
+		if _, err := client.Dynamic.Resource(catalogSourceGVR).Namespace(resolved.CatalogSourceNamespace).
+			Get(ctx, resolved.CatalogSource, metav1.GetOptions{}); err != nil {
+			return resolved, fmt.Errorf("CatalogSource %s/%s: %w", resolved.CatalogSourceNamespace, resolved.CatalogSource, err)
+		}
+		if resolved.Channel == "" {
+			pm, err := getPackageManifest(ctx, client, operatorName, resolved.CatalogSource, resolved.CatalogSourceNamespace)
+			if err != nil {
+				return resolved, fmt.Errorf("no channel given and the default channel is unknown: %w", err)
+			}
+			resolved.Channel, _, _ = unstructured.NestedString(pm.Object, "status", "defaultChannel")
+			if resolved.Channel == "" {
+				return resolved, fmt.Errorf("no channel given and %s has no default channel", resolved.CatalogSource)
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// buildSubscription builds the operator's Subscription from resolved options.
+func buildSubscription(namespace string, opts InstallOptions) (*unstructured.Unstructured, error) {
+	spec := map[string]interface{}{
+		"channel":             opts.Channel,
+		"installPlanApproval": opts.Approval,
+		"name":                operatorName,
+		"source":              opts.CatalogSource,
+		"sourceNamespace":     opts.CatalogSourceNamespace,
+	}
+	if opts.StartingCSV != "" {
+		spec["startingCSV"] = opts.StartingCSV
+	}
+	if opts.Config != nil {
+		// Round-trip through JSON so the typed tolerations, quantities and
+		// env vars become the plain values the dynamic client requires.
+		data, err := json.Marshal(opts.Config)
+		if err != nil {
+			return nil, fmt.Errorf("encoding Subscription config: %w", err)
+		}
+		var config map[string]interface{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("encoding Subscription config: %w", err)
+		}
+		if len(config) > 0 {
+			spec["config"] = config
+		}
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "operators.coreos.com/v1alpha1",
+			"kind":       "Subscription",
+			"metadata": map[string]interface{}{
+				"name":      subscriptionName,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		},
+	}, nil
+}
@@ -0,0 +1,220 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestInstallOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    InstallOptions
+		wantErr string
+	}{
+		{name: "defaults", opts: InstallOptions{}},
+		{
+			name: "custom catalog with config",
+			opts: InstallOptions{
+				Source:        InstallSourceCustom,
+				CatalogSource: "my-catalog",
+				Channel:       "stable",
+				StartingCSV:   "compliance-operator.v1.6.0",
+				Approval:      ApprovalManual,
+				Config: &SubscriptionConfig{
+					NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+					Tolerations:  []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+					Env:          []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}, {Name: "NO_PROXY", Value: ".cluster.local"}},
+				},
+			},
+		},
+		{name: "unknown source", opts: InstallOptions{Source: "quay"}, wantErr: "source must be"},
+		{name: "custom without catalog", opts: InstallOptions{Source: InstallSourceCustom}, wantErr: "requires catalog_source"},
+		{name: "catalog without custom source", opts: InstallOptions{Source: InstallSourceRedHat, CatalogSource: "x"}, wantErr: "requires source"},
+		{name: "disconnected with mirror", opts: InstallOptions{Disconnected: true, MirrorRegistry: "mirror.lab:5000/ocp", CatalogImage: "mirror.lab:5000/ocp/catalog:v1.7.0"}},
+		{name: "mirror registry without disconnected", opts: InstallOptions{MirrorRegistry: "mirror.lab:5000"}, wantErr: "requires disconnected"},
+		{name: "mirror registry with scheme", opts: InstallOptions{Disconnected: true, MirrorRegistry: "https://mirror.lab"}, wantErr: "mirror_registry must be"},
+		{name: "catalog image with red hat source", opts: InstallOptions{Source: InstallSourceRedHat, CatalogImage: "x/catalog:1"}, wantErr: "catalog_image requires"},
+		{name: "bad approval", opts: InstallOptions{Approval: "manual"}, wantErr: "approval must be"},
+		{name: "foreign starting CSV", opts: InstallOptions{StartingCSV: "file-integrity-operator.v1.0.0"}, wantErr: "starting_csv"},
+		{
+			name:    "exists toleration with value",
+			opts:    InstallOptions{Config: &SubscriptionConfig{Tolerations: []corev1.Toleration{{Key: "k", Operator: corev1.TolerationOpExists, Value: "v"}}}},
+			wantErr: "value must be empty",
+		},
+		{
+			name:    "unknown toleration effect",
+			opts:    InstallOptions{Config: &SubscriptionConfig{Tolerations: []corev1.Toleration{{Key: "k", Effect: "Evict"}}}},
+			wantErr: "unknown effect",
+		},
+		{
+			name: "request above limit",
+			opts: InstallOptions{Config: &SubscriptionConfig{Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			}}},
+			wantErr: "exceeds limit",
+		},
+		{
+			name:    "duplicate env var",
+			opts:    InstallOptions{Config: &SubscriptionConfig{Env: []corev1.EnvVar{{Name: "HTTP_PROXY"}, {Name: "HTTP_PROXY"}}}},
+			wantErr: "duplicate name",
+		},
+		{
+			name:    "invalid env var name",
+			opts:    InstallOptions{Config: &SubscriptionConfig{Env: []corev1.EnvVar{{Name: "1PROXY"}}}},
+			wantErr: "invalid name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if !strings.HasPrefix(err.Error(), "invalid install options") {
+				t.Errorf("error %q should start with \"invalid install options\"", err)
+			}
+		})
+	}
+}
+
+func TestSubscribeOperator_ExistingSubscription(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	opts := InstallOptions{CatalogSource: "redhat-operators", CatalogSourceNamespace: marketplaceNS, Channel: "stable", Approval: ApprovalAutomatic}
+
+	client := newTestClient()
+	if err := subscribeOperator(ctx, client, ns, opts); err != nil {
+		t.Fatalf("subscribeOperator: %v", err)
+	}
+	// Re-running with the same options accepts what it created.
+	if err := subscribeOperator(ctx, client, ns, opts); err != nil {
+		t.Fatalf("subscribeOperator with an identical Subscription: %v", err)
+	}
+
+	manual := opts
+	manual.Channel = "release-0.1"
+	manual.Approval = ApprovalManual
+	err := subscribeOperator(ctx, client, ns, manual)
+	if err == nil {
+		t.Fatal("expected an error for an existing Subscription with other options")
+	}
+	for _, want := range []string{"already exists", `spec.channel is "stable", install wants "release-0.1"`, "spec.installPlanApproval"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	got, _ := client.Dynamic.Resource(subscriptionGVR).Namespace(ns).Get(ctx, subscriptionName, metav1.GetOptions{})
+	if info := subscriptionInfo(got); info.Channel != "stable" || info.Approval != ApprovalAutomatic {
+		t.Errorf("existing Subscription was changed: %+v", info)
+	}
+}
+
+func TestResolveInstallOptions(t *testing.T) {
+	ctx := context.Background()
+	catalog := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "CatalogSource",
+		"metadata":   map[string]any{"name": "mirror", "namespace": marketplaceNS},
+	}}
+	client := newTestClient(catalog, newPackageManifest("mirror"))
+
+	t.Run("no source falls back to community", func(t *testing.T) {
+		got, err := resolveInstallOptions(ctx, client, "v1.7.0", InstallOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Source != InstallSourceCommunity || got.CatalogSource != operatorName ||
+			got.Channel != communityDefaultChannel || got.Approval != ApprovalAutomatic ||
+			got.CatalogImage != communityCatalogImage+":v1.7.0" {
+			t.Errorf("unexpected resolved options: %+v", got)
+		}
+	})
+
+	t.Run("red hat source must be available", func(t *testing.T) {
+		if _, err := resolveInstallOptions(ctx, client, "v1.7.0", InstallOptions{Source: InstallSourceRedHat}); err == nil {
+			t.Fatal("expected an error when the Red Hat catalog does not provide the operator")
+		}
+	})
+
+	t.Run("custom source uses the package default channel", func(t *testing.T) {
+		got, err := resolveInstallOptions(ctx, client, "v1.7.0", InstallOptions{Source: InstallSourceCustom, CatalogSource: "mirror", Approval: ApprovalManual})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.CatalogSourceNamespace != marketplaceNS || got.Channel != "stable" || got.Approval != ApprovalManual {
+			t.Errorf("unexpected resolved options: %+v", got)
+		}
+	})
+
+	t.Run("custom source must exist", func(t *testing.T) {
+		_, err := resolveInstallOptions(ctx, client, "v1.7.0", InstallOptions{Source: InstallSourceCustom, CatalogSource: "missing", Channel: "stable"})
+		if err == nil || !strings.Contains(err.Error(), "CatalogSource") {
+			t.Fatalf("expected missing CatalogSource error, got %v", err)
+		}
+	})
+}
+
+func TestBuildSubscription(t *testing.T) {
+	opts := InstallOptions{
+		CatalogSource:          "mirror",
+		CatalogSourceNamespace: marketplaceNS,
+		Channel:                "stable",
+		StartingCSV:            "compliance-operator.v1.6.0",
+		Approval:               ApprovalManual,
+		Config: &SubscriptionConfig{
+			NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+			Tolerations:  []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}},
+			Resources: &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+			Env: []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}},
+		},
+	}
+	sub, err := buildSubscription("openshift-compliance", opts)
+	if err != nil {
+		t.Fatalf("buildSubscription: %v", err)
+	}
+
+	info := subscriptionInfo(sub)
+	if info.Source != "mirror" || info.Channel != "stable" || info.Approval != ApprovalManual || info.StartingCSV != opts.StartingCSV {
+		t.Errorf("unexpected subscription spec: %+v", info)
+	}
+	if v, _, _ := unstructured.NestedString(sub.Object, "spec", "config", "resources", "limits", "memory"); v != "512Mi" {
+		t.Errorf("memory limit = %q, want 512Mi", v)
+	}
+	env, _, _ := unstructured.NestedSlice(sub.Object, "spec", "config", "env")
+	if len(env) != 1 || env[0].(map[string]any)["name"] != "HTTPS_PROXY" {
+		t.Errorf("unexpected env: %v", env)
+	}
+	if _, found, _ := unstructured.NestedStringMap(sub.Object, "spec", "config", "nodeSelector"); !found {
+		t.Error("expected spec.config.nodeSelector")
+	}
+	// The object must be encodable by the dynamic client.
+	if _, err := sub.MarshalJSON(); err != nil {
+		t.Errorf("subscription does not encode: %v", err)
+	}
+
+	plain, err := buildSubscription("openshift-compliance", InstallOptions{CatalogSource: "mirror", Channel: "stable", Approval: ApprovalAutomatic})
+	if err != nil {
+		t.Fatalf("buildSubscription: %v", err)
+	}
+	if _, found, _ := unstructured.NestedMap(plain.Object, "spec", "config"); found {
+		t.Error("expected no spec.config without options")
+	}
+	if _, found, _ := unstructured.NestedString(plain.Object, "spec", "startingCSV"); found {
+		t.Error("expected no spec.startingCSV without options")
+	}
+}
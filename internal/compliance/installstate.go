@@ -0,0 +1,432 @@
+package compliance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	installStateConfigMap = "compliance-dashboard-install"
+	installStateKey       = "state.json"
+	// maxStepLog bounds the messages kept per step so the ConfigMap stays
+	// well under its size limit.
+	maxStepLog = 50
+
+	// installHeartbeatInterval is how often a running install or uninstall
+	// refreshes its heartbeat. A Running state whose heartbeat is older
+	// than installHeartbeatTimeout is no longer being executed.
+	installHeartbeatInterval = 15 * time.Second
+	installHeartbeatTimeout  = time.Minute
+)
+
+// errStepSkipped is returned by a step that does not apply to this run.
+var errStepSkipped = errors.New("step skipped")
+
+// errInstallRunning is returned when another process holds the install
+// state.
+var errInstallRunning = errors.New("another install or uninstall is already running")
+
+// stepError is a step failure whose message is shown to the user as is.
+type stepError string
+
+func (e stepError) Error() string { return string(e) }
+
+func stepFailed(format string, args ...any) error {
+	return stepError(fmt.Sprintf(format, args...))
+}
+
+// installStep is one step of an install or uninstall. A resumed run repeats
+// the step that did not finish, so run must be safe to call again.
+type installStep struct {
+	name  string
+	title string
+	run   func(ctx context.Context, r *installRun) error
+}
+
+// installRun executes steps, sending progress to the channel and recording
+// it in the persisted state.
+type installRun struct {
+	client         *k8s.Client
+	images         ImageChecker
+	stateNamespace string
+	state          *InstallState
+	step           *InstallStepStatus
+	ps             progressSender
+	// holder identifies this run in the persisted state.
+	holder string
+}
+
+// newInstallHolder returns an identity for one run: the host, which is the
+// pod name in a cluster, and a random suffix.
+func newInstallHolder() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
+
+// live reports whether the state records a run some dashboard process is
+// still executing: Running, with a recent heartbeat.
+func (s *InstallState) live(now time.Time) bool {
+	return s.Phase == InstallRunning && s.HeartbeatAt != nil && now.Sub(*s.HeartbeatAt) < installHeartbeatTimeout
+}
+
+func newInstallState(op InstallOperation, namespace, coRef string, opts InstallOptions, steps []installStep) *InstallState {
+	now := time.Now().UTC()
+	state := &InstallState{
+		Operation: op,
+		Phase:     InstallRunning,
+		Namespace: namespace,
+		CORef:     coRef,
+		Options:   opts,
+		Attempt:   1,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	for _, s := range steps {
+		state.Steps = append(state.Steps, InstallStepStatus{
+			Name:  s.name,
+			Title: s.title,
+			State: StepPending,
+			Log:   []InstallLogEntry{},
+		})
+	}
+	return state
+}
+
+// GetInstallState returns the step log of the most recent install or
+// uninstall, or nil when none has been recorded. A run still marked Running
+// whose holder has stopped sending heartbeats is reported as Interrupted.
+func GetInstallState(ctx context.Context, client *k8s.Client, stateNamespace string) (*InstallState, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	cm, err := client.Clientset.CoreV1().ConfigMaps(stateNamespace).Get(ctx, installStateConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting install state: %w", err)
+	}
+	raw := cm.Data[installStateKey]
+	if raw == "" {
+		return nil, nil
+	}
+	state := &InstallState{}
+	if err := json.Unmarshal([]byte(raw), state); err != nil {
+		return nil, fmt.Errorf("decoding install state: %w", err)
+	}
+
+	if state.Phase == InstallRunning && !state.live(time.Now()) {
+		state.Phase = InstallInterrupted
+		for _, s := range state.Steps {
+			if s.State == StepRunning {
+				state.FailedStep = s.Name
+				break
+			}
+		}
+	}
+	state.Resumable = state.Phase == InstallFailed || state.Phase == InstallInterrupted
+	return state, nil
+}
+
+// saveInstallState writes the state, creating the ConfigMap on first use.
+func saveInstallState(ctx context.Context, client *k8s.Client, stateNamespace string, state *InstallState) error {
+	return updateInstallState(ctx, client, stateNamespace, func(*InstallState) (*InstallState, error) {
+		return state, nil
+	})
+}
+
+// updateInstallState replaces the recorded state with the one update
+// returns, creating the ConfigMap on first use. update is given the
+// recorded state, or nil, and is called again if the ConfigMap changes
+// before the write; returning nil leaves the record unchanged.
+func updateInstallState(ctx context.Context, client *k8s.Client, stateNamespace string, update func(current *InstallState) (*InstallState, error)) error {
+	configMaps := client.Clientset.CoreV1().ConfigMaps(stateNamespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(ctx, installStateConfigMap, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			cm = nil
+		} else if err != nil {
+			return fmt.Errorf("getting install state: %w", err)
+		}
+		var current *InstallState
+		if cm != nil && cm.Data[installStateKey] != "" {
+			current = &InstallState{}
+			if err := json.Unmarshal([]byte(cm.Data[installStateKey]), current); err != nil {
+				// An unreadable record is replaced.
+				current = nil
+			}
+		}
+
+		state, err := update(current)
+		if err != nil || state == nil {
+			return err
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("encoding install state: %w", err)
+		}
+
+		if cm == nil {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: installStateConfigMap, Namespace: stateNamespace},
+				Data:       map[string]string{installStateKey: string(data)},
+			}
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), installStateConfigMap, err)
+			}
+			return err
+		}
+		cm.Data = map[string]string{installStateKey: string(data)}
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// save persists the state with a fresh heartbeat. Failures are logged
+// rather than failing the run: the cluster changes matter more than their
+// record. The write outlives ctx so a canceled run still records where it
+// stopped. A record taken over by another run is not overwritten.
+func (r *installRun) save(ctx context.Context) {
+	now := time.Now().UTC()
+	r.state.UpdatedAt = now
+	r.state.HeartbeatAt = &now
+	err := updateInstallState(context.WithoutCancel(ctx), r.client, r.stateNamespace, func(current *InstallState) (*InstallState, error) {
+		if current != nil && current.Holder != r.holder && current.live(now) {
+			return nil, fmt.Errorf("the record is held by %s", current.Holder)
+		}
+		return r.state, nil
+	})
+	if err != nil {
+		slog.Warn("failed to record install state", "operation", r.state.Operation, "error", err)
+	}
+}
+
+// acquire records the run as Running under its holder, unless another
+// process is executing a run.
+func (r *installRun) acquire(ctx context.Context) error {
+	r.holder = newInstallHolder()
+	now := time.Now().UTC()
+	r.state.Holder = r.holder
+	r.state.UpdatedAt = now
+	r.state.HeartbeatAt = &now
+	return updateInstallState(ctx, r.client, r.stateNamespace, func(current *InstallState) (*InstallState, error) {
+		if current != nil && current.live(time.Now()) {
+			return nil, errInstallRunning
+		}
+		return r.state, nil
+	})
+}
+
+// heartbeat refreshes the recorded heartbeat until stop is closed, so the
+// run is still seen as executing during long waits without progress.
+func (r *installRun) heartbeat(ctx context.Context, stop <-chan struct{}) {
+	ticker := time.NewTicker(installHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := updateInstallState(ctx, r.client, r.stateNamespace, func(current *InstallState) (*InstallState, error) {
+			if current == nil || current.Holder != r.holder || current.Phase != InstallRunning {
+				return nil, nil
+			}
+			now := time.Now().UTC()
+			current.HeartbeatAt = &now
+			return current, nil
+		})
+		if err != nil {
+			slog.Warn("failed to refresh install heartbeat", "operation", r.state.Operation, "error", err)
+		}
+	}
+}
+
+// record appends a message to the current step's log, dropping the oldest
+// entries past maxStepLog.
+func (r *installRun) record(ctx context.Context, message string, isError bool) {
+	r.step.Log = append(r.step.Log, InstallLogEntry{Time: time.Now().UTC(), Message: message, Error: isError})
+	if n := len(r.step.Log); n > maxStepLog {
+		r.step.Log = r.step.Log[n-maxStepLog:]
+	}
+	r.save(ctx)
+}
+
+func (r *installRun) send(ctx context.Context, message string) {
+	r.record(ctx, message, false)
+	r.ps.send(r.step.Name, message)
+}
+
+func (r *installRun) sendOptions(ctx context.Context, message string, opts InstallOptions) {
+	r.record(ctx, message, false)
+	r.ps.sendOptions(r.step.Name, message, opts)
+}
+
+// waitReporter returns a report func for waitForPhase that sends the wait
+// progress of the current step, prefixed with what is being waited for.
+func (r *installRun) waitReporter(ctx context.Context, what string) func(WaitProgress) {
+	return func(w WaitProgress) {
+		message := fmt.Sprintf("%s: %d%% (%s of %s)", what, w.Percent,
+			time.Duration(w.ElapsedSeconds)*time.Second, time.Duration(w.TimeoutSeconds)*time.Second)
+		if w.Blocking != "" {
+			message += " - " + w.Blocking
+		}
+		r.record(ctx, message, false)
+		r.ps.sendWait(r.step.Name, message, w)
+	}
+}
+
+// execute runs every step that has not already succeeded or been skipped,
+// stopping at the first failure.
+func (r *installRun) execute(ctx context.Context, steps []installStep, doneMessage string) {
+	if err := r.acquire(ctx); errors.Is(err, errInstallRunning) {
+		r.ps.sendError("init", "Another install or uninstall is already running")
+		return
+	} else if err != nil {
+		r.ps.sendError("init", fmt.Sprintf("Could not record the %s state: %v", r.state.Operation, err))
+		return
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.heartbeat(ctx, stop)
+
+	for i, step := range steps {
+		r.step = &r.state.Steps[i]
+		if r.step.State == StepSucceeded || r.step.State == StepSkipped {
+			continue
+		}
+		started := time.Now().UTC()
+		r.step.State = StepRunning
+		r.step.Error = ""
+		r.step.Attempts++
+		r.step.StartedAt = &started
+		r.step.FinishedAt = nil
+		r.save(ctx)
+
+		err := step.run(ctx, r)
+		finished := time.Now().UTC()
+		r.step.FinishedAt = &finished
+		switch {
+		case errors.Is(err, errStepSkipped):
+			r.step.State = StepSkipped
+			r.save(ctx)
+		case err != nil:
+			r.step.State = StepFailed
+			r.step.Error = err.Error()
+			r.state.Phase = InstallFailed
+			r.state.FailedStep = step.name
+			r.state.Error = err.Error()
+			r.state.FinishedAt = &finished
+			r.record(ctx, err.Error(), true)
+			r.ps.sendError(step.name, err.Error())
+			return
+		default:
+			r.step.State = StepSucceeded
+			r.save(ctx)
+		}
+	}
+
+	finished := time.Now().UTC()
+	r.state.Phase = InstallSucceeded
+	r.state.FinishedAt = &finished
+	r.save(ctx)
+	r.ps.sendDone("complete", doneMessage)
+}
+
+// resume resets the unfinished steps of a failed or interrupted run and
+// executes it again from the first of them.
+func (r *installRun) resume(ctx context.Context, steps []installStep, doneMessage string) error {
+	if len(r.state.Steps) != len(steps) {
+		return fmt.Errorf("the recorded %s steps do not match this version of the dashboard; start a new %s", r.state.Operation, r.state.Operation)
+	}
+	from := ""
+	for i := range r.state.Steps {
+		s := &r.state.Steps[i]
+		if s.Name != steps[i].name {
+			return fmt.Errorf("the recorded %s steps do not match this version of the dashboard; start a new %s", r.state.Operation, r.state.Operation)
+		}
+		if s.State == StepFailed || s.State == StepRunning {
+			s.State = StepPending
+		}
+		if s.State == StepPending && from == "" {
+			from = s.Name
+		}
+	}
+
+	r.state.Phase = InstallRunning
+	r.state.Resumable = false
+	r.state.Attempt++
+	r.state.FailedStep = ""
+	r.state.Error = ""
+	r.state.FinishedAt = nil
+	r.ps.send(from, fmt.Sprintf("Resuming %s at step %s (attempt %d)", r.state.Operation, from, r.state.Attempt))
+	r.execute(ctx, steps, doneMessage)
+	return nil
+}
+
+// ResumeInstall re-runs the most recent failed or interrupted install or
+// uninstall from the step that did not finish. Non-nil timeouts replace the
+// ones the run started with. It sends progress updates to the provided
+// channel.
+func ResumeInstall(ctx context.Context, client *k8s.Client, images ImageChecker, stateNamespace string, timeouts *InstallTimeouts, progress chan<- InstallProgress) {
+	defer close(progress)
+
+	ps := newProgressSender(progress)
+
+	if client == nil {
+		ps.sendError("init", "Kubernetes client is not connected")
+		return
+	}
+	state, err := GetInstallState(ctx, client, stateNamespace)
+	if err != nil {
+		ps.sendError("init", err.Error())
+		return
+	}
+	if state == nil {
+		ps.sendError("init", "No install or uninstall has been recorded")
+		return
+	}
+	if !state.Resumable {
+		ps.sendError("init", fmt.Sprintf("The last %s is %s and cannot be resumed", state.Operation, strings.ToLower(string(state.Phase))))
+		return
+	}
+
+	if timeouts != nil {
+		state.Options.Timeouts = timeouts
+		if state.Resolved != nil {
+			state.Resolved.Timeouts = timeouts
+		}
+	}
+
+	r := &installRun{client: client, images: images, stateNamespace: stateNamespace, state: state, ps: ps}
+	var runErr error
+	switch state.Operation {
+	case OperationUninstall:
+		runErr = r.resume(ctx, uninstallSteps, uninstallDoneMessage)
+	default:
+		runErr = r.resume(ctx, installSteps, installDoneMessage)
+	}
+	if runErr != nil {
+		ps.sendError("init", runErr.Error())
+	}
+}
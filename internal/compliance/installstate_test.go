@@ -0,0 +1,203 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstallRecordsFailedStep(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+
+	// No marketplace namespace, so the preflight step fails.
+	progress := make(chan InstallProgress, 64)
+	Install(ctx, client, ImageChecker{}, "openshift-compliance", "dashboard", "v1.7.0", InstallOptions{}, progress)
+	var last InstallProgress
+	for p := range progress {
+		last = p
+	}
+	if last.Step != "preflight" || last.Error == "" || !last.Done {
+		t.Fatalf("expected a preflight failure, got %+v", last)
+	}
+
+	state, err := GetInstallState(ctx, client, "dashboard")
+	if err != nil {
+		t.Fatalf("GetInstallState: %v", err)
+	}
+	if state == nil || state.Operation != OperationInstall || state.Phase != InstallFailed {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	if state.FailedStep != "preflight" || !state.Resumable || !strings.Contains(state.Error, "Marketplace health: ") {
+		t.Errorf("failure not recorded: step %q, resumable %v, error %q", state.FailedStep, state.Resumable, state.Error)
+	}
+	if len(state.Steps) != len(installSteps) || state.Steps[0].State != StepSucceeded || state.Steps[1].State != StepFailed || state.Steps[2].State != StepPending {
+		t.Errorf("unexpected steps: %+v", state.Steps)
+	}
+	if log := state.Steps[1].Log; len(log) < 2 || !log[len(log)-1].Error {
+		t.Errorf("expected the check and its failure in the step log, got %+v", log)
+	}
+}
+
+func TestInstallRunResume(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+	stateNS := "dashboard"
+
+	calls := map[string]int{}
+	steps := []installStep{
+		{name: "first", title: "First", run: func(ctx context.Context, r *installRun) error {
+			calls["first"]++
+			r.send(ctx, "first done")
+			return nil
+		}},
+		{name: "second", title: "Second", run: func(ctx context.Context, r *installRun) error {
+			calls["second"]++
+			if calls["second"] == 1 {
+				return stepFailed("Second step failed")
+			}
+			return nil
+		}},
+		{name: "optional", title: "Optional", run: func(ctx context.Context, r *installRun) error {
+			calls["optional"]++
+			return errStepSkipped
+		}},
+	}
+
+	progress := make(chan InstallProgress, 64)
+	r := &installRun{
+		client:         client,
+		stateNamespace: stateNS,
+		state:          newInstallState(OperationInstall, "openshift-compliance", "v1.7.0", InstallOptions{}, steps),
+		ps:             newProgressSender(progress),
+	}
+	r.execute(ctx, steps, "done")
+
+	state, err := GetInstallState(ctx, client, stateNS)
+	if err != nil {
+		t.Fatalf("GetInstallState: %v", err)
+	}
+	if state.Phase != InstallFailed || state.FailedStep != "second" || !state.Resumable {
+		t.Fatalf("expected a resumable failure at second, got phase %s at %q", state.Phase, state.FailedStep)
+	}
+	if state.Steps[0].State != StepSucceeded || state.Steps[0].Log[0].Message != "first done" {
+		t.Errorf("first step not recorded: %+v", state.Steps[0])
+	}
+	if state.Steps[1].State != StepFailed || state.Steps[1].Error != "Second step failed" || state.Steps[2].State != StepPending {
+		t.Errorf("unexpected steps after failure: %+v", state.Steps)
+	}
+
+	r = &installRun{client: client, stateNamespace: stateNS, state: state, ps: newProgressSender(progress)}
+	if err := r.resume(ctx, steps[:2], "done"); err == nil {
+		t.Error("expected resuming with different steps to fail")
+	}
+	if err := r.resume(ctx, steps, "done"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+
+	state, err = GetInstallState(ctx, client, stateNS)
+	if err != nil {
+		t.Fatalf("GetInstallState: %v", err)
+	}
+	if state.Phase != InstallSucceeded || state.Attempt != 2 || state.Resumable {
+		t.Errorf("expected a finished second attempt, got phase %s, attempt %d", state.Phase, state.Attempt)
+	}
+	if calls["first"] != 1 || calls["second"] != 2 || calls["optional"] != 1 {
+		t.Errorf("expected only unfinished steps to run again, got %v", calls)
+	}
+	if state.Steps[1].Attempts != 2 || state.Steps[1].Error != "" || state.Steps[2].State != StepSkipped {
+		t.Errorf("unexpected steps after resume: %+v", state.Steps)
+	}
+}
+
+func TestGetInstallStateInterrupted(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+
+	state := newInstallState(OperationUninstall, "openshift-compliance", "", InstallOptions{}, uninstallSteps)
+	state.Steps[0].State = StepSkipped
+	state.Steps[1].State = StepSucceeded
+	state.Steps[2].State = StepRunning
+	if err := saveInstallState(ctx, client, "dashboard", state); err != nil {
+		t.Fatalf("saveInstallState: %v", err)
+	}
+
+	got, err := GetInstallState(ctx, client, "dashboard")
+	if err != nil {
+		t.Fatalf("GetInstallState: %v", err)
+	}
+	if got.Phase != InstallInterrupted || got.FailedStep != "subscription" || !got.Resumable {
+		t.Errorf("expected an interrupted uninstall at subscription, got phase %s at %q", got.Phase, got.FailedStep)
+	}
+
+	if none, err := GetInstallState(ctx, client, "elsewhere"); err != nil || none != nil {
+		t.Errorf("expected no state in an unused namespace, got %+v, %v", none, err)
+	}
+}
+
+func TestInstallStateHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+	stateNS := "dashboard"
+
+	calls := 0
+	steps := []installStep{{name: "only", title: "Only", run: func(ctx context.Context, r *installRun) error {
+		calls++
+		return nil
+	}}}
+
+	// Another dashboard replica is executing a run and heartbeating.
+	state := newInstallState(OperationInstall, "openshift-compliance", "v1.7.0", InstallOptions{}, steps)
+	state.Steps[0].State = StepRunning
+	state.Holder = "replica-b-1234"
+	beat := time.Now().UTC()
+	state.HeartbeatAt = &beat
+	if err := saveInstallState(ctx, client, stateNS, state); err != nil {
+		t.Fatalf("saveInstallState: %v", err)
+	}
+
+	got, err := GetInstallState(ctx, client, stateNS)
+	if err != nil {
+		t.Fatalf("GetInstallState: %v", err)
+	}
+	if got.Phase != InstallRunning || got.Resumable {
+		t.Errorf("expected a live run to be reported as running, got phase %s", got.Phase)
+	}
+
+	progress := make(chan InstallProgress, 64)
+	r := &installRun{client: client, stateNamespace: stateNS, state: newInstallState(OperationUninstall, "openshift-compliance", "", InstallOptions{}, steps), ps: newProgressSender(progress)}
+	r.execute(ctx, steps, "done")
+	close(progress)
+	if last := drainProgress(progress); !strings.Contains(last.Error, "already running") {
+		t.Errorf("expected the live run to block another, got %+v", last)
+	}
+	if got, _ := GetInstallState(ctx, client, stateNS); got.Holder != "replica-b-1234" || got.Operation != OperationInstall || calls != 0 {
+		t.Errorf("the live run's record was replaced: %+v", got)
+	}
+
+	// The replica stopped heartbeating, so its run was interrupted and can
+	// be resumed here.
+	stale := beat.Add(-2 * installHeartbeatTimeout)
+	state.HeartbeatAt = &stale
+	if err := saveInstallState(ctx, client, stateNS, state); err != nil {
+		t.Fatalf("saveInstallState: %v", err)
+	}
+	got, err = GetInstallState(ctx, client, stateNS)
+	if err != nil {
+		t.Fatalf("GetInstallState: %v", err)
+	}
+	if got.Phase != InstallInterrupted || got.FailedStep != "only" || !got.Resumable {
+		t.Fatalf("expected a stale run to be interrupted, got phase %s at %q", got.Phase, got.FailedStep)
+	}
+
+	progress = make(chan InstallProgress, 64)
+	r = &installRun{client: client, stateNamespace: stateNS, state: got, ps: newProgressSender(progress)}
+	if err := r.resume(ctx, steps, "done"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	got, _ = GetInstallState(ctx, client, stateNS)
+	if got.Phase != InstallSucceeded || calls != 1 || got.Holder == "" || got.Holder == "replica-b-1234" {
+		t.Errorf("expected this process to take over and finish the run, got phase %s, holder %q, %d calls", got.Phase, got.Holder, calls)
+	}
+}
@@ -0,0 +1,306 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	// DefaultPhaseTimeout is how long an install waits for each phase unless
+	// InstallTimeouts says otherwise.
+	DefaultPhaseTimeout = 5 * time.Minute
+	// maxPhaseTimeout caps a configured phase timeout.
+	maxPhaseTimeout = 6 * time.Hour
+	// waitReportInterval is how often an unchanged wait is reported again.
+	waitReportInterval = time.Minute
+)
+
+// installPollInterval is how often an install phase is checked.
+var installPollInterval = 10 * time.Second
+
+// csvPhasePercent estimates install progress from the CSV phase.
+var csvPhasePercent = map[string]int{
+	"Pending":      20,
+	"InstallReady": 40,
+	"Installing":   60,
+	"Succeeded":    100,
+}
+
+func phaseTimeout(seconds int64) time.Duration {
+	if seconds <= 0 {
+		return DefaultPhaseTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// CSV returns the timeout for the ClusterServiceVersion to succeed.
+func (t *InstallTimeouts) CSV() time.Duration {
+	if t == nil {
+		return DefaultPhaseTimeout
+	}
+	return phaseTimeout(t.CSVSeconds)
+}
+
+// Pods returns the timeout for the operator pods to become ready.
+func (t *InstallTimeouts) Pods() time.Duration {
+	if t == nil {
+		return DefaultPhaseTimeout
+	}
+	return phaseTimeout(t.PodsSeconds)
+}
+
+// ProfileBundles returns the timeout for the ProfileBundles to be created
+// and become VALID.
+func (t *InstallTimeouts) ProfileBundles() time.Duration {
+	if t == nil {
+		return DefaultPhaseTimeout
+	}
+	return phaseTimeout(t.ProfileBundlesSeconds)
+}
+
+func (t *InstallTimeouts) validate() error {
+	for _, f := range []struct {
+		name    string
+		seconds int64
+	}{
+		{"csv_seconds", t.CSVSeconds},
+		{"pods_seconds", t.PodsSeconds},
+		{"profile_bundles_seconds", t.ProfileBundlesSeconds},
+	} {
+		if f.seconds < 0 {
+			return fmt.Errorf("timeouts.%s must not be negative", f.name)
+		}
+		if time.Duration(f.seconds)*time.Second > maxPhaseTimeout {
+			return fmt.Errorf("timeouts.%s must be at most %d", f.name, int64(maxPhaseTimeout.Seconds()))
+		}
+	}
+	return nil
+}
+
+// phaseCheck is one observation of an install phase.
+type phaseCheck struct {
+	done     bool
+	percent  int
+	blocking string
+}
+
+// waitForPhase checks the phase every installPollInterval until it is done
+// or timeout passes. report is called with the first observation, whenever
+// the percent or blocking reason changes and at least every
+// waitReportInterval otherwise. The timeout error names what was blocking.
+func waitForPhase(ctx context.Context, timeout time.Duration, report func(WaitProgress), check func(ctx context.Context) (phaseCheck, error)) error {
+	start := time.Now()
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(installPollInterval)
+	defer ticker.Stop()
+
+	var last phaseCheck
+	var lastReport time.Time
+	for {
+		state, err := check(ctx)
+		if err != nil {
+			state = phaseCheck{percent: last.percent, blocking: err.Error()}
+		}
+		if state.done {
+			return nil
+		}
+		if report != nil && (lastReport.IsZero() || state != last || time.Since(lastReport) >= waitReportInterval) {
+			report(WaitProgress{
+				Percent:        state.percent,
+				ElapsedSeconds: int64(time.Since(start).Seconds()),
+				TimeoutSeconds: int64(timeout.Seconds()),
+				Blocking:       state.blocking,
+			})
+			lastReport = time.Now()
+		}
+		last = state
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			if last.blocking != "" {
+				return fmt.Errorf("timed out after %s: %s", timeout, last.blocking)
+			}
+			return fmt.Errorf("timed out after %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForCSV waits for the Subscription to install a CSV and for that CSV
+// to reach Succeeded, returning its name.
+func waitForCSV(ctx context.Context, client *k8s.Client, namespace string, timeout time.Duration, report func(WaitProgress)) (string, error) {
+	var csvName string
+	err := waitForPhase(ctx, timeout, report, func(ctx context.Context) (phaseCheck, error) {
+		if csvName == "" {
+			sub, err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
+				Get(ctx, subscriptionName, metav1.GetOptions{})
+			if err != nil {
+				return phaseCheck{}, fmt.Errorf("getting Subscription: %w", err)
+			}
+			csvName, _, _ = unstructured.NestedString(sub.Object, "status", "installedCSV")
+			if csvName == "" {
+				return phaseCheck{blocking: subscriptionBlocking(sub)}, nil
+			}
+		}
+
+		csv, err := client.Dynamic.Resource(csvGVR).Namespace(namespace).
+			Get(ctx, csvName, metav1.GetOptions{})
+		if err != nil {
+			return phaseCheck{percent: 10, blocking: fmt.Sprintf("CSV %s not found yet", csvName)}, nil
+		}
+		phase, _, _ := unstructured.NestedString(csv.Object, "status", "phase")
+		if phase == "Succeeded" {
+			return phaseCheck{done: true, percent: 100}, nil
+		}
+		blocking := fmt.Sprintf("CSV %s is %s", csvName, phase)
+		if phase == "" {
+			blocking = fmt.Sprintf("CSV %s has no phase yet", csvName)
+		}
+		if message, _, _ := unstructured.NestedString(csv.Object, "status", "message"); message != "" {
+			blocking += ": " + message
+		}
+		return phaseCheck{percent: max(10, csvPhasePercent[phase]), blocking: blocking}, nil
+	})
+	return csvName, err
+}
+
+// subscriptionBlocking explains why a Subscription has no installed CSV.
+func subscriptionBlocking(sub *unstructured.Unstructured) string {
+	state, _, _ := unstructured.NestedString(sub.Object, "status", "state")
+	if state == "UpgradePending" {
+		if plan, _, _ := unstructured.NestedString(sub.Object, "status", "installPlanRef", "name"); plan != "" {
+			return fmt.Sprintf("InstallPlan %s is waiting for approval", plan)
+		}
+	}
+	conditions, _, _ := unstructured.NestedSlice(sub.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]any)
+		if !ok || cond["status"] != "True" {
+			continue
+		}
+		if message, _ := cond["message"].(string); message != "" {
+			return fmt.Sprintf("Subscription %v: %s", cond["type"], message)
+		}
+	}
+	if state != "" {
+		return fmt.Sprintf("Subscription is %s; no CSV installed yet", state)
+	}
+	return "Waiting for OLM to resolve the Subscription"
+}
+
+// waitForPodsReady waits until every operator pod that has not completed
+// is ready.
+func waitForPodsReady(ctx context.Context, client *k8s.Client, namespace string, timeout time.Duration, report func(WaitProgress)) error {
+	return waitForPhase(ctx, timeout, report, func(ctx context.Context) (phaseCheck, error) {
+		pods, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return phaseCheck{}, fmt.Errorf("listing pods: %w", err)
+		}
+
+		total, ready := 0, 0
+		blocking := ""
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodSucceeded {
+				continue
+			}
+			total++
+			if podReady(&pod) {
+				ready++
+			} else if blocking == "" {
+				blocking = podBlocking(&pod)
+			}
+		}
+		if total == 0 {
+			return phaseCheck{blocking: fmt.Sprintf("No pods in %s yet", namespace)}, nil
+		}
+		return phaseCheck{done: ready == total, percent: ready * 100 / total, blocking: blocking}, nil
+	})
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// podBlocking explains why a pod is not ready: a container's waiting reason,
+// an unschedulable condition, or its phase.
+func podBlocking(pod *corev1.Pod) string {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if w := cs.State.Waiting; w != nil && w.Reason != "" {
+			if w.Message != "" {
+				return fmt.Sprintf("Pod %s container %s is %s: %s", pod.Name, cs.Name, w.Reason, w.Message)
+			}
+			return fmt.Sprintf("Pod %s container %s is %s", pod.Name, cs.Name, w.Reason)
+		}
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			return fmt.Sprintf("Pod %s is %s: %s", pod.Name, cond.Reason, cond.Message)
+		}
+	}
+	return fmt.Sprintf("Pod %s is %s and not ready", pod.Name, pod.Status.Phase)
+}
+
+// waitForProfileBundles waits until every ProfileBundle is VALID.
+func waitForProfileBundles(ctx context.Context, client *k8s.Client, namespace string, timeout time.Duration, report func(WaitProgress)) error {
+	return waitForPhase(ctx, timeout, report, func(ctx context.Context) (phaseCheck, error) {
+		bundles, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+			List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return phaseCheck{}, fmt.Errorf("listing ProfileBundles: %w", err)
+		}
+		if len(bundles.Items) == 0 {
+			return phaseCheck{blocking: "The operator has not created any ProfileBundles yet"}, nil
+		}
+
+		valid := 0
+		blocking := ""
+		for _, bundle := range bundles.Items {
+			dsStatus, _, _ := unstructured.NestedString(bundle.Object, "status", "dataStreamStatus")
+			if dsStatus == "VALID" {
+				valid++
+				continue
+			}
+			if blocking == "" {
+				if dsStatus == "" {
+					dsStatus = "not parsed yet"
+				}
+				blocking = fmt.Sprintf("ProfileBundle %s is %s", bundle.GetName(), dsStatus)
+				if message, _, _ := unstructured.NestedString(bundle.Object, "status", "errorMessage"); message != "" {
+					blocking += ": " + message
+				}
+			}
+		}
+		return phaseCheck{done: valid == len(bundles.Items), percent: valid * 100 / len(bundles.Items), blocking: blocking}, nil
+	})
+}
+
+// waitForProfileBundlesCreated waits until the operator has created its
+// default ProfileBundles.
+func waitForProfileBundlesCreated(ctx context.Context, client *k8s.Client, namespace string, timeout time.Duration, report func(WaitProgress)) error {
+	return waitForPhase(ctx, timeout, report, func(ctx context.Context) (phaseCheck, error) {
+		bundles, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+			List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return phaseCheck{}, fmt.Errorf("listing ProfileBundles: %w", err)
+		}
+		if len(bundles.Items) == 0 {
+			return phaseCheck{blocking: "The operator has not created any ProfileBundles yet"}, nil
+		}
+		return phaseCheck{done: true, percent: 100}, nil
+	})
+}
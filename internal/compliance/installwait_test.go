@@ -0,0 +1,103 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func fastInstallPoll(t *testing.T) {
+	t.Helper()
+	orig := installPollInterval
+	installPollInterval = time.Millisecond
+	t.Cleanup(func() { installPollInterval = orig })
+}
+
+func TestWaitForPodsReadyReportsBlockingPod(t *testing.T) {
+	fastInstallPoll(t)
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	ready := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "compliance-operator-abc", Namespace: ns},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	pulling := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocp4-pp-xyz", Namespace: ns},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "profileparser",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+			}},
+		},
+	}
+	client := newTestClientWithPods(nil, []corev1.Pod{ready, pulling})
+
+	var reports []WaitProgress
+	err := waitForPodsReady(ctx, client, ns, 20*time.Millisecond, func(w WaitProgress) { reports = append(reports, w) })
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "ocp4-pp-xyz container profileparser is ImagePullBackOff") {
+		t.Fatalf("expected a timeout naming the pulling pod, got %v", err)
+	}
+	// Unchanged observations are not reported again within a minute.
+	if len(reports) != 1 {
+		t.Fatalf("expected one report, got %+v", reports)
+	}
+	if reports[0].Percent != 50 || !strings.Contains(reports[0].Blocking, "Back-off pulling image") {
+		t.Errorf("unexpected report: %+v", reports[0])
+	}
+}
+
+func TestWaitForCSV(t *testing.T) {
+	fastInstallPoll(t)
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	csv := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "ClusterServiceVersion",
+		"metadata":   map[string]any{"name": "compliance-operator.v1.7.0", "namespace": ns},
+		"status":     map[string]any{"phase": "Succeeded"},
+	}}
+	client := newTestClient(newSubscription(ns, "stable", "compliance-operator.v1.7.0", "compliance-operator.v1.7.0"), csv)
+
+	name, err := waitForCSV(ctx, client, ns, time.Second, nil)
+	if err != nil || name != "compliance-operator.v1.7.0" {
+		t.Fatalf("waitForCSV = %q, %v", name, err)
+	}
+
+	pending := newSubscription(ns, "stable", "compliance-operator.v1.7.0", "")
+	pending.Object["status"] = map[string]any{
+		"state":          "UpgradePending",
+		"installPlanRef": map[string]any{"name": "install-abcde"},
+	}
+	client = newTestClient(pending)
+	_, err = waitForCSV(ctx, client, ns, 10*time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), "InstallPlan install-abcde is waiting for approval") {
+		t.Errorf("expected the pending approval to be named, got %v", err)
+	}
+}
+
+func TestInstallTimeouts(t *testing.T) {
+	var none *InstallTimeouts
+	if none.CSV() != DefaultPhaseTimeout || none.Pods() != DefaultPhaseTimeout {
+		t.Error("nil timeouts should use the default")
+	}
+	timeouts := &InstallTimeouts{PodsSeconds: 900}
+	if timeouts.Pods() != 15*time.Minute || timeouts.ProfileBundles() != DefaultPhaseTimeout {
+		t.Errorf("unexpected timeouts: pods %s, bundles %s", timeouts.Pods(), timeouts.ProfileBundles())
+	}
+
+	for _, bad := range []InstallTimeouts{{CSVSeconds: -1}, {ProfileBundlesSeconds: 7 * 3600}} {
+		if err := (InstallOptions{Timeouts: &bad}).Validate(); err == nil || !strings.Contains(err.Error(), "invalid install options: timeouts.") {
+			t.Errorf("Validate(%+v) = %v, want a timeouts error", bad, err)
+		}
+	}
+}
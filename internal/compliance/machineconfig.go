@@ -0,0 +1,443 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	mcdCurrentConfigAnnotation = "machineconfiguration.openshift.io/currentConfig"
+	mcdDesiredConfigAnnotation = "machineconfiguration.openshift.io/desiredConfig"
+	mcdStateAnnotation         = "machineconfiguration.openshift.io/state"
+
+	// batchRolloutTimeout bounds how long a batched apply waits for the
+	// single rollout that follows unpausing the pools.
+	batchRolloutTimeout = 60 * time.Minute
+)
+
+var machineConfigPoolGVR = schema.GroupVersionResource{
+	Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfigpools",
+}
+
+// rolloutPollInterval is how often pool and node state is polled while a
+// rollout is in progress. It is a variable so tests can shorten it.
+var rolloutPollInterval = 15 * time.Second
+
+// IsDisruptiveKind reports whether applying an object of the given kind causes
+// the Machine Config Operator to roll out new configuration (and reboot nodes).
+func IsDisruptiveKind(kind string) bool {
+	return kind == "MachineConfig" || kind == "KubeletConfig"
+}
+
+// ApplyMachineConfigBatch applies the named remediations as a single rollout.
+// The MachineConfigPools targeted by MachineConfig/KubeletConfig remediations
+// are paused first so the MCO does not render and roll out a new config for
+// every object, then unpaused once everything has been applied. Remediations
+// of other kinds are applied directly. Per-item results and per-node rollout
+// state are sent to the progress channel, which is closed on return.
+func ApplyMachineConfigBatch(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace string, names []string, progress chan<- MachineConfigBatchProgress) {
+	defer close(progress)
+
+	if client == nil {
+		progress <- MachineConfigBatchProgress{Step: "init", Message: "Kubernetes client is not connected", Error: "kubernetes client is nil", Done: true}
+		return
+	}
+
+	// Step 1: Resolve the pools the selection rolls out to
+	pools, err := batchPools(ctx, client, namespace, names)
+	if err != nil {
+		progress <- MachineConfigBatchProgress{Step: "pause", Message: "Failed to resolve the target MachineConfigPools", Error: err.Error(), Done: true}
+		return
+	}
+
+	// Step 2: Pause the target pools, remembering which were already paused
+	initialConfigs := make(map[string]string, len(pools))
+	var paused []string
+	for _, pool := range pools {
+		wasPaused, err := setPoolPaused(ctx, client, pool, true)
+		if err != nil {
+			progress <- MachineConfigBatchProgress{Step: "pause", Message: fmt.Sprintf("Failed to pause MachineConfigPool %s", pool), Error: err.Error(), Done: true}
+			if err := unpausePools(client, paused); err != nil {
+				slog.Warn("could not unpause MachineConfigPools", "pools", paused, "error", err)
+			}
+			return
+		}
+		if wasPaused {
+			progress <- MachineConfigBatchProgress{Step: "pause", Message: fmt.Sprintf("MachineConfigPool %s was already paused and will be left paused", pool)}
+			continue
+		}
+		paused = append(paused, pool)
+		initialConfigs[pool] = renderedConfig(ctx, client, pool)
+		progress <- MachineConfigBatchProgress{Step: "pause", Message: fmt.Sprintf("Paused MachineConfigPool %s", pool)}
+	}
+
+	// Step 3: Apply every selected remediation while the pools are paused
+	applied := 0
+	for _, name := range names {
+		result, err := ApplyRemediation(ctx, client, mode, namespace, name)
+		if err != nil {
+			res := RemediationResult{Name: name, Error: err.Error()}
+			progress <- MachineConfigBatchProgress{Step: "apply", Message: fmt.Sprintf("Failed to apply %s", name), Result: &res}
+			continue
+		}
+		applied++
+		progress <- MachineConfigBatchProgress{Step: "apply", Message: result.Message, Result: result}
+	}
+
+	// Step 4: Unpause so the MCO rolls out one rendered config per pool
+	if err := unpausePools(client, paused); err != nil {
+		progress <- MachineConfigBatchProgress{Step: "unpause", Message: "Failed to unpause MachineConfigPools", Error: err.Error(), Done: true}
+		return
+	}
+	for _, pool := range paused {
+		progress <- MachineConfigBatchProgress{Step: "unpause", Message: fmt.Sprintf("Unpaused MachineConfigPool %s", pool)}
+	}
+
+	if applied == 0 || len(paused) == 0 {
+		progress <- MachineConfigBatchProgress{Step: "complete", Message: fmt.Sprintf("Applied %d of %d remediations; no rollout to track", applied, len(names)), Done: true}
+		return
+	}
+
+	// Step 5: Track the single rollout
+	progress <- MachineConfigBatchProgress{Step: "rollout", Message: "Waiting for MachineConfigPools to roll out the new configuration..."}
+	if err := trackRollout(ctx, client, initialConfigs, progress); err != nil {
+		progress <- MachineConfigBatchProgress{Step: "rollout", Message: "Rollout did not complete", Error: err.Error(), Done: true}
+		return
+	}
+
+	progress <- MachineConfigBatchProgress{Step: "complete", Message: fmt.Sprintf("Applied %d of %d remediations in a single rollout", applied, len(names)), Done: true}
+}
+
+// batchPools returns the names of the pools that roll out when the named
+// remediations are applied, matched the way AnalyzeImpact matches them, so
+// custom pools selecting worker MachineConfigs and pools selected by a
+// KubeletConfig are paused too. A disruptive remediation that matches no
+// pool is an error.
+func batchPools(ctx context.Context, client *k8s.Client, namespace string, names []string) ([]string, error) {
+	var pools []unstructured.Unstructured
+	if list, err := client.Dynamic.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{}); err == nil {
+		pools = list.Items
+	} else if !IsCRDNotFound(err) {
+		return nil, fmt.Errorf("listing MachineConfigPools: %w", err)
+	}
+
+	poolSet := make(map[string]bool)
+	for _, name := range names {
+		rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			// Reported when the remediation is applied.
+			continue
+		}
+		target, err := resolveRemediationTarget(rem, namespace)
+		if err != nil || !IsDisruptiveKind(target.Kind) {
+			continue
+		}
+		matched := targetPools(pools, target.Kind, target.Object, detectRole(name, *rem))
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no MachineConfigPool matches %s %s of remediation %s", target.Kind, target.Object.GetName(), name)
+		}
+		for _, pool := range matched {
+			poolSet[pool.GetName()] = true
+		}
+	}
+	result := make([]string, 0, len(poolSet))
+	for pool := range poolSet {
+		result = append(result, pool)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// ListMachineConfigPools returns the status of every MachineConfigPool,
+// including the MCD state of each node in the pool.
+func ListMachineConfigPools(ctx context.Context, client *k8s.Client) ([]MachineConfigPoolStatus, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	pools, err := client.Dynamic.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if IsCRDNotFound(err) {
+			return []MachineConfigPoolStatus{}, nil
+		}
+		return nil, fmt.Errorf("listing MachineConfigPools: %w", err)
+	}
+
+	statuses := make([]MachineConfigPoolStatus, 0, len(pools.Items))
+	for i := range pools.Items {
+		statuses = append(statuses, MachineConfigPoolStatusFromObject(ctx, client, &pools.Items[i]))
+	}
+	return statuses, nil
+}
+
+// GetMachineConfigPool returns the status of a single MachineConfigPool.
+func GetMachineConfigPool(ctx context.Context, client *k8s.Client, name string) (*MachineConfigPoolStatus, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	mcp, err := client.Dynamic.Resource(machineConfigPoolGVR).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting MachineConfigPool %s: %w", name, err)
+	}
+
+	status := MachineConfigPoolStatusFromObject(ctx, client, mcp)
+	return &status, nil
+}
+
+// SetMachineConfigPoolPaused pauses or resumes a MachineConfigPool.
+func SetMachineConfigPoolPaused(ctx context.Context, client *k8s.Client, name string, paused bool) error {
+	if client == nil {
+		return fmt.Errorf("kubernetes client is nil")
+	}
+	_, err := setPoolPaused(ctx, client, name, paused)
+	return err
+}
+
+// MachineConfigPoolStatusFromObject builds a MachineConfigPoolStatus from a
+// MachineConfigPool object, looking up the pool's nodes for their MCD state.
+func MachineConfigPoolStatusFromObject(ctx context.Context, client *k8s.Client, mcp *unstructured.Unstructured) MachineConfigPoolStatus {
+	paused, _, _ := unstructured.NestedBool(mcp.Object, "spec", "paused")
+	desired, _, _ := unstructured.NestedString(mcp.Object, "spec", "configuration", "name")
+	current, _, _ := unstructured.NestedString(mcp.Object, "status", "configuration", "name")
+	machines, _, _ := unstructured.NestedInt64(mcp.Object, "status", "machineCount")
+	updated, _, _ := unstructured.NestedInt64(mcp.Object, "status", "updatedMachineCount")
+	ready, _, _ := unstructured.NestedInt64(mcp.Object, "status", "readyMachineCount")
+	unavailable, _, _ := unstructured.NestedInt64(mcp.Object, "status", "unavailableMachineCount")
+	degraded, _, _ := unstructured.NestedInt64(mcp.Object, "status", "degradedMachineCount")
+
+	status := MachineConfigPoolStatus{
+		Name:                    mcp.GetName(),
+		Paused:                  paused,
+		MachineCount:            machines,
+		UpdatedMachineCount:     updated,
+		ReadyMachineCount:       ready,
+		UnavailableMachineCount: unavailable,
+		DegradedMachineCount:    degraded,
+		CurrentConfig:           current,
+		DesiredConfig:           desired,
+		Updated:                 poolCondition(mcp, "Updated"),
+		Updating:                poolCondition(mcp, "Updating"),
+		Degraded:                poolCondition(mcp, "Degraded"),
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(mcp.Object, "status", "conditions")
+	for _, cond := range conditions {
+		condMap, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condType, _ := condMap["type"].(string)
+		condStatus, _ := condMap["status"].(string)
+		reason, _ := condMap["reason"].(string)
+		lastTransition, _ := condMap["lastTransitionTime"].(string)
+		status.Conditions = append(status.Conditions, Condition{
+			Type:               condType,
+			Status:             condStatus,
+			Reason:             reason,
+			LastTransitionTime: lastTransition,
+		})
+	}
+
+	if client != nil {
+		status.Nodes = poolNodes(ctx, client, mcp)
+	}
+
+	return status
+}
+
+// setPoolPaused sets spec.paused on a MachineConfigPool and reports the
+// previous value.
+func setPoolPaused(ctx context.Context, client *k8s.Client, pool string, paused bool) (bool, error) {
+	mcp, err := client.Dynamic.Resource(machineConfigPoolGVR).Get(ctx, pool, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("getting MachineConfigPool %s: %w", pool, err)
+	}
+	wasPaused, _, _ := unstructured.NestedBool(mcp.Object, "spec", "paused")
+	if wasPaused == paused {
+		return wasPaused, nil
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"paused":%t}}`, paused))
+	_, err = client.Dynamic.Resource(machineConfigPoolGVR).
+		Patch(ctx, pool, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return wasPaused, fmt.Errorf("patching MachineConfigPool %s: %w", pool, err)
+	}
+	return wasPaused, nil
+}
+
+// unpausePools clears spec.paused on the given pools. It uses its own context
+// so pools are not left paused when the caller's context has been canceled.
+func unpausePools(client *k8s.Client, pools []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var errs []string
+	for _, pool := range pools {
+		if _, err := setPoolPaused(ctx, client, pool, false); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// renderedConfig returns the name of the rendered MachineConfig a pool is
+// targeting, or "" if the pool cannot be read.
+func renderedConfig(ctx context.Context, client *k8s.Client, pool string) string {
+	mcp, err := client.Dynamic.Resource(machineConfigPoolGVR).Get(ctx, pool, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	name, _, _ := unstructured.NestedString(mcp.Object, "spec", "configuration", "name")
+	return name
+}
+
+// trackRollout polls the given pools until each has rolled out its rendered
+// config to every machine, sending a progress update whenever a node's MCD
+// state changes. initialConfigs maps each pool to the rendered config it
+// targeted before the batch, so a pool is not reported finished before the
+// MCO has picked up the new MachineConfigs.
+func trackRollout(ctx context.Context, client *k8s.Client, initialConfigs map[string]string, progress chan<- MachineConfigBatchProgress) error {
+	timeout := time.After(batchRolloutTimeout)
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	lastSeen := make(map[string]NodeRolloutStatus)
+	unchangedPolls := make(map[string]int)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("rollout did not finish within %s", batchRolloutTimeout)
+		case <-ticker.C:
+		}
+
+		allDone := true
+		for pool, initial := range initialConfigs {
+			mcp, err := client.Dynamic.Resource(machineConfigPoolGVR).Get(ctx, pool, metav1.GetOptions{})
+			if err != nil {
+				slog.Warn("could not get MachineConfigPool during rollout", "pool", pool, "error", err)
+				allDone = false
+				continue
+			}
+
+			for _, node := range poolNodes(ctx, client, mcp) {
+				if prev, ok := lastSeen[node.Name]; ok && prev == node {
+					continue
+				}
+				lastSeen[node.Name] = node
+				progress <- MachineConfigBatchProgress{
+					Step:    "rollout",
+					Message: fmt.Sprintf("Node %s: %s", node.Name, nodeStateMessage(node)),
+					Node:    &node,
+				}
+			}
+
+			if poolCondition(mcp, "Degraded") {
+				return fmt.Errorf("MachineConfigPool %s is degraded", pool)
+			}
+
+			// Give the render controller a couple of polls to pick up the new
+			// MachineConfigs before accepting an unchanged config as final.
+			current, _, _ := unstructured.NestedString(mcp.Object, "spec", "configuration", "name")
+			if current == initial && unchangedPolls[pool] < 2 {
+				unchangedPolls[pool]++
+				allDone = false
+				continue
+			}
+			if !poolUpdated(mcp) {
+				allDone = false
+			}
+		}
+
+		if allDone {
+			return nil
+		}
+	}
+}
+
+// poolUpdated reports whether a pool has rolled out its target config to all
+// of its machines.
+func poolUpdated(mcp *unstructured.Unstructured) bool {
+	observed, _, _ := unstructured.NestedInt64(mcp.Object, "status", "observedGeneration")
+	if observed < mcp.GetGeneration() {
+		return false
+	}
+	machines, _, _ := unstructured.NestedInt64(mcp.Object, "status", "machineCount")
+	updated, _, _ := unstructured.NestedInt64(mcp.Object, "status", "updatedMachineCount")
+	return updated == machines && poolCondition(mcp, "Updated")
+}
+
+// poolCondition reports whether the named status condition is True on a pool.
+func poolCondition(mcp *unstructured.Unstructured, condType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(mcp.Object, "status", "conditions")
+	for _, cond := range conditions {
+		condMap, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _ := condMap["type"].(string); t == condType {
+			s, _ := condMap["status"].(string)
+			return s == "True"
+		}
+	}
+	return false
+}
+
+// poolNodes returns the MCD state of every node selected by a pool.
+func poolNodes(ctx context.Context, client *k8s.Client, mcp *unstructured.Unstructured) []NodeRolloutStatus {
+	pool := mcp.GetName()
+	selector := "node-role.kubernetes.io/" + pool
+	if nodeSelector, ok := labelSelectorAt(mcp.Object, "spec", "nodeSelector"); ok {
+		selector = nodeSelector.String()
+	}
+
+	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil
+	}
+
+	statuses := make([]NodeRolloutStatus, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		current := node.Annotations[mcdCurrentConfigAnnotation]
+		desired := node.Annotations[mcdDesiredConfigAnnotation]
+		statuses = append(statuses, NodeRolloutStatus{
+			Name:          node.Name,
+			Pool:          pool,
+			CurrentConfig: current,
+			DesiredConfig: desired,
+			State:         node.Annotations[mcdStateAnnotation],
+			Updated:       current != "" && current == desired,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+func nodeStateMessage(node NodeRolloutStatus) string {
+	if node.Updated && node.State == "Done" {
+		return fmt.Sprintf("updated to %s", node.CurrentConfig)
+	}
+	if node.State == "" {
+		return "waiting for the Machine Config Daemon"
+	}
+	return fmt.Sprintf("%s (%s -> %s)", node.State, node.CurrentConfig, node.DesiredConfig)
+}
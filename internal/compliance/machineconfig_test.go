@@ -0,0 +1,340 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newMachineConfigPool(name string, paused bool, machines, updated int64, updatedCond string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "machineconfiguration.openshift.io/v1",
+			"kind":       "MachineConfigPool",
+			"metadata": map[string]any{
+				"name":       name,
+				"generation": int64(1),
+			},
+			"spec": map[string]any{
+				"paused": paused,
+				"configuration": map[string]any{
+					"name": "rendered-" + name + "-abc",
+				},
+			},
+			"status": map[string]any{
+				"observedGeneration":  int64(1),
+				"machineCount":        machines,
+				"updatedMachineCount": updated,
+				"conditions": []any{
+					map[string]any{"type": "Updated", "status": updatedCond},
+					map[string]any{"type": "Degraded", "status": "False"},
+				},
+			},
+		},
+	}
+}
+
+func newMachineConfigRemediation(name, ns, role string) *unstructured.Unstructured {
+	return newRemediation(name, ns, map[string]any{
+		"spec": map[string]any{
+			"apply": false,
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "machineconfiguration.openshift.io/v1",
+					"kind":       "MachineConfig",
+					"metadata": map[string]any{
+						"name": "75-" + name,
+						"labels": map[string]any{
+							"machineconfiguration.openshift.io/role": role,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func drainBatchProgress(ch <-chan MachineConfigBatchProgress) []MachineConfigBatchProgress {
+	var updates []MachineConfigBatchProgress
+	for p := range ch {
+		updates = append(updates, p)
+	}
+	return updates
+}
+
+func TestIsDisruptiveKind(t *testing.T) {
+	tests := map[string]bool{
+		"MachineConfig": true,
+		"KubeletConfig": true,
+		"ConfigMap":     false,
+		"APIServer":     false,
+		"":              false,
+	}
+	for kind, want := range tests {
+		if got := IsDisruptiveKind(kind); got != want {
+			t.Errorf("IsDisruptiveKind(%q) = %v, want %v", kind, got, want)
+		}
+	}
+}
+
+func TestPoolUpdated(t *testing.T) {
+	if !poolUpdated(newMachineConfigPool("worker", false, 3, 3, "True")) {
+		t.Error("expected fully updated pool to report updated")
+	}
+	if poolUpdated(newMachineConfigPool("worker", false, 3, 1, "False")) {
+		t.Error("expected partially updated pool to report not updated")
+	}
+
+	stale := newMachineConfigPool("worker", false, 3, 3, "True")
+	stale.SetGeneration(2)
+	if poolUpdated(stale) {
+		t.Error("expected pool with stale observedGeneration to report not updated")
+	}
+}
+
+func TestApplyMachineConfigBatch(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	orig := rolloutPollInterval
+	rolloutPollInterval = 10 * time.Millisecond
+	defer func() { rolloutPollInterval = orig }()
+
+	t.Run("pauses, applies, and unpauses the target pool", func(t *testing.T) {
+		client := newTestClient(
+			newMachineConfigRemediation("worker-audit", ns, "worker"),
+			newMachineConfigRemediation("worker-sshd", ns, "worker"),
+			newMachineConfigPool("worker", false, 2, 2, "True"),
+		)
+
+		progress := make(chan MachineConfigBatchProgress, 64)
+		go ApplyMachineConfigBatch(ctx, client, RemediationModeDirect, ns, []string{"worker-audit", "worker-sshd"}, progress)
+		updates := drainBatchProgress(progress)
+
+		last := updates[len(updates)-1]
+		if !last.Done || last.Error != "" {
+			t.Fatalf("expected successful completion, got %+v", last)
+		}
+
+		var applied, paused int
+		for _, u := range updates {
+			if u.Result != nil && u.Result.Applied {
+				applied++
+			}
+			if u.Step == "pause" {
+				paused++
+			}
+		}
+		if applied != 2 {
+			t.Errorf("applied = %d, want 2", applied)
+		}
+		if paused != 1 {
+			t.Errorf("pause updates = %d, want 1", paused)
+		}
+
+		mcp, err := client.Dynamic.Resource(machineConfigPoolGVR).Get(ctx, "worker", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("getting pool: %v", err)
+		}
+		if p, _, _ := unstructured.NestedBool(mcp.Object, "spec", "paused"); p {
+			t.Error("expected pool to be unpaused after batch")
+		}
+	})
+
+	t.Run("leaves an already paused pool paused", func(t *testing.T) {
+		client := newTestClient(
+			newMachineConfigRemediation("worker-audit", ns, "worker"),
+			newMachineConfigPool("worker", true, 2, 2, "True"),
+		)
+
+		progress := make(chan MachineConfigBatchProgress, 64)
+		go ApplyMachineConfigBatch(ctx, client, RemediationModeDirect, ns, []string{"worker-audit"}, progress)
+		updates := drainBatchProgress(progress)
+
+		if last := updates[len(updates)-1]; !last.Done || last.Error != "" {
+			t.Fatalf("expected successful completion, got %+v", last)
+		}
+
+		mcp, err := client.Dynamic.Resource(machineConfigPoolGVR).Get(ctx, "worker", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("getting pool: %v", err)
+		}
+		if p, _, _ := unstructured.NestedBool(mcp.Object, "spec", "paused"); !p {
+			t.Error("expected pre-paused pool to stay paused")
+		}
+	})
+
+	t.Run("pauses every pool the selection rolls out to", func(t *testing.T) {
+		roleSelector := map[string]any{"matchLabels": map[string]any{"machineconfiguration.openshift.io/role": "worker"}}
+		worker := newMachineConfigPool("worker", false, 2, 2, "True")
+		_ = unstructured.SetNestedMap(worker.Object, roleSelector, "spec", "machineConfigSelector")
+		infra := newMachineConfigPool("infra", false, 1, 1, "True")
+		_ = unstructured.SetNestedMap(infra.Object, roleSelector, "spec", "machineConfigSelector")
+		custom := newMachineConfigPool("custom", false, 1, 1, "True")
+		custom.SetLabels(map[string]string{"custom-kubelet": "enabled"})
+		kubeletRem := newRemediation("custom-kubelet", ns, map[string]any{
+			"spec": map[string]any{
+				"current": map[string]any{
+					"object": map[string]any{
+						"apiVersion": "machineconfiguration.openshift.io/v1",
+						"kind":       "KubeletConfig",
+						"metadata":   map[string]any{"name": "custom-kubelet"},
+						"spec": map[string]any{
+							"machineConfigPoolSelector": map[string]any{
+								"matchLabels": map[string]any{"custom-kubelet": "enabled"},
+							},
+						},
+					},
+				},
+			},
+		})
+		client := newTestClient(newMachineConfigRemediation("worker-audit", ns, "worker"), kubeletRem, worker, infra, custom)
+
+		progress := make(chan MachineConfigBatchProgress, 64)
+		go ApplyMachineConfigBatch(ctx, client, RemediationModeDirect, ns, []string{"worker-audit", "custom-kubelet"}, progress)
+		updates := drainBatchProgress(progress)
+		if last := updates[len(updates)-1]; !last.Done || last.Error != "" {
+			t.Fatalf("expected successful completion, got %+v", last)
+		}
+
+		var paused []string
+		for _, u := range updates {
+			if u.Step == "pause" {
+				paused = append(paused, u.Message)
+			}
+		}
+		want := []string{"Paused MachineConfigPool custom", "Paused MachineConfigPool infra", "Paused MachineConfigPool worker"}
+		if strings.Join(paused, "; ") != strings.Join(want, "; ") {
+			t.Errorf("pause updates = %v, want %v", paused, want)
+		}
+	})
+
+	t.Run("missing pool aborts before applying", func(t *testing.T) {
+		client := newTestClient(newMachineConfigRemediation("worker-audit", ns, "worker"))
+
+		progress := make(chan MachineConfigBatchProgress, 64)
+		go ApplyMachineConfigBatch(ctx, client, RemediationModeDirect, ns, []string{"worker-audit"}, progress)
+		updates := drainBatchProgress(progress)
+
+		last := updates[len(updates)-1]
+		if last.Step != "pause" || last.Error == "" {
+			t.Fatalf("expected pause failure, got %+v", last)
+		}
+		for _, u := range updates {
+			if u.Result != nil {
+				t.Errorf("expected no apply results, got %+v", u.Result)
+			}
+		}
+	})
+
+	t.Run("nil client", func(t *testing.T) {
+		progress := make(chan MachineConfigBatchProgress, 1)
+		go ApplyMachineConfigBatch(ctx, nil, RemediationModeDirect, ns, []string{"x"}, progress)
+		updates := drainBatchProgress(progress)
+		if len(updates) != 1 || updates[0].Error == "" {
+			t.Fatalf("expected a single error update, got %+v", updates)
+		}
+	})
+}
+
+func TestListMachineConfigPools(t *testing.T) {
+	ctx := context.Background()
+
+	client := newTestClient(
+		newMachineConfigPool("worker", false, 2, 1, "False"),
+		newMachineConfigPool("master", true, 3, 3, "True"),
+	)
+	for _, node := range []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{
+			Name:   "worker-0",
+			Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+			Annotations: map[string]string{
+				mcdCurrentConfigAnnotation: "rendered-worker-abc",
+				mcdDesiredConfigAnnotation: "rendered-worker-abc",
+				mcdStateAnnotation:         "Done",
+			},
+		}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name:   "worker-1",
+			Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+			Annotations: map[string]string{
+				mcdCurrentConfigAnnotation: "rendered-worker-old",
+				mcdDesiredConfigAnnotation: "rendered-worker-abc",
+				mcdStateAnnotation:         "Working",
+			},
+		}},
+	} {
+		if _, err := client.Clientset.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("creating node: %v", err)
+		}
+	}
+
+	pools, err := ListMachineConfigPools(ctx, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pools) != 2 {
+		t.Fatalf("got %d pools, want 2", len(pools))
+	}
+
+	var worker *MachineConfigPoolStatus
+	for i := range pools {
+		if pools[i].Name == "worker" {
+			worker = &pools[i]
+		}
+	}
+	if worker == nil {
+		t.Fatal("worker pool not found")
+	}
+	if worker.MachineCount != 2 || worker.UpdatedMachineCount != 1 {
+		t.Errorf("counts = %d/%d, want 1/2", worker.UpdatedMachineCount, worker.MachineCount)
+	}
+	if worker.DesiredConfig != "rendered-worker-abc" {
+		t.Errorf("DesiredConfig = %q", worker.DesiredConfig)
+	}
+	if worker.Updated {
+		t.Error("expected worker pool not to be Updated")
+	}
+	if len(worker.Nodes) != 2 {
+		t.Fatalf("got %d nodes, want 2", len(worker.Nodes))
+	}
+	if !worker.Nodes[0].Updated || worker.Nodes[1].Updated {
+		t.Errorf("unexpected node update state: %+v", worker.Nodes)
+	}
+	if worker.Nodes[1].State != "Working" {
+		t.Errorf("node state = %q, want Working", worker.Nodes[1].State)
+	}
+}
+
+func TestSetMachineConfigPoolPaused(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(newMachineConfigPool("worker", false, 1, 1, "True"))
+
+	if err := SetMachineConfigPoolPaused(ctx, client, "worker", true); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	pool, err := GetMachineConfigPool(ctx, client, "worker")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !pool.Paused {
+		t.Error("expected pool to be paused")
+	}
+
+	if err := SetMachineConfigPoolPaused(ctx, client, "worker", false); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	pool, _ = GetMachineConfigPool(ctx, client, "worker")
+	if pool.Paused {
+		t.Error("expected pool to be resumed")
+	}
+
+	if err := SetMachineConfigPoolPaused(ctx, client, "missing", true); err == nil {
+		t.Error("expected error for missing pool")
+	}
+}
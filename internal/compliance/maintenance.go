@@ -0,0 +1,521 @@
+package compliance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// Maintenance windows and the remediations queued for them are stored in a
+// single ConfigMap so the queue survives dashboard restarts.
+const (
+	maintenanceConfigMap  = "compliance-dashboard-maintenance"
+	maintenanceWindowsKey = "windows.json"
+	maintenanceQueueKey   = "queue.json"
+)
+
+// maintenanceTickInterval is how often the scheduler looks for open windows.
+var maintenanceTickInterval = time.Minute
+
+// maxScheduledHistory is the number of finished queue items kept.
+const maxScheduledHistory = 100
+
+// maintenanceState is the stored windows and queue.
+type maintenanceState struct {
+	Windows []MaintenanceWindow
+	Queue   []ScheduledRemediation
+}
+
+// ListMaintenanceWindows returns the configured windows with their next start
+// and whether they are currently open.
+func ListMaintenanceWindows(ctx context.Context, client *k8s.Client, namespace string) ([]MaintenanceWindow, error) {
+	state, err := loadMaintenanceState(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range state.Windows {
+		state.Windows[i].describe(now)
+	}
+	return state.Windows, nil
+}
+
+// SaveMaintenanceWindow creates or replaces a window. Schedule is a
+// five-field cron expression for when the window opens, evaluated in
+// Timezone (default UTC); Duration is how long it stays open.
+func SaveMaintenanceWindow(ctx context.Context, client *k8s.Client, namespace string, window MaintenanceWindow) (*MaintenanceWindow, error) {
+	if window.Name == "" {
+		return nil, fmt.Errorf("window name is required")
+	}
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	if _, _, _, err := window.parse(); err != nil {
+		return nil, err
+	}
+	window.NextStart, window.Open, window.ClosesAt = nil, false, nil
+
+	err := updateMaintenanceState(ctx, client, namespace, func(state *maintenanceState) error {
+		i := slices.IndexFunc(state.Windows, func(w MaintenanceWindow) bool { return w.Name == window.Name })
+		if i >= 0 {
+			state.Windows[i] = window
+		} else {
+			state.Windows = append(state.Windows, window)
+		}
+		sort.Slice(state.Windows, func(i, j int) bool { return state.Windows[i].Name < state.Windows[j].Name })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	window.describe(time.Now())
+	return &window, nil
+}
+
+// DeleteMaintenanceWindow removes a window. It fails while remediations are
+// still queued for it.
+func DeleteMaintenanceWindow(ctx context.Context, client *k8s.Client, namespace, name string) error {
+	return updateMaintenanceState(ctx, client, namespace, func(state *maintenanceState) error {
+		i := slices.IndexFunc(state.Windows, func(w MaintenanceWindow) bool { return w.Name == name })
+		if i < 0 {
+			return k8serrors.NewNotFound(corev1.Resource("maintenancewindows"), name)
+		}
+		for _, item := range state.Queue {
+			if item.Window == name && item.waiting() {
+				return fmt.Errorf("window %s still has queued remediations; cancel them first", name)
+			}
+		}
+		state.Windows = slices.Delete(state.Windows, i, i+1)
+		return nil
+	})
+}
+
+// QueueRemediations schedules remediations to be applied in the next opening
+// of a window.
+func QueueRemediations(ctx context.Context, client *k8s.Client, namespace, window string, names []string) ([]ScheduledRemediation, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one remediation name is required")
+	}
+	for _, name := range names {
+		if _, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Get(ctx, name, metav1.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("getting remediation %s: %w", name, err)
+		}
+	}
+
+	var queued []ScheduledRemediation
+	err := updateMaintenanceState(ctx, client, namespace, func(state *maintenanceState) error {
+		if !slices.ContainsFunc(state.Windows, func(w MaintenanceWindow) bool { return w.Name == window }) {
+			return k8serrors.NewNotFound(corev1.Resource("maintenancewindows"), window)
+		}
+		queued = nil
+		now := time.Now().UTC()
+		for _, name := range names {
+			b := make([]byte, 4)
+			_, _ = rand.Read(b)
+			item := ScheduledRemediation{
+				ID:          hex.EncodeToString(b),
+				Remediation: name,
+				Window:      window,
+				State:       ScheduledQueued,
+				QueuedAt:    now,
+			}
+			state.Queue = append(state.Queue, item)
+			queued = append(queued, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return queued, nil
+}
+
+// ListScheduledRemediations returns queued items and recent history, oldest
+// first.
+func ListScheduledRemediations(ctx context.Context, client *k8s.Client, namespace string) ([]ScheduledRemediation, error) {
+	state, err := loadMaintenanceState(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return state.Queue, nil
+}
+
+// CancelScheduledRemediation cancels a queued or held item.
+func CancelScheduledRemediation(ctx context.Context, client *k8s.Client, namespace, id string) (*ScheduledRemediation, error) {
+	var canceled ScheduledRemediation
+	err := updateMaintenanceState(ctx, client, namespace, func(state *maintenanceState) error {
+		i := slices.IndexFunc(state.Queue, func(item ScheduledRemediation) bool { return item.ID == id })
+		if i < 0 {
+			return k8serrors.NewNotFound(corev1.Resource("scheduledremediations"), id)
+		}
+		if !state.Queue[i].waiting() {
+			return fmt.Errorf("scheduled remediation %s is %s and cannot be canceled", id, state.Queue[i].State)
+		}
+		now := time.Now().UTC()
+		state.Queue[i].State = ScheduledCanceled
+		state.Queue[i].FinishedAt = &now
+		canceled = state.Queue[i]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &canceled, nil
+}
+
+// MaintenanceScheduler applies queued remediations while their window is
+// open.
+type MaintenanceScheduler struct {
+	client    *k8s.Client
+	mode      RemediationMode
+	namespace string
+	notify    func(ScheduledRemediation, *RemediationResult)
+}
+
+// NewMaintenanceScheduler creates a scheduler that applies queued items in
+// the given remediation mode. notify is called for every change to a queued
+// item, with the apply result once it has been applied.
+func NewMaintenanceScheduler(client *k8s.Client, mode RemediationMode, namespace string, notify func(ScheduledRemediation, *RemediationResult)) *MaintenanceScheduler {
+	return &MaintenanceScheduler{client: client, mode: mode, namespace: namespace, notify: notify}
+}
+
+// Run checks for open windows until ctx is canceled. Items left Applying by
+// a previous run that was interrupted are queued again.
+func (s *MaintenanceScheduler) Run(ctx context.Context) {
+	if err := s.requeueInterrupted(ctx); err != nil {
+		slog.Warn("failed to recover maintenance queue", "error", err)
+	}
+
+	ticker := time.NewTicker(maintenanceTickInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.runDue(ctx, time.Now()); err != nil {
+			slog.Warn("maintenance scheduler", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *MaintenanceScheduler) requeueInterrupted(ctx context.Context) error {
+	state, err := loadMaintenanceState(ctx, s.client, s.namespace)
+	if err != nil || !slices.ContainsFunc(state.Queue, func(item ScheduledRemediation) bool { return item.State == ScheduledApplying }) {
+		return err
+	}
+	return updateMaintenanceState(ctx, s.client, s.namespace, func(state *maintenanceState) error {
+		for i := range state.Queue {
+			if state.Queue[i].State == ScheduledApplying {
+				state.Queue[i].State = ScheduledQueued
+				state.Queue[i].Message = "Interrupted by a dashboard restart; queued again"
+			}
+		}
+		return nil
+	})
+}
+
+// runDue applies the waiting items of every window open at now, oldest first.
+// An item is held when its estimated rollout, started after the rollouts
+// already begun in this window, would not finish before the window closes.
+// Held items are reconsidered in the next window.
+func (s *MaintenanceScheduler) runDue(ctx context.Context, now time.Time) error {
+	state, err := loadMaintenanceState(ctx, s.client, s.namespace)
+	if err != nil {
+		return err
+	}
+	// Times are measured from now so the pass is consistent with the
+	// window it was evaluated against
+	begin := time.Now()
+	clock := func() time.Time { return now.Add(time.Since(begin)).UTC() }
+
+	for _, window := range state.Windows {
+		start, end, open := window.openAt(now)
+		if !open {
+			continue
+		}
+
+		// Rollouts started earlier in this window keep the pools busy
+		busyUntil := now
+		for _, item := range state.Queue {
+			if item.Window == window.Name && item.StartedAt != nil && !item.StartedAt.Before(start) && item.StartedAt.Before(end) {
+				if done := item.StartedAt.Add(time.Duration(item.EstimatedSeconds) * time.Second); done.After(busyUntil) {
+					busyUntil = done
+				}
+			}
+		}
+
+		for _, item := range state.Queue {
+			if item.Window != window.Name || !item.waiting() {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var estimate time.Duration
+			if impact, err := AnalyzeImpact(ctx, s.client, s.namespace, []string{item.Remediation}); err == nil {
+				estimate = time.Duration(impact.EstimatedSeconds) * time.Second
+			}
+
+			if c := clock(); c.After(busyUntil) {
+				busyUntil = c
+			}
+			if finish := busyUntil.Add(estimate); finish.After(end) {
+				if item.State == ScheduledHeld && item.EstimatedSeconds == int64(estimate.Seconds()) {
+					continue
+				}
+				held := s.update(ctx, item.ID, func(it *ScheduledRemediation) bool {
+					if !it.waiting() {
+						return false
+					}
+					it.State = ScheduledHeld
+					it.EstimatedSeconds = int64(estimate.Seconds())
+					it.Message = fmt.Sprintf("Estimated rollout of %s would not finish before the window closes at %s; held for the next window",
+						estimate, end.Format(time.RFC3339))
+					return true
+				})
+				if held != nil {
+					s.notify(*held, nil)
+				}
+				continue
+			}
+
+			started := clock()
+			// Another scheduler may have claimed the item since it was
+			// listed; only a waiting item is applied.
+			applying := s.update(ctx, item.ID, func(it *ScheduledRemediation) bool {
+				if !it.waiting() {
+					return false
+				}
+				it.State = ScheduledApplying
+				it.StartedAt = &started
+				it.EstimatedSeconds = int64(estimate.Seconds())
+				it.Message = ""
+				return true
+			})
+			if applying == nil {
+				continue
+			}
+			s.notify(*applying, nil)
+			busyUntil = started.Add(estimate)
+
+			result, err := ApplyRemediation(ctx, s.client, s.mode, s.namespace, item.Remediation)
+			finished := s.update(ctx, item.ID, func(it *ScheduledRemediation) bool {
+				finishedAt := clock()
+				it.FinishedAt = &finishedAt
+				if err != nil {
+					it.State = ScheduledFailed
+					it.Message = err.Error()
+					return true
+				}
+				it.State = ScheduledApplied
+				it.Message = result.Message
+				return true
+			})
+			if finished != nil {
+				s.notify(*finished, result)
+			}
+		}
+	}
+	return nil
+}
+
+// update applies mutate to a queue item, returning the updated item or nil
+// if it was canceled meanwhile, mutate left it unchanged by returning false,
+// or it could not be saved.
+func (s *MaintenanceScheduler) update(ctx context.Context, id string, mutate func(*ScheduledRemediation) bool) *ScheduledRemediation {
+	var updated *ScheduledRemediation
+	err := updateMaintenanceState(ctx, s.client, s.namespace, func(state *maintenanceState) error {
+		updated = nil
+		i := slices.IndexFunc(state.Queue, func(item ScheduledRemediation) bool { return item.ID == id })
+		if i < 0 || state.Queue[i].State == ScheduledCanceled {
+			return nil
+		}
+		if !mutate(&state.Queue[i]) {
+			return nil
+		}
+		item := state.Queue[i]
+		updated = &item
+		return nil
+	})
+	if err != nil {
+		slog.Warn("failed to update scheduled remediation", "id", id, "error", err)
+		return nil
+	}
+	return updated
+}
+
+// waiting reports whether an item is still to be applied.
+func (r ScheduledRemediation) waiting() bool {
+	return r.State == ScheduledQueued || r.State == ScheduledHeld
+}
+
+// parse validates a window's schedule, duration and timezone.
+func (w MaintenanceWindow) parse() (*cronSchedule, time.Duration, *time.Location, error) {
+	sched, err := parseCron(w.Schedule)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	d, err := time.ParseDuration(w.Duration)
+	if err != nil || d <= 0 {
+		return nil, 0, nil, fmt.Errorf("invalid duration %q: must be a positive duration such as 6h", w.Duration)
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("invalid timezone %q: %w", w.Timezone, err)
+	}
+	return sched, d, loc, nil
+}
+
+// openAt reports whether the window is open at now, and if so the start and
+// end of that opening.
+func (w MaintenanceWindow) openAt(now time.Time) (start, end time.Time, open bool) {
+	sched, d, loc, err := w.parse()
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	// The opening that contains now is the first start after now-duration
+	start = sched.next(now.In(loc).Add(-d))
+	if start.IsZero() || start.After(now) {
+		return time.Time{}, time.Time{}, false
+	}
+	end = start.Add(d)
+	return start, end, now.Before(end)
+}
+
+// describe fills in the computed NextStart, Open and ClosesAt fields.
+func (w *MaintenanceWindow) describe(now time.Time) {
+	sched, _, loc, err := w.parse()
+	if err != nil {
+		return
+	}
+	if next := sched.next(now.In(loc)); !next.IsZero() {
+		w.NextStart = &next
+	}
+	if _, end, open := w.openAt(now); open {
+		w.Open = true
+		w.ClosesAt = &end
+	}
+}
+
+func loadMaintenanceState(ctx context.Context, client *k8s.Client, namespace string) (*maintenanceState, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	state := &maintenanceState{Windows: []MaintenanceWindow{}, Queue: []ScheduledRemediation{}}
+
+	cm, err := client.Clientset.CoreV1().ConfigMaps(namespace).Get(ctx, maintenanceConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting maintenance schedule: %w", err)
+	}
+	if err := decodeMaintenanceState(cm, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func decodeMaintenanceState(cm *corev1.ConfigMap, state *maintenanceState) error {
+	if raw := cm.Data[maintenanceWindowsKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &state.Windows); err != nil {
+			return fmt.Errorf("decoding maintenance windows: %w", err)
+		}
+	}
+	if raw := cm.Data[maintenanceQueueKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &state.Queue); err != nil {
+			return fmt.Errorf("decoding maintenance queue: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateMaintenanceState loads the stored state, applies mutate and writes
+// it back, retrying on conflicting writes. The ConfigMap's resourceVersion
+// guards concurrent writers, so mutate may run more than once and must reset
+// anything it records outside state. Finished queue items beyond
+// maxScheduledHistory are dropped, oldest first.
+func updateMaintenanceState(ctx context.Context, client *k8s.Client, namespace string, mutate func(*maintenanceState) error) error {
+	if client == nil {
+		return fmt.Errorf("kubernetes client is nil")
+	}
+	configMaps := client.Clientset.CoreV1().ConfigMaps(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		state := &maintenanceState{Windows: []MaintenanceWindow{}, Queue: []ScheduledRemediation{}}
+		cm, err := configMaps.Get(ctx, maintenanceConfigMap, metav1.GetOptions{})
+		exists := err == nil
+		switch {
+		case k8serrors.IsNotFound(err):
+			cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: maintenanceConfigMap, Namespace: namespace}}
+		case err != nil:
+			return fmt.Errorf("getting maintenance schedule: %w", err)
+		default:
+			if err := decodeMaintenanceState(cm, state); err != nil {
+				return err
+			}
+		}
+
+		if err := mutate(state); err != nil {
+			return err
+		}
+		pruneScheduledHistory(state)
+
+		windows, err := json.Marshal(state.Windows)
+		if err != nil {
+			return fmt.Errorf("encoding maintenance windows: %w", err)
+		}
+		queue, err := json.Marshal(state.Queue)
+		if err != nil {
+			return fmt.Errorf("encoding maintenance queue: %w", err)
+		}
+		cm.Data = map[string]string{
+			maintenanceWindowsKey: string(windows),
+			maintenanceQueueKey:   string(queue),
+		}
+
+		if !exists {
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		} else {
+			_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		}
+		if k8serrors.IsAlreadyExists(err) {
+			return k8serrors.NewConflict(corev1.Resource("configmaps"), maintenanceConfigMap, err)
+		}
+		return err
+	})
+}
+
+func pruneScheduledHistory(state *maintenanceState) {
+	finished := 0
+	for _, item := range state.Queue {
+		if !item.waiting() && item.State != ScheduledApplying {
+			finished++
+		}
+	}
+	for i := 0; finished > maxScheduledHistory && i < len(state.Queue); {
+		item := state.Queue[i]
+		if !item.waiting() && item.State != ScheduledApplying {
+			state.Queue = slices.Delete(state.Queue, i, i+1)
+			finished--
+			continue
+		}
+		i++
+	}
+}
@@ -0,0 +1,219 @@
+package compliance
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// weekendWindow opens Saturdays at 22:00 UTC for 6 hours.
+var weekendWindow = MaintenanceWindow{Name: "weekend", Schedule: "0 22 * * 6", Duration: "6h"}
+
+func TestMaintenanceWindow_OpenAt(t *testing.T) {
+	w := weekendWindow
+	w.Timezone = "UTC"
+
+	tests := []struct {
+		name string
+		now  time.Time
+		open bool
+	}{
+		{"before opening", time.Date(2026, 1, 10, 21, 59, 0, 0, time.UTC), false},
+		{"at opening", time.Date(2026, 1, 10, 22, 0, 0, 0, time.UTC), true},
+		{"after midnight", time.Date(2026, 1, 11, 3, 59, 0, 0, time.UTC), true},
+		{"at close", time.Date(2026, 1, 11, 4, 0, 0, 0, time.UTC), false},
+		{"midweek", time.Date(2026, 1, 7, 23, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, open := w.openAt(tt.now)
+			if open != tt.open {
+				t.Fatalf("open = %v, want %v", open, tt.open)
+			}
+			if open && (!start.Equal(time.Date(2026, 1, 10, 22, 0, 0, 0, time.UTC)) || end.Sub(start) != 6*time.Hour) {
+				t.Errorf("unexpected opening %s - %s", start, end)
+			}
+		})
+	}
+}
+
+func TestSaveMaintenanceWindow_Validation(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+	ns := "openshift-compliance"
+
+	for _, w := range []MaintenanceWindow{
+		{Name: "", Schedule: "0 22 * * 6", Duration: "6h"},
+		{Name: "w", Schedule: "bogus", Duration: "6h"},
+		{Name: "w", Schedule: "0 22 * * 6", Duration: "-1h"},
+		{Name: "w", Schedule: "0 22 * * 6", Duration: "6h", Timezone: "Nowhere/City"},
+	} {
+		if _, err := SaveMaintenanceWindow(ctx, client, ns, w); err == nil {
+			t.Errorf("expected error for %+v", w)
+		}
+	}
+
+	saved, err := SaveMaintenanceWindow(ctx, client, ns, weekendWindow)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if saved.Timezone != "UTC" || saved.NextStart == nil || saved.NextStart.Weekday() != time.Saturday {
+		t.Errorf("unexpected window: %+v", saved)
+	}
+}
+
+func TestQueueAndCancel(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(newConfigMapRemediation("rem-cm", ns, "cm", nil))
+
+	if _, err := QueueRemediations(ctx, client, ns, "weekend", []string{"rem-cm"}); err == nil {
+		t.Error("expected error for unknown window")
+	}
+	if _, err := SaveMaintenanceWindow(ctx, client, ns, weekendWindow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := QueueRemediations(ctx, client, ns, "weekend", []string{"missing"}); err == nil {
+		t.Error("expected error for unknown remediation")
+	}
+
+	queued, err := QueueRemediations(ctx, client, ns, "weekend", []string{"rem-cm"})
+	if err != nil || len(queued) != 1 || queued[0].State != ScheduledQueued {
+		t.Fatalf("queue: %+v (err %v)", queued, err)
+	}
+	if err := DeleteMaintenanceWindow(ctx, client, ns, "weekend"); err == nil {
+		t.Error("expected error deleting a window with queued items")
+	}
+
+	canceled, err := CancelScheduledRemediation(ctx, client, ns, queued[0].ID)
+	if err != nil || canceled.State != ScheduledCanceled {
+		t.Fatalf("cancel: %+v (err %v)", canceled, err)
+	}
+	if _, err := CancelScheduledRemediation(ctx, client, ns, queued[0].ID); err == nil {
+		t.Error("expected error canceling twice")
+	}
+	if err := DeleteMaintenanceWindow(ctx, client, ns, "weekend"); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+}
+
+func TestMaintenanceScheduler_RunDue(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(
+		newMachineConfigPool("worker", false, 6, 6, "True"),
+		newConfigMapRemediation("rem-cm", ns, "cm", nil),
+		newMachineConfigRemediation("rem-mc", ns, "worker"),
+	)
+
+	if _, err := SaveMaintenanceWindow(ctx, client, ns, weekendWindow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := QueueRemediations(ctx, client, ns, "weekend", []string{"rem-cm", "rem-mc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var notified []ScheduledRemediation
+	s := NewMaintenanceScheduler(client, RemediationModeDirect, ns, func(item ScheduledRemediation, _ *RemediationResult) {
+		notified = append(notified, item)
+	})
+
+	stateOf := func(name string) ScheduledRemediation {
+		items, err := ListScheduledRemediations(ctx, client, ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			if item.Remediation == name {
+				return item
+			}
+		}
+		t.Fatalf("no queue item for %s", name)
+		return ScheduledRemediation{}
+	}
+
+	// Outside the window nothing happens
+	if err := s.runDue(ctx, time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if len(notified) != 0 || stateOf("rem-cm").State != ScheduledQueued {
+		t.Fatalf("expected no activity outside the window, got %+v", notified)
+	}
+
+	// 30 minutes before close: the ConfigMap applies, the 6-node worker
+	// rollout (an hour at one node per 10 minutes) is held
+	if err := s.runDue(ctx, time.Date(2026, 1, 11, 3, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if got := stateOf("rem-cm"); got.State != ScheduledApplied || got.FinishedAt == nil {
+		t.Errorf("rem-cm: %+v", got)
+	}
+	if got := stateOf("rem-mc"); got.State != ScheduledHeld || !strings.Contains(got.Message, "held for the next window") {
+		t.Errorf("rem-mc: %+v", got)
+	}
+
+	// Early in the next window it fits
+	if err := s.runDue(ctx, time.Date(2026, 1, 17, 22, 5, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if got := stateOf("rem-mc"); got.State != ScheduledApplied || got.EstimatedSeconds != int64(time.Hour.Seconds()) {
+		t.Errorf("rem-mc: %+v", got)
+	}
+	if len(notified) == 0 {
+		t.Error("expected notifications")
+	}
+}
+
+func TestMaintenanceScheduler_SkipsClaimedItems(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(
+		newConfigMapRemediation("rem-a", ns, "a", nil),
+		newConfigMapRemediation("rem-b", ns, "b", nil),
+	)
+
+	if _, err := SaveMaintenanceWindow(ctx, client, ns, weekendWindow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := QueueRemediations(ctx, client, ns, "weekend", []string{"rem-a", "rem-b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another scheduler claims rem-b while this one is applying rem-a
+	var notified []string
+	s := NewMaintenanceScheduler(client, RemediationModeDirect, ns, func(item ScheduledRemediation, _ *RemediationResult) {
+		notified = append(notified, item.Remediation)
+		if item.Remediation != "rem-a" || item.State != ScheduledApplying {
+			return
+		}
+		err := updateMaintenanceState(ctx, client, ns, func(state *maintenanceState) error {
+			for i := range state.Queue {
+				if state.Queue[i].Remediation == "rem-b" {
+					state.Queue[i].State = ScheduledApplying
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := s.runDue(ctx, time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(notified, "rem-b") {
+		t.Errorf("expected rem-b to be left to the scheduler that claimed it, got notifications for %v", notified)
+	}
+	items, err := ListScheduledRemediations(ctx, client, ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if item.Remediation == "rem-b" && (item.State != ScheduledApplying || item.StartedAt != nil) {
+			t.Errorf("rem-b: %+v", item)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	// manifestInstallLabel marks every object a manifest install applied, so
+	// uninstall only deletes what the dashboard created.
+	manifestInstallLabel = "compliance-dashboard/manifest-install"
+
+	communityOperatorImage = "ghcr.io/complianceascode/compliance-operator"
+	communityOpenSCAPImage = "ghcr.io/complianceascode/openscap-ocp:latest"
+)
+
+// bundledManifests holds the manifests of each release a manifest install
+// supports, in manifests/<ref>/: the release's deploy/crds and its RBAC and
+// operator manifests, copied unchanged by make vendor-manifests.
+//
+//go:embed manifests
+var bundledManifests embed.FS
+
+// manifestFiles are applied in order, so the CRDs and RBAC exist before the
+// Deployment that needs them. Uninstall deletes in reverse.
+var manifestFiles = []string{
+	"crds/*.yaml",
+	"service_account.yaml",
+	"role.yaml",
+	"role_binding.yaml",
+	"operator.yaml",
+}
+
+var (
+	crdGVR = schema.GroupVersionResource{
+		Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions",
+	}
+	deploymentGVR = schema.GroupVersionResource{
+		Group: "apps", Version: "v1", Resource: "deployments",
+	}
+)
+
+// manifestResources maps the kinds in the bundled manifests to their
+// resources.
+var manifestResources = map[string]schema.GroupVersionResource{
+	"CustomResourceDefinition": crdGVR,
+	"ServiceAccount":           {Version: "v1", Resource: "serviceaccounts"},
+	"Role":                     {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+	"RoleBinding":              {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+	"ClusterRole":              {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+	"ClusterRoleBinding":       {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+	"Deployment":               deploymentGVR,
+}
+
+// manifestValues are set on the bundled manifests when they are rendered;
+// empty values leave the upstream ones.
+type manifestValues struct {
+	Namespace     string
+	OperatorImage string
+	OpenSCAPImage string
+	ContentImage  string
+}
+
+// relatedImage returns the image for one of the operator Deployment's
+// RELATED_IMAGE_* env vars, or "" for other vars.
+func (v manifestValues) relatedImage(env string) string {
+	switch env {
+	case "RELATED_IMAGE_OPENSCAP":
+		return v.OpenSCAPImage
+	case "RELATED_IMAGE_OPERATOR":
+		return v.OperatorImage
+	case "RELATED_IMAGE_PROFILE":
+		return v.ContentImage
+	}
+	return ""
+}
+
+// OLMInstalled reports whether the cluster serves the OLM APIs. An error
+// means it could not tell, for example for lack of permission.
+func OLMInstalled(ctx context.Context, client *k8s.Client) (bool, error) {
+	_, err := client.Dynamic.Resource(subscriptionGVR).List(ctx, metav1.ListOptions{Limit: 1})
+	switch {
+	case err == nil:
+		return true, nil
+	case IsCRDNotFound(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// BundledManifestRefs returns the releases a manifest install can install,
+// sorted by name.
+func BundledManifestRefs() []string {
+	entries, _ := bundledManifests.ReadDir("manifests")
+	var refs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			refs = append(refs, entry.Name())
+		}
+	}
+	return refs
+}
+
+// checkManifestRef fails unless manifests for coRef are bundled.
+func checkManifestRef(coRef string) error {
+	refs := BundledManifestRefs()
+	if !slices.Contains(refs, coRef) {
+		if coRef == "" {
+			coRef = "an unknown release"
+		}
+		return fmt.Errorf("no bundled manifests for %s; installs without OLM support %s, set --co-ref to one of them",
+			coRef, strings.Join(refs, ", "))
+	}
+	return nil
+}
+
+// newManifestValues returns the values a manifest install renders with. The
+// operator image is tagged with coRef; disconnected installs pull every image
+// from its mirror.
+func newManifestValues(ctx context.Context, client *k8s.Client, namespace, coRef string, opts InstallOptions) manifestValues {
+	values := manifestValues{
+		Namespace:     namespace,
+		OperatorImage: communityOperatorImage + ":" + coRef,
+		OpenSCAPImage: communityOpenSCAPImage,
+		ContentImage:  defaultContentImage,
+	}
+	if opts.Disconnected {
+		rules, _ := ListMirrorRules(ctx, client)
+		values.OperatorImage = MirrorImage(values.OperatorImage, opts.MirrorRegistry, rules)
+		values.OpenSCAPImage = MirrorImage(values.OpenSCAPImage, opts.MirrorRegistry, rules)
+		values.ContentImage = MirrorImage(values.ContentImage, opts.MirrorRegistry, rules)
+	}
+	return values
+}
+
+// renderManifests renders the manifests bundled for coRef into objects, in
+// apply order, each labelled with manifestInstallLabel. Only the namespace
+// and images differ from the upstream manifests.
+func renderManifests(coRef string, values manifestValues) ([]*unstructured.Unstructured, error) {
+	if err := checkManifestRef(coRef); err != nil {
+		return nil, err
+	}
+	var objects []*unstructured.Unstructured
+	for _, pattern := range manifestFiles {
+		files, err := fs.Glob(bundledManifests, path.Join("manifests", coRef, pattern))
+		if err != nil || len(files) == 0 {
+			return nil, fmt.Errorf("no bundled manifests match %s for %s", pattern, coRef)
+		}
+		for _, file := range files {
+			raw, err := bundledManifests.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", file, err)
+			}
+			decoded, err := decodeManifests(file, raw)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range decoded {
+				if err := localizeManifest(obj, values); err != nil {
+					return nil, fmt.Errorf("%s: %w", file, err)
+				}
+				labels := obj.GetLabels()
+				if labels == nil {
+					labels = map[string]string{}
+				}
+				labels[manifestInstallLabel] = "true"
+				obj.SetLabels(labels)
+				objects = append(objects, obj)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// decodeManifests decodes the objects of a multi-document YAML file.
+func decodeManifests(file string, raw []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(raw), 4096)
+	for {
+		var doc map[string]any
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decoding %s: %w", file, err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: doc}
+		if _, ok := manifestResources[obj.GetKind()]; !ok {
+			return nil, fmt.Errorf("%s: unsupported kind %s", file, obj.GetKind())
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// localizeManifest moves an upstream object into values.Namespace, binding
+// the ServiceAccounts there, and points the operator Deployment at
+// values' images.
+func localizeManifest(obj *unstructured.Unstructured, values manifestValues) error {
+	if obj.GetNamespace() != "" && values.Namespace != "" {
+		obj.SetNamespace(values.Namespace)
+	}
+	switch obj.GetKind() {
+	case "RoleBinding", "ClusterRoleBinding":
+		subjects, _, _ := unstructured.NestedSlice(obj.Object, "subjects")
+		for _, s := range subjects {
+			if subject, ok := s.(map[string]any); ok && subject["kind"] == "ServiceAccount" && values.Namespace != "" {
+				subject["namespace"] = values.Namespace
+			}
+		}
+		if subjects != nil {
+			return unstructured.SetNestedSlice(obj.Object, subjects, "subjects")
+		}
+	case "Deployment":
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		for _, c := range containers {
+			container, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			if container["name"] == operatorName && values.OperatorImage != "" {
+				container["image"] = values.OperatorImage
+			}
+			env, _ := container["env"].([]any)
+			for _, e := range env {
+				entry, ok := e.(map[string]any)
+				if !ok {
+					continue
+				}
+				name, _ := entry["name"].(string)
+				if image := values.relatedImage(name); image != "" {
+					entry["value"] = image
+				}
+			}
+		}
+		if containers != nil {
+			return unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+		}
+	}
+	return nil
+}
+
+func manifestResource(client *k8s.Client, obj *unstructured.Unstructured) dynamic.ResourceInterface {
+	gvr := manifestResources[obj.GetKind()]
+	if ns := obj.GetNamespace(); ns != "" {
+		return client.Dynamic.Resource(gvr).Namespace(ns)
+	}
+	return client.Dynamic.Resource(gvr)
+}
+
+// installManifests applies the bundled manifests for coRef, replacing
+// objects left by an earlier manifest install. It returns how many objects
+// it applied and names the existing objects it left alone because they lack
+// manifestInstallLabel, such as a ClusterRole of the same name created by
+// someone else. The options' Subscription config is applied to the operator
+// Deployment as OLM would.
+func installManifests(ctx context.Context, client *k8s.Client, namespace, coRef string, opts InstallOptions) (int, []string, error) {
+	objects, err := renderManifests(coRef, newManifestValues(ctx, client, namespace, coRef, opts))
+	if err != nil {
+		return 0, nil, err
+	}
+	applied := 0
+	var skipped []string
+	for _, obj := range objects {
+		if obj.GetKind() == "Deployment" && opts.Config != nil {
+			if err := applyDeploymentConfig(obj, opts.Config); err != nil {
+				return 0, nil, err
+			}
+		}
+		res := manifestResource(client, obj)
+		_, err := res.Create(ctx, obj, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			var existing *unstructured.Unstructured
+			existing, err = res.Get(ctx, obj.GetName(), metav1.GetOptions{})
+			if err == nil && existing.GetLabels()[manifestInstallLabel] != "true" {
+				skipped = append(skipped, obj.GetKind()+" "+obj.GetName())
+				continue
+			}
+			if err == nil {
+				obj.SetResourceVersion(existing.GetResourceVersion())
+				_, err = res.Update(ctx, obj, metav1.UpdateOptions{})
+			}
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("applying %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		applied++
+	}
+	return applied, skipped, nil
+}
+
+// applyDeploymentConfig sets the node selector, tolerations, resources and
+// env of a Subscription config on the operator Deployment. Env vars replace
+// those of the same name.
+func applyDeploymentConfig(deploy *unstructured.Unstructured, cfg *SubscriptionConfig) error {
+	// Round-trip through JSON, as in buildSubscription, for plain values.
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("encoding Subscription config: %w", err)
+	}
+	var config map[string]any
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("encoding Subscription config: %w", err)
+	}
+
+	podSpec, _, _ := unstructured.NestedMap(deploy.Object, "spec", "template", "spec")
+	if podSpec == nil {
+		return fmt.Errorf("deployment %s has no pod spec", deploy.GetName())
+	}
+	for _, field := range []string{"nodeSelector", "tolerations"} {
+		if v, ok := config[field]; ok {
+			podSpec[field] = v
+		}
+	}
+	containers, _ := podSpec["containers"].([]any)
+	for _, c := range containers {
+		container, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		if v, ok := config["resources"]; ok {
+			container["resources"] = v
+		}
+		extra, _ := config["env"].([]any)
+		env, _ := container["env"].([]any)
+		for _, e := range extra {
+			entry, _ := e.(map[string]any)
+			replaced := false
+			for i, cur := range env {
+				if current, ok := cur.(map[string]any); ok && current["name"] == entry["name"] {
+					env[i], replaced = e, true
+				}
+			}
+			if !replaced {
+				env = append(env, e)
+			}
+		}
+		container["env"] = env
+	}
+	return unstructured.SetNestedMap(deploy.Object, podSpec, "spec", "template", "spec")
+}
+
+// manifestDeployment returns the operator Deployment of a manifest install
+// in namespace, or nil when the operator was not installed that way.
+func manifestDeployment(ctx context.Context, client *k8s.Client, namespace string) *unstructured.Unstructured {
+	deploy, err := client.Dynamic.Resource(deploymentGVR).Namespace(namespace).
+		Get(ctx, operatorName, metav1.GetOptions{})
+	if err != nil || deploy.GetLabels()[manifestInstallLabel] != "true" {
+		return nil
+	}
+	return deploy
+}
+
+// manifestVersion names the release a manifest install runs, in the same
+// form as a CSV name, from the operator image tag.
+func manifestVersion(deploy *unstructured.Unstructured) string {
+	containers, _, _ := unstructured.NestedSlice(deploy.Object, "spec", "template", "spec", "containers")
+	for _, c := range containers {
+		container, _ := c.(map[string]any)
+		if container["name"] != operatorName {
+			continue
+		}
+		image, _ := container["image"].(string)
+		if tag := imageTag(image); tag != "" {
+			return operatorName + "." + tag
+		}
+	}
+	return operatorName
+}
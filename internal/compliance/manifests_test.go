@@ -0,0 +1,254 @@
+package compliance
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// newNoOLMClient returns a test client for a cluster that does not serve the
+// OLM APIs, such as kind.
+func newNoOLMClient(objects ...runtime.Object) *k8s.Client {
+	return withoutOLM(newTestClient(objects...))
+}
+
+// withoutOLM makes client's cluster stop serving the OLM APIs.
+func withoutOLM(client *k8s.Client) *k8s.Client {
+	client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("*", "subscriptions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewGenericServerResponse(http.StatusNotFound, action.GetVerb(), subscriptionGVR.GroupResource(), "", "", 0, false)
+	})
+	return client
+}
+
+func drainProgress(progress <-chan InstallProgress) InstallProgress {
+	var last InstallProgress
+	for p := range progress {
+		last = p
+	}
+	return last
+}
+
+func TestInstallWithoutOLM(t *testing.T) {
+	fastInstallPoll(t)
+	ctx := context.Background()
+	ns := "compliance-operator"
+	client := withoutOLM(newPreflightClient(t, nil))
+	images := ImageChecker{Client: testRegistryClient(t, func(w http.ResponseWriter, r *http.Request) {})}
+
+	opts := InstallOptions{
+		Config:   &SubscriptionConfig{Env: []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"}}},
+		Timeouts: &InstallTimeouts{PodsSeconds: 1, ProfileBundlesSeconds: 1},
+	}
+	progress := make(chan InstallProgress, 256)
+	Install(ctx, client, images, ns, "dashboard", "v1.7.0", opts, progress)
+	if last := drainProgress(progress); last.Error != "" || last.Message != installDoneMessage {
+		t.Fatalf("install did not finish: %+v", last)
+	}
+
+	state, err := GetInstallState(ctx, client, "dashboard")
+	if err != nil {
+		t.Fatalf("GetInstallState: %v", err)
+	}
+	if state.Resolved == nil || state.Resolved.Source != InstallSourceManifests {
+		t.Fatalf("expected the manifests source, got %+v", state.Resolved)
+	}
+	for _, step := range state.Steps {
+		if step.Name == "csv" && step.State != StepSkipped {
+			t.Errorf("step %s = %s, want skipped", step.Name, step.State)
+		}
+	}
+
+	deploy := manifestDeployment(ctx, client, ns)
+	if deploy == nil {
+		t.Fatal("operator Deployment was not created with the manifest label")
+	}
+	containers, _, _ := unstructured.NestedSlice(deploy.Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]any)
+	if container["image"] != "ghcr.io/complianceascode/compliance-operator:v1.7.0" {
+		t.Errorf("unexpected operator image %v", container["image"])
+	}
+	env := container["env"].([]any)
+	if last := env[len(env)-1].(map[string]any); last["name"] != "HTTPS_PROXY" {
+		t.Errorf("Subscription config env not applied: %v", env)
+	}
+	crd, err := client.Dynamic.Resource(crdGVR).Get(ctx, "profilebundles.compliance.openshift.io", metav1.GetOptions{})
+	if err != nil || crd.GetLabels()[manifestInstallLabel] != "true" {
+		t.Fatalf("ProfileBundle CRD not applied: %v", err)
+	}
+
+	status, err := GetStatus(ctx, client, ns)
+	if err != nil || !status.Installed || status.Source != InstallSourceManifests || status.Version != "compliance-operator.v1.7.0" {
+		t.Errorf("GetStatus = %+v, %v", status, err)
+	}
+
+	progress = make(chan InstallProgress, 256)
+	Uninstall(ctx, client, ns, "dashboard", UninstallOptions{}, progress)
+	if last := drainProgress(progress); last.Error != "" || last.Message != uninstallDoneMessage {
+		t.Fatalf("uninstall did not finish: %+v", last)
+	}
+	if _, err := client.Dynamic.Resource(crdGVR).Get(ctx, "profilebundles.compliance.openshift.io", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the CRD to be deleted, got %v", err)
+	}
+	if manifestDeployment(ctx, client, ns) != nil {
+		t.Error("expected the Deployment to be deleted")
+	}
+	state, _ = GetInstallState(ctx, client, "dashboard")
+	for _, step := range state.Steps {
+		want := StepSucceeded
+		switch step.Name {
+		case "export", "cleanup", "subscription", "csv", "operatorgroup", "catalogsource", "rbac":
+			want = StepSkipped
+		}
+		if step.State != want {
+			t.Errorf("uninstall step %s = %s, want %s", step.Name, step.State, want)
+		}
+	}
+}
+
+func TestResolveInstallOptionsWithoutOLM(t *testing.T) {
+	ctx := context.Background()
+	client := newNoOLMClient()
+
+	resolved, err := resolveInstallOptions(ctx, client, "v1.7.0", InstallOptions{})
+	if err != nil || resolved.Source != InstallSourceManifests {
+		t.Errorf("resolved = %+v, %v; want the manifests source", resolved, err)
+	}
+	if _, err := resolveInstallOptions(ctx, client, "v1.7.0", InstallOptions{Source: InstallSourceCommunity}); err == nil || !strings.Contains(err.Error(), "no OLM") {
+		t.Errorf("expected an OLM source to fail without OLM, got %v", err)
+	}
+	if _, err := resolveInstallOptions(ctx, client, "v9.9.9", InstallOptions{}); err == nil || !strings.Contains(err.Error(), "no bundled manifests") {
+		t.Errorf("expected a release without bundled manifests to be rejected, got %v", err)
+	}
+	if err := (InstallOptions{Source: InstallSourceManifests, Channel: "stable"}).Validate(); err == nil {
+		t.Error("expected a channel to be rejected for a manifests install")
+	}
+}
+
+func TestUninstallManifestsKeepsUnlabelledObjects(t *testing.T) {
+	ctx := context.Background()
+	crd := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]any{"name": "profilebundles.compliance.openshift.io"},
+	}}
+	client := newTestClient(crd)
+
+	objects, err := planManifests(ctx, client, "openshift-compliance", UninstallOptions{})
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("planManifests = %+v, %v", objects, err)
+	}
+	if _, err := client.Dynamic.Resource(crdGVR).Get(ctx, crd.GetName(), metav1.GetOptions{}); err != nil {
+		t.Errorf("the OLM-owned CRD was deleted: %v", err)
+	}
+}
+
+func TestInstallManifestsSkipsUnlabelledObjects(t *testing.T) {
+	ctx := context.Background()
+	role := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]any{"name": "compliance-operator"},
+		"rules":      []any{},
+	}}
+	client := newNoOLMClient(role)
+	clusterRoles := client.Dynamic.Resource(manifestResources["ClusterRole"])
+
+	applied, skipped, err := installManifests(ctx, client, "openshift-compliance", "v1.7.0", InstallOptions{})
+	if err != nil {
+		t.Fatalf("installManifests: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0] != "ClusterRole compliance-operator" || applied == 0 {
+		t.Errorf("applied %d, skipped %v; want only the ClusterRole skipped", applied, skipped)
+	}
+	existing, _ := clusterRoles.Get(ctx, "compliance-operator", metav1.GetOptions{})
+	if rules, _, _ := unstructured.NestedSlice(existing.Object, "rules"); len(rules) != 0 || existing.GetLabels()[manifestInstallLabel] != "" {
+		t.Errorf("the existing ClusterRole was replaced: %+v", existing.Object)
+	}
+
+	// Objects of an earlier manifest install are replaced.
+	if _, skipped, err := installManifests(ctx, client, "openshift-compliance", "v1.7.0", InstallOptions{}); err != nil || len(skipped) != 1 {
+		t.Errorf("second install skipped %v, %v; want only the ClusterRole", skipped, err)
+	}
+}
+
+func TestRenderManifestsKeepsUpstream(t *testing.T) {
+	values := manifestValues{
+		Namespace:     "compliance-operator",
+		OperatorImage: "mirror.example.com/compliance-operator:v1.7.0",
+		OpenSCAPImage: "mirror.example.com/openscap-ocp:latest",
+		ContentImage:  "mirror.example.com/k8scontent:latest",
+	}
+	objects, err := renderManifests("v1.7.0", values)
+	if err != nil {
+		t.Fatalf("renderManifests: %v", err)
+	}
+	byName := map[string]*unstructured.Unstructured{}
+	for _, obj := range objects {
+		byName[obj.GetKind()+"/"+obj.GetName()] = obj
+	}
+
+	// The CRDs are applied as vendored, defaults included
+	raw, err := bundledManifests.ReadFile("manifests/v1.7.0/crds/compliance.openshift.io_compliancescans.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vendored, err := decodeManifests("compliancescans", raw)
+	if err != nil || len(vendored) != 1 {
+		t.Fatalf("decoding the vendored CRD: %v", err)
+	}
+	crd := byName["CustomResourceDefinition/compliancescans.compliance.openshift.io"]
+	if crd == nil {
+		t.Fatal("ComplianceScan CRD not rendered")
+	}
+	vendored[0].SetLabels(map[string]string{manifestInstallLabel: "true"})
+	if !equality.Semantic.DeepEqual(crd.Object, vendored[0].Object) {
+		t.Error("the rendered ComplianceScan CRD differs from the vendored one")
+	}
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	spec := []string{"schema", "openAPIV3Schema", "properties", "spec", "properties"}
+	for _, tc := range []struct {
+		fields []string
+		want   any
+	}{
+		{append(spec, "scanType", "default"), "Node"},
+		{append(spec, "rawResultStorage", "properties", "size", "default"), "1Gi"},
+		{append(spec, "strictNodeScan", "default"), true},
+	} {
+		got, _, _ := unstructured.NestedFieldNoCopy(versions[0].(map[string]any), tc.fields...)
+		if got != tc.want {
+			t.Errorf("%s = %v, want %v", strings.Join(tc.fields[len(spec):], "."), got, tc.want)
+		}
+	}
+
+	// Only the namespace and images are changed
+	if sa := byName["ServiceAccount/compliance-operator"]; sa == nil || sa.GetNamespace() != values.Namespace {
+		t.Errorf("ServiceAccount not moved to %s: %+v", values.Namespace, sa)
+	}
+	subjects, _, _ := unstructured.NestedSlice(byName["ClusterRoleBinding/compliance-operator"].Object, "subjects")
+	if len(subjects) != 1 || subjects[0].(map[string]any)["namespace"] != values.Namespace {
+		t.Errorf("ClusterRoleBinding subjects = %v", subjects)
+	}
+	containers, _, _ := unstructured.NestedSlice(byName["Deployment/compliance-operator"].Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]any)
+	if container["image"] != values.OperatorImage {
+		t.Errorf("operator image = %v", container["image"])
+	}
+	for _, e := range container["env"].([]any) {
+		entry := e.(map[string]any)
+		if want := values.relatedImage(entry["name"].(string)); want != "" && entry["value"] != want {
+			t.Errorf("%s = %v, want %s", entry["name"], entry["value"], want)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	communityCatalogImage = "ghcr.io/complianceascode/compliance-operator-catalog"
+	// defaultContentImage is the content image the upstream operator's
+	// default ProfileBundles use.
+	defaultContentImage = "ghcr.io/complianceascode/k8scontent:latest"
+)
+
+// Image check statuses reported by the mirror preflight.
+const (
+	ImageResolved     = "resolved"
+	ImageUnauthorized = "unauthorized"
+	ImageMissing      = "missing"
+	ImageUnreachable  = "unreachable"
+	// ImageRejected marks an image on a registry the checker may not
+	// contact; it was not checked.
+	ImageRejected = "rejected"
+)
+
+var (
+	imageContentSourcePolicyGVR = schema.GroupVersionResource{
+		Group: "operator.openshift.io", Version: "v1alpha1", Resource: "imagecontentsourcepolicies",
+	}
+	imageDigestMirrorSetGVR = schema.GroupVersionResource{
+		Group: "config.openshift.io", Version: "v1", Resource: "imagedigestmirrorsets",
+	}
+	imageTagMirrorSetGVR = schema.GroupVersionResource{
+		Group: "config.openshift.io", Version: "v1", Resource: "imagetagmirrorsets",
+	}
+)
+
+// defaultRegistryClient checks images when an ImageChecker has no Client.
+var defaultRegistryClient = &http.Client{Timeout: 10 * time.Second}
+
+// ListMirrorRules returns the cluster's image mirror rules from
+// ImageContentSourcePolicies, ImageDigestMirrorSets and ImageTagMirrorSets.
+// Clusters without these APIs simply have no rules.
+func ListMirrorRules(ctx context.Context, client *k8s.Client) ([]MirrorRule, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	sources := []struct {
+		kind  string
+		gvr   schema.GroupVersionResource
+		field string
+	}{
+		{"ImageContentSourcePolicy", imageContentSourcePolicyGVR, "repositoryDigestMirrors"},
+		{"ImageDigestMirrorSet", imageDigestMirrorSetGVR, "imageDigestMirrors"},
+		{"ImageTagMirrorSet", imageTagMirrorSetGVR, "imageTagMirrors"},
+	}
+
+	rules := []MirrorRule{}
+	for _, src := range sources {
+		list, err := client.Dynamic.Resource(src.gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for _, item := range list.Items {
+			entries, _, _ := unstructured.NestedSlice(item.Object, "spec", src.field)
+			for _, e := range entries {
+				entry, ok := e.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				source, _ := entry["source"].(string)
+				mirrors, _, _ := unstructured.NestedStringSlice(entry, "mirrors")
+				if source == "" || len(mirrors) == 0 {
+					continue
+				}
+				rules = append(rules, MirrorRule{Kind: src.kind, Name: item.GetName(), Source: source, Mirrors: mirrors})
+			}
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Source < rules[j].Source })
+	return rules, nil
+}
+
+// splitImage splits an image reference into its registry, repository and
+// the tag or digest suffix including its separator.
+func splitImage(image string) (registry, repo, ref string) {
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref = name[:i], name[i:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref = name[:i], name[i:]
+	}
+
+	first, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first, rest, ref
+	}
+	if !found {
+		name = "library/" + name
+	}
+	return "docker.io", name, ref
+}
+
+// imageTag returns the tag of an image reference, or "" for digests and
+// untagged references.
+func imageTag(image string) string {
+	_, _, ref := splitImage(image)
+	if strings.HasPrefix(ref, ":") {
+		return ref[1:]
+	}
+	return ""
+}
+
+// MirrorImage returns the reference an image is pulled from in a
+// disconnected cluster. A registry prefix replaces the image's registry and
+// keeps its path, as oc-mirror does; without one the most specific cluster
+// mirror rule applies. Images with no mirror are returned unchanged.
+func MirrorImage(image, registry string, rules []MirrorRule) string {
+	if image == "" {
+		return image
+	}
+	_, repo, ref := splitImage(image)
+	name := strings.TrimSuffix(image, ref)
+
+	if registry != "" {
+		registry = strings.TrimSuffix(registry, "/")
+		if strings.HasPrefix(name, registry+"/") {
+			return image
+		}
+		return registry + "/" + repo + ref
+	}
+
+	best := -1
+	for i, rule := range rules {
+		if name != rule.Source && !strings.HasPrefix(name, rule.Source+"/") {
+			continue
+		}
+		if best < 0 || len(rule.Source) > len(rules[best].Source) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return image
+	}
+	return rules[best].Mirrors[0] + strings.TrimPrefix(name, rules[best].Source) + ref
+}
+
+// ImageChecker asks registries whether images exist. Images and mirror
+// prefixes can come from API callers, so it only contacts the server's
+// configured mirror registry, the registries named in the cluster's image
+// mirror rules and the registries of the install's default images; any
+// other image is rejected unchecked.
+type ImageChecker struct {
+	// MirrorRegistry is the server's --mirror-registry prefix.
+	MirrorRegistry string
+	// Client sends the registry requests; nil uses a client with a 10s
+	// timeout.
+	Client *http.Client
+}
+
+// defaultImageRegistries serve the images an install uses when no mirror
+// is involved.
+var defaultImageRegistries = []string{
+	imageRegistry(communityCatalogImage),
+	imageRegistry(communityOperatorImage),
+	imageRegistry(communityOpenSCAPImage),
+	imageRegistry(defaultContentImage),
+}
+
+// imageRegistry returns the registry host of an image reference.
+func imageRegistry(image string) string {
+	registry, _, _ := splitImage(image)
+	return registry
+}
+
+// prefixRegistry returns the registry host of a repository prefix such as
+// a mirror rule's source or mirror, or --mirror-registry.
+func prefixRegistry(prefix string) string {
+	registry, _, _ := strings.Cut(prefix, "/")
+	return registry
+}
+
+// allows reports whether the checker may contact registry.
+func (c ImageChecker) allows(registry string, rules []MirrorRule) bool {
+	if registry == "" {
+		return false
+	}
+	if c.MirrorRegistry != "" && registry == prefixRegistry(c.MirrorRegistry) {
+		return true
+	}
+	if slices.Contains(defaultImageRegistries, registry) {
+		return true
+	}
+	for _, rule := range rules {
+		if prefixRegistry(rule.Source) == registry {
+			return true
+		}
+		for _, m := range rule.Mirrors {
+			if prefixRegistry(m) == registry {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// check asks the image's registry whether the manifest exists, without
+// pulling it. rules are the cluster's image mirror rules.
+func (c ImageChecker) check(ctx context.Context, image string, rules []MirrorRule) MirrorImageCheck {
+	check := MirrorImageCheck{Image: image}
+	registry, repo, ref := splitImage(image)
+	if !c.allows(registry, rules) {
+		check.Status = ImageRejected
+		check.Message = fmt.Sprintf("registry %s is not the configured --mirror-registry or in the cluster's image mirror rules; not checked", registry)
+		return check
+	}
+	if registry == "docker.io" {
+		registry = "registry-1.docker.io"
+	}
+	reference := strings.TrimLeft(ref, ":@")
+	if reference == "" {
+		reference = "latest"
+	}
+
+	url := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repo, reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		check.Status, check.Message = ImageUnreachable, err.Error()
+		return check
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+	}, ", "))
+
+	httpClient := c.Client
+	if httpClient == nil {
+		httpClient = defaultRegistryClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		check.Status, check.Message = ImageUnreachable, fmt.Sprintf("registry %s is unreachable: %v", registry, err)
+		return check
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		check.Status, check.Message = ImageResolved, "manifest found"
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		check.Status, check.Message = ImageUnauthorized, "registry requires credentials; the cluster's pull secret is used at pull time"
+	case resp.StatusCode == http.StatusNotFound:
+		check.Status, check.Message = ImageMissing, fmt.Sprintf("%s is not in %s", reference, registry)
+	default:
+		check.Status, check.Message = ImageUnreachable, fmt.Sprintf("registry returned %s", resp.Status)
+	}
+	return check
+}
+
+// MirrorPreflight reports the mirrors an install would use and checks that
+// the mirrored catalog and ProfileBundle content images resolve.
+func MirrorPreflight(ctx context.Context, client *k8s.Client, images ImageChecker, namespace, coRef string, opts InstallOptions) (*MirrorReport, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	rules, err := ListMirrorRules(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	report := &MirrorReport{
+		Disconnected: opts.Disconnected,
+		Registry:     opts.MirrorRegistry,
+		Rules:        rules,
+		Images:       []MirrorImageCheck{},
+		Ready:        true,
+	}
+
+	var catalog string
+	switch opts.Source {
+	case "", InstallSourceCommunity:
+		catalog = opts.CatalogImage
+		if catalog == "" && coRef != "" {
+			catalog = fmt.Sprintf("%s:%s", communityCatalogImage, coRef)
+		}
+	default:
+		name, ns := opts.CatalogSource, opts.CatalogSourceNamespace
+		if opts.Source == InstallSourceRedHat {
+			name, ns = redHatCatalog, marketplaceNS
+		}
+		if ns == "" {
+			ns = marketplaceNS
+		}
+		if cs, err := client.Dynamic.Resource(catalogSourceGVR).Namespace(ns).
+			Get(ctx, name, metav1.GetOptions{}); err == nil {
+			catalog, _, _ = unstructured.NestedString(cs.Object, "spec", "image")
+		}
+	}
+	var refs []string
+	if catalog != "" {
+		refs = append(refs, catalog)
+		report.CatalogImage = MirrorImage(catalog, opts.MirrorRegistry, rules)
+	}
+
+	bundles, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err == nil && len(bundles.Items) > 0 {
+		for _, b := range bundles.Items {
+			if image, _, _ := unstructured.NestedString(b.Object, "spec", "contentImage"); image != "" {
+				refs = append(refs, image)
+			}
+		}
+	} else if opts.Source == "" || opts.Source == InstallSourceCommunity {
+		refs = append(refs, defaultContentImage)
+	}
+
+	seen := map[string]bool{}
+	for _, image := range refs {
+		mirror := MirrorImage(image, opts.MirrorRegistry, rules)
+		if seen[mirror] {
+			continue
+		}
+		seen[mirror] = true
+		check := images.check(ctx, mirror, rules)
+		check.Image, check.Mirror = image, mirror
+		if check.Status == ImageMissing || check.Status == ImageUnreachable || check.Status == ImageRejected {
+			report.Ready = false
+		}
+		report.Images = append(report.Images, check)
+	}
+	return report, nil
+}
+
+// RewriteProfileBundleImages points every ProfileBundle's content image at
+// its mirror. Bundles already using the mirror are left alone.
+func RewriteProfileBundleImages(ctx context.Context, client *k8s.Client, namespace, registry string) ([]ProfileBundleRewrite, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	rules, err := ListMirrorRules(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if registry == "" && len(rules) == 0 {
+		return nil, fmt.Errorf("invalid mirror: no registry prefix given and the cluster has no image mirror rules")
+	}
+
+	bundles, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing ProfileBundles: %w", err)
+	}
+
+	rewrites := []ProfileBundleRewrite{}
+	for _, b := range bundles.Items {
+		image, _, _ := unstructured.NestedString(b.Object, "spec", "contentImage")
+		mirror := MirrorImage(image, registry, rules)
+		if image == "" || mirror == image {
+			continue
+		}
+		patch := []byte(fmt.Sprintf(`{"spec":{"contentImage":%q}}`, mirror))
+		if _, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+			Patch(ctx, b.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return rewrites, fmt.Errorf("patching ProfileBundle %s: %w", b.GetName(), err)
+		}
+		rewrites = append(rewrites, ProfileBundleRewrite{Name: b.GetName(), From: image, To: mirror})
+	}
+	return rewrites, nil
+}
@@ -0,0 +1,175 @@
+package compliance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newContentProfileBundle(name, ns, image string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "compliance.openshift.io/v1alpha1",
+		"kind":       "ProfileBundle",
+		"metadata":   map[string]any{"name": name, "namespace": ns},
+		"spec":       map[string]any{"contentImage": image, "contentFile": "ssg-" + name + "-ds.xml"},
+	}}
+}
+
+func newImageDigestMirrorSet(name, source, mirror string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "config.openshift.io/v1",
+		"kind":       "ImageDigestMirrorSet",
+		"metadata":   map[string]any{"name": name},
+		"spec": map[string]any{
+			"imageDigestMirrors": []any{
+				map[string]any{"source": source, "mirrors": []any{mirror}},
+			},
+		},
+	}}
+}
+
+func TestMirrorImage(t *testing.T) {
+	rules := []MirrorRule{
+		{Source: "ghcr.io/complianceascode", Mirrors: []string{"mirror.lab:5000/cac"}},
+		{Source: "ghcr.io/complianceascode/k8scontent", Mirrors: []string{"mirror.lab:5000/content/k8scontent"}},
+	}
+	tests := []struct {
+		name, image, registry, want string
+	}{
+		{"registry prefix keeps the path", "ghcr.io/complianceascode/k8scontent:latest", "mirror.lab:5000", "mirror.lab:5000/complianceascode/k8scontent:latest"},
+		{"registry prefix with a path", "registry.redhat.io/compliance/content@sha256:abc", "mirror.lab:5000/ocp/", "mirror.lab:5000/ocp/compliance/content@sha256:abc"},
+		{"already mirrored", "mirror.lab:5000/ocp/compliance/content:v1", "mirror.lab:5000/ocp", "mirror.lab:5000/ocp/compliance/content:v1"},
+		{"most specific rule wins", "ghcr.io/complianceascode/k8scontent:latest", "", "mirror.lab:5000/content/k8scontent:latest"},
+		{"prefix rule", "ghcr.io/complianceascode/compliance-operator-catalog:v1.7.0", "", "mirror.lab:5000/cac/compliance-operator-catalog:v1.7.0"},
+		{"rule must match a path boundary", "ghcr.io/complianceascode-fork/x:1", "", "ghcr.io/complianceascode-fork/x:1"},
+		{"no mirror", "quay.io/other/image:1", "", "quay.io/other/image:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MirrorImage(tt.image, tt.registry, rules); got != tt.want {
+				t.Errorf("MirrorImage(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+
+	if tag := imageTag("mirror.lab:5000/cac/catalog:v1.7.0"); tag != "v1.7.0" {
+		t.Errorf("imageTag = %q, want v1.7.0", tag)
+	}
+	if tag := imageTag("mirror.lab:5000/cac/catalog@sha256:abc"); tag != "" {
+		t.Errorf("imageTag of a digest = %q, want empty", tag)
+	}
+}
+
+func TestMirrorPreflight(t *testing.T) {
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodHead:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case strings.HasSuffix(r.URL.Path, "/compliance-operator-catalog/manifests/v1.7.0"):
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	mirrorHost := strings.TrimPrefix(registry.URL, "https://")
+	client := newTestClient(newImageDigestMirrorSet("cac", "ghcr.io/complianceascode", mirrorHost+"/cac"))
+
+	report, err := MirrorPreflight(ctx, client, ImageChecker{Client: registry.Client()}, ns, "v1.7.0", InstallOptions{Disconnected: true})
+	if err != nil {
+		t.Fatalf("MirrorPreflight: %v", err)
+	}
+	if len(report.Rules) != 1 || report.Rules[0].Kind != "ImageDigestMirrorSet" {
+		t.Fatalf("unexpected rules: %+v", report.Rules)
+	}
+	if report.CatalogImage != mirrorHost+"/cac/compliance-operator-catalog:v1.7.0" {
+		t.Errorf("catalog image = %q", report.CatalogImage)
+	}
+	if len(report.Images) != 2 {
+		t.Fatalf("expected the catalog and default content image, got %+v", report.Images)
+	}
+	if report.Images[0].Status != ImageResolved {
+		t.Errorf("catalog check = %+v, want resolved", report.Images[0])
+	}
+	if report.Images[1].Status != ImageMissing || report.Ready {
+		t.Errorf("expected the missing content image to fail the preflight: %+v", report)
+	}
+}
+
+func TestMirrorPreflight_RejectsUnconfiguredRegistry(t *testing.T) {
+	var probes atomic.Int32
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+	}))
+	defer registry.Close()
+
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	host := strings.TrimPrefix(registry.URL, "https://")
+	client := newTestClient()
+
+	// A registry given by the caller is not contacted unless the server is
+	// configured with it.
+	opts := InstallOptions{Disconnected: true, MirrorRegistry: host}
+	report, err := MirrorPreflight(ctx, client, ImageChecker{MirrorRegistry: "mirror.lab:5000", Client: registry.Client()}, ns, "v1.7.0", opts)
+	if err != nil {
+		t.Fatalf("MirrorPreflight: %v", err)
+	}
+	if report.Ready || len(report.Images) == 0 {
+		t.Fatalf("expected the unconfigured registry to fail the preflight: %+v", report)
+	}
+	for _, img := range report.Images {
+		if img.Status != ImageRejected {
+			t.Errorf("image %s = %s, want %s", img.Mirror, img.Status, ImageRejected)
+		}
+	}
+	if n := probes.Load(); n != 0 {
+		t.Errorf("registry was contacted %d times", n)
+	}
+
+	report, err = MirrorPreflight(ctx, client, ImageChecker{MirrorRegistry: host, Client: registry.Client()}, ns, "v1.7.0", opts)
+	if err != nil {
+		t.Fatalf("MirrorPreflight: %v", err)
+	}
+	if !report.Ready || probes.Load() == 0 {
+		t.Errorf("expected the configured registry to be checked: %+v", report)
+	}
+}
+
+func TestRewriteProfileBundleImages(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(
+		newContentProfileBundle("ocp4", ns, "ghcr.io/complianceascode/k8scontent:latest"),
+		newContentProfileBundle("rhcos4", ns, "mirror.lab:5000/complianceascode/k8scontent:latest"),
+	)
+
+	if _, err := RewriteProfileBundleImages(ctx, client, ns, ""); err == nil || !strings.Contains(err.Error(), "invalid mirror") {
+		t.Fatalf("expected an error without a registry or mirror rules, got %v", err)
+	}
+
+	rewrites, err := RewriteProfileBundleImages(ctx, client, ns, "mirror.lab:5000")
+	if err != nil {
+		t.Fatalf("RewriteProfileBundleImages: %v", err)
+	}
+	if len(rewrites) != 1 || rewrites[0].Name != "ocp4" {
+		t.Fatalf("expected only ocp4 to be rewritten, got %+v", rewrites)
+	}
+
+	bundle, err := client.Dynamic.Resource(profileBundleGVR).Namespace(ns).Get(ctx, "ocp4", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting ProfileBundle: %v", err)
+	}
+	if image, _, _ := unstructured.NestedString(bundle.Object, "spec", "contentImage"); image != "mirror.lab:5000/complianceascode/k8scontent:latest" {
+		t.Errorf("contentImage = %q", image)
+	}
+}
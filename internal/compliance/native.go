@@ -0,0 +1,133 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// RemediationMode selects how remediations are applied.
+type RemediationMode string
+
+const (
+	// RemediationModeDirect creates or updates spec.current.object from the
+	// dashboard, snapshotting the prior state for rollback.
+	RemediationModeDirect RemediationMode = "direct"
+	// RemediationModeOperator only patches spec.apply and lets the
+	// Compliance Operator reconcile the object.
+	RemediationModeOperator RemediationMode = "operator"
+)
+
+// Application states reported by the operator in status.applicationState.
+const (
+	ApplicationStateApplied             = "Applied"
+	ApplicationStateError               = "Error"
+	ApplicationStateNeedsReview         = "NeedsReview"
+	ApplicationStateMissingDependencies = "MissingDependencies"
+	ApplicationStateOutdated            = "Outdated"
+	ApplicationStateNotApplied          = "NotApplied"
+)
+
+// applicationStateTimeout bounds how long the operator is given to report a
+// remediation as applied.
+const applicationStateTimeout = 5 * time.Minute
+
+// applicationStatePollInterval is how often status.applicationState is polled.
+var applicationStatePollInterval = 5 * time.Second
+
+// ParseRemediationMode validates the --remediation-mode flag. An empty mode
+// selects direct.
+func ParseRemediationMode(mode string) (RemediationMode, error) {
+	switch m := RemediationMode(mode); m {
+	case "":
+		return RemediationModeDirect, nil
+	case RemediationModeDirect, RemediationModeOperator:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid remediation mode %q: must be %q or %q", mode, RemediationModeDirect, RemediationModeOperator)
+	}
+}
+
+// setSpecApply patches spec.apply on a remediation, leaving the object it
+// describes to the operator.
+func setSpecApply(ctx context.Context, client *k8s.Client, namespace, name string, apply bool) (*RemediationResult, error) {
+	result := &RemediationResult{Name: name}
+
+	rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		result.Error = fmt.Sprintf("getting remediation: %v", err)
+		return result, fmt.Errorf("getting remediation %s: %w", name, err)
+	}
+
+	// A direct apply or removal supersedes any pending GitOps export and
+	// clears recorded drift
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null,%q:null,%q:null}},"spec":{"apply":%t}}`,
+		annotationGitOpsPending, annotationDrift, annotationDriftFields, apply))
+	_, err = client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		result.Error = fmt.Sprintf("setting spec.apply: %v", err)
+		return result, fmt.Errorf("setting spec.apply=%t on remediation %s: %w", apply, name, err)
+	}
+
+	kind, _, _ := unstructured.NestedString(rem.Object, "spec", "current", "object", "kind")
+	result.Applied = apply
+	if apply {
+		result.Message = fmt.Sprintf("Set spec.apply=true; the Compliance Operator will apply %s", kind)
+		if kind == "MachineConfig" {
+			result.Message += fmt.Sprintf(" (MachineConfig - nodes with role %s will reboot)", detectRole(name, *rem))
+		}
+	} else {
+		result.Message = fmt.Sprintf("Set spec.apply=false; the Compliance Operator will remove %s", kind)
+	}
+	return result, nil
+}
+
+// remediationStatus returns status.applicationState and status.errorMessage.
+func remediationStatus(rem *unstructured.Unstructured) (state, message string) {
+	state, _, _ = unstructured.NestedString(rem.Object, "status", "applicationState")
+	message, _, _ = unstructured.NestedString(rem.Object, "status", "errorMessage")
+	return state, message
+}
+
+// waitForApplicationState waits until the operator reports the remediation
+// as Applied, returning the operator's error message if it reports anything
+// that needs attention instead.
+func waitForApplicationState(ctx context.Context, client *k8s.Client, namespace, name string) error {
+	timeout := time.After(applicationStateTimeout)
+	ticker := time.NewTicker(applicationStatePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("remediation %s was not applied by the operator within %s", name, applicationStateTimeout)
+		case <-ticker.C:
+		}
+
+		rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		state, message := remediationStatus(rem)
+		switch state {
+		case ApplicationStateApplied:
+			return nil
+		case ApplicationStateError, ApplicationStateNeedsReview, ApplicationStateMissingDependencies:
+			if message != "" {
+				return fmt.Errorf("operator reported %s for remediation %s: %s", state, name, message)
+			}
+			return fmt.Errorf("operator reported %s for remediation %s", state, name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseRemediationMode(t *testing.T) {
+	if _, err := ParseRemediationMode("bogus"); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+	if mode, err := ParseRemediationMode(""); err != nil || mode != RemediationModeDirect {
+		t.Errorf("empty mode should select direct, got %q (err %v)", mode, err)
+	}
+	if mode, err := ParseRemediationMode("operator"); err != nil || mode != RemediationModeOperator {
+		t.Errorf("expected operator mode, got %q (err %v)", mode, err)
+	}
+}
+
+func TestOperatorMode_ApplyAndRemove(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	client := newTestClient(newConfigMapRemediation("rem-cm", ns, "target-cm", nil))
+
+	result, err := ApplyRemediation(ctx, client, RemediationModeOperator, ns, "rem-cm")
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !result.Applied || !strings.Contains(result.Message, "spec.apply=true") {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	rem, _ := client.Dynamic.Resource(complianceRemediationGVR).Namespace(ns).Get(ctx, "rem-cm", metav1.GetOptions{})
+	if !remediationApplied(rem) {
+		t.Error("expected spec.apply=true")
+	}
+	if _, err := client.Dynamic.Resource(configMapGVR).Namespace(ns).Get(ctx, "target-cm", metav1.GetOptions{}); err == nil {
+		t.Error("operator mode must not create the object itself")
+	}
+	if _, err := GetRemediationSnapshot(ctx, client, ns, "rem-cm"); err == nil {
+		t.Error("operator mode must not take a snapshot")
+	}
+
+	if _, err := RemoveRemediation(ctx, client, RemediationModeOperator, ns, "rem-cm"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	rem, _ = client.Dynamic.Resource(complianceRemediationGVR).Namespace(ns).Get(ctx, "rem-cm", metav1.GetOptions{})
+	if remediationApplied(rem) {
+		t.Error("expected spec.apply=false")
+	}
+}
+
+func TestListRemediations_ApplicationState(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	rem := newConfigMapRemediation("rem-cm", ns, "target-cm", nil)
+	rem.Object["status"] = map[string]any{
+		"applicationState": "Error",
+		"errorMessage":     "configmaps \"target-cm\" is forbidden",
+	}
+	client := newTestClient(rem)
+
+	infos, err := ListRemediations(ctx, client, ns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if infos[0].ApplicationState != ApplicationStateError || !strings.Contains(infos[0].ErrorMessage, "forbidden") {
+		t.Errorf("unexpected info: %+v", infos[0])
+	}
+}
+
+func TestWaitForApplicationState(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	orig := applicationStatePollInterval
+	applicationStatePollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { applicationStatePollInterval = orig })
+
+	withState := func(state, msg string) *unstructured.Unstructured {
+		rem := newConfigMapRemediation("rem-cm", ns, "target-cm", nil)
+		rem.Object["status"] = map[string]any{"applicationState": state, "errorMessage": msg}
+		return rem
+	}
+
+	if err := waitForApplicationState(ctx, newTestClient(withState("Applied", "")), ns, "rem-cm"); err != nil {
+		t.Errorf("Applied: unexpected error: %v", err)
+	}
+
+	err := waitForApplicationState(ctx, newTestClient(withState("MissingDependencies", "needs rem-other")), ns, "rem-cm")
+	if err == nil || !strings.Contains(err.Error(), "needs rem-other") {
+		t.Errorf("expected operator message in error, got %v", err)
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strings"
-	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -16,7 +16,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
 )
@@ -184,9 +183,29 @@ func CheckARMCompatibility(ctx context.Context, client *k8s.Client, coRef string
 	return armNodes, true, nil
 }
 
-// Install performs the full Compliance Operator installation.
-// It sends progress updates to the provided channel.
-func Install(ctx context.Context, client *k8s.Client, namespace, coRef string, progress chan<- InstallProgress) {
+const (
+	installDoneMessage   = "Compliance Operator installed successfully"
+	uninstallDoneMessage = "Compliance Operator uninstalled successfully"
+)
+
+// installSteps are the steps of an install, in order.
+var installSteps = []installStep{
+	{name: "version", title: "Resolve version", run: installResolveVersion},
+	{name: "preflight", title: "Run preflight checks", run: installPreflight},
+	{name: "namespace", title: "Create namespace", run: installCreateNamespace},
+	{name: "source", title: "Resolve install source", run: installResolveSource},
+	{name: "install", title: "Install operator", run: installOperator},
+	{name: "csv", title: "Wait for ClusterServiceVersion", run: installWaitForCSV},
+	{name: "rbac", title: "Apply supplemental RBAC", run: installApplyRBAC},
+	{name: "pods", title: "Wait for operator pods", run: installWaitForPods},
+	{name: "bundle_images", title: "Rewrite ProfileBundle images", run: installRewriteBundleImages},
+	{name: "bundles", title: "Wait for ProfileBundles", run: installWaitForBundles},
+}
+
+// Install performs the full Compliance Operator installation with the given
+// options. It sends progress updates to the provided channel and records
+// each step in stateNamespace so the install can be inspected and resumed.
+func Install(ctx context.Context, client *k8s.Client, images ImageChecker, namespace, stateNamespace, coRef string, opts InstallOptions, progress chan<- InstallProgress) {
 	defer close(progress)
 
 	ps := newProgressSender(progress)
@@ -195,18 +214,31 @@ func Install(ctx context.Context, client *k8s.Client, namespace, coRef string, p
 		ps.sendError("init", "Kubernetes client is not connected")
 		return
 	}
-
-	// Step 1: Check marketplace health
-	ps.send("marketplace", "Checking marketplace health...")
-	if err := CheckMarketplaceHealth(ctx, client); err != nil {
-		ps.sendError("marketplace", fmt.Sprintf("Marketplace health check failed: %v", err))
+	if err := opts.Validate(); err != nil {
+		ps.sendError("init", err.Error())
 		return
 	}
-	ps.send("marketplace", "Marketplace is healthy")
 
-	// Step 2: Resolve version
-	if coRef == "" {
-		ps.send("version", "Resolving latest release from GitHub...")
+	r := &installRun{
+		client:         client,
+		images:         images,
+		stateNamespace: stateNamespace,
+		state:          newInstallState(OperationInstall, namespace, coRef, opts, installSteps),
+		ps:             ps,
+	}
+	r.execute(ctx, installSteps, installDoneMessage)
+}
+
+func installResolveVersion(ctx context.Context, r *installRun) error {
+	opts := r.state.Options
+	coRef := r.state.CORef
+	if opts.Disconnected {
+		if coRef == "" {
+			coRef = imageTag(opts.CatalogImage)
+		}
+		r.send(ctx, "Disconnected install: skipping the GitHub release lookup")
+	} else if coRef == "" {
+		r.send(ctx, "Resolving latest release from GitHub...")
 		var err error
 		coRef, err = GetLatestRelease(ctx)
 		if err != nil {
@@ -214,200 +246,166 @@ func Install(ctx context.Context, client *k8s.Client, namespace, coRef string, p
 			coRef = "master"
 		}
 	}
-	ps.send("version", fmt.Sprintf("Using Compliance Operator ref: %s", coRef))
+	r.state.CORef = coRef
+	if coRef != "" {
+		r.send(ctx, fmt.Sprintf("Using Compliance Operator ref: %s", coRef))
+	}
+	return nil
+}
 
-	// Step 3: Check ARM compatibility
-	ps.send("arch", "Checking cluster architecture...")
-	armNodes, compatible, err := CheckARMCompatibility(ctx, client, coRef)
+// installPreflight runs the checks of RunPreflight against the resolved
+// version, failing the install on any failed check.
+func installPreflight(ctx context.Context, r *installRun) error {
+	r.send(ctx, "Running preflight checks...")
+	report, err := RunPreflight(ctx, r.client, r.images, r.state.Namespace, r.state.CORef, r.state.Options)
 	if err != nil {
-		ps.sendError("arch", fmt.Sprintf("Architecture check failed: %v", err))
-		return
-	}
-	if !compatible {
-		ps.sendError("arch", fmt.Sprintf("Version %s does not support ARM64 (%d ARM nodes detected). Use v1.7.0+.", coRef, armNodes))
-		return
+		return stepFailed("Preflight failed: %v", err)
+	}
+	var failed []string
+	for _, c := range report.Checks {
+		switch c.Status {
+		case PreflightFail:
+			failed = append(failed, fmt.Sprintf("%s: %s", c.Title, c.Message))
+		case PreflightWarn:
+			r.send(ctx, fmt.Sprintf("%s: %s", c.Title, c.Message))
+		}
 	}
-	if armNodes > 0 {
-		ps.send("arch", fmt.Sprintf("ARM64 compatible (%d ARM nodes)", armNodes))
-	} else {
-		ps.send("arch", "x86_64 cluster detected")
+	if len(failed) > 0 {
+		return stepFailed("Preflight failed: %s", strings.Join(failed, "; "))
 	}
+	r.send(ctx, fmt.Sprintf("%d preflight checks passed", len(report.Checks)))
+	return nil
+}
 
-	// Step 4: Create namespace
-	ps.send("namespace", fmt.Sprintf("Creating namespace %s...", namespace))
+func installCreateNamespace(ctx context.Context, r *installRun) error {
+	namespace := r.state.Namespace
+	r.send(ctx, fmt.Sprintf("Creating namespace %s...", namespace))
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{Name: namespace},
 	}
-	_, err = client.Clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	_, err := r.client.Clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
 	if err != nil && !k8serrors.IsAlreadyExists(err) {
-		ps.sendError("namespace", fmt.Sprintf("Failed to create namespace: %v", err))
-		return
-	}
-	ps.send("namespace", fmt.Sprintf("Namespace %s ready", namespace))
-
-	// Step 5: Detect install source
-	ps.send("source", "Checking for Red Hat certified operator...")
-	useRedHat, err := CheckRedHatOperator(ctx, client)
-	if err != nil {
-		slog.Warn("Red Hat operator check error, falling back to community", "error", err)
-	}
-
-	// Step 6: Install operator
-	if useRedHat {
-		ps.send("install", "Installing Red Hat certified Compliance Operator...")
-		if err := installRedHatOperator(ctx, client, namespace); err != nil {
-			ps.sendError("install", fmt.Sprintf("Red Hat operator install failed: %v", err))
-			return
-		}
-	} else {
-		ps.send("install", "Installing community Compliance Operator...")
-		if err := installCommunityOperator(ctx, client, namespace, coRef); err != nil {
-			ps.sendError("install", fmt.Sprintf("Community operator install failed: %v", err))
-			return
-		}
+		return stepFailed("Failed to create namespace: %v", err)
 	}
+	r.send(ctx, fmt.Sprintf("Namespace %s ready", namespace))
+	return nil
+}
 
-	// Step 7: Wait for CSV
-	ps.send("csv", "Waiting for ClusterServiceVersion...")
-	csvName, err := waitForCSV(ctx, client, namespace)
+func installResolveSource(ctx context.Context, r *installRun) error {
+	r.send(ctx, "Resolving install source...")
+	resolved, err := resolveInstallOptions(ctx, r.client, r.state.CORef, r.state.Options)
 	if err != nil {
-		ps.sendError("csv", fmt.Sprintf("CSV wait failed: %v", err))
-		return
-	}
-	ps.send("csv", fmt.Sprintf("CSV %s succeeded", csvName))
-
-	// Step 8: Apply supplemental RBAC
-	ps.send("rbac", "Applying supplemental RBAC for Job creation...")
-	if err := applySupplementalRBAC(ctx, client, namespace); err != nil {
-		slog.Warn("supplemental RBAC failed", "error", err)
+		return stepFailed("Install source check failed: %v", err)
 	}
-	ps.send("rbac", "Supplemental RBAC applied")
-
-	// Step 9: Wait for pods
-	ps.send("pods", "Waiting for operator pods to be ready...")
-	if err := waitForPodsReady(ctx, client, namespace); err != nil {
-		slog.Warn("some pods may not be ready", "error", err)
+	r.state.Resolved = &resolved
+	if resolved.Source == InstallSourceManifests {
+		values := newManifestValues(ctx, r.client, r.state.Namespace, r.state.CORef, resolved)
+		r.sendOptions(ctx, fmt.Sprintf("No OLM: installing from the bundled manifests with operator image %s", values.OperatorImage), resolved)
+		return nil
 	}
-	ps.send("pods", "Operator pods are ready")
-
-	// Step 10: Wait for ProfileBundles
-	ps.send("bundles", "Waiting for ProfileBundles to become VALID...")
-	if err := waitForProfileBundles(ctx, client, namespace); err != nil {
-		slog.Warn("ProfileBundles may not be valid", "error", err)
-	}
-
-	ps.sendDone("complete", "Compliance Operator installed successfully")
+	r.sendOptions(ctx, fmt.Sprintf("Using %s catalog %s/%s, channel %s, %s approval",
+		resolved.Source, resolved.CatalogSourceNamespace, resolved.CatalogSource, resolved.Channel, resolved.Approval), resolved)
+	return nil
 }
 
-// Uninstall removes the Compliance Operator and all its resources.
-// It sends progress updates to the provided channel.
-func Uninstall(ctx context.Context, client *k8s.Client, namespace string, progress chan<- InstallProgress) {
-	defer close(progress)
-
-	ps := newProgressSender(progress)
-
-	if client == nil {
-		ps.sendError("init", "Kubernetes client is not connected")
-		return
-	}
-
-	// Step 1: Delete all Compliance CRs (remove finalizers first)
-	complianceCRDs := []struct {
-		name string
-		gvr  schema.GroupVersionResource
-	}{
-		{"ComplianceCheckResults", complianceCheckResultGVR},
-		{"ComplianceRemediations", complianceRemediationGVR},
-		{"ComplianceSuites", complianceSuiteGVR},
-		{"ComplianceScans", complianceScanGVR},
-		{"ScanSettingBindings", scanSettingBindingGVR},
-		{"ScanSettings", scanSettingGVR},
-		{"ProfileBundles", profileBundleGVR},
-		{"Profiles", profileGVR},
-	}
-
-	for _, crd := range complianceCRDs {
-		ps.send("cleanup", fmt.Sprintf("Removing %s...", crd.name))
-		items, err := client.Dynamic.Resource(crd.gvr).Namespace(namespace).
-			List(ctx, metav1.ListOptions{})
+func installOperator(ctx context.Context, r *installRun) error {
+	resolved := *r.state.Resolved
+	switch resolved.Source {
+	case InstallSourceManifests:
+		r.send(ctx, "Applying the bundled operator manifests...")
+		applied, skipped, err := installManifests(ctx, r.client, r.state.Namespace, r.state.CORef, resolved)
 		if err != nil {
-			slog.Warn("error listing compliance resource", "resource", crd.name, "error", err)
-			continue
+			return stepFailed("Manifest install failed: %v", err)
+		}
+		r.send(ctx, fmt.Sprintf("Applied %d CRDs, RBAC and Deployment objects", applied))
+		if len(skipped) > 0 {
+			r.send(ctx, fmt.Sprintf("Left existing objects the dashboard did not create unchanged: %s", strings.Join(skipped, ", ")))
+		}
+	case InstallSourceCommunity:
+		r.send(ctx, "Installing community Compliance Operator...")
+		if err := installCommunityOperator(ctx, r.client, r.state.Namespace, resolved); err != nil {
+			return stepFailed("Community operator install failed: %v", err)
 		}
-		for _, item := range items.Items {
-			// Remove finalizers
-			_, _ = client.Dynamic.Resource(crd.gvr).Namespace(namespace).
-				Patch(ctx, item.GetName(), types.MergePatchType, FinalizerRemovalPatch, metav1.PatchOptions{})
-			// Delete
-			_ = client.Dynamic.Resource(crd.gvr).Namespace(namespace).
-				Delete(ctx, item.GetName(), metav1.DeleteOptions{})
+	default:
+		r.send(ctx, fmt.Sprintf("Subscribing to Compliance Operator from %s...", resolved.CatalogSource))
+		if err := subscribeOperator(ctx, r.client, r.state.Namespace, resolved); err != nil {
+			return stepFailed("Operator install failed: %v", err)
 		}
 	}
-	ps.send("cleanup", "Compliance resources removed")
-
-	// Step 2: Delete Subscription
-	ps.send("subscription", "Deleting Subscription...")
-	err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
-		Delete(ctx, subscriptionName, metav1.DeleteOptions{})
-	if err != nil && !k8serrors.IsNotFound(err) {
-		slog.Warn("error deleting Subscription", "error", err)
+	if resolved.Approval == ApprovalManual {
+		r.send(ctx, "Manual approval: approve the pending InstallPlan to continue")
 	}
-	ps.send("subscription", "Subscription deleted")
+	return nil
+}
 
-	// Step 3: Delete CSV
-	ps.send("csv", "Deleting ClusterServiceVersion...")
-	csvs, err := client.Dynamic.Resource(csvGVR).Namespace(namespace).
-		List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, csv := range csvs.Items {
-			_ = client.Dynamic.Resource(csvGVR).Namespace(namespace).
-				Delete(ctx, csv.GetName(), metav1.DeleteOptions{})
-		}
+func installWaitForCSV(ctx context.Context, r *installRun) error {
+	if r.state.Resolved.Source == InstallSourceManifests {
+		return errStepSkipped
 	}
-	ps.send("csv", "ClusterServiceVersion deleted")
-
-	// Step 4: Delete OperatorGroup
-	ps.send("operatorgroup", "Deleting OperatorGroup...")
-	err = client.Dynamic.Resource(operatorGroupGVR).Namespace(namespace).
-		Delete(ctx, operatorName, metav1.DeleteOptions{})
-	if err != nil && !k8serrors.IsNotFound(err) {
-		slog.Warn("error deleting OperatorGroup", "error", err)
+	r.send(ctx, "Waiting for ClusterServiceVersion...")
+	csvName, err := waitForCSV(ctx, r.client, r.state.Namespace, r.state.Options.Timeouts.CSV(),
+		r.waitReporter(ctx, "Waiting for ClusterServiceVersion"))
+	if err != nil {
+		return stepFailed("CSV wait failed: %v", err)
 	}
-	ps.send("operatorgroup", "OperatorGroup deleted")
+	r.send(ctx, fmt.Sprintf("CSV %s succeeded", csvName))
+	return nil
+}
 
-	// Step 5: Delete CatalogSource (community install)
-	ps.send("catalogsource", "Deleting CatalogSource...")
-	err = client.Dynamic.Resource(catalogSourceGVR).Namespace(marketplaceNS).
-		Delete(ctx, operatorName, metav1.DeleteOptions{})
-	if err != nil && !k8serrors.IsNotFound(err) {
-		slog.Warn("error deleting CatalogSource", "error", err)
+func installApplyRBAC(ctx context.Context, r *installRun) error {
+	r.send(ctx, "Applying supplemental RBAC for Job creation...")
+	if err := applySupplementalRBAC(ctx, r.client, r.state.Namespace); err != nil {
+		slog.Warn("supplemental RBAC failed", "error", err)
+		r.send(ctx, fmt.Sprintf("Supplemental RBAC could not be applied: %v", err))
+		return nil
 	}
-	ps.send("catalogsource", "CatalogSource deleted")
+	r.send(ctx, "Supplemental RBAC applied")
+	return nil
+}
 
-	// Step 6: Delete namespace
-	ps.send("namespace", fmt.Sprintf("Deleting namespace %s...", namespace))
-	err = client.Clientset.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
-	if err != nil && !k8serrors.IsNotFound(err) {
-		ps.sendError("namespace", fmt.Sprintf("Failed to delete namespace: %v", err))
-		return
+func installWaitForPods(ctx context.Context, r *installRun) error {
+	r.send(ctx, "Waiting for operator pods to be ready...")
+	if err := waitForPodsReady(ctx, r.client, r.state.Namespace, r.state.Options.Timeouts.Pods(),
+		r.waitReporter(ctx, "Waiting for operator pods")); err != nil {
+		slog.Warn("some pods may not be ready", "error", err)
+		r.send(ctx, fmt.Sprintf("Some operator pods may not be ready: %v", err))
+		return nil
 	}
+	r.send(ctx, "Operator pods are ready")
+	return nil
+}
 
-	// Wait for namespace deletion
-	for i := 0; i < 30; i++ {
-		_, err := client.Clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
-		if err != nil {
-			break // Namespace is gone
-		}
-		select {
-		case <-ctx.Done():
-			ps.sendError("namespace", "Timed out waiting for namespace deletion")
-			return
-		case <-time.After(5 * time.Second):
-		}
+// installRewriteBundleImages points ProfileBundles at the mirror in
+// disconnected installs.
+func installRewriteBundleImages(ctx context.Context, r *installRun) error {
+	resolved := r.state.Resolved
+	if !resolved.Disconnected {
+		return errStepSkipped
+	}
+	r.send(ctx, "Rewriting ProfileBundle content images to the mirror...")
+	if err := waitForProfileBundlesCreated(ctx, r.client, r.state.Namespace, r.state.Options.Timeouts.ProfileBundles(),
+		r.waitReporter(ctx, "Waiting for ProfileBundles to be created")); err != nil {
+		slog.Warn("ProfileBundles were not created", "error", err)
+		r.send(ctx, fmt.Sprintf("ProfileBundles were not created: %v", err))
+	} else if rewrites, err := RewriteProfileBundleImages(ctx, r.client, r.state.Namespace, resolved.MirrorRegistry); err != nil {
+		slog.Warn("rewriting ProfileBundle content images failed", "error", err)
+		r.send(ctx, fmt.Sprintf("Could not rewrite ProfileBundle content images: %v", err))
+	} else {
+		r.send(ctx, fmt.Sprintf("Rewrote %d ProfileBundle content images", len(rewrites)))
 	}
-	ps.send("namespace", "Namespace deleted")
+	return nil
+}
 
-	ps.sendDone("complete", "Compliance Operator uninstalled successfully")
+func installWaitForBundles(ctx context.Context, r *installRun) error {
+	r.send(ctx, "Waiting for ProfileBundles to become VALID...")
+	if err := waitForProfileBundles(ctx, r.client, r.state.Namespace, r.state.Options.Timeouts.ProfileBundles(),
+		r.waitReporter(ctx, "Waiting for ProfileBundles")); err != nil {
+		slog.Warn("ProfileBundles may not be valid", "error", err)
+		r.send(ctx, fmt.Sprintf("ProfileBundles may not be valid: %v", err))
+		return nil
+	}
+	r.send(ctx, "ProfileBundles are VALID")
+	return nil
 }
 
 // GetStatus returns the current status of the Compliance Operator.
@@ -418,27 +416,31 @@ func GetStatus(ctx context.Context, client *k8s.Client, namespace string) (*Oper
 
 	status := &OperatorStatus{}
 
-	// Check for subscription
+	// Check for subscription, then for an install without OLM
 	sub, err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
 		Get(ctx, subscriptionName, metav1.GetOptions{})
-	if err != nil {
-		return status, nil // Not installed
-	}
-
-	csvName, _, _ := unstructured.NestedString(sub.Object, "status", "installedCSV")
-	if csvName == "" {
-		return status, nil
-	}
+	if err == nil {
+		csvName, _, _ := unstructured.NestedString(sub.Object, "status", "installedCSV")
+		if csvName == "" {
+			return status, nil
+		}
 
-	status.Installed = true
-	status.Version = csvName
+		status.Installed = true
+		status.Version = csvName
 
-	// Check CSV phase
-	csv, err := client.Dynamic.Resource(csvGVR).Namespace(namespace).
-		Get(ctx, csvName, metav1.GetOptions{})
-	if err == nil {
-		phase, _, _ := unstructured.NestedString(csv.Object, "status", "phase")
-		status.CSVPhase = phase
+		// Check CSV phase
+		csv, err := client.Dynamic.Resource(csvGVR).Namespace(namespace).
+			Get(ctx, csvName, metav1.GetOptions{})
+		if err == nil {
+			phase, _, _ := unstructured.NestedString(csv.Object, "status", "phase")
+			status.CSVPhase = phase
+		}
+	} else if deploy := manifestDeployment(ctx, client, namespace); deploy != nil {
+		status.Installed = true
+		status.Version = manifestVersion(deploy)
+		status.Source = InstallSourceManifests
+	} else {
+		return status, nil // Not installed
 	}
 
 	// Get pod statuses
@@ -475,7 +477,9 @@ func GetStatus(ctx context.Context, client *k8s.Client, namespace string) (*Oper
 	return status, nil
 }
 
-func installRedHatOperator(ctx context.Context, client *k8s.Client, namespace string) error {
+// subscribeOperator creates the OperatorGroup and the Subscription for the
+// resolved options.
+func subscribeOperator(ctx context.Context, client *k8s.Client, namespace string, opts InstallOptions) error {
 	// Create OperatorGroup
 	og := &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -492,38 +496,81 @@ func installRedHatOperator(ctx context.Context, client *k8s.Client, namespace st
 	}
 	_, err := client.Dynamic.Resource(operatorGroupGVR).Namespace(namespace).
 		Create(ctx, og, metav1.CreateOptions{})
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
+	if k8serrors.IsAlreadyExists(err) {
+		err = checkExisting(ctx, client, operatorGroupGVR, og, "spec", "targetNamespaces")
+	}
+	if err != nil {
 		return fmt.Errorf("creating OperatorGroup: %w", err)
 	}
 
-	// Create Subscription
-	sub := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"apiVersion": "operators.coreos.com/v1alpha1",
-			"kind":       "Subscription",
-			"metadata": map[string]interface{}{
-				"name":      subscriptionName,
-				"namespace": namespace,
-			},
-			"spec": map[string]interface{}{
-				"channel":             "stable",
-				"installPlanApproval": "Automatic",
-				"name":                operatorName,
-				"source":              "redhat-operators",
-				"sourceNamespace":     marketplaceNS,
-			},
-		},
+	// Create Subscription. An existing one is left alone only when it already
+	// asks for what was resolved; otherwise the options reported for this
+	// install would not be the ones OLM acts on.
+	sub, err := buildSubscription(namespace, opts)
+	if err != nil {
+		return err
 	}
 	_, err = client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
 		Create(ctx, sub, metav1.CreateOptions{})
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
+	if k8serrors.IsAlreadyExists(err) {
+		err = checkExisting(ctx, client, subscriptionGVR, sub, "spec")
+	}
+	if err != nil {
 		return fmt.Errorf("creating Subscription: %w", err)
 	}
 
 	return nil
 }
 
-func installCommunityOperator(ctx context.Context, client *k8s.Client, namespace, coRef string) error {
+// checkExisting compares an object that already exists with the one an
+// install wanted to create, returning an error naming each field under path
+// that differs. Fields only the existing object sets, such as those OLM
+// defaults, are ignored.
+func checkExisting(ctx context.Context, client *k8s.Client, gvr schema.GroupVersionResource, want *unstructured.Unstructured, path ...string) error {
+	existing, err := client.Dynamic.Resource(gvr).Namespace(want.GetNamespace()).
+		Get(ctx, want.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("reading existing %s %s/%s: %w", want.GetKind(), want.GetNamespace(), want.GetName(), err)
+	}
+	wantValue, _, _ := unstructured.NestedFieldNoCopy(want.Object, path...)
+	haveValue, _, _ := unstructured.NestedFieldNoCopy(existing.Object, path...)
+
+	var diffs []string
+	field := strings.Join(path, ".")
+	if wantFields, ok := wantValue.(map[string]interface{}); ok {
+		haveFields, _ := haveValue.(map[string]interface{})
+		keys := make([]string, 0, len(wantFields))
+		for k := range wantFields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if d := fieldDiff(field+"."+k, haveFields[k], wantFields[k]); d != "" {
+				diffs = append(diffs, d)
+			}
+		}
+	} else if d := fieldDiff(field, haveValue, wantValue); d != "" {
+		diffs = append(diffs, d)
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%s %s/%s already exists with different settings: %s; update or remove it first",
+			want.GetKind(), want.GetNamespace(), want.GetName(), strings.Join(diffs, "; "))
+	}
+	return nil
+}
+
+// fieldDiff describes a differing field, or returns "" when have and want
+// encode to the same JSON.
+func fieldDiff(field string, have, want interface{}) string {
+	haveJSON, _ := json.Marshal(have)
+	wantJSON, _ := json.Marshal(want)
+	if string(haveJSON) == string(wantJSON) {
+		return ""
+	}
+	return fmt.Sprintf("%s is %s, install wants %s", field, haveJSON, wantJSON)
+}
+
+func installCommunityOperator(ctx context.Context, client *k8s.Client, namespace string, opts InstallOptions) error {
 	// Create CatalogSource
 	cs := &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -535,7 +582,7 @@ func installCommunityOperator(ctx context.Context, client *k8s.Client, namespace
 			},
 			"spec": map[string]interface{}{
 				"displayName": "Compliance Operator Upstream",
-				"image":       fmt.Sprintf("ghcr.io/complianceascode/compliance-operator-catalog:%s", coRef),
+				"image":       opts.CatalogImage,
 				"publisher":   "github.com/complianceascode/compliance-operator",
 				"sourceType":  "grpc",
 				"grpcPodConfig": map[string]interface{}{
@@ -557,107 +604,21 @@ func installCommunityOperator(ctx context.Context, client *k8s.Client, namespace
 	}
 	_, err := client.Dynamic.Resource(catalogSourceGVR).Namespace(marketplaceNS).
 		Create(ctx, cs, metav1.CreateOptions{})
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
-		return fmt.Errorf("creating CatalogSource: %w", err)
-	}
-
-	// Create OperatorGroup
-	og := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"apiVersion": "operators.coreos.com/v1",
-			"kind":       "OperatorGroup",
-			"metadata": map[string]interface{}{
-				"name":      operatorName,
-				"namespace": namespace,
-			},
-			"spec": map[string]interface{}{
-				"targetNamespaces": []interface{}{namespace},
-			},
-		},
-	}
-	_, err = client.Dynamic.Resource(operatorGroupGVR).Namespace(namespace).
-		Create(ctx, og, metav1.CreateOptions{})
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
-		return fmt.Errorf("creating OperatorGroup: %w", err)
-	}
-
-	// Create Subscription
-	sub := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"apiVersion": "operators.coreos.com/v1alpha1",
-			"kind":       "Subscription",
-			"metadata": map[string]interface{}{
-				"name":      subscriptionName,
-				"namespace": namespace,
-			},
-			"spec": map[string]interface{}{
-				"channel":             "alpha",
-				"installPlanApproval": "Automatic",
-				"name":                operatorName,
-				"source":              operatorName,
-				"sourceNamespace":     marketplaceNS,
-			},
-		},
-	}
-	_, err = client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
-		Create(ctx, sub, metav1.CreateOptions{})
-	if err != nil && !k8serrors.IsAlreadyExists(err) {
-		return fmt.Errorf("creating Subscription: %w", err)
-	}
-
-	return nil
-}
-
-func waitForCSV(ctx context.Context, client *k8s.Client, namespace string) (string, error) {
-	var csvName string
-
-	// Wait for subscription to populate installedCSV
-	for i := 0; i < 30; i++ {
-		sub, err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
-			Get(ctx, subscriptionName, metav1.GetOptions{})
-		if err == nil {
-			name, _, _ := unstructured.NestedString(sub.Object, "status", "installedCSV")
-			if name != "" {
-				csvName = name
-				break
-			}
-		}
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		case <-time.After(10 * time.Second):
-		}
-	}
-
-	if csvName == "" {
-		return "", fmt.Errorf("installedCSV not populated after timeout")
+	if k8serrors.IsAlreadyExists(err) {
+		err = checkExisting(ctx, client, catalogSourceGVR, cs, "spec", "image")
 	}
-
-	// Wait for CSV to reach Succeeded phase
-	for i := 0; i < 30; i++ {
-		csv, err := client.Dynamic.Resource(csvGVR).Namespace(namespace).
-			Get(ctx, csvName, metav1.GetOptions{})
-		if err == nil {
-			phase, _, _ := unstructured.NestedString(csv.Object, "status", "phase")
-			if phase == "Succeeded" {
-				return csvName, nil
-			}
-		}
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		case <-time.After(10 * time.Second):
-		}
+	if err != nil {
+		return fmt.Errorf("creating CatalogSource: %w", err)
 	}
 
-	return csvName, fmt.Errorf("CSV %s did not reach Succeeded phase", csvName)
+	return subscribeOperator(ctx, client, namespace, opts)
 }
 
 func applySupplementalRBAC(ctx context.Context, client *k8s.Client, namespace string) error {
 	// Create Role for Job permissions
 	role := &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "compliance-operator-job-permissions",
+			Name:      supplementalRBACName,
 			Namespace: namespace,
 		},
 		Rules: []rbacv1.PolicyRule{
@@ -676,13 +637,13 @@ func applySupplementalRBAC(ctx context.Context, client *k8s.Client, namespace st
 	// Create RoleBinding
 	rb := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "compliance-operator-job-permissions",
+			Name:      supplementalRBACName,
 			Namespace: namespace,
 		},
 		RoleRef: rbacv1.RoleRef{
 			APIGroup: "rbac.authorization.k8s.io",
 			Kind:     "Role",
-			Name:     "compliance-operator-job-permissions",
+			Name:     supplementalRBACName,
 		},
 		Subjects: []rbacv1.Subject{
 			{
@@ -699,79 +660,3 @@ func applySupplementalRBAC(ctx context.Context, client *k8s.Client, namespace st
 
 	return nil
 }
-
-func waitForPodsReady(ctx context.Context, client *k8s.Client, namespace string) error {
-	for i := 0; i < 30; i++ {
-		pods, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-		if err != nil {
-			return fmt.Errorf("listing pods: %w", err)
-		}
-
-		allReady := true
-		hasPods := false
-		for _, pod := range pods.Items {
-			if pod.Status.Phase == corev1.PodSucceeded {
-				continue
-			}
-			hasPods = true
-			ready := false
-			for _, cond := range pod.Status.Conditions {
-				if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
-					ready = true
-					break
-				}
-			}
-			if !ready {
-				allReady = false
-			}
-		}
-
-		if hasPods && allReady {
-			return nil
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(10 * time.Second):
-		}
-	}
-
-	return fmt.Errorf("pods not ready after timeout")
-}
-
-func waitForProfileBundles(ctx context.Context, client *k8s.Client, namespace string) error {
-	for i := 0; i < 30; i++ {
-		bundles, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
-			List(ctx, metav1.ListOptions{})
-		if err != nil || len(bundles.Items) == 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(10 * time.Second):
-			}
-			continue
-		}
-
-		allValid := true
-		for _, bundle := range bundles.Items {
-			dsStatus, _, _ := unstructured.NestedString(bundle.Object, "status", "dataStreamStatus")
-			if dsStatus != "VALID" {
-				allValid = false
-				break
-			}
-		}
-
-		if allValid {
-			return nil
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(10 * time.Second):
-		}
-	}
-
-	return fmt.Errorf("ProfileBundles not VALID after timeout")
-}
@@ -0,0 +1,96 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// outdatedRemediationLabel is set by the operator on remediations whose
+// content changed after a ProfileBundle update. spec.current then holds the
+// new version and spec.outdated the one that was previously applied.
+const outdatedRemediationLabel = "compliance.openshift.io/outdated-remediation"
+
+// isOutdated reports whether a remediation has a newer version waiting to be
+// promoted.
+func isOutdated(rem *unstructured.Unstructured) bool {
+	if _, ok := rem.GetLabels()[outdatedRemediationLabel]; ok {
+		return true
+	}
+	if state, _ := remediationStatus(rem); state == ApplicationStateOutdated {
+		return true
+	}
+	obj, found, _ := unstructured.NestedMap(rem.Object, "spec", "outdated", "object")
+	return found && len(obj) > 0
+}
+
+// ListOutdatedRemediations returns the remediations whose content changed
+// after a ProfileBundle update and need their new version promoted.
+func ListOutdatedRemediations(ctx context.Context, client *k8s.Client, namespace string) ([]RemediationInfo, error) {
+	all, err := ListRemediations(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	outdated := []RemediationInfo{}
+	for _, rem := range all {
+		if rem.Outdated {
+			outdated = append(outdated, rem)
+		}
+	}
+	return outdated, nil
+}
+
+// PromoteRemediation moves an outdated remediation to its new version. If
+// the remediation is applied (in direct mode), spec.current is applied over
+// the live object first; then spec.outdated and the outdated label are
+// dropped so the operator stops reporting it.
+func PromoteRemediation(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace, name string) (*RemediationResult, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting remediation %s: %w", name, err)
+	}
+	if !isOutdated(rem) {
+		return nil, fmt.Errorf("remediation %s is not outdated", name)
+	}
+
+	// In operator mode the operator applies spec.current itself once
+	// spec.outdated is gone
+	result := &RemediationResult{Name: name}
+	if remediationApplied(rem) {
+		if mode == RemediationModeOperator {
+			result.Applied = true
+			result.Message = "the Compliance Operator will apply it"
+		} else {
+			result, err = ApplyRemediation(ctx, client, mode, namespace, name)
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:null}},"spec":{"outdated":null}}`, outdatedRemediationLabel))
+	_, err = client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		result.Error = fmt.Sprintf("clearing outdated version: %v", err)
+		return result, fmt.Errorf("clearing outdated version of remediation %s: %w", name, err)
+	}
+
+	if result.Applied {
+		result.Message = fmt.Sprintf("Promoted to the new version: %s", result.Message)
+	} else {
+		result.Message = "Promoted to the new version (not applied)"
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newOutdatedRemediation(name, ns string, applied bool) *unstructured.Unstructured {
+	rem := newRemediation(name, ns, map[string]any{
+		"spec": map[string]any{
+			"apply": applied,
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      "target-cm",
+						"namespace": ns,
+					},
+					"data": map[string]any{"key": "new"},
+				},
+			},
+			"outdated": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      "target-cm",
+						"namespace": ns,
+					},
+					"data": map[string]any{"key": "old"},
+				},
+			},
+		},
+	})
+	rem.SetLabels(map[string]string{outdatedRemediationLabel: ""})
+	return rem
+}
+
+func TestListOutdatedRemediations(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	client := newTestClient(
+		newOutdatedRemediation("rem-outdated", ns, true),
+		newConfigMapRemediation("rem-current", ns, "other-cm", nil),
+	)
+
+	outdated, err := ListOutdatedRemediations(ctx, client, ns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outdated) != 1 || outdated[0].Name != "rem-outdated" {
+		t.Errorf("expected only rem-outdated, got %+v", outdated)
+	}
+}
+
+func TestGetRemediation_OutdatedDiff(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	client := newTestClient(newOutdatedRemediation("rem-outdated", ns, true))
+
+	detail, err := GetRemediation(ctx, client, ns, "rem-outdated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !detail.Outdated {
+		t.Error("expected Outdated=true")
+	}
+	if !strings.Contains(detail.OutdatedYAML, "key: old") {
+		t.Errorf("expected outdated YAML, got:\n%s", detail.OutdatedYAML)
+	}
+	if !strings.Contains(detail.OutdatedDiff, "-  key: old") || !strings.Contains(detail.OutdatedDiff, "+  key: new") {
+		t.Errorf("unexpected diff:\n%s", detail.OutdatedDiff)
+	}
+}
+
+func TestPromoteRemediation(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	t.Run("applied remediation is re-applied with the new version", func(t *testing.T) {
+		client := newTestClient(newOutdatedRemediation("rem-outdated", ns, true))
+
+		result, err := PromoteRemediation(ctx, client, RemediationModeDirect, ns, "rem-outdated")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Applied {
+			t.Error("expected Applied=true")
+		}
+
+		cm, err := client.Dynamic.Resource(configMapGVR).Namespace(ns).Get(ctx, "target-cm", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("expected ConfigMap to exist: %v", err)
+		}
+		if v, _, _ := unstructured.NestedString(cm.Object, "data", "key"); v != "new" {
+			t.Errorf("data.key = %q, want new", v)
+		}
+
+		rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(ns).Get(ctx, "rem-outdated", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("getting remediation: %v", err)
+		}
+		if isOutdated(rem) {
+			t.Error("expected outdated label and spec.outdated to be cleared")
+		}
+	})
+
+	t.Run("unapplied remediation is only cleared", func(t *testing.T) {
+		client := newTestClient(newOutdatedRemediation("rem-outdated", ns, false))
+
+		result, err := PromoteRemediation(ctx, client, RemediationModeDirect, ns, "rem-outdated")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Applied {
+			t.Error("expected Applied=false")
+		}
+		if _, err := client.Dynamic.Resource(configMapGVR).Namespace(ns).Get(ctx, "target-cm", metav1.GetOptions{}); err == nil {
+			t.Error("expected ConfigMap not to be created")
+		}
+	})
+
+	t.Run("rejects remediation that is not outdated", func(t *testing.T) {
+		client := newTestClient(newConfigMapRemediation("rem-current", ns, "cm", nil))
+		if _, err := PromoteRemediation(ctx, client, RemediationModeDirect, ns, "rem-current"); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if d := unifiedDiff("a\nb\n", "a\nb\n", "x", "y"); d != "" {
+		t.Errorf("expected empty diff for equal input, got %q", d)
+	}
+
+	d := unifiedDiff("a\nb\nc\n", "a\nB\nc\nd\n", "old", "new")
+	want := "--- old\n+++ new\n@@ -1,3 +1,4 @@\n a\n-b\n+B\n c\n+d\n"
+	if d != want {
+		t.Errorf("diff =\n%s\nwant\n%s", d, want)
+	}
+}
@@ -0,0 +1,410 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// Preflight check results.
+const (
+	PreflightPass = "pass"
+	PreflightWarn = "warn"
+	PreflightFail = "fail"
+)
+
+// installPermissions are the API calls an install makes, checked with
+// SelfSubjectAccessReviews. An empty namespace is cluster scope.
+var installPermissions = []struct {
+	verb, group, resource, namespace string
+}{
+	{"create", "", "namespaces", ""},
+	{"create", "operators.coreos.com", "operatorgroups", "$ns"},
+	{"create", "operators.coreos.com", "subscriptions", "$ns"},
+	{"create", "operators.coreos.com", "catalogsources", marketplaceNS},
+	{"create", "rbac.authorization.k8s.io", "roles", "$ns"},
+	{"create", "rbac.authorization.k8s.io", "rolebindings", "$ns"},
+	{"patch", "compliance.openshift.io", "profilebundles", "$ns"},
+}
+
+// manifestPermissions are the API calls an install from the bundled
+// manifests makes.
+var manifestPermissions = []struct {
+	verb, group, resource, namespace string
+}{
+	{"create", "", "namespaces", ""},
+	{"create", "apiextensions.k8s.io", "customresourcedefinitions", ""},
+	{"create", "rbac.authorization.k8s.io", "clusterroles", ""},
+	{"create", "rbac.authorization.k8s.io", "clusterrolebindings", ""},
+	{"create", "", "serviceaccounts", "$ns"},
+	{"create", "rbac.authorization.k8s.io", "roles", "$ns"},
+	{"create", "rbac.authorization.k8s.io", "rolebindings", "$ns"},
+	{"create", "apps", "deployments", "$ns"},
+	{"patch", "compliance.openshift.io", "profilebundles", "$ns"},
+}
+
+// RunPreflight checks whether an install with the given options can succeed,
+// without changing anything in the cluster. Every check runs even when an
+// earlier one fails, so the report lists all problems at once. Install runs
+// the same checks as its preflight step.
+func RunPreflight(ctx context.Context, client *k8s.Client, images ImageChecker, namespace, coRef string, opts InstallOptions) (*PreflightReport, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	// Without OLM an install with no source uses the bundled manifests.
+	if opts.Source == "" {
+		if olm, err := OLMInstalled(ctx, client); err == nil && !olm {
+			opts.Source = InstallSourceManifests
+			opts.CatalogImage = ""
+		}
+	}
+
+	report := &PreflightReport{
+		Checks:    []PreflightCheck{},
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	report.Checks = append(report.Checks,
+		preflightOLM(ctx, client, namespace, opts),
+		preflightMarketplace(ctx, client, opts),
+		preflightArchitecture(ctx, client, coRef, opts),
+		preflightStorage(ctx, client),
+		preflightRBAC(ctx, client, namespace, opts),
+		preflightNamespace(ctx, client, namespace),
+		preflightCatalog(ctx, client, images, namespace, coRef, opts),
+	)
+	if opts.Disconnected {
+		report.Checks = append(report.Checks, preflightMirror(ctx, client, images, namespace, coRef, opts))
+	}
+
+	report.Status = PreflightPass
+	for _, c := range report.Checks {
+		switch c.Status {
+		case PreflightFail:
+			report.Status = PreflightFail
+		case PreflightWarn:
+			if report.Status == PreflightPass {
+				report.Status = PreflightWarn
+			}
+		}
+	}
+	report.Ready = report.Status != PreflightFail
+	return report, nil
+}
+
+func preflightOLM(ctx context.Context, client *k8s.Client, namespace string, opts InstallOptions) PreflightCheck {
+	check := PreflightCheck{Name: "olm", Title: "Operator Lifecycle Manager"}
+	_, err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	switch {
+	case err == nil:
+		check.Status, check.Message = PreflightPass, "OLM APIs are available"
+	case IsCRDNotFound(err) && opts.Source == InstallSourceManifests:
+		check.Status, check.Message = PreflightPass, "OLM is not installed; the operator will be installed from the bundled manifests"
+	case IsCRDNotFound(err):
+		check.Status, check.Message = PreflightFail, "The operators.coreos.com APIs are not installed"
+		check.Hint = "Install OLM (operator-sdk olm install) or use a cluster that includes it, such as OpenShift."
+	default:
+		check.Status, check.Message = PreflightWarn, fmt.Sprintf("Could not query OLM: %v", err)
+		check.Hint = "Check that the dashboard's user can list Subscriptions."
+	}
+	return check
+}
+
+func preflightMarketplace(ctx context.Context, client *k8s.Client, opts InstallOptions) PreflightCheck {
+	check := PreflightCheck{Name: "marketplace", Title: "Marketplace health"}
+	if opts.Source == InstallSourceManifests {
+		check.Status, check.Message = PreflightPass, "Not needed for an install from the bundled manifests"
+		return check
+	}
+	err := CheckMarketplaceHealth(ctx, client)
+	switch {
+	case err == nil:
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("Pods in %s are healthy", marketplaceNS)
+	case opts.Source == InstallSourceCustom && opts.CatalogSourceNamespace != "" && opts.CatalogSourceNamespace != marketplaceNS:
+		check.Status, check.Message = PreflightWarn, err.Error()
+		check.Hint = fmt.Sprintf("The custom catalog is in %s, so the install does not depend on %s.", opts.CatalogSourceNamespace, marketplaceNS)
+	default:
+		check.Status, check.Message = PreflightFail, err.Error()
+		check.Hint = fmt.Sprintf("Fix the failing pods in %s (oc get pods -n %s) before installing.", marketplaceNS, marketplaceNS)
+	}
+	return check
+}
+
+func preflightArchitecture(ctx context.Context, client *k8s.Client, coRef string, opts InstallOptions) PreflightCheck {
+	check := PreflightCheck{Name: "architecture", Title: "Cluster architecture"}
+	if coRef == "" && opts.Disconnected {
+		coRef = imageTag(opts.CatalogImage)
+	}
+	armNodes, compatible, err := CheckARMCompatibility(ctx, client, coRef)
+	switch {
+	case err != nil:
+		check.Status, check.Message = PreflightWarn, fmt.Sprintf("Architecture check failed: %v", err)
+		check.Hint = "Check that the dashboard's user can list nodes."
+	case !compatible:
+		check.Status = PreflightFail
+		check.Message = fmt.Sprintf("Version %s does not support ARM64 (%d ARM nodes detected)", coRef, armNodes)
+		check.Hint = "Use Compliance Operator v1.7.0 or later (--co-ref)."
+	case armNodes > 0:
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("ARM64 compatible (%d ARM nodes)", armNodes)
+	default:
+		check.Status, check.Message = PreflightPass, "x86_64 cluster detected"
+	}
+	return check
+}
+
+func preflightStorage(ctx context.Context, client *k8s.Client) PreflightCheck {
+	check := PreflightCheck{Name: "storage", Title: "Raw result storage"}
+	info, err := DetectStorage(ctx, client)
+	switch {
+	case err != nil:
+		check.Status, check.Message = PreflightWarn, fmt.Sprintf("Storage check failed: %v", err)
+		check.Hint = "Check that the dashboard's user can list StorageClasses."
+	case info.StorageClassName == "":
+		check.Status, check.Message = PreflightFail, "No StorageClass found; scans cannot store raw results"
+		check.Hint = "Deploy a storage provisioner, such as the KubeVirt HostPath CSI driver."
+	case info.HostpathCSIDeployed || (info.HasDefaultStorageClass && info.Recommendation == ""):
+		check.Status = PreflightPass
+		check.Message = fmt.Sprintf("StorageClass %s (%s)", info.StorageClassName, info.Provisioner)
+	default:
+		check.Status = PreflightWarn
+		check.Message = fmt.Sprintf("StorageClass %s (%s)", info.StorageClassName, info.Provisioner)
+		check.Hint = info.Recommendation
+	}
+	return check
+}
+
+func preflightRBAC(ctx context.Context, client *k8s.Client, namespace string, opts InstallOptions) PreflightCheck {
+	check := PreflightCheck{Name: "rbac", Title: "Permissions"}
+	var denied []string
+	permissions := installPermissions
+	if opts.Source == InstallSourceManifests {
+		permissions = manifestPermissions
+	}
+	for _, p := range permissions {
+		if p.resource == "catalogsources" && opts.Source != "" && opts.Source != InstallSourceCommunity {
+			continue
+		}
+		ns := p.namespace
+		if ns == "$ns" {
+			ns = namespace
+		}
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Verb: p.verb, Group: p.group, Resource: p.resource, Namespace: ns,
+				},
+			},
+		}
+		result, err := client.Clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			check.Status, check.Message = PreflightWarn, fmt.Sprintf("Could not review permissions: %v", err)
+			return check
+		}
+		if !result.Status.Allowed {
+			target := p.resource
+			if p.group != "" {
+				target += "." + p.group
+			}
+			if ns != "" {
+				target += " in " + ns
+			}
+			denied = append(denied, p.verb+" "+target)
+		}
+	}
+	if len(denied) > 0 {
+		check.Status = PreflightFail
+		check.Message = "Missing permissions: " + strings.Join(denied, ", ")
+		check.Hint = "Run the dashboard as a cluster-admin, or grant these permissions to its user or ServiceAccount."
+		return check
+	}
+	check.Status, check.Message = PreflightPass, "All permissions the install needs are granted"
+	return check
+}
+
+func preflightNamespace(ctx context.Context, client *k8s.Client, namespace string) PreflightCheck {
+	check := PreflightCheck{Name: "namespace", Title: "Target namespace"}
+
+	ns, err := client.Clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	exists := err == nil
+	switch {
+	case err != nil && !k8serrors.IsNotFound(err):
+		check.Status, check.Message = PreflightWarn, fmt.Sprintf("Could not get namespace %s: %v", namespace, err)
+		return check
+	case exists && ns.Status.Phase == corev1.NamespaceTerminating:
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("Namespace %s is being deleted", namespace)
+		check.Hint = "Wait for the namespace to finish terminating, or remove the finalizers holding it."
+		return check
+	case exists:
+		groups, err := client.Dynamic.Resource(operatorGroupGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, og := range groups.Items {
+				if og.GetName() != operatorName {
+					check.Status = PreflightFail
+					check.Message = fmt.Sprintf("Namespace %s already has OperatorGroup %s", namespace, og.GetName())
+					check.Hint = "OLM allows one OperatorGroup per namespace; install into another namespace (--namespace) or remove it."
+					return check
+				}
+			}
+		}
+		if sub, err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
+			Get(ctx, subscriptionName, metav1.GetOptions{}); err == nil {
+			csv, _, _ := unstructured.NestedString(sub.Object, "status", "installedCSV")
+			check.Status = PreflightWarn
+			check.Message = fmt.Sprintf("The Compliance Operator is already subscribed in %s (%s)", namespace, csv)
+			check.Hint = "Installing again reuses the Subscription only if it matches the install options; use the Subscription settings to change it."
+			return check
+		}
+	}
+
+	csvs, err := client.Dynamic.Resource(csvGVR).Namespace("").List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, csv := range csvs.Items {
+			if strings.HasPrefix(csv.GetName(), operatorName+".") && csv.GetNamespace() != namespace {
+				// Copied CSVs from an AllNamespaces install carry this label.
+				if csv.GetLabels()["olm.copiedFrom"] != "" {
+					continue
+				}
+				check.Status = PreflightFail
+				check.Message = fmt.Sprintf("The Compliance Operator is already installed in %s (%s)", csv.GetNamespace(), csv.GetName())
+				check.Hint = fmt.Sprintf("Point the dashboard at that namespace (--namespace=%s) instead of installing a second copy.", csv.GetNamespace())
+				return check
+			}
+		}
+	}
+
+	check.Status = PreflightPass
+	if exists {
+		check.Message = fmt.Sprintf("Namespace %s exists and has no conflicting operators", namespace)
+	} else {
+		check.Message = fmt.Sprintf("Namespace %s will be created", namespace)
+	}
+	return check
+}
+
+func preflightCatalog(ctx context.Context, client *k8s.Client, images ImageChecker, namespace, coRef string, opts InstallOptions) PreflightCheck {
+	check := PreflightCheck{Name: "catalog", Title: "Catalog reachability"}
+
+	// Resolve the version and source the way the install does, so the
+	// images and catalog checked are the ones it will use. The release is
+	// only looked up when an image is named after it.
+	var releaseErr error
+	switch {
+	case coRef != "":
+	case opts.Disconnected:
+		coRef = imageTag(opts.CatalogImage)
+	case opts.CatalogImage == "" || opts.Source == InstallSourceManifests:
+		if coRef, releaseErr = GetLatestRelease(ctx); releaseErr != nil {
+			coRef = "master"
+		}
+	}
+	resolved, err := resolveInstallOptions(ctx, client, coRef, opts)
+	if err != nil {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("Install source check failed: %v", err)
+		check.Hint = "Check the source and catalog options; disconnected installs need --co-ref or --catalog-image."
+		return check
+	}
+	rules, _ := ListMirrorRules(ctx, client)
+
+	switch resolved.Source {
+	case InstallSourceManifests:
+		check.Title = "Operator image"
+		image := newManifestValues(ctx, client, namespace, coRef, resolved).OperatorImage
+		result := images.check(ctx, image, rules)
+		switch result.Status {
+		case ImageResolved:
+			check.Status, check.Message = PreflightPass, fmt.Sprintf("Operator image %s resolves", image)
+		case ImageUnauthorized:
+			check.Status, check.Message = PreflightWarn, fmt.Sprintf("Could not verify %s: %s", image, result.Message)
+		default:
+			check.Status, check.Message = PreflightFail, fmt.Sprintf("Operator image %s: %s", image, result.Message)
+			check.Hint = "Check the --co-ref version, or mirror the image and use --disconnected."
+		}
+		return check
+
+	case InstallSourceCommunity:
+		if releaseErr != nil && opts.CatalogImage == "" {
+			check.Status, check.Message = PreflightWarn, fmt.Sprintf("Could not resolve the latest release: %v", releaseErr)
+			check.Hint = "The install falls back to the master catalog; set --co-ref to pin a version."
+			return check
+		}
+		image := resolved.CatalogImage
+		result := images.check(ctx, image, rules)
+		switch result.Status {
+		case ImageResolved:
+			check.Status, check.Message = PreflightPass, fmt.Sprintf("Catalog image %s resolves", image)
+		case ImageUnauthorized:
+			check.Status, check.Message = PreflightWarn, fmt.Sprintf("Could not verify %s: %s", image, result.Message)
+		default:
+			check.Status, check.Message = PreflightFail, fmt.Sprintf("Catalog image %s: %s", image, result.Message)
+			check.Hint = "Check the --co-ref version, or use --disconnected with a mirrored catalog image."
+		}
+		return check
+	}
+
+	name, ns := resolved.CatalogSource, resolved.CatalogSourceNamespace
+	cs, err := client.Dynamic.Resource(catalogSourceGVR).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("CatalogSource %s/%s not found", ns, name)
+		check.Hint = "Create the CatalogSource or choose another install source."
+		return check
+	}
+	state, _, _ := unstructured.NestedString(cs.Object, "status", "connectionState", "lastObservedState")
+	if _, err := getPackageManifest(ctx, client, operatorName, name, ns); err != nil {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("CatalogSource %s does not provide %s", name, operatorName)
+		check.Hint = "Check that the catalog includes the compliance-operator package."
+		return check
+	}
+	switch state {
+	case "READY":
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("CatalogSource %s is READY", name)
+	case "":
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("CatalogSource %s provides %s", name, operatorName)
+	default:
+		check.Status, check.Message = PreflightWarn, fmt.Sprintf("CatalogSource %s is %s", name, state)
+		check.Hint = fmt.Sprintf("Check the catalog pod in %s; OLM cannot resolve packages until it is READY.", ns)
+	}
+	return check
+}
+
+func preflightMirror(ctx context.Context, client *k8s.Client, images ImageChecker, namespace, coRef string, opts InstallOptions) PreflightCheck {
+	check := PreflightCheck{Name: "mirror", Title: "Image mirrors"}
+	report, err := MirrorPreflight(ctx, client, images, namespace, coRef, opts)
+	if err != nil {
+		check.Status, check.Message = PreflightWarn, fmt.Sprintf("Mirror check failed: %v", err)
+		return check
+	}
+	var failed, unverified []string
+	for _, img := range report.Images {
+		switch img.Status {
+		case ImageMissing, ImageUnreachable, ImageRejected:
+			failed = append(failed, img.Mirror)
+		case ImageUnauthorized:
+			unverified = append(unverified, img.Mirror)
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		check.Status = PreflightFail
+		check.Message = "Mirrored images do not resolve: " + strings.Join(failed, ", ")
+		check.Hint = "Mirror the images with oc-mirror, or correct --mirror-registry."
+	case len(unverified) > 0:
+		check.Status = PreflightWarn
+		check.Message = "Could not verify mirrored images without credentials: " + strings.Join(unverified, ", ")
+	case opts.MirrorRegistry == "" && len(report.Rules) == 0:
+		check.Status = PreflightWarn
+		check.Message = "No mirror registry is set and the cluster has no image mirror rules"
+		check.Hint = "Set --mirror-registry or create an ImageDigestMirrorSet."
+	default:
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("%d mirrored images resolve", len(report.Images))
+	}
+	return check
+}
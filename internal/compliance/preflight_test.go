@@ -0,0 +1,220 @@
+package compliance
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// newPreflightClient returns a client for a healthy cluster: a marketplace
+// namespace, a default StorageClass and one x86 node. Access reviews for the
+// resources in denied are refused.
+func newPreflightClient(t *testing.T, denied map[string]bool, objects ...runtime.Object) *k8s.Client {
+	t.Helper()
+	ctx := context.Background()
+	client := newTestClient(objects...)
+	cs := client.Clientset
+
+	if _, err := cs.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: marketplaceNS}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "gp3", Annotations: map[string]string{defaultSCAnnotation: "true"}},
+		Provisioner: "ebs.csi.aws.com",
+	}
+	if _, err := cs.StorageV1().StorageClasses().Create(ctx, sc, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}}
+	node.Status.NodeInfo.Architecture = "amd64"
+	if _, err := cs.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cs.(*kubefake.Clientset).PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = !denied[review.Spec.ResourceAttributes.Resource]
+		return true, review, nil
+	})
+	return client
+}
+
+// testRegistryClient returns a client that answers every registry check
+// with handler, whatever registry the checked image names.
+func testRegistryClient(t *testing.T, handler http.HandlerFunc) *http.Client {
+	t.Helper()
+	registry := httptest.NewTLSServer(handler)
+	t.Cleanup(registry.Close)
+
+	transport := registry.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.ServerName = "example.com"
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, registry.Listener.Addr().String())
+	}
+	return &http.Client{Transport: transport}
+}
+
+func newReadyCatalogSource(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "CatalogSource",
+		"metadata":   map[string]any{"name": name, "namespace": marketplaceNS},
+		"spec":       map[string]any{"image": "quay.io/example/catalog:v1"},
+		"status": map[string]any{
+			"connectionState": map[string]any{"lastObservedState": "READY"},
+		},
+	}}
+}
+
+func preflightCheck(t *testing.T, report *PreflightReport, name string) PreflightCheck {
+	t.Helper()
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in report: %+v", name, report.Checks)
+	return PreflightCheck{}
+}
+
+func TestRunPreflightPasses(t *testing.T) {
+	ctx := context.Background()
+	client := newPreflightClient(t, nil, newReadyCatalogSource("mirror"), newPackageManifest("mirror"))
+
+	report, err := RunPreflight(ctx, client, ImageChecker{}, "openshift-compliance", "v1.7.0",
+		InstallOptions{Source: InstallSourceCustom, CatalogSource: "mirror"})
+	if err != nil {
+		t.Fatalf("RunPreflight: %v", err)
+	}
+	for _, c := range report.Checks {
+		if c.Status != PreflightPass {
+			t.Errorf("check %s = %s: %s", c.Name, c.Status, c.Message)
+		}
+	}
+	if !report.Ready || report.Status != PreflightPass {
+		t.Errorf("report status = %s, ready = %v", report.Status, report.Ready)
+	}
+	if c := preflightCheck(t, report, "namespace"); !strings.Contains(c.Message, "will be created") {
+		t.Errorf("namespace message = %q", c.Message)
+	}
+}
+
+func TestRunPreflightFailures(t *testing.T) {
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer registry.Close()
+
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	otherGroup := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "operators.coreos.com/v1",
+		"kind":       "OperatorGroup",
+		"metadata":   map[string]any{"name": "other-operators", "namespace": ns},
+	}}
+	client := newPreflightClient(t, map[string]bool{"catalogsources": true}, otherGroup)
+	if _, err := client.Clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(registry.URL, "https://")
+	catalog := host + "/cac/compliance-operator-catalog:v1.7.0"
+	report, err := RunPreflight(ctx, client, ImageChecker{MirrorRegistry: host, Client: registry.Client()}, ns, "", InstallOptions{Source: InstallSourceCommunity, CatalogImage: catalog})
+	if err != nil {
+		t.Fatalf("RunPreflight: %v", err)
+	}
+	if report.Ready || report.Status != PreflightFail {
+		t.Errorf("expected a failing report, got status %s", report.Status)
+	}
+
+	rbac := preflightCheck(t, report, "rbac")
+	if rbac.Status != PreflightFail || !strings.Contains(rbac.Message, "catalogsources.operators.coreos.com in openshift-marketplace") || rbac.Hint == "" {
+		t.Errorf("unexpected rbac check: %+v", rbac)
+	}
+	if c := preflightCheck(t, report, "namespace"); c.Status != PreflightFail || !strings.Contains(c.Message, "other-operators") {
+		t.Errorf("unexpected namespace check: %+v", c)
+	}
+	if c := preflightCheck(t, report, "catalog"); c.Status != PreflightFail {
+		t.Errorf("expected the missing catalog image to fail: %+v", c)
+	}
+	for _, name := range []string{"olm", "marketplace", "storage", "architecture"} {
+		if c := preflightCheck(t, report, name); c.Status != PreflightPass {
+			t.Errorf("check %s = %s: %s", name, c.Status, c.Message)
+		}
+	}
+}
+
+func TestPreflightCatalogResolvesOptions(t *testing.T) {
+	ctx := context.Background()
+	client := newPreflightClient(t, nil)
+
+	c := preflightCatalog(ctx, client, ImageChecker{}, "openshift-compliance", "v1.7.0", InstallOptions{Source: InstallSourceRedHat})
+	if c.Status != PreflightFail || !strings.Contains(c.Message, "does not provide") {
+		t.Errorf("expected the missing Red Hat package to fail the check: %+v", c)
+	}
+	c = preflightCatalog(ctx, client, ImageChecker{}, "openshift-compliance", "", InstallOptions{Source: InstallSourceCommunity, Disconnected: true})
+	if c.Status != PreflightFail || !strings.Contains(c.Message, "needs --co-ref or a catalog image") {
+		t.Errorf("expected a disconnected install without a version to fail: %+v", c)
+	}
+}
+
+func TestPreflightStorage(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+	if c := preflightStorage(ctx, client); c.Status != PreflightFail {
+		t.Errorf("no StorageClass: status = %s, want fail", c.Status)
+	}
+
+	sc := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "local"}, Provisioner: localPathProvisioner}
+	if _, err := client.Clientset.StorageV1().StorageClasses().Create(ctx, sc, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if c := preflightStorage(ctx, client); c.Status != PreflightWarn || c.Hint == "" {
+		t.Errorf("non-default StorageClass: %+v, want warn with a hint", c)
+	}
+}
+
+func TestRunPreflightWithoutOLM(t *testing.T) {
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer registry.Close()
+
+	ctx := context.Background()
+	client := newPreflightClient(t, map[string]bool{"customresourcedefinitions": true})
+	client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("*", "subscriptions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewGenericServerResponse(http.StatusNotFound, action.GetVerb(), subscriptionGVR.GroupResource(), "", "", 0, false)
+	})
+
+	mirror := strings.TrimPrefix(registry.URL, "https://")
+	report, err := RunPreflight(ctx, client, ImageChecker{MirrorRegistry: mirror, Client: registry.Client()}, "compliance-operator", "v1.7.0", InstallOptions{Disconnected: true, MirrorRegistry: mirror})
+	if err != nil {
+		t.Fatalf("RunPreflight: %v", err)
+	}
+	if c := preflightCheck(t, report, "catalog"); c.Status != PreflightPass || !strings.Contains(c.Message, mirror+"/complianceascode/compliance-operator:v1.7.0") {
+		t.Errorf("expected the mirrored operator image to be checked: %+v", c)
+	}
+	if c := preflightCheck(t, report, "olm"); c.Status != PreflightPass || !strings.Contains(c.Message, "bundled manifests") {
+		t.Errorf("unexpected olm check: %+v", c)
+	}
+	if c := preflightCheck(t, report, "marketplace"); c.Status != PreflightPass {
+		t.Errorf("unexpected marketplace check: %+v", c)
+	}
+	if c := preflightCheck(t, report, "rbac"); c.Status != PreflightFail || !strings.Contains(c.Message, "customresourcedefinitions.apiextensions.k8s.io") {
+		t.Errorf("expected the manifest permissions to be reviewed: %+v", c)
+	}
+}
@@ -0,0 +1,252 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// profileBundleLabel names the ProfileBundle a Profile was parsed from.
+const profileBundleLabel = "compliance.openshift.io/profile-bundle"
+
+// operatorProfileBundles are the bundles the operator creates. It restores
+// them when they change, so the dashboard only edits custom bundles.
+var operatorProfileBundles = map[string]bool{
+	"ocp4":   true,
+	"rhcos4": true,
+}
+
+// profileBundleOf returns the name of the ProfileBundle a Profile came from,
+// by its label or else its owner.
+func profileBundleOf(profile *unstructured.Unstructured) string {
+	if bundle := profile.GetLabels()[profileBundleLabel]; bundle != "" {
+		return bundle
+	}
+	for _, ref := range profile.GetOwnerReferences() {
+		if ref.Kind == "ProfileBundle" {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// ListProfileBundles returns every ProfileBundle, sorted by name, with the
+// profiles parsed from it.
+func ListProfileBundles(ctx context.Context, client *k8s.Client, namespace string) ([]ProfileBundleInfo, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	bundles, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if IsCRDNotFound(err) {
+			return []ProfileBundleInfo{}, nil
+		}
+		return nil, fmt.Errorf("listing ProfileBundles: %w", err)
+	}
+	profiles, err := ListProfiles(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ProfileBundleInfo, 0, len(bundles.Items))
+	for i := range bundles.Items {
+		infos = append(infos, newProfileBundleInfo(&bundles.Items[i], profiles))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// GetProfileBundle returns a ProfileBundle with the profiles parsed from it.
+func GetProfileBundle(ctx context.Context, client *k8s.Client, namespace, name string) (*ProfileBundleInfo, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	bundle, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := ListProfiles(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	info := newProfileBundleInfo(bundle, profiles)
+	return &info, nil
+}
+
+func newProfileBundleInfo(bundle *unstructured.Unstructured, profiles []ProfileInfo) ProfileBundleInfo {
+	image, _, _ := unstructured.NestedString(bundle.Object, "spec", "contentImage")
+	file, _, _ := unstructured.NestedString(bundle.Object, "spec", "contentFile")
+	dsStatus, _, _ := unstructured.NestedString(bundle.Object, "status", "dataStreamStatus")
+	errorMessage, _, _ := unstructured.NestedString(bundle.Object, "status", "errorMessage")
+
+	info := ProfileBundleInfo{
+		Name:             bundle.GetName(),
+		ContentImage:     image,
+		ContentFile:      file,
+		DataStreamStatus: dsStatus,
+		ErrorMessage:     errorMessage,
+		Custom:           !operatorProfileBundles[bundle.GetName()],
+		Profiles:         []ProfileInfo{},
+		CreatedAt:        bundle.GetCreationTimestamp().UTC(),
+	}
+	for _, p := range profiles {
+		if p.Bundle == info.Name {
+			info.Profiles = append(info.Profiles, p)
+		}
+	}
+	info.ProfileCount = len(info.Profiles)
+	return info
+}
+
+// validate checks a custom bundle's name and content.
+func (s ProfileBundleSpec) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("bundle name is required")
+	}
+	if errs := validation.IsDNS1123Subdomain(s.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid bundle name %q: %s", s.Name, strings.Join(errs, "; "))
+	}
+	if operatorProfileBundles[s.Name] {
+		return fmt.Errorf("invalid bundle name %q: it is reserved for a bundle the operator manages", s.Name)
+	}
+	if s.ContentImage == "" {
+		return fmt.Errorf("content_image is required")
+	}
+	if strings.ContainsAny(s.ContentImage, " \t\n") {
+		return fmt.Errorf("invalid content_image %q", s.ContentImage)
+	}
+	if s.ContentFile == "" {
+		return fmt.Errorf("content_file is required")
+	}
+	if strings.HasPrefix(s.ContentFile, "/") || !strings.HasSuffix(s.ContentFile, ".xml") {
+		return fmt.Errorf("invalid content_file %q: expected a data stream path relative to the image root, such as ssg-ocp4-ds.xml", s.ContentFile)
+	}
+	return nil
+}
+
+// CreateProfileBundle creates a custom ProfileBundle. The operator then
+// parses the data stream and creates its Profiles.
+func CreateProfileBundle(ctx context.Context, client *k8s.Client, namespace string, spec ProfileBundleSpec) (*ProfileBundleInfo, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
+	bundle := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "compliance.openshift.io/v1alpha1",
+			"kind":       "ProfileBundle",
+			"metadata": map[string]interface{}{
+				"name":      spec.Name,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"contentImage": spec.ContentImage,
+				"contentFile":  spec.ContentFile,
+			},
+		},
+	}
+	created, err := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+		Create(ctx, bundle, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	info := newProfileBundleInfo(created, nil)
+	return &info, nil
+}
+
+// UpdateProfileBundle points a custom ProfileBundle at new content. The
+// operator re-parses it and updates its Profiles.
+func UpdateProfileBundle(ctx context.Context, client *k8s.Client, namespace string, spec ProfileBundleSpec) (*ProfileBundleInfo, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	if operatorProfileBundles[spec.Name] {
+		return nil, fmt.Errorf("ProfileBundle %s is managed by the operator and cannot be changed", spec.Name)
+	}
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
+	bundles := client.Dynamic.Resource(profileBundleGVR).Namespace(namespace)
+	bundle, err := bundles.Get(ctx, spec.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(bundle.Object, spec.ContentImage, "spec", "contentImage"); err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(bundle.Object, spec.ContentFile, "spec", "contentFile"); err != nil {
+		return nil, err
+	}
+	updated, err := bundles.Update(ctx, bundle, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := ListProfiles(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	info := newProfileBundleInfo(updated, profiles)
+	return &info, nil
+}
+
+// DeleteProfileBundle deletes a custom ProfileBundle and, through their owner
+// references, its Profiles. It fails while a ScanSettingBinding still scans
+// one of those profiles.
+func DeleteProfileBundle(ctx context.Context, client *k8s.Client, namespace, name string) error {
+	if client == nil {
+		return fmt.Errorf("kubernetes client is nil")
+	}
+	if operatorProfileBundles[name] {
+		return fmt.Errorf("ProfileBundle %s is managed by the operator and cannot be changed", name)
+	}
+
+	bundle, err := GetProfileBundle(ctx, client, namespace, name)
+	if err != nil {
+		return err
+	}
+	if len(bundle.Profiles) > 0 {
+		bindings, err := client.Dynamic.Resource(scanSettingBindingGVR).Namespace(namespace).
+			List(ctx, metav1.ListOptions{})
+		if err != nil && !IsCRDNotFound(err) {
+			return fmt.Errorf("listing ScanSettingBindings: %w", err)
+		}
+		owned := map[string]bool{}
+		for _, p := range bundle.Profiles {
+			owned[p.Name] = true
+		}
+		if bindings != nil {
+			for _, ssb := range bindings.Items {
+				refs, _, _ := unstructured.NestedSlice(ssb.Object, "profiles")
+				for _, r := range refs {
+					ref, _ := r.(map[string]interface{})
+					if ref["kind"] == "Profile" && owned[fmt.Sprint(ref["name"])] {
+						return fmt.Errorf("ProfileBundle %s is in use: ScanSettingBinding %s scans its profile %s", name, ssb.GetName(), ref["name"])
+					}
+				}
+			}
+		}
+	}
+
+	err = client.Dynamic.Resource(profileBundleGVR).Namespace(namespace).
+		Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("deleting ProfileBundle %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newBundleProfile(name, ns, bundle string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "compliance.openshift.io/v1alpha1",
+		"kind":       "Profile",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": ns,
+			"labels":    map[string]any{profileBundleLabel: bundle},
+		},
+		"title": name + " title",
+	}}
+}
+
+func TestListProfileBundles(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	ocp4 := newContentProfileBundle("ocp4", ns, defaultContentImage)
+	custom := newContentProfileBundle("acme", ns, "quay.io/acme/content:latest")
+	_ = unstructured.SetNestedField(custom.Object, "INVALID", "status", "dataStreamStatus")
+	_ = unstructured.SetNestedField(custom.Object, "profile acme-cis has no rules", "status", "errorMessage")
+	owned := newBundleProfile("ocp4-cis-node", ns, "")
+	owned.SetLabels(nil)
+	owned.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ProfileBundle", Name: "ocp4", APIVersion: "compliance.openshift.io/v1alpha1"}})
+	client := newTestClient(ocp4, custom,
+		newBundleProfile("ocp4-cis", ns, "ocp4"),
+		owned,
+		newBundleProfile("acme-cis", ns, "acme"),
+	)
+
+	bundles, err := ListProfileBundles(ctx, client, ns)
+	if err != nil {
+		t.Fatalf("ListProfileBundles: %v", err)
+	}
+	if len(bundles) != 2 || bundles[0].Name != "acme" || bundles[1].Name != "ocp4" {
+		t.Fatalf("expected acme and ocp4 in order, got %+v", bundles)
+	}
+	acme, ocp := bundles[0], bundles[1]
+	if !acme.Custom || ocp.Custom {
+		t.Errorf("Custom = %v for acme, %v for ocp4", acme.Custom, ocp.Custom)
+	}
+	if acme.ContentImage != "quay.io/acme/content:latest" || acme.ContentFile == "" {
+		t.Errorf("unexpected content for acme: %+v", acme)
+	}
+	if acme.DataStreamStatus != "INVALID" || acme.ErrorMessage == "" {
+		t.Errorf("expected acme's parse error, got %+v", acme)
+	}
+	if acme.ProfileCount != 1 || acme.Profiles[0].Name != "acme-cis" {
+		t.Errorf("unexpected acme profiles: %+v", acme.Profiles)
+	}
+	if ocp.ProfileCount != 2 {
+		t.Errorf("expected the labelled and the owned profile under ocp4, got %+v", ocp.Profiles)
+	}
+}
+
+func TestProfileBundleCRUD(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(newContentProfileBundle("ocp4", ns, defaultContentImage))
+
+	spec := ProfileBundleSpec{Name: "acme", ContentImage: "quay.io/acme/content:v1", ContentFile: "ssg-ocp4-ds.xml"}
+	created, err := CreateProfileBundle(ctx, client, ns, spec)
+	if err != nil || created.Name != "acme" || !created.Custom {
+		t.Fatalf("CreateProfileBundle = %+v, %v", created, err)
+	}
+	if _, err := CreateProfileBundle(ctx, client, ns, spec); !k8serrors.IsAlreadyExists(err) {
+		t.Errorf("expected a duplicate create to fail, got %v", err)
+	}
+
+	spec.ContentImage = "quay.io/acme/content:v2"
+	updated, err := UpdateProfileBundle(ctx, client, ns, spec)
+	if err != nil || updated.ContentImage != "quay.io/acme/content:v2" {
+		t.Fatalf("UpdateProfileBundle = %+v, %v", updated, err)
+	}
+
+	if _, err := UpdateProfileBundle(ctx, client, ns, ProfileBundleSpec{Name: "ocp4", ContentImage: "x", ContentFile: "ssg-ocp4-ds.xml"}); err == nil || !strings.Contains(err.Error(), "managed by the operator") {
+		t.Errorf("expected the operator's bundle to be protected, got %v", err)
+	}
+	if err := DeleteProfileBundle(ctx, client, ns, "ocp4"); err == nil {
+		t.Error("expected deleting the operator's bundle to fail")
+	}
+
+	if err := DeleteProfileBundle(ctx, client, ns, "acme"); err != nil {
+		t.Fatalf("DeleteProfileBundle: %v", err)
+	}
+	if _, err := GetProfileBundle(ctx, client, ns, "acme"); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected acme to be deleted, got %v", err)
+	}
+}
+
+func TestProfileBundleSpecValidate(t *testing.T) {
+	valid := ProfileBundleSpec{Name: "acme", ContentImage: "quay.io/acme/content:v1", ContentFile: "ssg-ocp4-ds.xml"}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("valid spec rejected: %v", err)
+	}
+	for name, mutate := range map[string]func(*ProfileBundleSpec){
+		"no name":       func(s *ProfileBundleSpec) { s.Name = "" },
+		"bad name":      func(s *ProfileBundleSpec) { s.Name = "Acme_Bundle" },
+		"reserved name": func(s *ProfileBundleSpec) { s.Name = "rhcos4" },
+		"no image":      func(s *ProfileBundleSpec) { s.ContentImage = "" },
+		"no file":       func(s *ProfileBundleSpec) { s.ContentFile = "" },
+		"absolute file": func(s *ProfileBundleSpec) { s.ContentFile = "/ssg-ocp4-ds.xml" },
+	} {
+		spec := valid
+		mutate(&spec)
+		if err := spec.validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDeleteProfileBundleInUse(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	ssb := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "compliance.openshift.io/v1alpha1",
+		"kind":       "ScanSettingBinding",
+		"metadata":   map[string]any{"name": "acme-scan", "namespace": ns},
+		"profiles": []any{
+			map[string]any{"apiGroup": "compliance.openshift.io/v1alpha1", "kind": "Profile", "name": "acme-cis"},
+		},
+	}}
+	client := newTestClient(
+		newContentProfileBundle("acme", ns, "quay.io/acme/content:v1"),
+		newBundleProfile("acme-cis", ns, "acme"),
+		ssb,
+	)
+
+	err := DeleteProfileBundle(ctx, client, ns, "acme")
+	if err == nil || !strings.Contains(err.Error(), "acme-scan") {
+		t.Fatalf("expected the bundle in use by acme-scan to be kept, got %v", err)
+	}
+	if _, err := GetProfileBundle(ctx, client, ns, "acme"); err != nil {
+		t.Errorf("bundle was deleted: %v", err)
+	}
+}
@@ -4,56 +4,65 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 
 	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
 )
 
-// ApplyRemediation applies a single ComplianceRemediation by extracting its
-// spec.current.object and performing a server-side apply.
-// Reimplements misc/apply-remediations-by-severity.sh single-item logic.
-func ApplyRemediation(ctx context.Context, client *k8s.Client, namespace, name string) (*RemediationResult, error) {
-	if client == nil {
-		return nil, fmt.Errorf("kubernetes client is nil")
-	}
-
-	result := &RemediationResult{Name: name}
+// remediationTarget is the object a ComplianceRemediation applies, resolved
+// to the resource and namespace it lives in.
+type remediationTarget struct {
+	Object    *unstructured.Unstructured
+	GVR       schema.GroupVersionResource
+	Namespace string
+	Name      string
+	Kind      string
+}
 
-	// Get the remediation
-	rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
-		Get(ctx, name, metav1.GetOptions{})
-	if err != nil {
-		result.Error = fmt.Sprintf("getting remediation: %v", err)
-		return result, fmt.Errorf("getting remediation %s: %w", name, err)
+// resource returns a dynamic client scoped to the target's resource.
+func (t *remediationTarget) resource(client *k8s.Client) dynamic.ResourceInterface {
+	if t.Namespace != "" {
+		return client.Dynamic.Resource(t.GVR).Namespace(t.Namespace)
 	}
+	return client.Dynamic.Resource(t.GVR)
+}
+
+// resolveRemediationTarget extracts spec.current.object from a remediation,
+// applies any stored customization and resolves where it should be applied.
+// An object without a name takes the remediation's name.
+func resolveRemediationTarget(rem *unstructured.Unstructured, namespace string) (*remediationTarget, error) {
+	name := rem.GetName()
 
-	// Extract spec.current.object
 	obj, found, err := unstructured.NestedMap(rem.Object, "spec", "current", "object")
 	if err != nil || !found {
-		result.Error = "remediation has no spec.current.object"
-		return result, fmt.Errorf("remediation %s has no spec.current.object", name)
+		return nil, fmt.Errorf("remediation %s has no spec.current.object", name)
+	}
+
+	customization, err := remediationCustomization(rem)
+	if err != nil {
+		return nil, err
+	}
+	if customization != nil {
+		if obj, err = customizeObject(obj, customization); err != nil {
+			return nil, fmt.Errorf("applying customization to remediation %s: %w", name, err)
+		}
 	}
 
-	// Create an Unstructured from the extracted object
 	remObj := &unstructured.Unstructured{Object: obj}
 	kind := remObj.GetKind()
 	apiVersion := remObj.GetAPIVersion()
-
 	if kind == "" || apiVersion == "" {
-		result.Error = "remediation object missing kind or apiVersion"
-		return result, fmt.Errorf("remediation %s object missing kind or apiVersion", name)
+		return nil, fmt.Errorf("remediation %s object missing kind or apiVersion", name)
 	}
 
-	// Determine the GVR for the remediation object
 	gvr, objNamespace, err := resolveGVR(kind, apiVersion, namespace)
 	if err != nil {
-		result.Error = fmt.Sprintf("resolving GVR: %v", err)
-		return result, err
+		return nil, fmt.Errorf("resolving GVR: %w", err)
 	}
 
 	// Prefer the object's own namespace over the resolved default
@@ -61,76 +70,150 @@ func ApplyRemediation(ctx context.Context, client *k8s.Client, namespace, name s
 		objNamespace = ns
 	}
 
-	// Ensure metadata.name is set
-	objName := remObj.GetName()
-	if objName == "" {
-		// Use the remediation name as the object name
+	if remObj.GetName() == "" {
 		remObj.SetName(name)
-		objName = name
 	}
 
-	// Apply the object
-	if objNamespace != "" {
-		_, err = client.Dynamic.Resource(gvr).Namespace(objNamespace).
-			Create(ctx, remObj, metav1.CreateOptions{})
-	} else {
-		_, err = client.Dynamic.Resource(gvr).
-			Create(ctx, remObj, metav1.CreateOptions{})
+	return &remediationTarget{
+		Object:    remObj,
+		GVR:       gvr,
+		Namespace: objNamespace,
+		Name:      remObj.GetName(),
+		Kind:      kind,
+	}, nil
+}
+
+// setRemediationApplied records the applied state in spec.apply so
+// ListRemediations reflects it. A direct apply or removal supersedes any
+// pending GitOps export and clears recorded drift; a removal also clears the
+// customization so the next apply starts from the remediation as shipped.
+func setRemediationApplied(ctx context.Context, client *k8s.Client, namespace string, rem *unstructured.Unstructured, applied bool) {
+	if annotations := rem.GetAnnotations(); annotations != nil {
+		delete(annotations, annotationGitOpsPending)
+		delete(annotations, annotationDrift)
+		delete(annotations, annotationDriftFields)
+		if !applied {
+			delete(annotations, annotationCustomization)
+		}
+		rem.SetAnnotations(annotations)
+	}
+	if err := unstructured.SetNestedField(rem.Object, applied, "spec", "apply"); err == nil {
+		_, _ = client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+			Update(ctx, rem, metav1.UpdateOptions{})
 	}
+}
 
-	if err != nil {
-		if k8serrors.IsAlreadyExists(err) {
-			// Update instead
-			if objNamespace != "" {
-				existing, getErr := client.Dynamic.Resource(gvr).Namespace(objNamespace).
-					Get(ctx, objName, metav1.GetOptions{})
-				if getErr == nil {
-					remObj.SetResourceVersion(existing.GetResourceVersion())
-				}
-				_, err = client.Dynamic.Resource(gvr).Namespace(objNamespace).
-					Update(ctx, remObj, metav1.UpdateOptions{})
-			} else {
-				existing, getErr := client.Dynamic.Resource(gvr).
-					Get(ctx, objName, metav1.GetOptions{})
-				if getErr == nil {
-					remObj.SetResourceVersion(existing.GetResourceVersion())
-				}
-				_, err = client.Dynamic.Resource(gvr).
-					Update(ctx, remObj, metav1.UpdateOptions{})
-			}
+// writeRemediationObject creates the remediation's object, or replaces the
+// live one with it.
+func writeRemediationObject(ctx context.Context, client *k8s.Client, target *remediationTarget) error {
+	_, err := target.resource(client).Create(ctx, target.Object, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		existing, getErr := target.resource(client).Get(ctx, target.Name, metav1.GetOptions{})
+		if getErr == nil {
+			target.Object.SetResourceVersion(existing.GetResourceVersion())
 		}
-		if err != nil {
-			result.Error = fmt.Sprintf("applying object: %v", err)
-			return result, fmt.Errorf("applying remediation %s: %w", name, err)
+		_, err = target.resource(client).Update(ctx, target.Object, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// ApplyRemediation applies a single ComplianceRemediation by extracting its
+// spec.current.object and creating or updating it. The live object's prior
+// state is snapshotted first so RemoveRemediation can restore it. In
+// operator mode only spec.apply is set and the operator does the rest.
+// Reimplements misc/apply-remediations-by-severity.sh single-item logic.
+func ApplyRemediation(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace, name string) (*RemediationResult, error) {
+	return ApplyCustomizedRemediation(ctx, client, mode, namespace, name, nil)
+}
+
+// ApplyCustomizedRemediation applies a remediation like ApplyRemediation,
+// first applying patch on top of spec.current.object. The patch is validated
+// before anything is changed and stored on the remediation, so later applies,
+// drift checks and exports use the customized object until it is removed. A
+// nil patch keeps any customization stored by an earlier apply. Patches are
+// not supported in operator mode, where the operator applies the object as
+// shipped.
+func ApplyCustomizedRemediation(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace, name string, patch *RemediationPatch) (*RemediationResult, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	if mode == RemediationModeOperator {
+		if patch != nil {
+			return &RemediationResult{Name: name, Error: "patches are not supported in operator remediation mode"},
+				fmt.Errorf("invalid patch for remediation %s: patches are not supported in operator remediation mode", name)
 		}
+		return setSpecApply(ctx, client, namespace, name, true)
 	}
 
-	// Mark the ComplianceRemediation CR as applied so ListRemediations reflects the state
-	if err := unstructured.SetNestedField(rem.Object, true, "spec", "apply"); err == nil {
-		_, _ = client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
-			Update(ctx, rem, metav1.UpdateOptions{})
+	result := &RemediationResult{Name: name}
+
+	// Get the remediation
+	rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		result.Error = fmt.Sprintf("getting remediation: %v", err)
+		return result, fmt.Errorf("getting remediation %s: %w", name, err)
+	}
+
+	if patch != nil {
+		if err := setRemediationCustomization(rem, patch); err != nil {
+			result.Error = err.Error()
+			return result, err
+		}
+	}
+
+	target, err := resolveRemediationTarget(rem, namespace)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+
+	// Capture the prior state before changing anything
+	if err := captureSnapshot(ctx, client, namespace, name, target); err != nil {
+		result.Error = fmt.Sprintf("capturing prior state: %v", err)
+		return result, fmt.Errorf("capturing prior state for remediation %s: %w", name, err)
+	}
+
+	// Apply the object
+	if err := writeRemediationObject(ctx, client, target); err != nil {
+		result.Error = fmt.Sprintf("applying object: %v", err)
+		return result, fmt.Errorf("applying remediation %s: %w", name, err)
 	}
 
+	setRemediationApplied(ctx, client, namespace, rem, true)
+
 	result.Applied = true
-	result.Message = fmt.Sprintf("Applied %s %s", kind, objName)
+	result.Message = fmt.Sprintf("Applied %s %s", target.Kind, target.Name)
+	if rem.GetAnnotations()[annotationCustomization] != "" {
+		result.Message += " with customizations"
+	}
 
 	// If MachineConfig, add reboot hint
-	if kind == "MachineConfig" {
-		role := detectRoleFromObject(remObj)
+	if target.Kind == "MachineConfig" {
+		role := detectRoleFromObject(target.Object)
 		result.Message += fmt.Sprintf(" (MachineConfig - nodes with role %s will reboot)", role)
 	}
 
 	return result, nil
 }
 
-// RemoveRemediation deletes the object that was created by applying a remediation.
-// This allows users to back out a MachineConfig (or similar) change before the
-// MCO triggers a reboot cycle.
-func RemoveRemediation(ctx context.Context, client *k8s.Client, namespace, name string) (*RemediationResult, error) {
+// RemoveRemediation rolls back a previously applied remediation. If a
+// snapshot of the object's prior state exists, that exact state is restored
+// (or the object deleted, if it did not exist before). Without a snapshot the
+// object is deleted, unless it is a cluster singleton or predates the
+// remediation, in which case removal is refused. This allows users to back out a MachineConfig (or
+// similar) change before the MCO triggers a reboot cycle. In operator mode
+// only spec.apply is cleared.
+func RemoveRemediation(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace, name string) (*RemediationResult, error) {
 	if client == nil {
 		return nil, fmt.Errorf("kubernetes client is nil")
 	}
 
+	if mode == RemediationModeOperator {
+		return setSpecApply(ctx, client, namespace, name, false)
+	}
+
 	result := &RemediationResult{Name: name}
 
 	// Get the remediation to find out what object it created
@@ -141,106 +224,132 @@ func RemoveRemediation(ctx context.Context, client *k8s.Client, namespace, name
 		return result, fmt.Errorf("getting remediation %s: %w", name, err)
 	}
 
-	// Extract spec.current.object to determine what to delete
-	obj, found, err := unstructured.NestedMap(rem.Object, "spec", "current", "object")
-	if err != nil || !found {
-		result.Error = "remediation has no spec.current.object"
-		return result, fmt.Errorf("remediation %s has no spec.current.object", name)
-	}
-
-	remObj := &unstructured.Unstructured{Object: obj}
-	kind := remObj.GetKind()
-	apiVersion := remObj.GetAPIVersion()
-
-	if kind == "" || apiVersion == "" {
-		result.Error = "remediation object missing kind or apiVersion"
-		return result, fmt.Errorf("remediation %s object missing kind or apiVersion", name)
-	}
-
-	gvr, objNamespace, err := resolveGVR(kind, apiVersion, namespace)
+	target, err := resolveRemediationTarget(rem, namespace)
 	if err != nil {
-		result.Error = fmt.Sprintf("resolving GVR: %v", err)
+		result.Error = err.Error()
 		return result, err
 	}
 
-	if ns := remObj.GetNamespace(); ns != "" {
-		objNamespace = ns
+	snapshot, err := loadSnapshot(ctx, client, namespace, name)
+	if err != nil {
+		result.Error = fmt.Sprintf("reading snapshot: %v", err)
+		return result, fmt.Errorf("reading snapshot for remediation %s: %w", name, err)
 	}
 
-	objName := remObj.GetName()
-	if objName == "" {
-		objName = name
+	if snapshot != nil && snapshot.Existed {
+		if err := restoreSnapshot(ctx, client, target, snapshot); err != nil {
+			result.Error = fmt.Sprintf("restoring prior state: %v", err)
+			return result, fmt.Errorf("restoring prior state for remediation %s: %w", name, err)
+		}
+		deleteSnapshot(ctx, client, namespace, name)
+		setRemediationApplied(ctx, client, namespace, rem, false)
+		result.Message = fmt.Sprintf("Restored %s %s to its state before the remediation", target.Kind, target.Name)
+		return result, nil
 	}
 
-	// Delete the object
-	if objNamespace != "" {
-		err = client.Dynamic.Resource(gvr).Namespace(objNamespace).
-			Delete(ctx, objName, metav1.DeleteOptions{})
-	} else {
-		err = client.Dynamic.Resource(gvr).
-			Delete(ctx, objName, metav1.DeleteOptions{})
+	// Without a snapshot there is no record that the remediation created the
+	// object, so refuse to delete one that is shared cluster configuration.
+	if snapshot == nil {
+		if err := checkDeletableWithoutSnapshot(ctx, client, rem, target); err != nil {
+			result.Error = err.Error()
+			return result, fmt.Errorf("removing remediation %s: %w", name, err)
+		}
 	}
 
+	// The object did not exist before the remediation: delete it
+	err = target.resource(client).Delete(ctx, target.Name, metav1.DeleteOptions{})
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
+			deleteSnapshot(ctx, client, namespace, name)
 			result.Applied = false
-			result.Message = fmt.Sprintf("Object %s %s was already removed", kind, objName)
+			result.Message = fmt.Sprintf("Object %s %s was already removed", target.Kind, target.Name)
 			return result, nil
 		}
 		result.Error = fmt.Sprintf("deleting object: %v", err)
 		return result, fmt.Errorf("removing remediation %s: %w", name, err)
 	}
 
-	// Clear the applied flag on the ComplianceRemediation CR
-	if err := unstructured.SetNestedField(rem.Object, false, "spec", "apply"); err == nil {
-		_, _ = client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
-			Update(ctx, rem, metav1.UpdateOptions{})
-	}
+	deleteSnapshot(ctx, client, namespace, name)
+	setRemediationApplied(ctx, client, namespace, rem, false)
 
 	result.Applied = false
-	result.Message = fmt.Sprintf("Removed %s %s", kind, objName)
+	result.Message = fmt.Sprintf("Removed %s %s", target.Kind, target.Name)
 	return result, nil
 }
 
-// ApplyBySeverity applies all remediations matching a given severity level.
-// Reimplements misc/apply-remediations-by-severity.sh bulk logic.
-func ApplyBySeverity(ctx context.Context, client *k8s.Client, namespace string, severity Severity, progress chan<- RemediationResult) error {
-	if client == nil {
-		return fmt.Errorf("kubernetes client is nil")
+// singletonObjects are cluster configuration objects that always exist.
+// A remediation can only have changed them, never created them.
+var singletonObjects = map[string]string{
+	"APIServer":         "cluster",
+	"OAuth":             "cluster",
+	"IngressController": "default",
+}
+
+// checkDeletableWithoutSnapshot refuses to delete a remediation's target
+// when no snapshot was taken and the object is a cluster singleton or
+// predates the remediation, since deleting it would remove configuration
+// the remediation did not create.
+func checkDeletableWithoutSnapshot(ctx context.Context, client *k8s.Client, rem *unstructured.Unstructured, target *remediationTarget) error {
+	if singletonObjects[target.Kind] == target.Name {
+		return fmt.Errorf("no snapshot of %s %s was taken when the remediation was applied, and it is a cluster singleton that cannot be deleted; revert the change manually", target.Kind, target.Name)
 	}
-	defer close(progress)
 
-	// List all remediations
+	live, err := target.resource(client).Get(ctx, target.Name, metav1.GetOptions{})
+	if err != nil {
+		// A missing object is reported by the delete.
+		return nil
+	}
+	created, remCreated := live.GetCreationTimestamp(), rem.GetCreationTimestamp()
+	if !created.IsZero() && !remCreated.IsZero() && created.Before(&remCreated) {
+		return fmt.Errorf("no snapshot of %s %s was taken when the remediation was applied, and it existed before the remediation; revert the change manually", target.Kind, target.Name)
+	}
+	return nil
+}
+
+// SelectBySeverity returns the remediations with the given severity that
+// match the filter, in the order ApplyBySeverity would apply them.
+func SelectBySeverity(ctx context.Context, client *k8s.Client, namespace string, severity Severity, filter RemediationFilter) ([]RemediationInfo, error) {
 	remediations, err := ListRemediations(ctx, client, namespace)
 	if err != nil {
-		return fmt.Errorf("listing remediations: %w", err)
+		return nil, fmt.Errorf("listing remediations: %w", err)
 	}
 
+	var selected []RemediationInfo
 	for _, rem := range remediations {
-		if rem.Severity != severity {
-			continue
+		if rem.Severity == severity && filter.Matches(rem) {
+			selected = append(selected, rem)
 		}
+	}
+	return selected, nil
+}
 
-		result, err := ApplyRemediation(ctx, client, namespace, rem.Name)
+// ApplyBySeverity applies exactly the remediations SelectBySeverity chose,
+// in order, sending each result to the progress channel. Taking the
+// selection rather than re-running it keeps the apply to what was
+// confirmed and approved. It does not wait for rollouts: callers apply
+// MachineConfig and KubeletConfig items with ApplyMachineConfigBatch so
+// they roll out once. Reimplements misc/apply-remediations-by-severity.sh
+// bulk logic.
+func ApplyBySeverity(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace string, remediations []RemediationInfo, progress chan<- RemediationResult) error {
+	defer close(progress)
+	if client == nil {
+		return fmt.Errorf("kubernetes client is nil")
+	}
+
+	for _, rem := range remediations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result, err := ApplyRemediation(ctx, client, mode, namespace, rem.Name)
 		if err != nil {
 			progress <- RemediationResult{
 				Name:  rem.Name,
 				Error: err.Error(),
 			}
-
-			// Wait briefly between operations for MachineConfig to avoid overwhelming MCP
-			if rem.Kind == "MachineConfig" {
-				waitForMCPReconciliation(ctx, client, rem.Role)
-			}
 			continue
 		}
-
 		progress <- *result
-
-		// Wait for MachineConfig changes to reconcile
-		if rem.Kind == "MachineConfig" {
-			waitForMCPReconciliation(ctx, client, rem.Role)
-		}
 	}
 
 	return nil
@@ -312,49 +421,3 @@ func detectRoleFromObject(obj *unstructured.Unstructured) string {
 	}
 	return "worker"
 }
-
-func waitForMCPReconciliation(ctx context.Context, client *k8s.Client, role string) {
-	if role == "" {
-		role = "worker"
-	}
-
-	mcpGVR := schema.GroupVersionResource{
-		Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfigpools",
-	}
-
-	// Wait up to 10 minutes for MCP to become Updated
-	timeout := time.After(10 * time.Minute)
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-timeout:
-			return
-		case <-ticker.C:
-			mcp, err := client.Dynamic.Resource(mcpGVR).Get(ctx, role, metav1.GetOptions{})
-			if err != nil {
-				continue
-			}
-
-			conditions, found, _ := unstructured.NestedSlice(mcp.Object, "status", "conditions")
-			if !found {
-				continue
-			}
-
-			for _, cond := range conditions {
-				condMap, ok := cond.(map[string]interface{})
-				if !ok {
-					continue
-				}
-				condType, _ := condMap["type"].(string)
-				condStatus, _ := condMap["status"].(string)
-				if condType == "Updated" && condStatus == "True" {
-					return
-				}
-			}
-		}
-	}
-}
@@ -2,6 +2,7 @@ package compliance
 
 import (
 	"context"
+	"slices"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -221,7 +222,7 @@ func TestApplyRemediation(t *testing.T) {
 
 		client := newTestClient(rem)
 
-		result, err := ApplyRemediation(ctx, client, ns, "rem-cm")
+		result, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-cm")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -245,7 +246,7 @@ func TestApplyRemediation(t *testing.T) {
 	})
 
 	t.Run("nil client returns error", func(t *testing.T) {
-		_, err := ApplyRemediation(ctx, nil, ns, "rem-1")
+		_, err := ApplyRemediation(ctx, nil, RemediationModeDirect, ns, "rem-1")
 		if err == nil {
 			t.Error("expected error for nil client")
 		}
@@ -253,7 +254,7 @@ func TestApplyRemediation(t *testing.T) {
 
 	t.Run("missing remediation returns error", func(t *testing.T) {
 		client := newTestClient()
-		_, err := ApplyRemediation(ctx, client, ns, "nonexistent")
+		_, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "nonexistent")
 		if err == nil {
 			t.Error("expected error for missing remediation")
 		}
@@ -267,7 +268,7 @@ func TestApplyRemediation(t *testing.T) {
 		})
 		client := newTestClient(rem)
 
-		_, err := ApplyRemediation(ctx, client, ns, "rem-empty")
+		_, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-empty")
 		if err == nil {
 			t.Error("expected error for remediation without spec.current.object")
 		}
@@ -288,7 +289,7 @@ func TestApplyRemediation(t *testing.T) {
 		})
 		client := newTestClient(rem)
 
-		_, err := ApplyRemediation(ctx, client, ns, "rem-no-kind")
+		_, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-no-kind")
 		if err == nil {
 			t.Error("expected error for object missing kind/apiVersion")
 		}
@@ -331,7 +332,7 @@ func TestRemoveRemediation(t *testing.T) {
 
 		client := newTestClient(rem, targetCM)
 
-		result, err := RemoveRemediation(ctx, client, ns, "rem-cm")
+		result, err := RemoveRemediation(ctx, client, RemediationModeDirect, ns, "rem-cm")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -370,7 +371,7 @@ func TestRemoveRemediation(t *testing.T) {
 
 		client := newTestClient(rem)
 
-		result, err := RemoveRemediation(ctx, client, ns, "rem-gone")
+		result, err := RemoveRemediation(ctx, client, RemediationModeDirect, ns, "rem-gone")
 		// The fake client returns "not found" for missing objects, which
 		// RemoveRemediation handles gracefully.
 		if err != nil {
@@ -382,7 +383,7 @@ func TestRemoveRemediation(t *testing.T) {
 	})
 
 	t.Run("nil client returns error", func(t *testing.T) {
-		_, err := RemoveRemediation(ctx, nil, ns, "rem-1")
+		_, err := RemoveRemediation(ctx, nil, RemediationModeDirect, ns, "rem-1")
 		if err == nil {
 			t.Error("expected error for nil client")
 		}
@@ -390,7 +391,7 @@ func TestRemoveRemediation(t *testing.T) {
 
 	t.Run("missing remediation returns error", func(t *testing.T) {
 		client := newTestClient()
-		_, err := RemoveRemediation(ctx, client, ns, "nonexistent")
+		_, err := RemoveRemediation(ctx, client, RemediationModeDirect, ns, "nonexistent")
 		if err == nil {
 			t.Error("expected error for missing remediation")
 		}
@@ -419,7 +420,7 @@ func TestApplyRemediation_SetsApplyFlag(t *testing.T) {
 
 	client := newTestClient(rem)
 
-	_, err := ApplyRemediation(ctx, client, ns, "rem-flag")
+	_, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-flag")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -463,7 +464,7 @@ func TestApplyRemediation_MachineConfig(t *testing.T) {
 
 	client := newTestClient(rem)
 
-	result, err := ApplyRemediation(ctx, client, ns, "rem-mc")
+	result, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-mc")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -487,3 +488,107 @@ func TestApplyRemediation_MachineConfig(t *testing.T) {
 		t.Errorf("MachineConfig name = %q, want 75-worker-audit", mc.GetName())
 	}
 }
+
+func TestRemediationFilter_Matches(t *testing.T) {
+	mc := RemediationInfo{Name: "a", Kind: "MachineConfig", Role: "worker", RebootNeeded: true}
+	kc := RemediationInfo{Name: "b", Kind: "KubeletConfig", Role: "master"}
+	cm := RemediationInfo{Name: "c", Kind: "ConfigMap", Role: "worker"}
+
+	tests := []struct {
+		name   string
+		filter RemediationFilter
+		rem    RemediationInfo
+		want   bool
+	}{
+		{"empty filter matches", RemediationFilter{}, mc, true},
+		{"role match is case-insensitive", RemediationFilter{Role: "Worker"}, mc, true},
+		{"role mismatch", RemediationFilter{Role: "master"}, mc, false},
+		{"kind match", RemediationFilter{Kind: "configmap"}, cm, true},
+		{"kind mismatch", RemediationFilter{Kind: "ConfigMap"}, mc, false},
+		{"exclude reboot drops MachineConfig", RemediationFilter{ExcludeReboot: true}, mc, false},
+		{"exclude reboot drops KubeletConfig", RemediationFilter{ExcludeReboot: true}, kc, false},
+		{"exclude reboot keeps ConfigMap", RemediationFilter{ExcludeReboot: true}, cm, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.rem); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyBySeverity(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	newCMRemediation := func(name string) *unstructured.Unstructured {
+		return newRemediation(name, ns, map[string]any{
+			"spec": map[string]any{
+				"apply": false,
+				"current": map[string]any{
+					"object": map[string]any{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata": map[string]any{
+							"name":      name + "-cm",
+							"namespace": ns,
+						},
+					},
+				},
+			},
+		})
+	}
+
+	client := newTestClient(
+		newCMRemediation("high-one"),
+		newCMRemediation("high-two"),
+		newCMRemediation("low-one"),
+		newMachineConfigRemediation("high-mc", ns, "worker"),
+		newCheckResult("high-one", ns, "FAIL", "high", "", "", ""),
+		newCheckResult("high-two", ns, "FAIL", "high", "", "", ""),
+		newCheckResult("low-one", ns, "FAIL", "low", "", "", ""),
+		newCheckResult("high-mc", ns, "FAIL", "high", "", "", ""),
+	)
+
+	selected, err := SelectBySeverity(ctx, client, ns, SeverityHigh, RemediationFilter{ExcludeReboot: true})
+	if err != nil {
+		t.Fatalf("SelectBySeverity: %v", err)
+	}
+	if len(selected) != 2 {
+		t.Fatalf("selected %d remediations, want 2: %+v", len(selected), selected)
+	}
+
+	// A remediation that matches after the selection was confirmed is not
+	// applied.
+	if _, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(ns).
+		Create(ctx, newCMRemediation("high-late"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating remediation: %v", err)
+	}
+
+	progress := make(chan RemediationResult, 10)
+	if err := ApplyBySeverity(ctx, client, RemediationModeDirect, ns, selected, progress); err != nil {
+		t.Fatalf("ApplyBySeverity: %v", err)
+	}
+
+	var applied []string
+	for result := range progress {
+		if !result.Applied {
+			t.Errorf("result %s not applied: %s", result.Name, result.Error)
+		}
+		applied = append(applied, result.Name)
+	}
+	if len(applied) != 2 || slices.Contains(applied, "high-late") {
+		t.Errorf("applied %v, want high-one and high-two", applied)
+	}
+
+	t.Run("nil client closes progress", func(t *testing.T) {
+		progress := make(chan RemediationResult)
+		if err := ApplyBySeverity(ctx, nil, RemediationModeDirect, ns, selected, progress); err == nil {
+			t.Error("expected error for nil client")
+		}
+		if _, ok := <-progress; ok {
+			t.Error("expected progress channel to be closed")
+		}
+	})
+}
@@ -220,13 +220,9 @@ func ListRemediations(ctx context.Context, client *k8s.Client, namespace string)
 		// Look up severity
 		severity := severityMap[name]
 
-		// Check if applied (handle both bool and string representations)
-		applied := false
-		if applyBool, found, err := unstructured.NestedBool(rem.Object, "spec", "apply"); err == nil && found {
-			applied = applyBool
-		} else if applyStr, found, err := unstructured.NestedString(rem.Object, "spec", "apply"); err == nil && found {
-			applied = applyStr == "true"
-		}
+		applied := remediationApplied(&rem)
+		appState, appError := remediationStatus(&rem)
+		drift, driftedFields := remediationDrift(&rem)
 
 		// Determine if reboot is needed (MachineConfig changes reboot nodes)
 		rebootNeeded := kind == "MachineConfig"
@@ -235,12 +231,19 @@ func ListRemediations(ctx context.Context, client *k8s.Client, namespace string)
 		role := detectRole(name, rem)
 
 		infos = append(infos, RemediationInfo{
-			Name:         name,
-			Kind:         kind,
-			Severity:     severity,
-			Applied:      applied,
-			RebootNeeded: rebootNeeded,
-			Role:         role,
+			Name:             name,
+			Kind:             kind,
+			Severity:         severity,
+			Applied:          applied,
+			RebootNeeded:     rebootNeeded,
+			Role:             role,
+			GitOpsPending:    rem.GetAnnotations()[annotationGitOpsPending] != "",
+			Outdated:         isOutdated(&rem),
+			ApplicationState: appState,
+			ErrorMessage:     appError,
+			Drift:            drift,
+			DriftedFields:    driftedFields,
+			Customized:       rem.GetAnnotations()[annotationCustomization] != "",
 		})
 	}
 
@@ -319,8 +322,9 @@ func GetRemediation(ctx context.Context, client *k8s.Client, namespace, name str
 	kind, _, _ := unstructured.NestedString(rem.Object, "spec", "current", "object", "kind")
 
 	// Check if applied
-	apply, _, _ := unstructured.NestedString(rem.Object, "spec", "apply")
-	applied := apply == "true"
+	applied := remediationApplied(rem)
+	appState, appError := remediationStatus(rem)
+	drift, driftedFields := remediationDrift(rem)
 
 	// Determine reboot
 	rebootNeeded := kind == "MachineConfig"
@@ -338,16 +342,13 @@ func GetRemediation(ctx context.Context, client *k8s.Client, namespace, name str
 	}
 
 	// Extract the object YAML from spec.current.object
-	obj, found, _ := unstructured.NestedMap(rem.Object, "spec", "current", "object")
-	var objectYAML string
-	if found && obj != nil {
-		jsonBytes, err := json.Marshal(obj)
-		if err == nil {
-			yamlBytes, err := sigsyaml.JSONToYAML(jsonBytes)
-			if err == nil {
-				objectYAML = string(yamlBytes)
-			}
-		}
+	objectYAML := nestedObjectYAML(rem, "spec", "current", "object")
+
+	// When content was updated, spec.outdated holds the previous version
+	var outdatedYAML, outdatedDiff string
+	if isOutdated(rem) {
+		outdatedYAML = nestedObjectYAML(rem, "spec", "outdated", "object")
+		outdatedDiff = unifiedDiff(outdatedYAML, objectYAML, "outdated", "current")
 	}
 
 	// Extract apiVersion from the inner object
@@ -356,21 +357,83 @@ func GetRemediation(ctx context.Context, client *k8s.Client, namespace, name str
 	// Extract target namespace from the inner object
 	objNamespace, _, _ := unstructured.NestedString(rem.Object, "spec", "current", "object", "metadata", "namespace")
 
+	// A customized remediation applies its patched object, so that is what
+	// Ignition content is decoded from.
+	var ignition *IgnitionConfig
+	var customizedYAML, customizationDiff string
+	customization, _ := remediationCustomization(rem)
+	if obj, found, _ := unstructured.NestedMap(rem.Object, "spec", "current", "object"); found {
+		if customization != nil {
+			if patched, err := customizeObject(obj, customization); err == nil {
+				obj = patched
+				customizedYAML = nestedObjectYAML(&unstructured.Unstructured{Object: patched})
+				customizationDiff = unifiedDiff(objectYAML, customizedYAML, "current", "customized")
+			}
+		}
+		ignition = DecodeIgnition(&unstructured.Unstructured{Object: obj})
+	}
+
+	snapshot, _ := loadSnapshot(ctx, client, namespace, name)
+	impact, _ := AnalyzeImpact(ctx, client, namespace, []string{name})
+
 	return &RemediationDetail{
 		RemediationInfo: RemediationInfo{
-			Name:         name,
-			Kind:         kind,
-			Severity:     severity,
-			Applied:      applied,
-			RebootNeeded: rebootNeeded,
-			Role:         role,
+			Name:             name,
+			Kind:             kind,
+			Severity:         severity,
+			Applied:          applied,
+			RebootNeeded:     rebootNeeded,
+			Role:             role,
+			GitOpsPending:    rem.GetAnnotations()[annotationGitOpsPending] != "",
+			Outdated:         isOutdated(rem),
+			ApplicationState: appState,
+			ErrorMessage:     appError,
+			Drift:            drift,
+			DriftedFields:    driftedFields,
+			Customized:       customization != nil,
 		},
-		ObjectYAML: objectYAML,
-		APIVersion: apiVersion,
-		Namespace:  objNamespace,
+		ObjectYAML:        objectYAML,
+		APIVersion:        apiVersion,
+		Namespace:         objNamespace,
+		HasSnapshot:       snapshot != nil,
+		OutdatedYAML:      outdatedYAML,
+		OutdatedDiff:      outdatedDiff,
+		Impact:            impact,
+		Ignition:          ignition,
+		Customization:     customization,
+		CustomizedYAML:    customizedYAML,
+		CustomizationDiff: customizationDiff,
 	}, nil
 }
 
+// remediationApplied reads spec.apply, which may be a bool or a string.
+func remediationApplied(rem *unstructured.Unstructured) bool {
+	if applyBool, found, err := unstructured.NestedBool(rem.Object, "spec", "apply"); err == nil && found {
+		return applyBool
+	}
+	if applyStr, found, err := unstructured.NestedString(rem.Object, "spec", "apply"); err == nil && found {
+		return applyStr == "true"
+	}
+	return false
+}
+
+// nestedObjectYAML renders the map at fields as YAML, or "" if absent.
+func nestedObjectYAML(rem *unstructured.Unstructured, fields ...string) string {
+	obj, found, _ := unstructured.NestedMap(rem.Object, fields...)
+	if !found || obj == nil {
+		return ""
+	}
+	jsonBytes, err := json.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	yamlBytes, err := sigsyaml.JSONToYAML(jsonBytes)
+	if err != nil {
+		return ""
+	}
+	return string(yamlBytes)
+}
+
 func extractCheckResult(item unstructured.Unstructured) CheckResult {
 	name := item.GetName()
 
@@ -58,15 +58,47 @@ func newTestClient(objects ...runtime.Object) *k8s.Client {
 		schema.GroupVersionKind{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "ClusterServiceVersionList"},
 		&unstructured.UnstructuredList{},
 	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "InstallPlanList"},
+		&unstructured.UnstructuredList{},
+	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "packages.operators.coreos.com", Version: "v1", Kind: "PackageManifestList"},
+		&unstructured.UnstructuredList{},
+	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "CatalogSourceList"},
+		&unstructured.UnstructuredList{},
+	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "operators.coreos.com", Version: "v1", Kind: "OperatorGroupList"},
+		&unstructured.UnstructuredList{},
+	)
 	// Register cluster-scoped resource lists used by remediation tests.
 	scheme.AddKnownTypeWithName(
 		schema.GroupVersionKind{Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "MachineConfigList"},
 		&unstructured.UnstructuredList{},
 	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "MachineConfigPoolList"},
+		&unstructured.UnstructuredList{},
+	)
 	scheme.AddKnownTypeWithName(
 		schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMapList"},
 		&unstructured.UnstructuredList{},
 	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "operator.openshift.io", Version: "v1alpha1", Kind: "ImageContentSourcePolicyList"},
+		&unstructured.UnstructuredList{},
+	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "ImageDigestMirrorSetList"},
+		&unstructured.UnstructuredList{},
+	)
+	scheme.AddKnownTypeWithName(
+		schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "ImageTagMirrorSetList"},
+		&unstructured.UnstructuredList{},
+	)
 
 	dynClient := dynamicfake.NewSimpleDynamicClient(scheme, objects...)
 	kubeClient := kubefake.NewClientset()
@@ -892,7 +924,7 @@ func TestScanTimestamp(t *testing.T) {
 
 func TestNewService(t *testing.T) {
 	client := newTestClient()
-	svc := NewService(client, "test-ns", "v1.0.0")
+	svc := NewService(client, "test-ns", "v1.0.0", RemediationModeDirect)
 	if svc == nil {
 		t.Fatal("NewService returned nil")
 	}
@@ -412,18 +412,26 @@ func RescanSuite(ctx context.Context, client *k8s.Client, namespace, suiteName s
 		return fmt.Errorf("no ComplianceScans found for suite %s", suiteName)
 	}
 
-	patch := []byte(`{"metadata":{"annotations":{"compliance.openshift.io/rescan":""}}}`)
 	for _, scan := range scans.Items {
-		_, err := client.Dynamic.Resource(complianceScanGVR).Namespace(namespace).
-			Patch(ctx, scan.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
-		if err != nil {
-			return fmt.Errorf("annotating ComplianceScan %s for rescan: %w", scan.GetName(), err)
+		if err := annotateRescan(ctx, client, namespace, scan.GetName()); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// annotateRescan sets compliance.openshift.io/rescan on a single ComplianceScan.
+func annotateRescan(ctx context.Context, client *k8s.Client, namespace, scanName string) error {
+	patch := []byte(`{"metadata":{"annotations":{"compliance.openshift.io/rescan":""}}}`)
+	_, err := client.Dynamic.Resource(complianceScanGVR).Namespace(namespace).
+		Patch(ctx, scanName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("annotating ComplianceScan %s for rescan: %w", scanName, err)
+	}
+	return nil
+}
+
 // DeleteScan deletes a ComplianceSuite and its matching ScanSettingBinding.
 // Finalizers are removed first to prevent deletion from hanging.
 func DeleteScan(ctx context.Context, client *k8s.Client, namespace, suiteName string) error {
@@ -483,6 +491,7 @@ func ListProfiles(ctx context.Context, client *k8s.Client, namespace string) ([]
 			Name:        p.GetName(),
 			Title:       title,
 			Description: description,
+			Bundle:      profileBundleOf(&p),
 		})
 	}
 
@@ -0,0 +1,236 @@
+package compliance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// Snapshots of the object a remediation replaces are stored next to the
+// remediation, in a ConfigMap (or a Secret when the object is itself a
+// Secret, so its data never lands in a ConfigMap).
+const (
+	snapshotPrefix          = "remediation-snapshot-"
+	snapshotLabel           = "compliance-dashboard/remediation-snapshot"
+	snapshotObjectKey       = "object.json"
+	annotationRemediation   = "compliance-dashboard/remediation"
+	annotationSnapKind      = "compliance-dashboard/kind"
+	annotationSnapAPI       = "compliance-dashboard/api-version"
+	annotationSnapName      = "compliance-dashboard/name"
+	annotationSnapNamespace = "compliance-dashboard/namespace"
+	annotationSnapExisted   = "compliance-dashboard/existed"
+	annotationSnapCaptured  = "compliance-dashboard/captured-at"
+)
+
+// snapshotName returns the name of the ConfigMap/Secret holding a
+// remediation's snapshot. Names too long for the prefix are truncated and
+// suffixed with a hash of the full name, so they stay unique.
+func snapshotName(remediation string) string {
+	name := snapshotPrefix + remediation
+	if len(name) > 253 {
+		sum := sha256.Sum256([]byte(remediation))
+		name = strings.TrimRight(name[:253-9], "-.") + "-" + hex.EncodeToString(sum[:4])
+	}
+	return name
+}
+
+// captureSnapshot records the live state of the remediation's target object
+// before it is applied. An existing snapshot is kept as-is: re-applying a
+// remediation must not overwrite the state from before the first apply.
+func captureSnapshot(ctx context.Context, client *k8s.Client, namespace, remediation string, target *remediationTarget) error {
+	existing, err := loadSnapshot(ctx, client, namespace, remediation)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	snap := &RemediationSnapshot{
+		Remediation: remediation,
+		Kind:        target.Kind,
+		APIVersion:  target.Object.GetAPIVersion(),
+		Name:        target.Name,
+		Namespace:   target.Namespace,
+		CapturedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var objectJSON []byte
+	live, err := target.resource(client).Get(ctx, target.Name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		snap.Existed = true
+		objectJSON, err = json.Marshal(sanitizeSnapshotObject(live).Object)
+		if err != nil {
+			return fmt.Errorf("encoding %s %s: %w", target.Kind, target.Name, err)
+		}
+	case k8serrors.IsNotFound(err):
+		snap.Existed = false
+	default:
+		return fmt.Errorf("reading %s %s: %w", target.Kind, target.Name, err)
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      snapshotName(remediation),
+		Namespace: namespace,
+		Labels:    map[string]string{snapshotLabel: "true"},
+		Annotations: map[string]string{
+			annotationRemediation:   remediation,
+			annotationSnapKind:      snap.Kind,
+			annotationSnapAPI:       snap.APIVersion,
+			annotationSnapName:      snap.Name,
+			annotationSnapNamespace: snap.Namespace,
+			annotationSnapExisted:   strconv.FormatBool(snap.Existed),
+			annotationSnapCaptured:  snap.CapturedAt,
+		},
+	}
+
+	if target.Kind == "Secret" {
+		secret := &corev1.Secret{ObjectMeta: meta}
+		if objectJSON != nil {
+			secret.Data = map[string][]byte{snapshotObjectKey: objectJSON}
+		}
+		_, err = client.Clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	} else {
+		cm := &corev1.ConfigMap{ObjectMeta: meta}
+		if objectJSON != nil {
+			cm.Data = map[string]string{snapshotObjectKey: string(objectJSON)}
+		}
+		_, err = client.Clientset.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("storing snapshot: %w", err)
+	}
+	return nil
+}
+
+// sanitizeSnapshotObject strips server-populated fields so the object can be
+// written back later.
+func sanitizeSnapshotObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	c := obj.DeepCopy()
+	unstructured.RemoveNestedField(c.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(c.Object, "metadata", "uid")
+	unstructured.RemoveNestedField(c.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(c.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(c.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(c.Object, "metadata", "selfLink")
+	unstructured.RemoveNestedField(c.Object, "status")
+	return c
+}
+
+// loadSnapshot returns the stored snapshot for a remediation, or nil if none
+// exists. The decoded prior object is returned in snapshot.object.
+func loadSnapshot(ctx context.Context, client *k8s.Client, namespace, remediation string) (*RemediationSnapshot, error) {
+	name := snapshotName(remediation)
+
+	cm, err := client.Clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return decodeSnapshot(cm.Annotations, []byte(cm.Data[snapshotObjectKey]), "ConfigMap/"+name)
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting snapshot ConfigMap: %w", err)
+	}
+
+	secret, err := client.Clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return decodeSnapshot(secret.Annotations, secret.Data[snapshotObjectKey], "Secret/"+name)
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting snapshot Secret: %w", err)
+	}
+
+	return nil, nil
+}
+
+func decodeSnapshot(annotations map[string]string, objectJSON []byte, storedIn string) (*RemediationSnapshot, error) {
+	existed, _ := strconv.ParseBool(annotations[annotationSnapExisted])
+	snap := &RemediationSnapshot{
+		Remediation: annotations[annotationRemediation],
+		Kind:        annotations[annotationSnapKind],
+		APIVersion:  annotations[annotationSnapAPI],
+		Name:        annotations[annotationSnapName],
+		Namespace:   annotations[annotationSnapNamespace],
+		Existed:     existed,
+		CapturedAt:  annotations[annotationSnapCaptured],
+		StoredIn:    storedIn,
+	}
+
+	if existed {
+		if len(objectJSON) == 0 {
+			return nil, fmt.Errorf("snapshot %s has no stored object", storedIn)
+		}
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(objectJSON, &obj); err != nil {
+			return nil, fmt.Errorf("decoding snapshot %s: %w", storedIn, err)
+		}
+		snap.object = &unstructured.Unstructured{Object: obj}
+		if snap.Kind != "Secret" {
+			if yamlBytes, err := sigsyaml.JSONToYAML(objectJSON); err == nil {
+				snap.ObjectYAML = string(yamlBytes)
+			}
+		}
+	}
+
+	return snap, nil
+}
+
+// restoreSnapshot writes the snapshotted object back over the live one,
+// recreating it if it has since been deleted.
+func restoreSnapshot(ctx context.Context, client *k8s.Client, target *remediationTarget, snap *RemediationSnapshot) error {
+	obj := snap.object.DeepCopy()
+	res := target.resource(client)
+
+	live, err := res.Get(ctx, target.Name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("reading %s %s: %w", target.Kind, target.Name, err)
+		}
+		if _, err := res.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("recreating %s %s: %w", target.Kind, target.Name, err)
+		}
+		return nil
+	}
+
+	obj.SetResourceVersion(live.GetResourceVersion())
+	if _, err := res.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("restoring %s %s: %w", target.Kind, target.Name, err)
+	}
+	return nil
+}
+
+// deleteSnapshot removes a remediation's snapshot once it has been rolled back.
+func deleteSnapshot(ctx context.Context, client *k8s.Client, namespace, remediation string) {
+	name := snapshotName(remediation)
+	_ = client.Clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	_ = client.Clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// GetRemediationSnapshot returns the prior state captured when a remediation
+// was applied. It returns a NotFound error if no snapshot is stored.
+func GetRemediationSnapshot(ctx context.Context, client *k8s.Client, namespace, remediation string) (*RemediationSnapshot, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	snap, err := loadSnapshot(ctx, client, namespace, remediation)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, k8serrors.NewNotFound(corev1.Resource("configmaps"), snapshotName(remediation))
+	}
+	return snap, nil
+}
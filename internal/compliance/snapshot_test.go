@@ -0,0 +1,230 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var configMapGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+
+func newConfigMapRemediation(name, ns, target string, data map[string]any) *unstructured.Unstructured {
+	return newRemediation(name, ns, map[string]any{
+		"spec": map[string]any{
+			"apply": false,
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      target,
+						"namespace": ns,
+					},
+					"data": data,
+				},
+			},
+		},
+	})
+}
+
+func TestRemediationSnapshot_RestoresPriorState(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	rem := newConfigMapRemediation("rem-cm", ns, "target-cm", map[string]any{"key": "remediated"})
+	prior := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":      "target-cm",
+				"namespace": ns,
+			},
+			"data": map[string]any{"key": "original", "other": "kept"},
+		},
+	}
+	client := newTestClient(rem, prior)
+
+	if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-cm"); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	snap, err := GetRemediationSnapshot(ctx, client, ns, "rem-cm")
+	if err != nil {
+		t.Fatalf("GetRemediationSnapshot: %v", err)
+	}
+	if !snap.Existed {
+		t.Error("expected Existed=true")
+	}
+	if snap.StoredIn != "ConfigMap/remediation-snapshot-rem-cm" {
+		t.Errorf("StoredIn = %q", snap.StoredIn)
+	}
+	if snap.ObjectYAML == "" {
+		t.Error("expected non-empty ObjectYAML")
+	}
+
+	// Re-applying must not overwrite the original snapshot
+	if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-cm"); err != nil {
+		t.Fatalf("re-apply: %v", err)
+	}
+
+	result, err := RemoveRemediation(ctx, client, RemediationModeDirect, ns, "rem-cm")
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if result.Applied {
+		t.Error("expected Applied=false")
+	}
+
+	cm, err := client.Dynamic.Resource(configMapGVR).Namespace(ns).Get(ctx, "target-cm", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected ConfigMap to be restored, got: %v", err)
+	}
+	data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+	if data["key"] != "original" || data["other"] != "kept" {
+		t.Errorf("restored data = %v, want original", data)
+	}
+
+	if _, err := GetRemediationSnapshot(ctx, client, ns, "rem-cm"); err == nil {
+		t.Error("expected snapshot to be deleted after restore")
+	}
+}
+
+func TestRemediationSnapshot_DeletesCreatedObject(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	rem := newConfigMapRemediation("rem-new", ns, "new-cm", map[string]any{"key": "value"})
+	client := newTestClient(rem)
+
+	if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-new"); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	snap, err := GetRemediationSnapshot(ctx, client, ns, "rem-new")
+	if err != nil {
+		t.Fatalf("GetRemediationSnapshot: %v", err)
+	}
+	if snap.Existed {
+		t.Error("expected Existed=false")
+	}
+
+	if _, err := RemoveRemediation(ctx, client, RemediationModeDirect, ns, "rem-new"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if _, err := client.Dynamic.Resource(configMapGVR).Namespace(ns).Get(ctx, "new-cm", metav1.GetOptions{}); err == nil {
+		t.Error("expected ConfigMap to be deleted")
+	}
+}
+
+func TestRemediationSnapshot_SecretStoredInSecret(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	rem := newRemediation("rem-secret", ns, map[string]any{
+		"spec": map[string]any{
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Secret",
+					"metadata": map[string]any{
+						"name":      "target-secret",
+						"namespace": ns,
+					},
+				},
+			},
+		},
+	})
+	prior := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]any{
+				"name":      "target-secret",
+				"namespace": ns,
+			},
+			"data": map[string]any{"password": "c2VjcmV0"},
+		},
+	}
+	client := newTestClient(rem, prior)
+
+	if _, err := ApplyRemediation(ctx, client, RemediationModeDirect, ns, "rem-secret"); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	snap, err := GetRemediationSnapshot(ctx, client, ns, "rem-secret")
+	if err != nil {
+		t.Fatalf("GetRemediationSnapshot: %v", err)
+	}
+	if snap.StoredIn != "Secret/remediation-snapshot-rem-secret" {
+		t.Errorf("StoredIn = %q, want Secret", snap.StoredIn)
+	}
+	if snap.ObjectYAML != "" {
+		t.Error("expected Secret contents to be withheld from ObjectYAML")
+	}
+}
+
+func TestGetRemediationSnapshot_NotFound(t *testing.T) {
+	client := newTestClient()
+	if _, err := GetRemediationSnapshot(context.Background(), client, "openshift-compliance", "missing"); err == nil {
+		t.Error("expected error for missing snapshot")
+	}
+}
+
+func TestRemoveRemediation_RefusesWithoutSnapshot(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	apiServer := newRemediation("rem-apiserver", ns, map[string]any{
+		"spec": map[string]any{
+			"apply": true,
+			"current": map[string]any{
+				"object": map[string]any{
+					"apiVersion": "config.openshift.io/v1",
+					"kind":       "APIServer",
+					"metadata":   map[string]any{"name": "cluster"},
+				},
+			},
+		},
+	})
+	older := newConfigMapRemediation("rem-old", ns, "old-cm", map[string]any{"key": "remediated"})
+	older.SetCreationTimestamp(metav1.Now())
+	prior := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":              "old-cm",
+			"namespace":         ns,
+			"creationTimestamp": "2020-01-01T00:00:00Z",
+		},
+	}}
+	client := newTestClient(apiServer, older, prior)
+
+	for _, name := range []string{"rem-apiserver", "rem-old"} {
+		if _, err := RemoveRemediation(ctx, client, RemediationModeDirect, ns, name); err == nil || !strings.Contains(err.Error(), "no snapshot") {
+			t.Errorf("%s: expected removal without a snapshot to be refused, got %v", name, err)
+		}
+	}
+	if _, err := client.Dynamic.Resource(configMapGVR).Namespace(ns).Get(ctx, "old-cm", metav1.GetOptions{}); err != nil {
+		t.Errorf("pre-existing ConfigMap was deleted: %v", err)
+	}
+}
+
+func TestSnapshotName_LongNamesStayUnique(t *testing.T) {
+	base := strings.Repeat("a", 250)
+	one, two := snapshotName(base+"-one"), snapshotName(base+"-two")
+	if len(one) > 253 || len(two) > 253 {
+		t.Errorf("names exceed 253 characters: %d, %d", len(one), len(two))
+	}
+	if one == two {
+		t.Errorf("long names collide: %s", one)
+	}
+	if got := snapshotName("rem-cm"); got != "remediation-snapshot-rem-cm" {
+		t.Errorf("short name = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,297 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+var installPlanGVR = schema.GroupVersionResource{
+	Group: "operators.coreos.com", Version: "v1alpha1", Resource: "installplans",
+}
+
+// Subscription install plan approval modes.
+const (
+	ApprovalAutomatic = "Automatic"
+	ApprovalManual    = "Manual"
+)
+
+// GetSubscription returns the operator's Subscription with its upgrade state,
+// the channels and versions its PackageManifest offers and any InstallPlans
+// waiting for approval.
+func GetSubscription(ctx context.Context, client *k8s.Client, namespace string) (*SubscriptionInfo, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	sub, err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
+		Get(ctx, subscriptionName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) || IsCRDNotFound(err) {
+			return nil, fmt.Errorf("subscription %s not found: the operator is not installed with OLM", subscriptionName)
+		}
+		return nil, fmt.Errorf("getting Subscription: %w", err)
+	}
+
+	info := subscriptionInfo(sub)
+	info.UpgradeAvailable = info.CurrentCSV != "" && info.InstalledCSV != "" && info.CurrentCSV != info.InstalledCSV
+
+	if pm, err := getPackageManifest(ctx, client, info.Package, info.Source, info.SourceNamespace); err == nil {
+		info.DefaultChannel, _, _ = unstructured.NestedString(pm.Object, "status", "defaultChannel")
+		info.Channels = packageChannels(pm, info.DefaultChannel)
+	}
+
+	plans, err := listInstallPlans(ctx, client, namespace)
+	if err == nil {
+		info.InstallPlans = plans
+		for _, plan := range plans {
+			if plan.RequiresApproval {
+				info.PendingApproval = true
+			}
+		}
+	}
+	return info, nil
+}
+
+// UpdateSubscription switches the Subscription's channel and/or install plan
+// approval mode. The channel must be offered by the package's PackageManifest.
+func UpdateSubscription(ctx context.Context, client *k8s.Client, namespace string, update SubscriptionUpdate) (*SubscriptionInfo, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	if update.Channel == "" && update.Approval == "" {
+		return nil, fmt.Errorf("invalid subscription update: nothing to change")
+	}
+	switch update.Approval {
+	case "", ApprovalAutomatic, ApprovalManual:
+	default:
+		return nil, fmt.Errorf("invalid subscription update: approval must be %q or %q", ApprovalAutomatic, ApprovalManual)
+	}
+
+	current, err := GetSubscription(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if update.Channel != "" && len(current.Channels) > 0 {
+		found := false
+		for _, ch := range current.Channels {
+			if ch.Name == update.Channel {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid subscription update: channel %q is not offered by package %s", update.Channel, current.Package)
+		}
+	}
+
+	spec := map[string]interface{}{}
+	if update.Channel != "" {
+		spec["channel"] = update.Channel
+	}
+	if update.Approval != "" {
+		spec["installPlanApproval"] = update.Approval
+	}
+	patch, err := (&unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}).MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("encoding Subscription patch: %w", err)
+	}
+	if _, err := client.Dynamic.Resource(subscriptionGVR).Namespace(namespace).
+		Patch(ctx, subscriptionName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return nil, fmt.Errorf("patching Subscription: %w", err)
+	}
+
+	return GetSubscription(ctx, client, namespace)
+}
+
+// ApproveInstallPlan approves a pending InstallPlan created for the
+// operator's Subscription, letting OLM install the CSVs it lists. A plan
+// that also installs other operators' CSVs is approved only when every one
+// of them is listed in confirmCSVs.
+func ApproveInstallPlan(ctx context.Context, client *k8s.Client, namespace, name string, confirmCSVs []string) (*InstallPlanInfo, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	plan, err := client.Dynamic.Resource(installPlanGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting InstallPlan %s: %w", name, err)
+	}
+	info := installPlanInfo(plan)
+	if info.Approved {
+		return nil, fmt.Errorf("InstallPlan %s is already approved", name)
+	}
+	if !installPlanForOperator(info) {
+		return nil, fmt.Errorf("InstallPlan %s does not install the Compliance Operator", name)
+	}
+	var unconfirmed []string
+	for _, csv := range info.OtherCSVs {
+		if !slices.Contains(confirmCSVs, csv) {
+			unconfirmed = append(unconfirmed, csv)
+		}
+	}
+	if len(unconfirmed) > 0 {
+		return nil, fmt.Errorf("InstallPlan %s also installs %s; confirm them to approve it", name, strings.Join(unconfirmed, ", "))
+	}
+
+	patch := []byte(`{"spec":{"approved":true}}`)
+	updated, err := client.Dynamic.Resource(installPlanGVR).Namespace(namespace).
+		Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("approving InstallPlan %s: %w", name, err)
+	}
+	approved := installPlanInfo(updated)
+	return &approved, nil
+}
+
+func subscriptionInfo(sub *unstructured.Unstructured) *SubscriptionInfo {
+	str := func(fields ...string) string {
+		v, _, _ := unstructured.NestedString(sub.Object, fields...)
+		return v
+	}
+	info := &SubscriptionInfo{
+		Name:            sub.GetName(),
+		Package:         str("spec", "name"),
+		Channel:         str("spec", "channel"),
+		Source:          str("spec", "source"),
+		SourceNamespace: str("spec", "sourceNamespace"),
+		Approval:        str("spec", "installPlanApproval"),
+		StartingCSV:     str("spec", "startingCSV"),
+		CurrentCSV:      str("status", "currentCSV"),
+		InstalledCSV:    str("status", "installedCSV"),
+		State:           str("status", "state"),
+		Channels:        []PackageChannel{},
+		InstallPlans:    []InstallPlanInfo{},
+	}
+	if info.Approval == "" {
+		info.Approval = ApprovalAutomatic
+	}
+	if info.Package == "" {
+		info.Package = operatorName
+	}
+	return info
+}
+
+// getPackageManifest finds the package's PackageManifest for the catalog the
+// Subscription uses. Several catalogs can ship a package of the same name, so
+// manifests are matched on their catalog label before falling back to a get
+// by name.
+func getPackageManifest(ctx context.Context, client *k8s.Client, pkg, source, sourceNamespace string) (*unstructured.Unstructured, error) {
+	if sourceNamespace == "" {
+		sourceNamespace = marketplaceNS
+	}
+	if source != "" {
+		list, err := client.Dynamic.Resource(packageManifestGVR).Namespace(sourceNamespace).
+			List(ctx, metav1.ListOptions{LabelSelector: "catalog=" + source})
+		if err == nil {
+			for i := range list.Items {
+				if list.Items[i].GetName() == pkg {
+					return &list.Items[i], nil
+				}
+			}
+		}
+	}
+	pm, err := client.Dynamic.Resource(packageManifestGVR).Namespace(sourceNamespace).
+		Get(ctx, pkg, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting PackageManifest %s: %w", pkg, err)
+	}
+	return pm, nil
+}
+
+// packageChannels lists a PackageManifest's channels with their head CSV and,
+// where the catalog reports them, every version in the channel.
+func packageChannels(pm *unstructured.Unstructured, defaultChannel string) []PackageChannel {
+	raw, _, _ := unstructured.NestedSlice(pm.Object, "status", "channels")
+	channels := make([]PackageChannel, 0, len(raw))
+	for _, c := range raw {
+		ch, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pc := PackageChannel{}
+		pc.Name, _ = ch["name"].(string)
+		pc.CurrentCSV, _ = ch["currentCSV"].(string)
+		pc.Version, _, _ = unstructured.NestedString(ch, "currentCSVDesc", "version")
+		pc.Default = pc.Name == defaultChannel
+
+		entries, _ := ch["entries"].([]interface{})
+		for _, e := range entries {
+			entry, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := entry["name"].(string)
+			version, _ := entry["version"].(string)
+			pc.Entries = append(pc.Entries, PackageChannelEntry{Name: name, Version: version})
+		}
+		channels = append(channels, pc)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	return channels
+}
+
+// listInstallPlans returns the namespace's InstallPlans for the operator,
+// newest first.
+func listInstallPlans(ctx context.Context, client *k8s.Client, namespace string) ([]InstallPlanInfo, error) {
+	list, err := client.Dynamic.Resource(installPlanGVR).Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing InstallPlans: %w", err)
+	}
+
+	plans := make([]InstallPlanInfo, 0, len(list.Items))
+	for i := range list.Items {
+		info := installPlanInfo(&list.Items[i])
+		if installPlanForOperator(info) {
+			plans = append(plans, info)
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].CreatedAt > plans[j].CreatedAt })
+	return plans, nil
+}
+
+func installPlanInfo(plan *unstructured.Unstructured) InstallPlanInfo {
+	csvs, _, _ := unstructured.NestedStringSlice(plan.Object, "spec", "clusterServiceVersionNames")
+	approval, _, _ := unstructured.NestedString(plan.Object, "spec", "approval")
+	approved, _, _ := unstructured.NestedBool(plan.Object, "spec", "approved")
+	phase, _, _ := unstructured.NestedString(plan.Object, "status", "phase")
+	info := InstallPlanInfo{
+		Name:             plan.GetName(),
+		CSVs:             csvs,
+		Approval:         approval,
+		Approved:         approved,
+		Phase:            phase,
+		RequiresApproval: !approved && phase == "RequiresApproval",
+		CreatedAt:        plan.GetCreationTimestamp().UTC().Format(time.RFC3339),
+	}
+	for _, csv := range csvs {
+		if !strings.HasPrefix(csv, operatorName+".") {
+			info.OtherCSVs = append(info.OtherCSVs, csv)
+		}
+	}
+	return info
+}
+
+// installPlanForOperator reports whether an InstallPlan installs a
+// Compliance Operator CSV.
+func installPlanForOperator(plan InstallPlanInfo) bool {
+	for _, csv := range plan.CSVs {
+		if strings.HasPrefix(csv, operatorName+".") {
+			return true
+		}
+	}
+	return false
+}
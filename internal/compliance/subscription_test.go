@@ -0,0 +1,183 @@
+package compliance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newSubscription(ns, channel, currentCSV, installedCSV string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "Subscription",
+		"metadata":   map[string]any{"name": subscriptionName, "namespace": ns},
+		"spec": map[string]any{
+			"channel":             channel,
+			"installPlanApproval": "Manual",
+			"name":                operatorName,
+			"source":              "redhat-operators",
+			"sourceNamespace":     marketplaceNS,
+		},
+		"status": map[string]any{
+			"currentCSV":   currentCSV,
+			"installedCSV": installedCSV,
+			"state":        "UpgradePending",
+		},
+	}}
+}
+
+func newPackageManifest(catalog string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "packages.operators.coreos.com/v1",
+		"kind":       "PackageManifest",
+		"metadata": map[string]any{
+			"name":      operatorName,
+			"namespace": marketplaceNS,
+			"labels":    map[string]any{"catalog": catalog},
+		},
+		"status": map[string]any{
+			"defaultChannel": "stable",
+			"channels": []any{
+				map[string]any{
+					"name":           "stable",
+					"currentCSV":     "compliance-operator.v1.6.0",
+					"currentCSVDesc": map[string]any{"version": "1.6.0"},
+					"entries": []any{
+						map[string]any{"name": "compliance-operator.v1.6.0", "version": "1.6.0"},
+						map[string]any{"name": "compliance-operator.v1.5.0", "version": "1.5.0"},
+					},
+				},
+				map[string]any{
+					"name":       "release-0.1",
+					"currentCSV": "compliance-operator.v0.1.61",
+				},
+			},
+		},
+	}}
+}
+
+func newInstallPlan(ns, name, csv string, approved bool, phase string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "InstallPlan",
+		"metadata":   map[string]any{"name": name, "namespace": ns},
+		"spec": map[string]any{
+			"approval":                   "Manual",
+			"approved":                   approved,
+			"clusterServiceVersionNames": []any{csv},
+		},
+		"status": map[string]any{"phase": phase},
+	}}
+}
+
+func TestGetSubscription(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+
+	client := newTestClient(
+		newSubscription(ns, "stable", "compliance-operator.v1.6.0", "compliance-operator.v1.5.0"),
+		newPackageManifest("redhat-operators"),
+		newInstallPlan(ns, "install-abc", "compliance-operator.v1.6.0", false, "RequiresApproval"),
+		newInstallPlan(ns, "install-other", "other-operator.v2.0.0", false, "RequiresApproval"),
+	)
+
+	info, err := GetSubscription(ctx, client, ns)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if info.Channel != "stable" || info.Approval != ApprovalManual || info.DefaultChannel != "stable" {
+		t.Errorf("unexpected subscription: %+v", info)
+	}
+	if !info.UpgradeAvailable {
+		t.Error("expected an upgrade to be available when currentCSV differs from installedCSV")
+	}
+	if len(info.Channels) != 2 || info.Channels[1].Name != "stable" || !info.Channels[1].Default || len(info.Channels[1].Entries) != 2 {
+		t.Errorf("unexpected channels: %+v", info.Channels)
+	}
+	if len(info.InstallPlans) != 1 || info.InstallPlans[0].Name != "install-abc" || !info.PendingApproval {
+		t.Errorf("expected only the operator's pending InstallPlan, got %+v", info.InstallPlans)
+	}
+
+	if _, err := GetSubscription(ctx, newTestClient(), ns); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newTestClient(
+		newSubscription(ns, "stable", "compliance-operator.v1.5.0", "compliance-operator.v1.5.0"),
+		newPackageManifest("redhat-operators"),
+	)
+
+	for _, update := range []SubscriptionUpdate{
+		{},
+		{Approval: "Sometimes"},
+		{Channel: "nightly"},
+	} {
+		if _, err := UpdateSubscription(ctx, client, ns, update); err == nil || !strings.Contains(err.Error(), "invalid subscription update") {
+			t.Errorf("UpdateSubscription(%+v): expected validation error, got %v", update, err)
+		}
+	}
+
+	info, err := UpdateSubscription(ctx, client, ns, SubscriptionUpdate{Channel: "release-0.1", Approval: ApprovalAutomatic})
+	if err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+	if info.Channel != "release-0.1" || info.Approval != ApprovalAutomatic {
+		t.Errorf("subscription not updated: %+v", info)
+	}
+}
+
+func TestApproveInstallPlan(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	mixed := newInstallPlan(ns, "install-mixed", "compliance-operator.v1.6.0", false, "RequiresApproval")
+	_ = unstructured.SetNestedStringSlice(mixed.Object, []string{"compliance-operator.v1.6.0", "other-operator.v2.0.0"}, "spec", "clusterServiceVersionNames")
+	client := newTestClient(
+		newInstallPlan(ns, "install-abc", "compliance-operator.v1.6.0", false, "RequiresApproval"),
+		newInstallPlan(ns, "install-done", "compliance-operator.v1.5.0", true, "Complete"),
+		newInstallPlan(ns, "install-other", "other-operator.v2.0.0", false, "RequiresApproval"),
+		mixed,
+	)
+
+	plan, err := ApproveInstallPlan(ctx, client, ns, "install-abc", nil)
+	if err != nil {
+		t.Fatalf("ApproveInstallPlan: %v", err)
+	}
+	if !plan.Approved {
+		t.Error("expected the InstallPlan to be approved")
+	}
+	got, _ := client.Dynamic.Resource(installPlanGVR).Namespace(ns).Get(ctx, "install-abc", metav1.GetOptions{})
+	if approved, _, _ := unstructured.NestedBool(got.Object, "spec", "approved"); !approved {
+		t.Error("spec.approved was not set")
+	}
+
+	if _, err := ApproveInstallPlan(ctx, client, ns, "install-done", nil); err == nil || !strings.Contains(err.Error(), "already approved") {
+		t.Errorf("expected already approved error, got %v", err)
+	}
+	if _, err := ApproveInstallPlan(ctx, client, ns, "install-other", nil); err == nil {
+		t.Error("expected an error approving another operator's InstallPlan")
+	}
+	if _, err := ApproveInstallPlan(ctx, client, ns, "install-mixed", nil); err == nil || !strings.Contains(err.Error(), "also installs other-operator.v2.0.0") {
+		t.Errorf("expected an error approving a plan with another operator's CSV, got %v", err)
+	}
+	got, _ = client.Dynamic.Resource(installPlanGVR).Namespace(ns).Get(ctx, "install-mixed", metav1.GetOptions{})
+	if approved, _, _ := unstructured.NestedBool(got.Object, "spec", "approved"); approved {
+		t.Error("an unconfirmed mixed InstallPlan was approved")
+	}
+	plan, err = ApproveInstallPlan(ctx, client, ns, "install-mixed", []string{"other-operator.v2.0.0"})
+	if err != nil {
+		t.Fatalf("ApproveInstallPlan with confirmation: %v", err)
+	}
+	if !plan.Approved || len(plan.OtherCSVs) != 1 {
+		t.Errorf("unexpected mixed plan: %+v", plan)
+	}
+	if _, err := ApproveInstallPlan(ctx, client, ns, "missing", nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
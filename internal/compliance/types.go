@@ -1,8 +1,14 @@
 package compliance
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
 	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
 )
 
@@ -81,13 +87,74 @@ type SeverityMap struct {
 
 // OperatorStatus represents the current state of the Compliance Operator.
 type OperatorStatus struct {
-	Installed      bool           `json:"installed"`
-	Version        string         `json:"version,omitempty"`
+	Installed bool   `json:"installed"`
+	Version   string `json:"version,omitempty"`
+	// Source is InstallSourceManifests when the operator was installed
+	// without OLM, and empty otherwise.
+	Source         InstallSource  `json:"source,omitempty"`
 	CSVPhase       string         `json:"csv_phase,omitempty"`
 	Pods           []PodStatus    `json:"pods,omitempty"`
 	ProfileBundles []BundleStatus `json:"profile_bundles,omitempty"`
 }
 
+// SubscriptionInfo is the operator's OLM Subscription and upgrade state.
+// CurrentCSV is the latest CSV OLM resolved in the channel and InstalledCSV
+// the one running; they differ while an upgrade is pending.
+type SubscriptionInfo struct {
+	Name             string            `json:"name"`
+	Package          string            `json:"package"`
+	Channel          string            `json:"channel"`
+	DefaultChannel   string            `json:"default_channel,omitempty"`
+	Source           string            `json:"source"`
+	SourceNamespace  string            `json:"source_namespace"`
+	Approval         string            `json:"approval"`
+	StartingCSV      string            `json:"starting_csv,omitempty"`
+	CurrentCSV       string            `json:"current_csv,omitempty"`
+	InstalledCSV     string            `json:"installed_csv,omitempty"`
+	State            string            `json:"state,omitempty"`
+	UpgradeAvailable bool              `json:"upgrade_available"`
+	PendingApproval  bool              `json:"pending_approval"`
+	Channels         []PackageChannel  `json:"channels"`
+	InstallPlans     []InstallPlanInfo `json:"install_plans"`
+}
+
+// PackageChannel is a channel offered by the operator's PackageManifest.
+type PackageChannel struct {
+	Name       string `json:"name"`
+	CurrentCSV string `json:"current_csv"`
+	Version    string `json:"version,omitempty"`
+	Default    bool   `json:"default,omitempty"`
+	// Entries are the versions in the channel, when the catalog lists them.
+	Entries []PackageChannelEntry `json:"entries,omitempty"`
+}
+
+// PackageChannelEntry is one CSV in a channel.
+type PackageChannelEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// InstallPlanInfo summarizes an OLM InstallPlan for the operator.
+type InstallPlanInfo struct {
+	Name             string   `json:"name"`
+	CSVs             []string `json:"csvs"`
+	Approval         string   `json:"approval"`
+	Approved         bool     `json:"approved"`
+	Phase            string   `json:"phase,omitempty"`
+	RequiresApproval bool     `json:"requires_approval"`
+	CreatedAt        string   `json:"created_at"`
+	// OtherCSVs are CSVs of other operators in the same plan, which OLM
+	// bundles when they share the namespace. Approving installs them too.
+	OtherCSVs []string `json:"other_csvs,omitempty"`
+}
+
+// SubscriptionUpdate changes a Subscription's channel and/or approval mode.
+// Empty fields are left unchanged.
+type SubscriptionUpdate struct {
+	Channel  string `json:"channel,omitempty"`
+	Approval string `json:"approval,omitempty"`
+}
+
 // PodStatus represents a pod's status summary.
 type PodStatus struct {
 	Name   string `json:"name"`
@@ -108,6 +175,10 @@ type InstallProgress struct {
 	Message string `json:"message"`
 	Done    bool   `json:"done"`
 	Error   string `json:"error,omitempty"`
+	// Options echoes the resolved install options once the source is known.
+	Options *InstallOptions `json:"options,omitempty"`
+	// Wait reports progress while a step waits on the cluster.
+	Wait *WaitProgress `json:"wait,omitempty"`
 }
 
 // InstallSource indicates whether using Red Hat certified or community operator.
@@ -116,13 +187,130 @@ type InstallSource string
 const (
 	InstallSourceRedHat    InstallSource = "redhat"
 	InstallSourceCommunity InstallSource = "community"
+	// InstallSourceCustom subscribes to an existing CatalogSource.
+	InstallSourceCustom InstallSource = "custom"
+	// InstallSourceManifests applies the bundled upstream manifests without
+	// OLM. It is picked automatically when the cluster has no OLM.
+	InstallSourceManifests InstallSource = "manifests"
 )
 
+// InstallOptions customizes an operator install. Empty fields keep the
+// defaults: the source is detected, the channel is the source's usual one and
+// install plans are approved automatically.
+type InstallOptions struct {
+	Source                 InstallSource       `json:"source,omitempty"`
+	CatalogSource          string              `json:"catalog_source,omitempty"`
+	CatalogSourceNamespace string              `json:"catalog_source_namespace,omitempty"`
+	Channel                string              `json:"channel,omitempty"`
+	StartingCSV            string              `json:"starting_csv,omitempty"`
+	Approval               string              `json:"approval,omitempty"`
+	Config                 *SubscriptionConfig `json:"config,omitempty"`
+	// Disconnected skips internet lookups and pulls images from mirrors:
+	// MirrorRegistry when set, otherwise the cluster's mirror rules.
+	Disconnected   bool   `json:"disconnected,omitempty"`
+	MirrorRegistry string `json:"mirror_registry,omitempty"`
+	// CatalogImage replaces the community catalog image.
+	CatalogImage string `json:"catalog_image,omitempty"`
+	// Timeouts bounds the waits on the operator's install phases.
+	Timeouts *InstallTimeouts `json:"timeouts,omitempty"`
+}
+
+// InstallTimeouts bounds how long an install waits for each phase, in
+// seconds. Zero uses DefaultPhaseTimeout.
+type InstallTimeouts struct {
+	CSVSeconds            int64 `json:"csv_seconds,omitempty"`
+	PodsSeconds           int64 `json:"pods_seconds,omitempty"`
+	ProfileBundlesSeconds int64 `json:"profile_bundles_seconds,omitempty"`
+}
+
+// WaitProgress is how far an install has got waiting on a phase. Percent is
+// estimated from the phase's own progress, e.g. ready pods out of all pods;
+// Blocking names what the phase is still waiting for.
+type WaitProgress struct {
+	Percent        int    `json:"percent"`
+	ElapsedSeconds int64  `json:"elapsed_seconds"`
+	TimeoutSeconds int64  `json:"timeout_seconds"`
+	Blocking       string `json:"blocking,omitempty"`
+}
+
+// MirrorRule maps an image source to its mirrors, from an
+// ImageContentSourcePolicy, ImageDigestMirrorSet or ImageTagMirrorSet.
+type MirrorRule struct {
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	Source  string   `json:"source"`
+	Mirrors []string `json:"mirrors"`
+}
+
+// MirrorImageCheck reports whether an image's mirror resolves.
+type MirrorImageCheck struct {
+	Image   string `json:"image"`
+	Mirror  string `json:"mirror"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// MirrorReport is the disconnected-install preflight: the mirror rules in
+// effect and whether each image the install needs resolves in its mirror.
+// Ready is false when any image is missing or its registry is unreachable.
+type MirrorReport struct {
+	Disconnected bool               `json:"disconnected"`
+	Registry     string             `json:"registry,omitempty"`
+	CatalogImage string             `json:"catalog_image,omitempty"`
+	Rules        []MirrorRule       `json:"rules"`
+	Images       []MirrorImageCheck `json:"images"`
+	Ready        bool               `json:"ready"`
+}
+
+// ProfileBundleRewrite records a ProfileBundle content image moved to its
+// mirror.
+type ProfileBundleRewrite struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SubscriptionConfig is the subset of a Subscription's spec.config the
+// dashboard sets. Env is typically used for HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY.
+type SubscriptionConfig struct {
+	NodeSelector map[string]string            `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration          `json:"tolerations,omitempty"`
+	Resources    *corev1.ResourceRequirements `json:"resources,omitempty"`
+	Env          []corev1.EnvVar              `json:"env,omitempty"`
+}
+
 // ProfileInfo represents an available compliance profile.
 type ProfileInfo struct {
 	Name        string `json:"name"`
 	Title       string `json:"title"`
 	Description string `json:"description,omitempty"`
+	// Bundle is the ProfileBundle the profile was parsed from.
+	Bundle string `json:"bundle,omitempty"`
+}
+
+// ProfileBundleInfo is a ProfileBundle, its parse status and the profiles
+// the operator produced from it. Custom is false for the bundles the
+// operator creates and manages itself.
+type ProfileBundleInfo struct {
+	Name             string        `json:"name"`
+	ContentImage     string        `json:"content_image"`
+	ContentFile      string        `json:"content_file"`
+	DataStreamStatus string        `json:"data_stream_status,omitempty"`
+	ErrorMessage     string        `json:"error_message,omitempty"`
+	Custom           bool          `json:"custom"`
+	ProfileCount     int           `json:"profile_count"`
+	Profiles         []ProfileInfo `json:"profiles"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+// ProfileBundleSpec is the content a custom ProfileBundle parses: a
+// ComplianceAsCode content image and the data stream file in it, such as
+// ssg-ocp4-ds.xml.
+type ProfileBundleSpec struct {
+	Name         string `json:"name"`
+	ContentImage string `json:"content_image"`
+	ContentFile  string `json:"content_file"`
 }
 
 // ScanOptions configures a one-off compliance scan.
@@ -182,6 +370,22 @@ type RemediationInfo struct {
 	Applied      bool     `json:"applied"`
 	RebootNeeded bool     `json:"reboot_needed"`
 	Role         string   `json:"role,omitempty"`
+	// GitOpsPending is true when the remediation was exported for a GitOps
+	// tool to apply instead of being applied directly.
+	GitOpsPending bool `json:"gitops_pending,omitempty"`
+	// Outdated is true when a content update produced a new version of the
+	// remediation that has not been promoted yet.
+	Outdated bool `json:"outdated,omitempty"`
+	// ApplicationState and ErrorMessage are the operator's view of the
+	// remediation (status.applicationState and status.errorMessage).
+	ApplicationState string `json:"application_state,omitempty"`
+	ErrorMessage     string `json:"error_message,omitempty"`
+	// Drift is Missing or Drifted when the drift reconciler found the
+	// applied object deleted or changed; DriftedFields lists the paths.
+	Drift         string   `json:"drift,omitempty"`
+	DriftedFields []string `json:"drifted_fields,omitempty"`
+	// Customized is true when the remediation was applied with a patch.
+	Customized bool `json:"customized,omitempty"`
 }
 
 // RemediationDetail is a full remediation with its object YAML.
@@ -190,6 +394,154 @@ type RemediationDetail struct {
 	ObjectYAML string `json:"object_yaml"`
 	APIVersion string `json:"api_version,omitempty"`
 	Namespace  string `json:"namespace,omitempty"`
+	// HasSnapshot is true when the prior state of the object was captured on
+	// apply and will be restored on removal.
+	HasSnapshot bool `json:"has_snapshot"`
+	// OutdatedYAML is the previous version of the object (spec.outdated) and
+	// OutdatedDiff a unified diff from it to the current version.
+	OutdatedYAML string `json:"outdated_yaml,omitempty"`
+	OutdatedDiff string `json:"outdated_diff,omitempty"`
+	// Impact is what applying this remediation would disrupt.
+	Impact *RemediationImpact `json:"impact,omitempty"`
+	// Ignition is the decoded content of a MachineConfig remediation.
+	Ignition *IgnitionConfig `json:"ignition,omitempty"`
+	// Customization is the patch the remediation was applied with.
+	// CustomizedYAML is the object actually applied and CustomizationDiff a
+	// unified diff from ObjectYAML to it.
+	Customization     *RemediationPatch `json:"customization,omitempty"`
+	CustomizedYAML    string            `json:"customized_yaml,omitempty"`
+	CustomizationDiff string            `json:"customization_diff,omitempty"`
+}
+
+// RemediationPatch is a customization applied on top of a remediation's
+// spec.current.object before it is applied.
+type RemediationPatch struct {
+	// Type is "merge" (RFC 7386 JSON merge patch, the default) or
+	// "strategic" (Kubernetes strategic merge patch, built-in kinds only).
+	Type  string          `json:"type,omitempty"`
+	Patch json.RawMessage `json:"patch"`
+	// CustomizedAt is set when the patch is stored on the remediation.
+	CustomizedAt string `json:"customized_at,omitempty"`
+}
+
+// IgnitionConfig is the decoded storage and systemd sections of a
+// MachineConfig's Ignition config.
+type IgnitionConfig struct {
+	Version string         `json:"version,omitempty"`
+	Files   []IgnitionFile `json:"files"`
+	Units   []SystemdUnit  `json:"units"`
+}
+
+// IgnitionFile is a file written by Ignition. Mode is octal (e.g. "0644")
+// and Owner is "user:group". Content holds the decoded plaintext; Source is
+// kept only when it could not be decoded, with the reason in Error.
+type IgnitionFile struct {
+	Path      string `json:"path"`
+	Mode      string `json:"mode,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Overwrite *bool  `json:"overwrite,omitempty"`
+	// Encoding is how the data: URL was encoded: "url" or "base64",
+	// suffixed with "+gzip" when the contents were compressed.
+	Encoding  string `json:"encoding,omitempty"`
+	Content   string `json:"content"`
+	Size      int    `json:"size"`
+	Binary    bool   `json:"binary,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	// digest is the SHA-256 of the full decoded contents, so files whose
+	// Content is truncated or omitted can still be compared.
+	digest string
+}
+
+// SystemdUnit is a systemd unit configured by Ignition.
+type SystemdUnit struct {
+	Name     string          `json:"name"`
+	Enabled  *bool           `json:"enabled,omitempty"`
+	Mask     bool            `json:"mask,omitempty"`
+	Contents string          `json:"contents,omitempty"`
+	Dropins  []SystemdDropin `json:"dropins,omitempty"`
+}
+
+// SystemdDropin is a drop-in for a systemd unit.
+type SystemdDropin struct {
+	Name     string `json:"name"`
+	Contents string `json:"contents,omitempty"`
+}
+
+// RoleIgnitionPreview combines the Ignition content of every MachineConfig
+// remediation for a role, so overlapping writes can be spotted before they
+// are applied.
+type RoleIgnitionPreview struct {
+	Role      string             `json:"role"`
+	Files     []RoleIgnitionFile `json:"files"`
+	Units     []RoleSystemdUnit  `json:"units"`
+	Conflicts []IgnitionConflict `json:"conflicts"`
+}
+
+// RoleIgnitionFile is a file in a role preview and the remediation writing it.
+type RoleIgnitionFile struct {
+	IgnitionFile
+	Remediation string `json:"remediation"`
+	Applied     bool   `json:"applied"`
+}
+
+// RoleSystemdUnit is a unit in a role preview and the remediation defining it.
+type RoleSystemdUnit struct {
+	SystemdUnit
+	Remediation string `json:"remediation"`
+	Applied     bool   `json:"applied"`
+}
+
+// IgnitionConflict is a file path (Type "file") or unit name (Type "unit")
+// written by more than one remediation. Identical is true when they all
+// write the same content and mode, in which case the overlap is harmless.
+type IgnitionConflict struct {
+	Type         string   `json:"type"`
+	Path         string   `json:"path"`
+	Remediations []string `json:"remediations"`
+	Identical    bool     `json:"identical"`
+}
+
+// RemediationSnapshot is the state of a remediation's target object captured
+// just before the remediation was first applied.
+type RemediationSnapshot struct {
+	Remediation string `json:"remediation"`
+	Kind        string `json:"kind"`
+	APIVersion  string `json:"api_version"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace,omitempty"`
+	// Existed is false when the object did not exist before the apply, in
+	// which case rollback deletes it.
+	Existed    bool   `json:"existed"`
+	CapturedAt string `json:"captured_at"`
+	// ObjectYAML is the prior object. It is omitted for Secrets.
+	ObjectYAML string `json:"object_yaml,omitempty"`
+	StoredIn   string `json:"stored_in"`
+
+	object *unstructured.Unstructured
+}
+
+// RemediationFilter narrows a set of remediations. Empty fields match anything.
+type RemediationFilter struct {
+	Role          string `json:"role,omitempty"`
+	Kind          string `json:"kind,omitempty"`
+	ExcludeReboot bool   `json:"exclude_reboot,omitempty"`
+}
+
+// Matches reports whether a remediation passes the filter.
+func (f RemediationFilter) Matches(rem RemediationInfo) bool {
+	if f.Role != "" && !strings.EqualFold(rem.Role, f.Role) {
+		return false
+	}
+	if f.Kind != "" && !strings.EqualFold(rem.Kind, f.Kind) {
+		return false
+	}
+	if f.ExcludeReboot && (rem.RebootNeeded || IsDisruptiveKind(rem.Kind)) {
+		return false
+	}
+	return true
 }
 
 // RemediationResult is the outcome of applying a remediation.
@@ -200,6 +552,323 @@ type RemediationResult struct {
 	Error   string `json:"error,omitempty"`
 }
 
+// NodeRolloutStatus is a node's Machine Config Daemon state during a rollout.
+type NodeRolloutStatus struct {
+	Name          string `json:"name"`
+	Pool          string `json:"pool"`
+	CurrentConfig string `json:"current_config,omitempty"`
+	DesiredConfig string `json:"desired_config,omitempty"`
+	State         string `json:"state,omitempty"`
+	Updated       bool   `json:"updated"`
+}
+
+// MachineConfigPoolStatus summarizes a MachineConfigPool's rollout state.
+type MachineConfigPoolStatus struct {
+	Name                    string              `json:"name"`
+	Paused                  bool                `json:"paused"`
+	MachineCount            int64               `json:"machine_count"`
+	UpdatedMachineCount     int64               `json:"updated_machine_count"`
+	ReadyMachineCount       int64               `json:"ready_machine_count"`
+	UnavailableMachineCount int64               `json:"unavailable_machine_count"`
+	DegradedMachineCount    int64               `json:"degraded_machine_count"`
+	CurrentConfig           string              `json:"current_config,omitempty"`
+	DesiredConfig           string              `json:"desired_config,omitempty"`
+	Updated                 bool                `json:"updated"`
+	Updating                bool                `json:"updating"`
+	Degraded                bool                `json:"degraded"`
+	Conditions              []Condition         `json:"conditions,omitempty"`
+	Nodes                   []NodeRolloutStatus `json:"nodes,omitempty"`
+}
+
+// MachineConfigBatchProgress is a step in a batched MachineConfig apply.
+type MachineConfigBatchProgress struct {
+	Step    string             `json:"step"`
+	Message string             `json:"message"`
+	Result  *RemediationResult `json:"result,omitempty"`
+	Node    *NodeRolloutStatus `json:"node,omitempty"`
+	Done    bool               `json:"done"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// PoolImpact describes the rollout a set of remediations triggers in one
+// MachineConfigPool.
+type PoolImpact struct {
+	Pool              string   `json:"pool"`
+	NodeCount         int      `json:"node_count"`
+	MaxUnavailable    string   `json:"max_unavailable"`
+	Paused            bool     `json:"paused"`
+	EstimatedSeconds  int64    `json:"estimated_seconds"`
+	EstimatedDuration string   `json:"estimated_duration"`
+	Remediations      []string `json:"remediations"`
+}
+
+// RemediationImpact is what applying one or more remediations would disrupt.
+// Durations are rough estimates.
+type RemediationImpact struct {
+	Remediations        []string     `json:"remediations"`
+	Pools               []PoolImpact `json:"pools,omitempty"`
+	NodesAffected       int          `json:"nodes_affected"`
+	RebootNeeded        bool         `json:"reboot_needed"`
+	TouchesKubelet      bool         `json:"touches_kubelet"`
+	TouchesAPIServer    bool         `json:"touches_api_server"`
+	TouchesOAuth        bool         `json:"touches_oauth"`
+	ControlPlaneRestart bool         `json:"control_plane_restart"`
+	RestartedComponents []string     `json:"restarted_components,omitempty"`
+	EstimatedSeconds    int64        `json:"estimated_seconds"`
+	EstimatedDuration   string       `json:"estimated_duration"`
+}
+
+// VerificationProgress is a step in a remediate-and-verify operation.
+type VerificationProgress struct {
+	Step    string `json:"step"`
+	Message string `json:"message"`
+}
+
+// ChangeRequestAction is the operation a change request asks to perform.
+type ChangeRequestAction string
+
+const (
+	ChangeRequestApply  ChangeRequestAction = "apply"
+	ChangeRequestRemove ChangeRequestAction = "remove"
+)
+
+// ChangeRequestState is the lifecycle state of a change request.
+type ChangeRequestState string
+
+const (
+	ChangeRequestPending  ChangeRequestState = "Pending"
+	ChangeRequestApproved ChangeRequestState = "Approved"
+	ChangeRequestRejected ChangeRequestState = "Rejected"
+	ChangeRequestExecuted ChangeRequestState = "Executed"
+	ChangeRequestExpired  ChangeRequestState = "Expired"
+)
+
+// ChangeRequestComment is a note left on a change request.
+type ChangeRequestComment struct {
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChangeRequest asks for remediations to be applied or removed. It must be
+// approved by a second user before it can be executed, once.
+type ChangeRequest struct {
+	ID           string                 `json:"id"`
+	Action       ChangeRequestAction    `json:"action"`
+	Remediations []string               `json:"remediations"`
+	Requester    string                 `json:"requester"`
+	Approver     string                 `json:"approver,omitempty"`
+	State        ChangeRequestState     `json:"state"`
+	Comments     []ChangeRequestComment `json:"comments,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+	ExpiresAt    time.Time              `json:"expires_at"`
+	DecidedAt    *time.Time             `json:"decided_at,omitempty"`
+	ExecutedAt   *time.Time             `json:"executed_at,omitempty"`
+	// Outcome records why the last execution released the approval instead
+	// of consuming it.
+	Outcome string `json:"outcome,omitempty"`
+	// Patch is the customization an apply request was approved with, and
+	// PatchSHA256 the digest it is claimed against.
+	Patch       *RemediationPatch `json:"patch,omitempty"`
+	PatchSHA256 string            `json:"patch_sha256,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period in which queued remediations may
+// be applied. NextStart, Open and ClosesAt are computed when listed.
+type MaintenanceWindow struct {
+	Name string `json:"name"`
+	// Schedule is a five-field cron expression for when the window opens.
+	Schedule string `json:"schedule"`
+	// Duration is how long the window stays open, e.g. "6h".
+	Duration string `json:"duration"`
+	// Timezone is the IANA zone Schedule is evaluated in; default UTC.
+	Timezone  string     `json:"timezone,omitempty"`
+	NextStart *time.Time `json:"next_start,omitempty"`
+	Open      bool       `json:"open,omitempty"`
+	ClosesAt  *time.Time `json:"closes_at,omitempty"`
+}
+
+// ScheduledRemediationState is the state of a remediation queued for a
+// maintenance window.
+type ScheduledRemediationState string
+
+const (
+	ScheduledQueued   ScheduledRemediationState = "Queued"
+	ScheduledHeld     ScheduledRemediationState = "Held"
+	ScheduledApplying ScheduledRemediationState = "Applying"
+	ScheduledApplied  ScheduledRemediationState = "Applied"
+	ScheduledFailed   ScheduledRemediationState = "Failed"
+	ScheduledCanceled ScheduledRemediationState = "Canceled"
+)
+
+// ScheduledRemediation is a remediation queued for a maintenance window.
+type ScheduledRemediation struct {
+	ID               string                    `json:"id"`
+	Remediation      string                    `json:"remediation"`
+	Window           string                    `json:"window"`
+	State            ScheduledRemediationState `json:"state"`
+	Message          string                    `json:"message,omitempty"`
+	EstimatedSeconds int64                     `json:"estimated_seconds,omitempty"`
+	QueuedAt         time.Time                 `json:"queued_at"`
+	StartedAt        *time.Time                `json:"started_at,omitempty"`
+	FinishedAt       *time.Time                `json:"finished_at,omitempty"`
+}
+
+// DriftReport is the drift state of one applied remediation.
+type DriftReport struct {
+	Remediation string `json:"remediation"`
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace,omitempty"`
+	// Drift is Missing, Drifted, or empty when the live object matches.
+	Drift string `json:"drift,omitempty"`
+	// Fields lists the paths whose live value differs from the remediation.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// VerificationResult is the outcome of applying a remediation and rescanning
+// to confirm its check now passes.
+type VerificationResult struct {
+	Remediation    string      `json:"remediation"`
+	CheckResult    string      `json:"check_result"`
+	Scan           string      `json:"scan"`
+	Applied        bool        `json:"applied"`
+	ApplyMessage   string      `json:"apply_message,omitempty"`
+	PreviousStatus CheckStatus `json:"previous_status"`
+	Status         CheckStatus `json:"status,omitempty"`
+	// Verified is true when the check flipped to PASS after the rescan.
+	Verified bool   `json:"verified"`
+	Message  string `json:"message,omitempty"`
+}
+
+// PreflightCheck is one install preflight check: pass, warn or fail, with a
+// hint on how to fix anything that is not a pass.
+type PreflightCheck struct {
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// PreflightReport collects the install preflight checks. Status is the worst
+// check result; Ready is false when any check failed.
+type PreflightReport struct {
+	Checks    []PreflightCheck `json:"checks"`
+	Status    string           `json:"status"`
+	Ready     bool             `json:"ready"`
+	CheckedAt string           `json:"checked_at"`
+}
+
+// InstallOperation is the operation an install state records.
+type InstallOperation string
+
+const (
+	OperationInstall   InstallOperation = "install"
+	OperationUninstall InstallOperation = "uninstall"
+)
+
+// InstallPhase is the overall state of an install or uninstall run.
+// Interrupted means the run was still recorded as running when no process
+// was executing it, e.g. after a dashboard restart.
+type InstallPhase string
+
+const (
+	InstallRunning     InstallPhase = "Running"
+	InstallSucceeded   InstallPhase = "Succeeded"
+	InstallFailed      InstallPhase = "Failed"
+	InstallInterrupted InstallPhase = "Interrupted"
+)
+
+// InstallStepState is the state of one step of an install or uninstall.
+type InstallStepState string
+
+const (
+	StepPending   InstallStepState = "Pending"
+	StepRunning   InstallStepState = "Running"
+	StepSucceeded InstallStepState = "Succeeded"
+	StepFailed    InstallStepState = "Failed"
+	StepSkipped   InstallStepState = "Skipped"
+)
+
+// InstallLogEntry is one progress message recorded for a step.
+type InstallLogEntry struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Error   bool      `json:"error,omitempty"`
+}
+
+// InstallStepStatus is one step of an install or uninstall with its log.
+type InstallStepStatus struct {
+	Name       string            `json:"name"`
+	Title      string            `json:"title"`
+	State      InstallStepState  `json:"state"`
+	Error      string            `json:"error,omitempty"`
+	Attempts   int               `json:"attempts,omitempty"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Log        []InstallLogEntry `json:"log"`
+}
+
+// InstallState is the persisted step log of the most recent install or
+// uninstall. Resolved and CORef carry values earlier steps computed so a
+// resumed run can skip them.
+type InstallState struct {
+	Operation  InstallOperation    `json:"operation"`
+	Phase      InstallPhase        `json:"phase"`
+	Namespace  string              `json:"namespace"`
+	CORef      string              `json:"co_ref,omitempty"`
+	Options    InstallOptions      `json:"options"`
+	Resolved   *InstallOptions     `json:"resolved,omitempty"`
+	Uninstall  *UninstallOptions   `json:"uninstall,omitempty"`
+	Attempt    int                 `json:"attempt"`
+	FailedStep string              `json:"failed_step,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Resumable  bool                `json:"resumable"`
+	StartedAt  time.Time           `json:"started_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	Steps      []InstallStepStatus `json:"steps"`
+	// Holder identifies the dashboard process executing the run, and
+	// HeartbeatAt is when it last reported. A Running state whose heartbeat
+	// is stale was interrupted.
+	Holder      string     `json:"holder,omitempty"`
+	HeartbeatAt *time.Time `json:"heartbeat_at,omitempty"`
+}
+
+// UninstallOptions controls what an uninstall removes.
+type UninstallOptions struct {
+	// KeepNamespace removes only the operator's objects, leaving the
+	// namespace and everything else in it.
+	KeepNamespace bool `json:"keep_namespace,omitempty"`
+	// Export saves results and remediation state to a downloadable archive
+	// before anything is deleted.
+	Export bool `json:"export,omitempty"`
+	// Force continues past an export that could not collect everything.
+	Force bool `json:"force,omitempty"`
+}
+
+// UninstallObject is an object an uninstall step deletes. Note describes
+// what else goes with it, such as the contents of a namespace.
+type UninstallObject struct {
+	Step      string `json:"step"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Note      string `json:"note,omitempty"`
+
+	remove func(ctx context.Context) error
+}
+
+// UninstallPlan lists what an uninstall with the given options would delete,
+// step by step, without deleting anything.
+type UninstallPlan struct {
+	Namespace     string            `json:"namespace"`
+	KeepNamespace bool              `json:"keep_namespace"`
+	Export        bool              `json:"export"`
+	Objects       []UninstallObject `json:"objects"`
+}
+
 // StorageInfo represents detected storage information.
 type StorageInfo struct {
 	HasDefaultStorageClass bool   `json:"has_default_storage_class"`
@@ -221,17 +890,20 @@ type ClusterStatus struct {
 
 // Service provides compliance operator operations.
 type Service struct {
-	k8sClient     *k8s.Client
-	namespace     string
-	complianceRef string
+	k8sClient       *k8s.Client
+	namespace       string
+	complianceRef   string
+	remediationMode RemediationMode
 }
 
-// NewService creates a new compliance Service.
-func NewService(k8sClient *k8s.Client, namespace, complianceRef string) *Service {
+// NewService creates a new compliance Service that applies remediations in
+// the given mode.
+func NewService(k8sClient *k8s.Client, namespace, complianceRef string, mode RemediationMode) *Service {
 	return &Service{
-		k8sClient:     k8sClient,
-		namespace:     namespace,
-		complianceRef: complianceRef,
+		k8sClient:       k8sClient,
+		namespace:       namespace,
+		complianceRef:   complianceRef,
+		remediationMode: mode,
 	}
 }
 
@@ -243,6 +915,14 @@ func (s *Service) K8sClient() *k8s.Client {
 	return s.k8sClient
 }
 
+// RemediationMode returns how remediations are applied, direct when unset.
+func (s *Service) RemediationMode() RemediationMode {
+	if s == nil || s.remediationMode == "" {
+		return RemediationModeDirect
+	}
+	return s.remediationMode
+}
+
 // DefaultPeriodicScanOptions returns sensible defaults for periodic scans.
 func DefaultPeriodicScanOptions(namespace string) PeriodicScanOptions {
 	return PeriodicScanOptions{
@@ -280,6 +960,14 @@ func (ps progressSender) sendError(step, message string) {
 	ps.ch <- InstallProgress{Step: step, Message: message, Error: message, Done: true}
 }
 
+func (ps progressSender) sendOptions(step, message string, opts InstallOptions) {
+	ps.ch <- InstallProgress{Step: step, Message: message, Options: &opts}
+}
+
+func (ps progressSender) sendWait(step, message string, wait WaitProgress) {
+	ps.ch <- InstallProgress{Step: step, Message: message, Wait: &wait}
+}
+
 func (ps progressSender) sendDone(step, message string) {
 	ps.ch <- InstallProgress{Step: step, Message: message, Done: true}
 }
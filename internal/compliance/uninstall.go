@@ -0,0 +1,701 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+const (
+	// uninstallExportConfigMap holds the archive exported before the last
+	// uninstall, in the state namespace.
+	uninstallExportConfigMap = "compliance-dashboard-uninstall-export"
+	uninstallExportKey       = "export.tar.gz"
+	uninstallExportNameKey   = "filename"
+
+	// uninstallExportLimit keeps a saved export within the 1 MiB a ConfigMap
+	// can hold, leaving room for its metadata.
+	uninstallExportLimit = 1000 * 1000
+
+	supplementalRBACName = "compliance-operator-job-permissions"
+)
+
+// complianceKinds are the compliance CRs an uninstall removes, finalizers
+// first, and an export saves.
+var complianceKinds = []struct {
+	kind string
+	dir  string
+	gvr  schema.GroupVersionResource
+}{
+	{"ComplianceCheckResult", "compliancecheckresults", complianceCheckResultGVR},
+	{"ComplianceRemediation", "complianceremediations", complianceRemediationGVR},
+	{"ComplianceSuite", "compliancesuites", complianceSuiteGVR},
+	{"ComplianceScan", "compliancescans", complianceScanGVR},
+	{"ScanSettingBinding", "scansettingbindings", scanSettingBindingGVR},
+	{"ScanSetting", "scansettings", scanSettingGVR},
+	{"ProfileBundle", "profilebundles", profileBundleGVR},
+	{"Profile", "profiles", profileGVR},
+}
+
+// UninstallExport is the results and remediation state archived before an
+// uninstall. Errors lists what could not be collected.
+type UninstallExport struct {
+	Namespace  string
+	ExportedAt time.Time
+	Files      []DiagnosticsFile
+	Errors     []string
+}
+
+// uninstallPlanner lists the objects an uninstall step deletes.
+type uninstallPlanner func(ctx context.Context, client *k8s.Client, namespace string, opts UninstallOptions) ([]UninstallObject, error)
+
+// uninstallStage is an uninstall step that deletes exactly what its planner
+// lists, so a dry run reports what the uninstall does.
+type uninstallStage struct {
+	name  string
+	title string
+	plan  uninstallPlanner
+}
+
+var uninstallStages = []uninstallStage{
+	{"cleanup", "Remove compliance resources", planComplianceObjects},
+	{"subscription", "Delete Subscription", planSubscription},
+	{"csv", "Delete ClusterServiceVersion", planCSVs},
+	{"operatorgroup", "Delete OperatorGroup", planOperatorGroup},
+	{"catalogsource", "Delete CatalogSource", planCatalogSource},
+	{"manifests", "Delete bundled manifests", planManifests},
+	{"rbac", "Delete job permissions", planSupplementalRBAC},
+	{"namespace", "Delete namespace", planNamespace},
+}
+
+// uninstallSteps are the steps of an uninstall, in order: the export, then
+// one step per stage.
+var uninstallSteps = func() []installStep {
+	steps := []installStep{{name: "export", title: "Export results", run: uninstallExport}}
+	for _, s := range uninstallStages {
+		steps = append(steps, installStep{name: s.name, title: s.title, run: s.run})
+	}
+	return steps
+}()
+
+// olmServed reports whether the cluster serves the OLM APIs, assuming it
+// does when that cannot be checked.
+func olmServed(ctx context.Context, client *k8s.Client) bool {
+	olm, err := OLMInstalled(ctx, client)
+	return err != nil || olm
+}
+
+// Uninstall removes the Compliance Operator and, unless opts keep the
+// namespace, all its resources. It sends progress updates to the provided
+// channel and records each step in stateNamespace so the uninstall can be
+// inspected and resumed.
+func Uninstall(ctx context.Context, client *k8s.Client, namespace, stateNamespace string, opts UninstallOptions, progress chan<- InstallProgress) {
+	defer close(progress)
+
+	ps := newProgressSender(progress)
+
+	if client == nil {
+		ps.sendError("init", "Kubernetes client is not connected")
+		return
+	}
+
+	state := newInstallState(OperationUninstall, namespace, "", InstallOptions{}, uninstallSteps)
+	state.Uninstall = &opts
+	r := &installRun{
+		client:         client,
+		stateNamespace: stateNamespace,
+		state:          state,
+		ps:             ps,
+	}
+	r.execute(ctx, uninstallSteps, uninstallDoneMessage)
+}
+
+// PlanUninstall lists what an uninstall with opts would delete, without
+// deleting anything.
+func PlanUninstall(ctx context.Context, client *k8s.Client, namespace string, opts UninstallOptions) (*UninstallPlan, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	plan := &UninstallPlan{
+		Namespace:     namespace,
+		KeepNamespace: opts.KeepNamespace,
+		Export:        opts.Export,
+		Objects:       []UninstallObject{},
+	}
+	for _, s := range uninstallStages {
+		objects, err := s.plan(ctx, client, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("planning %s: %w", s.name, err)
+		}
+		for _, obj := range objects {
+			obj.Step = s.name
+			plan.Objects = append(plan.Objects, obj)
+		}
+	}
+	return plan, nil
+}
+
+func (r *installRun) uninstallOptions() UninstallOptions {
+	if r.state.Uninstall == nil {
+		return UninstallOptions{}
+	}
+	return *r.state.Uninstall
+}
+
+// run deletes what the stage's planner lists, skipping the step when there
+// is nothing to delete.
+func (s uninstallStage) run(ctx context.Context, r *installRun) error {
+	objects, err := s.plan(ctx, r.client, r.state.Namespace, r.uninstallOptions())
+	if err != nil {
+		return stepFailed("Listing objects to delete failed: %v", err)
+	}
+	if len(objects) == 0 {
+		return errStepSkipped
+	}
+
+	var failed []string
+	for i, obj := range objects {
+		if i == 0 || obj.Kind != objects[i-1].Kind {
+			r.send(ctx, deletingMessage(objects[i:]))
+		}
+		if err := obj.remove(ctx); err != nil && !k8serrors.IsNotFound(err) {
+			failed = append(failed, fmt.Sprintf("%s %s: %v", obj.Kind, obj.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return stepFailed("Failed to delete %d of %d objects: %s", len(failed), len(objects), strings.Join(failed, "; "))
+	}
+	r.send(ctx, fmt.Sprintf("Deleted %d objects", len(objects)))
+	return nil
+}
+
+// deletingMessage describes the run of objects of the same kind at the
+// start of objects.
+func deletingMessage(objects []UninstallObject) string {
+	n := 1
+	for n < len(objects) && objects[n].Kind == objects[0].Kind {
+		n++
+	}
+	if n == 1 {
+		return fmt.Sprintf("Deleting %s %s...", objects[0].Kind, objects[0].Name)
+	}
+	return fmt.Sprintf("Deleting %d %s objects...", n, objects[0].Kind)
+}
+
+// dynamicObject returns an UninstallObject that deletes obj through res.
+func dynamicObject(res dynamic.ResourceInterface, kind string, obj *unstructured.Unstructured, note string) UninstallObject {
+	name := obj.GetName()
+	return UninstallObject{
+		Kind:      kind,
+		Name:      name,
+		Namespace: obj.GetNamespace(),
+		Note:      note,
+		remove: func(ctx context.Context) error {
+			propagation := metav1.DeletePropagationBackground
+			return res.Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		},
+	}
+}
+
+// planNamed lists the named object when it exists.
+func planNamed(ctx context.Context, client *k8s.Client, kind string, gvr schema.GroupVersionResource, namespace, name string) ([]UninstallObject, error) {
+	res := client.Dynamic.Resource(gvr).Namespace(namespace)
+	obj, err := res.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) || IsCRDNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting %s %s: %w", kind, name, err)
+	}
+	return []UninstallObject{dynamicObject(res, kind, obj, "")}, nil
+}
+
+// planComplianceObjects lists every compliance CR in the namespace, which
+// are deleted with their finalizers removed so they do not wait on the
+// operator being removed. Keeping the namespace keeps them, with the scan
+// results.
+func planComplianceObjects(ctx context.Context, client *k8s.Client, namespace string, opts UninstallOptions) ([]UninstallObject, error) {
+	if opts.KeepNamespace {
+		return nil, nil
+	}
+	var objects []UninstallObject
+	for _, kind := range complianceKinds {
+		res := client.Dynamic.Resource(kind.gvr).Namespace(namespace)
+		items, err := res.List(ctx, metav1.ListOptions{})
+		if IsCRDNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", kind.dir, err)
+		}
+		for _, item := range items.Items {
+			name := item.GetName()
+			objects = append(objects, UninstallObject{
+				Kind:      kind.kind,
+				Name:      name,
+				Namespace: namespace,
+				remove: func(ctx context.Context) error {
+					_, _ = res.Patch(ctx, name, types.MergePatchType, FinalizerRemovalPatch, metav1.PatchOptions{})
+					return res.Delete(ctx, name, metav1.DeleteOptions{})
+				},
+			})
+		}
+	}
+	return objects, nil
+}
+
+func planSubscription(ctx context.Context, client *k8s.Client, namespace string, _ UninstallOptions) ([]UninstallObject, error) {
+	if !olmServed(ctx, client) {
+		return nil, nil
+	}
+	return planNamed(ctx, client, "Subscription", subscriptionGVR, namespace, subscriptionName)
+}
+
+// planCSVs lists the operator's ClusterServiceVersions, leaving those of
+// other operators sharing the namespace.
+func planCSVs(ctx context.Context, client *k8s.Client, namespace string, _ UninstallOptions) ([]UninstallObject, error) {
+	if !olmServed(ctx, client) {
+		return nil, nil
+	}
+	res := client.Dynamic.Resource(csvGVR).Namespace(namespace)
+	csvs, err := res.List(ctx, metav1.ListOptions{})
+	if IsCRDNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing ClusterServiceVersions: %w", err)
+	}
+	var objects []UninstallObject
+	var owners []string
+	for i := range csvs.Items {
+		csv := &csvs.Items[i]
+		if strings.HasPrefix(csv.GetName(), operatorName+".") {
+			objects = append(objects, dynamicObject(res, "ClusterServiceVersion", csv, ""))
+			owners = append(owners, csv.GetName())
+		}
+	}
+	if len(owners) == 0 {
+		return objects, nil
+	}
+	owned, err := planCSVOwned(ctx, client, namespace, owners)
+	if err != nil {
+		return nil, err
+	}
+	return append(objects, owned...), nil
+}
+
+// planCSVOwned lists the Deployments, ServiceAccounts, Roles and
+// RoleBindings OLM created for the named CSVs. OLM deletes them with their
+// CSV, even when the namespace is kept; they are listed, and deleted after
+// the CSVs, so the plan shows them.
+func planCSVOwned(ctx context.Context, client *k8s.Client, namespace string, owners []string) ([]UninstallObject, error) {
+	ownedBy := func(obj metav1.Object) string {
+		for _, ref := range obj.GetOwnerReferences() {
+			if ref.Kind == "ClusterServiceVersion" && slices.Contains(owners, ref.Name) {
+				return ref.Name
+			}
+		}
+		return ""
+	}
+	var objects []UninstallObject
+	add := func(kind string, obj metav1.Object, remove func(ctx context.Context, name string) error) {
+		csv := ownedBy(obj)
+		if csv == "" {
+			return
+		}
+		name := obj.GetName()
+		objects = append(objects, UninstallObject{
+			Kind: kind, Name: name, Namespace: namespace,
+			Note:   fmt.Sprintf("Owned by ClusterServiceVersion %s", csv),
+			remove: func(ctx context.Context) error { return remove(ctx, name) },
+		})
+	}
+
+	apps, core, rbac := client.Clientset.AppsV1(), client.Clientset.CoreV1(), client.Clientset.RbacV1()
+	deployments, err := apps.Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing Deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		add("Deployment", &deployments.Items[i], func(ctx context.Context, name string) error {
+			return apps.Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		})
+	}
+	accounts, err := core.ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing ServiceAccounts: %w", err)
+	}
+	for i := range accounts.Items {
+		add("ServiceAccount", &accounts.Items[i], func(ctx context.Context, name string) error {
+			return core.ServiceAccounts(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		})
+	}
+	roles, err := rbac.Roles(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing Roles: %w", err)
+	}
+	for i := range roles.Items {
+		add("Role", &roles.Items[i], func(ctx context.Context, name string) error {
+			return rbac.Roles(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		})
+	}
+	bindings, err := rbac.RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing RoleBindings: %w", err)
+	}
+	for i := range bindings.Items {
+		add("RoleBinding", &bindings.Items[i], func(ctx context.Context, name string) error {
+			return rbac.RoleBindings(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		})
+	}
+	return objects, nil
+}
+
+func planOperatorGroup(ctx context.Context, client *k8s.Client, namespace string, _ UninstallOptions) ([]UninstallObject, error) {
+	if !olmServed(ctx, client) {
+		return nil, nil
+	}
+	return planNamed(ctx, client, "OperatorGroup", operatorGroupGVR, namespace, operatorName)
+}
+
+// planCatalogSource lists the CatalogSource of a community install.
+func planCatalogSource(ctx context.Context, client *k8s.Client, _ string, _ UninstallOptions) ([]UninstallObject, error) {
+	if !olmServed(ctx, client) {
+		return nil, nil
+	}
+	return planNamed(ctx, client, "CatalogSource", catalogSourceGVR, marketplaceNS, operatorName)
+}
+
+// planManifests lists the objects of an install without OLM, in reverse
+// apply order, whichever bundled release was installed. Objects without
+// manifestInstallLabel, such as CRDs OLM installed, are left alone.
+// Deleting a CRD deletes its objects in every namespace, so keeping the
+// namespace keeps the CRDs too.
+func planManifests(ctx context.Context, client *k8s.Client, namespace string, opts UninstallOptions) ([]UninstallObject, error) {
+	var rendered []*unstructured.Unstructured
+	for _, ref := range BundledManifestRefs() {
+		objects, err := renderManifests(ref, manifestValues{Namespace: namespace})
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, objects...)
+	}
+	// Keep the apply order across releases: CRDs, then RBAC, then the
+	// Deployment.
+	slices.SortStableFunc(rendered, func(a, b *unstructured.Unstructured) int {
+		return manifestKindRank(a.GetKind()) - manifestKindRank(b.GetKind())
+	})
+	var objects []UninstallObject
+	seen := map[string]bool{}
+	for i := len(rendered) - 1; i >= 0; i-- {
+		obj := rendered[i]
+		if opts.KeepNamespace && obj.GetKind() == "CustomResourceDefinition" {
+			continue
+		}
+		key := obj.GetKind() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		res := manifestResource(client, obj)
+		existing, err := res.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) || IsCRDNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		if existing.GetLabels()[manifestInstallLabel] != "true" {
+			continue
+		}
+		var note string
+		if obj.GetKind() == "CustomResourceDefinition" {
+			kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+			note = fmt.Sprintf("Also deletes every %s in the cluster", kind)
+		}
+		objects = append(objects, dynamicObject(res, obj.GetKind(), existing, note))
+	}
+	return objects, nil
+}
+
+// manifestKindRank orders the kinds of the bundled manifests for applying.
+func manifestKindRank(kind string) int {
+	switch kind {
+	case "CustomResourceDefinition":
+		return 0
+	case "Deployment":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// planSupplementalRBAC lists the Role and RoleBinding that let the operator
+// run scan Jobs. They go with the namespace unless it is kept.
+func planSupplementalRBAC(ctx context.Context, client *k8s.Client, namespace string, opts UninstallOptions) ([]UninstallObject, error) {
+	if !opts.KeepNamespace {
+		return nil, nil
+	}
+	rbac := client.Clientset.RbacV1()
+	var objects []UninstallObject
+	if _, err := rbac.RoleBindings(namespace).Get(ctx, supplementalRBACName, metav1.GetOptions{}); err == nil {
+		objects = append(objects, UninstallObject{
+			Kind: "RoleBinding", Name: supplementalRBACName, Namespace: namespace,
+			remove: func(ctx context.Context) error {
+				return rbac.RoleBindings(namespace).Delete(ctx, supplementalRBACName, metav1.DeleteOptions{})
+			},
+		})
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting RoleBinding %s: %w", supplementalRBACName, err)
+	}
+	if _, err := rbac.Roles(namespace).Get(ctx, supplementalRBACName, metav1.GetOptions{}); err == nil {
+		objects = append(objects, UninstallObject{
+			Kind: "Role", Name: supplementalRBACName, Namespace: namespace,
+			remove: func(ctx context.Context) error {
+				return rbac.Roles(namespace).Delete(ctx, supplementalRBACName, metav1.DeleteOptions{})
+			},
+		})
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting Role %s: %w", supplementalRBACName, err)
+	}
+	return objects, nil
+}
+
+// planNamespace lists the operator namespace unless it is kept. Deleting it
+// waits for everything in it to be removed.
+func planNamespace(ctx context.Context, client *k8s.Client, namespace string, opts UninstallOptions) ([]UninstallObject, error) {
+	if opts.KeepNamespace {
+		return nil, nil
+	}
+	namespaces := client.Clientset.CoreV1().Namespaces()
+	if _, err := namespaces.Get(ctx, namespace, metav1.GetOptions{}); k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting namespace %s: %w", namespace, err)
+	}
+	return []UninstallObject{{
+		Kind: "Namespace",
+		Name: namespace,
+		Note: "Also deletes everything else in the namespace, including the dashboard's change requests, remediation snapshots and maintenance windows",
+		remove: func(ctx context.Context) error {
+			if err := namespaces.Delete(ctx, namespace, metav1.DeleteOptions{}); err != nil {
+				return err
+			}
+			// Wait for namespace deletion
+			for i := 0; i < 30; i++ {
+				_, err := namespaces.Get(ctx, namespace, metav1.GetOptions{})
+				if k8serrors.IsNotFound(err) {
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("checking namespace %s: %w", namespace, err)
+				}
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf("waiting for namespace %s deletion: %w", namespace, ctx.Err())
+				case <-time.After(5 * time.Second):
+				}
+			}
+			return fmt.Errorf("namespace %s was not deleted after %s", namespace, 30*5*time.Second)
+		},
+	}}, nil
+}
+
+// uninstallExport saves the results and remediation state to the state
+// namespace before anything is deleted, when the options ask for it. An
+// incomplete export is saved but stops the uninstall unless it is forced.
+func uninstallExport(ctx context.Context, r *installRun) error {
+	opts := r.uninstallOptions()
+	if !opts.Export {
+		return errStepSkipped
+	}
+	if !opts.KeepNamespace && r.stateNamespace == r.state.Namespace {
+		return stepFailed("The export would be deleted with namespace %s; keep the namespace or run the dashboard with another state namespace", r.state.Namespace)
+	}
+
+	r.send(ctx, "Exporting results and remediation state...")
+	export, err := ExportComplianceState(ctx, r.client, r.state.Namespace)
+	if err != nil {
+		return stepFailed("Export failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := export.WriteTarGz(&buf); err != nil {
+		return stepFailed("Export failed: %v", err)
+	}
+	if buf.Len() > uninstallExportLimit {
+		return stepFailed("The export is %d bytes, more than a ConfigMap holds; download it from GET /api/operator/export and uninstall without export", buf.Len())
+	}
+	if err := saveUninstallExport(ctx, r.client, r.stateNamespace, export.DirName()+".tar.gz", buf.Bytes()); err != nil {
+		return stepFailed("Saving the export failed: %v", err)
+	}
+	if len(export.Errors) > 0 {
+		if !opts.Force {
+			return stepFailed("The export is incomplete: %d items could not be collected (%s); download it from GET /api/operator/uninstall/export, then fix the cause or uninstall with force",
+				len(export.Errors), strings.Join(export.Errors, "; "))
+		}
+		r.send(ctx, fmt.Sprintf("Exported %d files; %d could not be collected, see errors.txt", len(export.Files), len(export.Errors)))
+		return nil
+	}
+	r.send(ctx, fmt.Sprintf("Exported %d files", len(export.Files)))
+	return nil
+}
+
+// ExportComplianceState gathers the scan results, remediation state,
+// compliance CR YAML and the dashboard's change requests, snapshots and
+// maintenance windows from the operator namespace. Errors lists what could
+// not be collected; collection carries on past individual failures.
+func ExportComplianceState(ctx context.Context, client *k8s.Client, namespace string) (*UninstallExport, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+	e := &UninstallExport{Namespace: namespace, ExportedAt: time.Now().UTC()}
+
+	if results, err := GetComplianceResults(ctx, client, namespace); err != nil {
+		e.addError("collecting results: %v", err)
+	} else {
+		e.addJSON("results.json", results)
+	}
+	if remediations, err := ListRemediations(ctx, client, namespace); err != nil {
+		e.addError("collecting remediations: %v", err)
+	} else {
+		e.addJSON("remediations.json", remediations)
+	}
+
+	for _, kind := range complianceKinds {
+		items, err := client.Dynamic.Resource(kind.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if !IsCRDNotFound(err) {
+				e.addError("listing %s: %v", kind.dir, err)
+			}
+			continue
+		}
+		for i := range items.Items {
+			item := &items.Items[i]
+			e.addYAML(path.Join("compliance", kind.dir, item.GetName()+".yaml"), cleanObject(item))
+		}
+	}
+
+	configMaps := client.Clientset.CoreV1().ConfigMaps(namespace)
+	for _, label := range []string{changeRequestLabel, snapshotLabel} {
+		list, err := configMaps.List(ctx, metav1.ListOptions{LabelSelector: label})
+		if err != nil {
+			e.addError("listing ConfigMaps labelled %s: %v", label, err)
+			continue
+		}
+		for i := range list.Items {
+			e.addConfigMap(&list.Items[i])
+		}
+	}
+	if cm, err := configMaps.Get(ctx, maintenanceConfigMap, metav1.GetOptions{}); err == nil {
+		e.addConfigMap(cm)
+	} else if !k8serrors.IsNotFound(err) {
+		e.addError("getting ConfigMap %s: %v", maintenanceConfigMap, err)
+	}
+	return e, nil
+}
+
+func (e *UninstallExport) addError(format string, args ...any) {
+	e.Errors = append(e.Errors, fmt.Sprintf(format, args...))
+}
+
+func (e *UninstallExport) addJSON(name string, v any) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		e.addError("rendering %s: %v", name, err)
+		return
+	}
+	e.Files = append(e.Files, DiagnosticsFile{Path: name, Content: append(out, '\n')})
+}
+
+func (e *UninstallExport) addYAML(name string, v any) {
+	out, err := sigsyaml.Marshal(v)
+	if err != nil {
+		e.addError("rendering %s: %v", name, err)
+		return
+	}
+	e.Files = append(e.Files, DiagnosticsFile{Path: name, Content: out})
+}
+
+func (e *UninstallExport) addConfigMap(cm *corev1.ConfigMap) {
+	cm = cm.DeepCopy()
+	cm.ManagedFields = nil
+	cm.APIVersion, cm.Kind = "v1", "ConfigMap"
+	e.addYAML(path.Join("dashboard", cm.Name+".yaml"), cm)
+}
+
+// WriteTarGz writes the export as a gzipped tarball rooted at
+// compliance-export-<time>/, with errors.txt listing anything that could
+// not be collected.
+func (e *UninstallExport) WriteTarGz(w io.Writer) error {
+	return writeTarGz(w, e.DirName(), e.ExportedAt, e.Files, e.Errors)
+}
+
+// DirName is the export's top-level directory and file name stem.
+func (e *UninstallExport) DirName() string {
+	return "compliance-export-" + e.ExportedAt.Format("20060102-150405")
+}
+
+// saveUninstallExport stores the archive, replacing any earlier export.
+func saveUninstallExport(ctx context.Context, client *k8s.Client, stateNamespace, filename string, data []byte) error {
+	configMaps := client.Clientset.CoreV1().ConfigMaps(stateNamespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(ctx, uninstallExportConfigMap, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: uninstallExportConfigMap, Namespace: stateNamespace},
+				Data:       map[string]string{uninstallExportNameKey: filename},
+				BinaryData: map[string][]byte{uninstallExportKey: data},
+			}
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), uninstallExportConfigMap, err)
+			}
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("getting uninstall export: %w", err)
+		}
+		cm.Data = map[string]string{uninstallExportNameKey: filename}
+		cm.BinaryData = map[string][]byte{uninstallExportKey: data}
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// GetUninstallExport returns the file name and contents of the archive
+// exported before the last uninstall, or an empty name when there is none.
+func GetUninstallExport(ctx context.Context, client *k8s.Client, stateNamespace string) (string, []byte, error) {
+	if client == nil {
+		return "", nil, fmt.Errorf("kubernetes client is nil")
+	}
+	cm, err := client.Clientset.CoreV1().ConfigMaps(stateNamespace).Get(ctx, uninstallExportConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("getting uninstall export: %w", err)
+	}
+	data := cm.BinaryData[uninstallExportKey]
+	if len(data) == 0 {
+		return "", nil, nil
+	}
+	return cm.Data[uninstallExportNameKey], data, nil
+}
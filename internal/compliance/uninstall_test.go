@@ -0,0 +1,274 @@
+package compliance
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+func newCSV(ns, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "ClusterServiceVersion",
+		"metadata":   map[string]any{"name": name, "namespace": ns},
+	}}
+}
+
+// newUninstallClient returns a test client with an OLM install of the
+// operator in ns, a scan result, another operator's CSV and the dashboard's
+// state in it. The operator's Deployment and ServiceAccount are owned by its
+// CSV, as OLM creates them.
+func newUninstallClient(t *testing.T, ns string) *k8s.Client {
+	t.Helper()
+	ctx := context.Background()
+	client := newTestClient(
+		newSubscription(ns, "stable", "compliance-operator.v1.7.0", "compliance-operator.v1.7.0"),
+		newCSV(ns, "compliance-operator.v1.7.0"),
+		newCSV(ns, "other-operator.v2.0.0"),
+		newCheckResult("ocp4-cis-audit-log", ns, "FAIL", "high", "Audit logging", "ocp4-cis", "cis"),
+	)
+	if _, err := client.Clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating namespace: %v", err)
+	}
+	if _, err := client.Clientset.RbacV1().Roles(ns).Create(ctx, &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: supplementalRBACName}}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating Role: %v", err)
+	}
+	owner := []metav1.OwnerReference{{APIVersion: "operators.coreos.com/v1alpha1", Kind: "ClusterServiceVersion", Name: "compliance-operator.v1.7.0"}}
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: operatorName, Namespace: ns, OwnerReferences: owner}}
+	if _, err := client.Clientset.AppsV1().Deployments(ns).Create(ctx, deploy, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating Deployment: %v", err)
+	}
+	for _, sa := range []*corev1.ServiceAccount{
+		{ObjectMeta: metav1.ObjectMeta{Name: operatorName, OwnerReferences: owner}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+	} {
+		if _, err := client.Clientset.CoreV1().ServiceAccounts(ns).Create(ctx, sa, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("creating ServiceAccount: %v", err)
+		}
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "remediation-cr-1", Labels: map[string]string{changeRequestLabel: "true"}}}
+	if _, err := client.Clientset.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating ConfigMap: %v", err)
+	}
+	return client
+}
+
+func planned(plan *UninstallPlan) map[string]UninstallObject {
+	out := map[string]UninstallObject{}
+	for _, obj := range plan.Objects {
+		out[obj.Kind+"/"+obj.Name] = obj
+	}
+	return out
+}
+
+func TestPlanUninstall(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newUninstallClient(t, ns)
+
+	plan, err := PlanUninstall(ctx, client, ns, UninstallOptions{})
+	if err != nil {
+		t.Fatalf("PlanUninstall: %v", err)
+	}
+	objects := planned(plan)
+	for _, want := range []string{
+		"ComplianceCheckResult/ocp4-cis-audit-log",
+		"Subscription/" + subscriptionName,
+		"ClusterServiceVersion/compliance-operator.v1.7.0",
+		"Namespace/" + ns,
+	} {
+		if _, ok := objects[want]; !ok {
+			t.Errorf("plan is missing %s: %+v", want, plan.Objects)
+		}
+	}
+	if _, ok := objects["ClusterServiceVersion/other-operator.v2.0.0"]; ok {
+		t.Error("plan deletes another operator's CSV")
+	}
+	if objects["Namespace/"+ns].Note == "" {
+		t.Error("expected the namespace to note what it takes with it")
+	}
+	if _, ok := objects["Role/"+supplementalRBACName]; ok {
+		t.Error("the Role goes with the namespace and should not be listed")
+	}
+
+	plan, err = PlanUninstall(ctx, client, ns, UninstallOptions{KeepNamespace: true})
+	if err != nil {
+		t.Fatalf("PlanUninstall: %v", err)
+	}
+	objects = planned(plan)
+	for _, kept := range []string{"ComplianceCheckResult/ocp4-cis-audit-log", "Namespace/" + ns} {
+		if _, ok := objects[kept]; ok {
+			t.Errorf("keepNamespace plan deletes %s", kept)
+		}
+	}
+	if obj, ok := objects["Role/"+supplementalRBACName]; !ok || obj.Step != "rbac" {
+		t.Errorf("keepNamespace plan should delete the job Role: %+v", plan.Objects)
+	}
+	for _, owned := range []string{"Deployment/" + operatorName, "ServiceAccount/" + operatorName} {
+		if obj, ok := objects[owned]; !ok || obj.Step != "csv" || obj.Note == "" {
+			t.Errorf("keepNamespace plan should list the CSV-owned %s: %+v", owned, plan.Objects)
+		}
+	}
+	if _, ok := objects["ServiceAccount/default"]; ok {
+		t.Error("keepNamespace plan deletes a ServiceAccount the CSV does not own")
+	}
+
+	// A dry run deletes nothing.
+	if _, err := client.Dynamic.Resource(subscriptionGVR).Namespace(ns).Get(ctx, subscriptionName, metav1.GetOptions{}); err != nil {
+		t.Errorf("dry run deleted the Subscription: %v", err)
+	}
+}
+
+func TestUninstallKeepNamespaceWithExport(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newUninstallClient(t, ns)
+
+	progress := make(chan InstallProgress, 256)
+	Uninstall(ctx, client, ns, "dashboard", UninstallOptions{KeepNamespace: true, Export: true}, progress)
+	if last := drainProgress(progress); last.Error != "" || last.Message != uninstallDoneMessage {
+		t.Fatalf("uninstall did not finish: %+v", last)
+	}
+
+	if _, err := client.Dynamic.Resource(subscriptionGVR).Namespace(ns).Get(ctx, subscriptionName, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the Subscription to be deleted, got %v", err)
+	}
+	if _, err := client.Dynamic.Resource(csvGVR).Namespace(ns).Get(ctx, "other-operator.v2.0.0", metav1.GetOptions{}); err != nil {
+		t.Errorf("another operator's CSV was deleted: %v", err)
+	}
+	if _, err := client.Dynamic.Resource(complianceCheckResultGVR).Namespace(ns).Get(ctx, "ocp4-cis-audit-log", metav1.GetOptions{}); err != nil {
+		t.Errorf("keepNamespace deleted a check result: %v", err)
+	}
+	if _, err := client.Clientset.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{}); err != nil {
+		t.Errorf("keepNamespace deleted the namespace: %v", err)
+	}
+	if _, err := client.Clientset.RbacV1().Roles(ns).Get(ctx, supplementalRBACName, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the job Role to be deleted, got %v", err)
+	}
+	if _, err := client.Clientset.AppsV1().Deployments(ns).Get(ctx, operatorName, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the CSV-owned Deployment to be deleted, got %v", err)
+	}
+
+	state, _ := GetInstallState(ctx, client, "dashboard")
+	if state.Uninstall == nil || !state.Uninstall.KeepNamespace {
+		t.Errorf("uninstall options not recorded: %+v", state.Uninstall)
+	}
+
+	name, data, err := GetUninstallExport(ctx, client, "dashboard")
+	if err != nil || name == "" {
+		t.Fatalf("GetUninstallExport = %q, %v", name, err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[strings.TrimPrefix(hdr.Name, strings.TrimSuffix(name, ".tar.gz")+"/")] = string(content)
+	}
+	for _, want := range []string{
+		"results.json",
+		"remediations.json",
+		"compliance/compliancecheckresults/ocp4-cis-audit-log.yaml",
+		"dashboard/remediation-cr-1.yaml",
+	} {
+		if _, ok := files[want]; !ok {
+			t.Errorf("export has no %s", want)
+		}
+	}
+	if !strings.Contains(files["results.json"], "ocp4-cis-audit-log") {
+		t.Errorf("results.json does not list the check: %s", files["results.json"])
+	}
+}
+
+func TestUninstallExportRefusesStateInDeletedNamespace(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newUninstallClient(t, ns)
+
+	progress := make(chan InstallProgress, 256)
+	Uninstall(ctx, client, ns, ns, UninstallOptions{Export: true}, progress)
+	if last := drainProgress(progress); last.Error == "" {
+		t.Fatalf("expected the export step to fail: %+v", last)
+	}
+	state, _ := GetInstallState(ctx, client, ns)
+	if state.FailedStep != "export" {
+		t.Errorf("FailedStep = %q, want export", state.FailedStep)
+	}
+	if _, err := client.Dynamic.Resource(complianceCheckResultGVR).Namespace(ns).Get(ctx, "ocp4-cis-audit-log", metav1.GetOptions{}); err != nil {
+		t.Errorf("a failed export should delete nothing: %v", err)
+	}
+}
+
+func TestUninstallIncompleteExport(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newUninstallClient(t, ns)
+	client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("list", "scansettings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewForbidden(scanSettingGVR.GroupResource(), "", nil)
+	})
+
+	opts := UninstallOptions{KeepNamespace: true, Export: true}
+	progress := make(chan InstallProgress, 256)
+	Uninstall(ctx, client, ns, "dashboard", opts, progress)
+	if last := drainProgress(progress); !strings.Contains(last.Error, "export is incomplete") {
+		t.Fatalf("expected the incomplete export to stop the uninstall: %+v", last)
+	}
+	if _, err := client.Dynamic.Resource(subscriptionGVR).Namespace(ns).Get(ctx, subscriptionName, metav1.GetOptions{}); err != nil {
+		t.Errorf("a failed export should delete nothing: %v", err)
+	}
+	if _, _, err := GetUninstallExport(ctx, client, "dashboard"); err != nil {
+		t.Errorf("expected the incomplete export to be saved: %v", err)
+	}
+
+	opts.Force = true
+	progress = make(chan InstallProgress, 256)
+	Uninstall(ctx, client, ns, "dashboard", opts, progress)
+	if last := drainProgress(progress); last.Error != "" || last.Message != uninstallDoneMessage {
+		t.Fatalf("forced uninstall did not finish: %+v", last)
+	}
+}
+
+func TestPlanNamespace_WaitReportsErrors(t *testing.T) {
+	ctx := context.Background()
+	ns := "openshift-compliance"
+	client := newUninstallClient(t, ns)
+
+	plan, err := planNamespace(ctx, client, ns, UninstallOptions{})
+	if err != nil || len(plan) != 1 {
+		t.Fatalf("planNamespace = %+v, %v", plan, err)
+	}
+	// A failing lookup is not mistaken for the namespace being gone
+	client.Clientset.(*kubefake.Clientset).PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewForbidden(corev1.Resource("namespaces"), ns, nil)
+	})
+	if err := plan[0].remove(ctx); err == nil || !k8serrors.IsForbidden(err) {
+		t.Fatalf("expected the lookup error, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+// scanVerifyTimeout bounds how long a verification waits for the rescan.
+const scanVerifyTimeout = 30 * time.Minute
+
+// scanPollInterval is how often a ComplianceScan is polled while waiting for
+// a rescan to finish.
+var scanPollInterval = 10 * time.Second
+
+// remediationIndexSuffix matches the "-<n>" suffix the operator appends when a
+// check has several remediations.
+var remediationIndexSuffix = regexp.MustCompile(`-\d+$`)
+
+// VerifyRemediation applies a remediation, waits for any MachineConfigPool
+// rollout it triggers, rescans the scan that produced its check and reports
+// whether the check now passes. Progress is sent on progress, which is closed
+// on return. An error is returned alongside the result if the check did not
+// flip to PASS.
+func VerifyRemediation(ctx context.Context, client *k8s.Client, mode RemediationMode, namespace, name string, progress chan<- VerificationProgress) (*VerificationResult, error) {
+	defer close(progress)
+
+	if client == nil {
+		return nil, fmt.Errorf("kubernetes client is nil")
+	}
+
+	send := func(step, msg string) {
+		progress <- VerificationProgress{Step: step, Message: msg}
+	}
+
+	rem, err := client.Dynamic.Resource(complianceRemediationGVR).Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting remediation %s: %w", name, err)
+	}
+
+	check, err := linkedCheckResult(ctx, client, namespace, rem)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{
+		Remediation:    name,
+		CheckResult:    check.GetName(),
+		Scan:           check.GetLabels()["compliance.openshift.io/scan-name"],
+		PreviousStatus: checkStatus(check),
+	}
+	if result.Scan == "" {
+		result.Scan = rem.GetLabels()["compliance.openshift.io/scan-name"]
+	}
+	if result.Scan == "" {
+		return result, fmt.Errorf("cannot determine the scan that produced check %s", check.GetName())
+	}
+
+	// The rendered config before the apply tells us when the MCO picks it up
+	kind, _, _ := unstructured.NestedString(rem.Object, "spec", "current", "object", "kind")
+	var pool, initialConfig string
+	if IsDisruptiveKind(kind) {
+		pool = detectRole(name, *rem)
+		initialConfig = renderedConfig(ctx, client, pool)
+	}
+
+	send("apply", fmt.Sprintf("Applying %s", name))
+	applyResult, err := ApplyRemediation(ctx, client, mode, namespace, name)
+	if err != nil {
+		return result, err
+	}
+	result.Applied = true
+	result.ApplyMessage = applyResult.Message
+
+	if mode == RemediationModeOperator {
+		send("wait-operator", "Waiting for the Compliance Operator to apply the remediation")
+		if err := waitForApplicationState(ctx, client, namespace, name); err != nil {
+			return result, err
+		}
+	}
+
+	if pool != "" {
+		send("wait-mcp", fmt.Sprintf("Waiting for MachineConfigPool %s to roll out", pool))
+		if err := waitForPoolRollout(ctx, client, pool, initialConfig, send); err != nil {
+			return result, err
+		}
+	}
+
+	scan, err := client.Dynamic.Resource(complianceScanGVR).Namespace(namespace).
+		Get(ctx, result.Scan, metav1.GetOptions{})
+	if err != nil {
+		return result, fmt.Errorf("getting ComplianceScan %s: %w", result.Scan, err)
+	}
+	previousEnd, _, _ := unstructured.NestedString(scan.Object, "status", "endTimestamp")
+
+	send("rescan", fmt.Sprintf("Rescanning %s", result.Scan))
+	if err := annotateRescan(ctx, client, namespace, result.Scan); err != nil {
+		return result, err
+	}
+
+	send("wait-scan", fmt.Sprintf("Waiting for %s to finish", result.Scan))
+	if err := waitForScanDone(ctx, client, namespace, result.Scan, previousEnd, send); err != nil {
+		return result, err
+	}
+
+	check, err = client.Dynamic.Resource(complianceCheckResultGVR).Namespace(namespace).
+		Get(ctx, result.CheckResult, metav1.GetOptions{})
+	if err != nil {
+		return result, fmt.Errorf("getting ComplianceCheckResult %s: %w", result.CheckResult, err)
+	}
+	result.Status = checkStatus(check)
+	result.Verified = result.Status == CheckStatusPass
+
+	if !result.Verified {
+		result.Message = fmt.Sprintf("Check %s is %s after rescan (was %s)", result.CheckResult, result.Status, result.PreviousStatus)
+		send("verify", result.Message)
+		return result, fmt.Errorf("%s", result.Message)
+	}
+	result.Message = fmt.Sprintf("Check %s now passes (was %s)", result.CheckResult, result.PreviousStatus)
+	send("verify", result.Message)
+	return result, nil
+}
+
+// linkedCheckResult finds the ComplianceCheckResult a remediation belongs to:
+// its owner, else a check with the same name, else the name without the
+// "-<n>" index suffix.
+func linkedCheckResult(ctx context.Context, client *k8s.Client, namespace string, rem *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	candidates := []string{}
+	for _, ref := range rem.GetOwnerReferences() {
+		if ref.Kind == "ComplianceCheckResult" {
+			candidates = append(candidates, ref.Name)
+		}
+	}
+	candidates = append(candidates, rem.GetName())
+	if trimmed := remediationIndexSuffix.ReplaceAllString(rem.GetName(), ""); trimmed != rem.GetName() {
+		candidates = append(candidates, trimmed)
+	}
+
+	for _, name := range candidates {
+		check, err := client.Dynamic.Resource(complianceCheckResultGVR).Namespace(namespace).
+			Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return check, nil
+		}
+		if !k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("getting ComplianceCheckResult %s: %w", name, err)
+		}
+	}
+	return nil, fmt.Errorf("no ComplianceCheckResult found for remediation %s", rem.GetName())
+}
+
+func checkStatus(check *unstructured.Unstructured) CheckStatus {
+	status, _, _ := unstructured.NestedString(check.Object, "status")
+	return CheckStatus(status)
+}
+
+// waitForPoolRollout waits for a pool to move off initialConfig and finish
+// updating, forwarding per-node progress.
+func waitForPoolRollout(ctx context.Context, client *k8s.Client, pool, initialConfig string, send func(step, msg string)) error {
+	rollout := make(chan MachineConfigBatchProgress)
+	done := make(chan error, 1)
+	go func() {
+		done <- trackRollout(ctx, client, map[string]string{pool: initialConfig}, rollout)
+		close(rollout)
+	}()
+	for p := range rollout {
+		send("wait-mcp", p.Message)
+	}
+	return <-done
+}
+
+// waitForScanDone waits until a scan reaches DONE with an end timestamp other
+// than previousEnd, i.e. the rescan has completed.
+func waitForScanDone(ctx context.Context, client *k8s.Client, namespace, scanName, previousEnd string, send func(step, msg string)) error {
+	timeout := time.After(scanVerifyTimeout)
+	ticker := time.NewTicker(scanPollInterval)
+	defer ticker.Stop()
+
+	lastPhase := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("ComplianceScan %s did not finish within %s", scanName, scanVerifyTimeout)
+		case <-ticker.C:
+		}
+
+		scan, err := client.Dynamic.Resource(complianceScanGVR).Namespace(namespace).
+			Get(ctx, scanName, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(scan.Object, "status", "phase")
+		end, _, _ := unstructured.NestedString(scan.Object, "status", "endTimestamp")
+
+		if phase != lastPhase {
+			lastPhase = phase
+			send("wait-scan", fmt.Sprintf("%s: %s", scanName, phase))
+		}
+		if phase == "DONE" && end != previousEnd {
+			return nil
+		}
+	}
+}
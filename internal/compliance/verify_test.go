@@ -0,0 +1,137 @@
+package compliance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/sebrandon1/compliance-operator-dashboard/internal/k8s"
+)
+
+func newDoneScan(name, ns, end string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "compliance.openshift.io/v1alpha1",
+			"kind":       "ComplianceScan",
+			"metadata": map[string]any{
+				"name":      name,
+				"namespace": ns,
+			},
+			"status": map[string]any{
+				"phase":        "DONE",
+				"endTimestamp": end,
+			},
+		},
+	}
+}
+
+// simulateRescan waits for the rescan annotation on the scan, then sets the
+// check to checkStatus and finishes the scan with a new end timestamp.
+func simulateRescan(t *testing.T, client *k8s.Client, ns, scanName, checkName, status string) {
+	t.Helper()
+	ctx := context.Background()
+	go func() {
+		for range 500 {
+			scan, err := client.Dynamic.Resource(complianceScanGVR).Namespace(ns).Get(ctx, scanName, metav1.GetOptions{})
+			if err == nil {
+				if _, ok := scan.GetAnnotations()["compliance.openshift.io/rescan"]; ok {
+					check, _ := client.Dynamic.Resource(complianceCheckResultGVR).Namespace(ns).Get(ctx, checkName, metav1.GetOptions{})
+					check.Object["status"] = status
+					_, _ = client.Dynamic.Resource(complianceCheckResultGVR).Namespace(ns).Update(ctx, check, metav1.UpdateOptions{})
+
+					_ = unstructured.SetNestedField(scan.Object, "2026-01-02T00:00:00Z", "status", "endTimestamp")
+					_, _ = client.Dynamic.Resource(complianceScanGVR).Namespace(ns).Update(ctx, scan, metav1.UpdateOptions{})
+					return
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+}
+
+func runVerify(client *k8s.Client, ns, name string) (*VerificationResult, []VerificationProgress, error) {
+	progress := make(chan VerificationProgress)
+	var updates []VerificationProgress
+	done := make(chan struct{})
+	go func() {
+		for p := range progress {
+			updates = append(updates, p)
+		}
+		close(done)
+	}()
+	result, err := VerifyRemediation(context.Background(), client, RemediationModeDirect, ns, name, progress)
+	<-done
+	return result, updates, err
+}
+
+func TestVerifyRemediation(t *testing.T) {
+	ns := "openshift-compliance"
+
+	orig := scanPollInterval
+	scanPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { scanPollInterval = orig })
+
+	t.Run("check flips to PASS", func(t *testing.T) {
+		client := newTestClient(
+			newConfigMapRemediation("ocp4-cis-check-1", ns, "target-cm", nil),
+			newCheckResult("ocp4-cis-check", ns, "FAIL", "high", "", "ocp4-cis", "cis"),
+			newDoneScan("ocp4-cis", ns, "2026-01-01T00:00:00Z"),
+		)
+		simulateRescan(t, client, ns, "ocp4-cis", "ocp4-cis-check", "PASS")
+
+		result, updates, err := runVerify(client, ns, "ocp4-cis-check-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Verified || result.Status != CheckStatusPass || result.PreviousStatus != CheckStatusFail {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if result.CheckResult != "ocp4-cis-check" || result.Scan != "ocp4-cis" {
+			t.Errorf("expected check linked by index suffix and scan label, got %+v", result)
+		}
+
+		var steps []string
+		for _, u := range updates {
+			if len(steps) == 0 || steps[len(steps)-1] != u.Step {
+				steps = append(steps, u.Step)
+			}
+		}
+		want := []string{"apply", "rescan", "wait-scan", "verify"}
+		if len(steps) != len(want) {
+			t.Fatalf("steps = %v, want %v", steps, want)
+		}
+		for i := range want {
+			if steps[i] != want[i] {
+				t.Errorf("steps = %v, want %v", steps, want)
+				break
+			}
+		}
+	})
+
+	t.Run("check still failing is reported", func(t *testing.T) {
+		client := newTestClient(
+			newConfigMapRemediation("ocp4-cis-check", ns, "target-cm", nil),
+			newCheckResult("ocp4-cis-check", ns, "FAIL", "high", "", "ocp4-cis", "cis"),
+			newDoneScan("ocp4-cis", ns, "2026-01-01T00:00:00Z"),
+		)
+		simulateRescan(t, client, ns, "ocp4-cis", "ocp4-cis-check", "FAIL")
+
+		result, _, err := runVerify(client, ns, "ocp4-cis-check")
+		if err == nil {
+			t.Fatal("expected error when check does not pass")
+		}
+		if result == nil || result.Verified || !result.Applied || result.Status != CheckStatusFail {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("no linked check", func(t *testing.T) {
+		client := newTestClient(newConfigMapRemediation("orphan", ns, "target-cm", nil))
+		if _, _, err := runVerify(client, ns, "orphan"); err == nil {
+			t.Error("expected error for remediation without a check result")
+		}
+	})
+}
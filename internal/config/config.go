@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // Config holds the application configuration.
 type Config struct {
 	KubeConfig      string
@@ -7,4 +9,28 @@ type Config struct {
 	Port            int
 	ComplianceOpRef string
 	LogFormat       string
+	// GitOpsRepo is a local git repository that remediation exports may be
+	// committed to. Empty disables committing.
+	GitOpsRepo string
+	// RemediationMode is "direct" (the dashboard applies objects) or
+	// "operator" (only spec.apply is set).
+	RemediationMode string
+	// RequireApproval makes applying and removing remediations require a
+	// change request approved by a second user.
+	RequireApproval bool
+	// Disconnected makes installs skip internet lookups and use image
+	// mirrors. MirrorRegistry is a registry prefix images are rewritten to;
+	// without it the cluster's ImageContentSourcePolicies and mirror sets
+	// are used. CatalogImage replaces the community catalog image.
+	Disconnected   bool
+	MirrorRegistry string
+	CatalogImage   string
+	// StateNamespace holds the persisted install and uninstall step log. It
+	// must outlive the operator namespace, which uninstall deletes.
+	StateNamespace string
+	// CSVTimeout, PodsTimeout and ProfileBundlesTimeout bound how long an
+	// install waits for each phase unless the request overrides them.
+	CSVTimeout            time.Duration
+	PodsTimeout           time.Duration
+	ProfileBundlesTimeout time.Duration
 }
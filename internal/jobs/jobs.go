@@ -0,0 +1,318 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the lifecycle state of a job or one of its steps.
+type State string
+
+const (
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateCanceled  State = "canceled"
+)
+
+// Finished reports whether the state is terminal.
+func (s State) Finished() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCanceled
+}
+
+// Step is a single named phase of a job.
+type Step struct {
+	Name       string     `json:"name"`
+	State      State      `json:"state"`
+	Message    string     `json:"message,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Job is a long-running operation tracked by a Manager.
+type Job struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	State      State       `json:"state"`
+	Steps      []Step      `json:"steps"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// snapshot returns a copy of the job that is safe to hand out.
+func (j *Job) snapshot() Job {
+	c := *j
+	c.Steps = append([]Step(nil), j.Steps...)
+	c.cancel = nil
+	return c
+}
+
+// Func is the body of a job. It reports progress through the Reporter and
+// should return promptly once ctx is canceled.
+type Func func(ctx context.Context, r *Reporter) (interface{}, error)
+
+// Manager runs jobs in the background and keeps a bounded history of them.
+type Manager struct {
+	mu         sync.RWMutex
+	jobs       map[string]*Job
+	maxHistory int
+	notify     func(Job)
+}
+
+// NewManager creates a Manager that retains at most maxHistory finished jobs.
+// notify, if non-nil, is called with a snapshot of a job every time it changes.
+func NewManager(maxHistory int, notify func(Job)) *Manager {
+	if maxHistory <= 0 {
+		maxHistory = 50
+	}
+	return &Manager{
+		jobs:       make(map[string]*Job),
+		maxHistory: maxHistory,
+		notify:     notify,
+	}
+}
+
+// Start runs fn in a new goroutine and returns a snapshot of the created job.
+func (m *Manager) Start(jobType string, fn Func) Job {
+	m.mu.Lock()
+	job, ctx := m.addLocked(jobType)
+	snap := job.snapshot()
+	m.mu.Unlock()
+	m.publish(snap)
+
+	go m.run(ctx, job, fn)
+
+	return snap
+}
+
+// StartExclusive is Start for jobs that must not run concurrently. If a job
+// of the same type is already running it returns that job and false
+// without starting fn; the check and the start are atomic.
+func (m *Manager) StartExclusive(jobType string, fn Func) (Job, bool) {
+	m.mu.Lock()
+	if running, ok := m.runningLocked(jobType); ok {
+		snap := running.snapshot()
+		m.mu.Unlock()
+		return snap, false
+	}
+	job, ctx := m.addLocked(jobType)
+	snap := job.snapshot()
+	m.mu.Unlock()
+	m.publish(snap)
+
+	go m.run(ctx, job, fn)
+
+	return snap, true
+}
+
+// addLocked records a new running job and returns it with its context.
+func (m *Manager) addLocked(jobType string) (*Job, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:        newID(),
+		Type:      jobType,
+		State:     StateRunning,
+		Steps:     []Step{},
+		CreatedAt: time.Now().UTC(),
+		cancel:    cancel,
+	}
+	m.jobs[job.ID] = job
+	m.pruneLocked()
+	return job, ctx
+}
+
+func (m *Manager) run(ctx context.Context, job *Job, fn Func) {
+	r := &Reporter{manager: m, job: job}
+
+	var result interface{}
+	var err error
+	func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				slog.Error("job panicked", "id", job.ID, "type", job.Type, "panic", rec)
+				err = fmt.Errorf("job panicked: %v", rec)
+			}
+		}()
+		result, err = fn(ctx, r)
+	}()
+
+	m.mu.Lock()
+	now := time.Now().UTC()
+	job.Result = result
+	job.FinishedAt = &now
+	switch {
+	case ctx.Err() != nil:
+		job.State = StateCanceled
+		job.Error = "canceled"
+	case err != nil:
+		job.State = StateFailed
+		job.Error = err.Error()
+	default:
+		job.State = StateSucceeded
+	}
+	r.finishCurrentLocked(job.State, job.Error, now)
+	job.cancel()
+	snap := job.snapshot()
+	m.pruneLocked()
+	m.mu.Unlock()
+	m.publish(snap)
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return job.snapshot(), true
+}
+
+// List returns snapshots of all retained jobs, newest first.
+func (m *Manager) List() []Job {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		list = append(list, job.snapshot())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list
+}
+
+// Running returns the first running job of the given type, if any.
+func (m *Manager) Running(jobType string) (Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if job, ok := m.runningLocked(jobType); ok {
+		return job.snapshot(), true
+	}
+	return Job{}, false
+}
+
+func (m *Manager) runningLocked(jobType string) (*Job, bool) {
+	for _, job := range m.jobs {
+		if job.Type == jobType && !job.State.Finished() {
+			return job, true
+		}
+	}
+	return nil, false
+}
+
+// Cancel requests cancellation of a running job. The job transitions to
+// canceled once its Func returns.
+func (m *Manager) Cancel(id string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return fmt.Errorf("job %s not found", id)
+	}
+	if job.State.Finished() {
+		return fmt.Errorf("job %s already %s", id, job.State)
+	}
+	job.cancel()
+	return nil
+}
+
+// pruneLocked drops the oldest finished jobs beyond maxHistory.
+func (m *Manager) pruneLocked() {
+	var finished []*Job
+	for _, job := range m.jobs {
+		if job.State.Finished() {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= m.maxHistory {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].CreatedAt.Before(finished[j].CreatedAt) })
+	for _, job := range finished[:len(finished)-m.maxHistory] {
+		delete(m.jobs, job.ID)
+	}
+}
+
+func (m *Manager) publish(job Job) {
+	if m.notify != nil {
+		m.notify(job)
+	}
+}
+
+// Reporter records step progress for a running job.
+type Reporter struct {
+	manager *Manager
+	job     *Job
+}
+
+// JobID returns the ID of the job being reported on.
+func (r *Reporter) JobID() string {
+	return r.job.ID
+}
+
+// Step records progress for the named step. Moving to a new step marks the
+// previous one as succeeded; repeating the current step updates its message.
+func (r *Reporter) Step(name, message string) {
+	r.update(name, message, "")
+}
+
+// StepError marks the named step as failed. The job itself keeps running
+// until its Func returns, so non-fatal step failures can be recorded.
+func (r *Reporter) StepError(name, message string) {
+	r.update(name, message, message)
+}
+
+func (r *Reporter) update(name, message, errMsg string) {
+	m := r.manager
+	m.mu.Lock()
+	now := time.Now().UTC()
+	steps := r.job.Steps
+	if n := len(steps); n == 0 || steps[n-1].Name != name || steps[n-1].State.Finished() {
+		r.finishCurrentLocked(StateSucceeded, "", now)
+		r.job.Steps = append(r.job.Steps, Step{Name: name, State: StateRunning, StartedAt: now})
+	}
+	cur := &r.job.Steps[len(r.job.Steps)-1]
+	cur.Message = message
+	if errMsg != "" {
+		cur.State = StateFailed
+		cur.Error = errMsg
+		cur.FinishedAt = &now
+	}
+	snap := r.job.snapshot()
+	m.mu.Unlock()
+	m.publish(snap)
+}
+
+// finishCurrentLocked closes the last step if it is still running.
+func (r *Reporter) finishCurrentLocked(state State, errMsg string, now time.Time) {
+	n := len(r.job.Steps)
+	if n == 0 || r.job.Steps[n-1].State.Finished() {
+		return
+	}
+	cur := &r.job.Steps[n-1]
+	cur.State = state
+	cur.FinishedAt = &now
+	if state == StateFailed && cur.Error == "" {
+		cur.Error = errMsg
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitFinished(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, ok := m.Get(id); ok && job.State.Finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestManager_Succeeds(t *testing.T) {
+	m := NewManager(10, nil)
+
+	job := m.Start("test", func(ctx context.Context, r *Reporter) (interface{}, error) {
+		r.Step("one", "first")
+		r.Step("one", "first again")
+		r.Step("two", "second")
+		return "done", nil
+	})
+	if job.ID == "" {
+		t.Fatal("expected job ID")
+	}
+
+	got := waitFinished(t, m, job.ID)
+	if got.State != StateSucceeded {
+		t.Errorf("State = %s, want succeeded", got.State)
+	}
+	if got.Result != "done" {
+		t.Errorf("Result = %v, want done", got.Result)
+	}
+	if len(got.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(got.Steps))
+	}
+	if got.Steps[0].Message != "first again" {
+		t.Errorf("step message = %q, want updated message", got.Steps[0].Message)
+	}
+	for _, step := range got.Steps {
+		if step.State != StateSucceeded || step.FinishedAt == nil {
+			t.Errorf("step %s = %s, want finished succeeded", step.Name, step.State)
+		}
+	}
+}
+
+func TestManager_Fails(t *testing.T) {
+	m := NewManager(10, nil)
+
+	job := m.Start("test", func(ctx context.Context, r *Reporter) (interface{}, error) {
+		r.Step("one", "working")
+		return nil, errors.New("boom")
+	})
+
+	got := waitFinished(t, m, job.ID)
+	if got.State != StateFailed || got.Error != "boom" {
+		t.Errorf("got state %s error %q, want failed/boom", got.State, got.Error)
+	}
+	if got.Steps[0].State != StateFailed {
+		t.Errorf("step state = %s, want failed", got.Steps[0].State)
+	}
+}
+
+func TestManager_StepError(t *testing.T) {
+	m := NewManager(10, nil)
+
+	job := m.Start("test", func(ctx context.Context, r *Reporter) (interface{}, error) {
+		r.StepError("rbac", "could not apply RBAC")
+		r.Step("pods", "pods ready")
+		return nil, nil
+	})
+
+	got := waitFinished(t, m, job.ID)
+	if got.State != StateSucceeded {
+		t.Errorf("State = %s, want succeeded", got.State)
+	}
+	if got.Steps[0].State != StateFailed || got.Steps[1].State != StateSucceeded {
+		t.Errorf("unexpected step states: %+v", got.Steps)
+	}
+}
+
+func TestManager_Cancel(t *testing.T) {
+	m := NewManager(10, nil)
+
+	started := make(chan struct{})
+	job := m.Start("test", func(ctx context.Context, r *Reporter) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+
+	if _, ok := m.Running("test"); !ok {
+		t.Error("expected a running job of type test")
+	}
+	if err := m.Cancel(job.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	got := waitFinished(t, m, job.ID)
+	if got.State != StateCanceled {
+		t.Errorf("State = %s, want canceled", got.State)
+	}
+	if err := m.Cancel(job.ID); err == nil {
+		t.Error("expected error canceling a finished job")
+	}
+	if err := m.Cancel("missing"); err == nil {
+		t.Error("expected error canceling an unknown job")
+	}
+}
+
+func TestManager_Panic(t *testing.T) {
+	m := NewManager(10, nil)
+
+	job := m.Start("test", func(ctx context.Context, r *Reporter) (interface{}, error) {
+		panic("oops")
+	})
+
+	got := waitFinished(t, m, job.ID)
+	if got.State != StateFailed {
+		t.Errorf("State = %s, want failed", got.State)
+	}
+}
+
+func TestManager_BoundedHistory(t *testing.T) {
+	m := NewManager(2, nil)
+
+	for range 5 {
+		job := m.Start("test", func(ctx context.Context, r *Reporter) (interface{}, error) {
+			return nil, nil
+		})
+		waitFinished(t, m, job.ID)
+	}
+
+	if got := len(m.List()); got != 2 {
+		t.Errorf("retained %d jobs, want 2", got)
+	}
+}
+
+func TestManager_Notify(t *testing.T) {
+	var mu sync.Mutex
+	var states []State
+	m := NewManager(10, func(job Job) {
+		mu.Lock()
+		states = append(states, job.State)
+		mu.Unlock()
+	})
+
+	job := m.Start("test", func(ctx context.Context, r *Reporter) (interface{}, error) {
+		r.Step("one", "working")
+		return nil, nil
+	})
+	waitFinished(t, m, job.ID)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(states) < 3 {
+		t.Fatalf("got %d notifications, want at least 3", len(states))
+	}
+	if states[len(states)-1] != StateSucceeded {
+		t.Errorf("last notification state = %s, want succeeded", states[len(states)-1])
+	}
+}
+
+func TestManager_StartExclusive(t *testing.T) {
+	m := NewManager(10, nil)
+	release := make(chan struct{})
+	block := func(ctx context.Context, r *Reporter) (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := m.StartExclusive("install", block); ok {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if started != 1 {
+		t.Fatalf("started %d concurrent jobs, want 1", started)
+	}
+
+	running, ok := m.StartExclusive("install", block)
+	if ok {
+		t.Fatal("expected a second job to be refused")
+	}
+	if _, ok := m.StartExclusive("uninstall", func(ctx context.Context, r *Reporter) (interface{}, error) { return nil, nil }); !ok {
+		t.Error("a job of another type should start")
+	}
+
+	close(release)
+	waitFinished(t, m, running.ID)
+	if _, ok := m.StartExclusive("install", block); !ok {
+		t.Error("expected a job to start once the previous one finished")
+	}
+}
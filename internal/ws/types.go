@@ -4,15 +4,22 @@ package ws
 type MessageType string
 
 const (
-	MessageTypeClusterStatus     MessageType = "cluster_status"
-	MessageTypeOperatorStatus    MessageType = "operator_status"
-	MessageTypeInstallProgress   MessageType = "install_progress"
-	MessageTypeScanStatus        MessageType = "scan_status"
-	MessageTypeCheckResult       MessageType = "check_result"
-	MessageTypeRemediation       MessageType = "remediation"
-	MessageTypeRemediationResult MessageType = "remediation_result"
-	MessageTypeUninstallProgress MessageType = "uninstall_progress"
-	MessageTypeError             MessageType = "error"
+	MessageTypeClusterStatus              MessageType = "cluster_status"
+	MessageTypeOperatorStatus             MessageType = "operator_status"
+	MessageTypeInstallProgress            MessageType = "install_progress"
+	MessageTypeScanStatus                 MessageType = "scan_status"
+	MessageTypeCheckResult                MessageType = "check_result"
+	MessageTypeRemediation                MessageType = "remediation"
+	MessageTypeRemediationResult          MessageType = "remediation_result"
+	MessageTypeUninstallProgress          MessageType = "uninstall_progress"
+	MessageTypeMachineConfigBatchProgress MessageType = "machineconfig_batch_progress"
+	MessageTypeMachineConfigPool          MessageType = "machineconfigpool_status"
+	MessageTypeJobUpdate                  MessageType = "job_update"
+	MessageTypeChangeRequest              MessageType = "change_request"
+	MessageTypeScheduledRemediation       MessageType = "scheduled_remediation"
+	MessageTypeRemediationDrift           MessageType = "remediation_drift"
+	MessageTypeSubscription               MessageType = "operator_subscription"
+	MessageTypeError                      MessageType = "error"
 )
 
 // Message is a typed WebSocket message envelope.
@@ -3,6 +3,8 @@ package ws
 import (
 	"context"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,8 +17,9 @@ import (
 )
 
 var watchedResources = []struct {
-	GVR          schema.GroupVersionResource
-	ResourceType string
+	GVR           schema.GroupVersionResource
+	ResourceType  string
+	ClusterScoped bool
 }{
 	{
 		GVR:          schema.GroupVersionResource{Group: "compliance.openshift.io", Version: "v1alpha1", Resource: "compliancecheckresults"},
@@ -34,6 +37,27 @@ var watchedResources = []struct {
 		GVR:          schema.GroupVersionResource{Group: "compliance.openshift.io", Version: "v1alpha1", Resource: "compliancescans"},
 		ResourceType: "ComplianceScan",
 	},
+	{
+		GVR:           schema.GroupVersionResource{Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfigpools"},
+		ResourceType:  "MachineConfigPool",
+		ClusterScoped: true,
+	},
+	{
+		GVR:           schema.GroupVersionResource{Version: "v1", Resource: "nodes"},
+		ResourceType:  "Node",
+		ClusterScoped: true,
+	},
+}
+
+// Machine Config Daemon annotations that describe a node's rollout state.
+var mcdAnnotations = []string{
+	"machineconfiguration.openshift.io/state",
+	"machineconfiguration.openshift.io/currentConfig",
+	"machineconfiguration.openshift.io/desiredConfig",
+}
+
+var machineConfigPoolGVR = schema.GroupVersionResource{
+	Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfigpools",
 }
 
 // Watcher bridges Kubernetes watch events to WebSocket broadcasts.
@@ -41,25 +65,34 @@ type Watcher struct {
 	client    *k8s.Client
 	hub       *Hub
 	namespace string
+
+	// nodeStates holds the last seen MCD annotations of each node. Only the
+	// Node watch goroutine uses it.
+	nodeStates map[string]string
 }
 
 // NewWatcher creates a new K8s Watch → WebSocket bridge.
 func NewWatcher(client *k8s.Client, hub *Hub, namespace string) *Watcher {
 	return &Watcher{
-		client:    client,
-		hub:       hub,
-		namespace: namespace,
+		client:     client,
+		hub:        hub,
+		namespace:  namespace,
+		nodeStates: make(map[string]string),
 	}
 }
 
 // Start begins watching all compliance-related resources.
 func (w *Watcher) Start(ctx context.Context) {
 	for _, res := range watchedResources {
-		go w.watchResource(ctx, res.GVR, res.ResourceType)
+		namespace := w.namespace
+		if res.ClusterScoped {
+			namespace = ""
+		}
+		go w.watchResource(ctx, res.GVR, res.ResourceType, namespace)
 	}
 }
 
-func (w *Watcher) watchResource(ctx context.Context, gvr schema.GroupVersionResource, resourceType string) {
+func (w *Watcher) watchResource(ctx context.Context, gvr schema.GroupVersionResource, resourceType, namespace string) {
 	backoff := time.Second
 
 	for {
@@ -69,7 +102,7 @@ func (w *Watcher) watchResource(ctx context.Context, gvr schema.GroupVersionReso
 		default:
 		}
 
-		watcher, err := w.client.Dynamic.Resource(gvr).Namespace(w.namespace).
+		watcher, err := w.client.Dynamic.Resource(gvr).Namespace(namespace).
 			Watch(ctx, metav1.ListOptions{})
 		if err != nil {
 			// If the CRD doesn't exist, back off much longer (operator not installed)
@@ -126,6 +159,13 @@ func (w *Watcher) processEvents(ctx context.Context, watcher watch.Interface, re
 				continue
 			}
 
+			// The MCD records per-node rollout state on the Node, between
+			// pool status updates.
+			if resourceType == "Node" {
+				w.nodeChanged(ctx, obj, eventType)
+				continue
+			}
+
 			// Determine the appropriate message type
 			msgType := mapResourceToMessageType(resourceType, eventType)
 
@@ -137,6 +177,12 @@ func (w *Watcher) processEvents(ctx context.Context, watcher watch.Interface, re
 				Data:         extractRelevantData(resourceType, obj),
 			}
 
+			// Pool events carry the full rollout state, including per-node
+			// MCD state, so the UI can follow reboots as they happen.
+			if resourceType == "MachineConfigPool" && eventType != WatchEventDeleted {
+				watchEvent.Data = compliance.MachineConfigPoolStatusFromObject(ctx, w.client, obj)
+			}
+
 			w.hub.Broadcast(Message{
 				Type:    msgType,
 				Payload: watchEvent,
@@ -145,6 +191,50 @@ func (w *Watcher) processEvents(ctx context.Context, watcher watch.Interface, re
 	}
 }
 
+// nodeChanged broadcasts the pools a node belongs to when its MCD state
+// changes, so Working, Rebooting and Done reach the UI as they happen.
+func (w *Watcher) nodeChanged(ctx context.Context, node *unstructured.Unstructured, eventType WatchEventType) {
+	name := node.GetName()
+	if eventType == WatchEventDeleted {
+		delete(w.nodeStates, name)
+		return
+	}
+	annotations := node.GetAnnotations()
+	var state strings.Builder
+	for _, key := range mcdAnnotations {
+		state.WriteString(annotations[key] + "\x00")
+	}
+	prev, seen := w.nodeStates[name]
+	w.nodeStates[name] = state.String()
+	// The initial listing only records state.
+	if eventType == WatchEventAdded || (seen && prev == state.String()) {
+		return
+	}
+
+	pools, err := w.client.Dynamic.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if !compliance.IsCRDNotFound(err) {
+			slog.Warn("could not list MachineConfigPools for node update", "node", name, "error", err)
+		}
+		return
+	}
+	for i := range pools.Items {
+		status := compliance.MachineConfigPoolStatusFromObject(ctx, w.client, &pools.Items[i])
+		if !slices.ContainsFunc(status.Nodes, func(n compliance.NodeRolloutStatus) bool { return n.Name == name }) {
+			continue
+		}
+		w.hub.Broadcast(Message{
+			Type: MessageTypeMachineConfigPool,
+			Payload: WatchEvent{
+				EventType:    WatchEventModified,
+				ResourceType: "MachineConfigPool",
+				Name:         status.Name,
+				Data:         status,
+			},
+		})
+	}
+}
+
 func mapResourceToMessageType(resourceType string, _ WatchEventType) MessageType {
 	switch resourceType {
 	case "ComplianceCheckResult":
@@ -153,6 +243,8 @@ func mapResourceToMessageType(resourceType string, _ WatchEventType) MessageType
 		return MessageTypeRemediation
 	case "ComplianceSuite", "ComplianceScan":
 		return MessageTypeScanStatus
+	case "MachineConfigPool":
+		return MessageTypeMachineConfigPool
 	default:
 		return MessageTypeError
 	}